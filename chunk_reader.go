@@ -1,6 +1,7 @@
 package goparquet
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"math/bits"
@@ -68,14 +69,14 @@ func getBooleanValuesDecoder(pageEncoding parquet.Encoding, dictValues []interfa
 	}
 }
 
-func getByteArrayValuesDecoder(pageEncoding parquet.Encoding, dictValues []interface{}) (valuesDecoder, error) {
+func getByteArrayValuesDecoder(pageEncoding parquet.Encoding, dictValues []interface{}, zeroCopy bool) (valuesDecoder, error) {
 	switch pageEncoding {
 	case parquet.Encoding_PLAIN:
-		return &byteArrayPlainDecoder{}, nil
+		return &byteArrayPlainDecoder{zeroCopy: zeroCopy}, nil
 	case parquet.Encoding_DELTA_LENGTH_BYTE_ARRAY:
-		return &byteArrayDeltaLengthDecoder{}, nil
+		return &byteArrayDeltaLengthDecoder{zeroCopy: zeroCopy}, nil
 	case parquet.Encoding_DELTA_BYTE_ARRAY:
-		return &byteArrayDeltaDecoder{}, nil
+		return &byteArrayDeltaDecoder{zeroCopy: zeroCopy}, nil
 	case parquet.Encoding_RLE_DICTIONARY:
 		return &dictDecoder{values: dictValues}, nil
 	default:
@@ -83,12 +84,12 @@ func getByteArrayValuesDecoder(pageEncoding parquet.Encoding, dictValues []inter
 	}
 }
 
-func getFixedLenByteArrayValuesDecoder(pageEncoding parquet.Encoding, len int, dictValues []interface{}) (valuesDecoder, error) {
+func getFixedLenByteArrayValuesDecoder(pageEncoding parquet.Encoding, len int, dictValues []interface{}, zeroCopy bool) (valuesDecoder, error) {
 	switch pageEncoding {
 	case parquet.Encoding_PLAIN:
-		return &byteArrayPlainDecoder{length: len}, nil
+		return &byteArrayPlainDecoder{length: len, zeroCopy: zeroCopy}, nil
 	case parquet.Encoding_DELTA_BYTE_ARRAY:
-		return &byteArrayDeltaDecoder{}, nil
+		return &byteArrayDeltaDecoder{zeroCopy: zeroCopy}, nil
 	case parquet.Encoding_RLE_DICTIONARY:
 		return &dictDecoder{values: dictValues}, nil
 	default:
@@ -140,7 +141,9 @@ func getInt64ValuesDecoder(pageEncoding parquet.Encoding, typ *parquet.SchemaEle
 	}
 }
 
-func getValuesDecoder(pageEncoding parquet.Encoding, typ *parquet.SchemaElement, dictValues []interface{}) (valuesDecoder, error) {
+// getValuesDecoder returns the decoder for the values of a data page. In zero-copy mode, the
+// byte array decoders return slices of the page buffer instead of copies.
+func getValuesDecoder(pageEncoding parquet.Encoding, typ *parquet.SchemaElement, dictValues []interface{}, zeroCopy bool) (valuesDecoder, error) {
 	// Change the deprecated value
 	if pageEncoding == parquet.Encoding_PLAIN_DICTIONARY {
 		pageEncoding = parquet.Encoding_RLE_DICTIONARY
@@ -151,13 +154,13 @@ func getValuesDecoder(pageEncoding parquet.Encoding, typ *parquet.SchemaElement,
 		return getBooleanValuesDecoder(pageEncoding, dictValues)
 
 	case parquet.Type_BYTE_ARRAY:
-		return getByteArrayValuesDecoder(pageEncoding, dictValues)
+		return getByteArrayValuesDecoder(pageEncoding, dictValues, zeroCopy)
 
 	case parquet.Type_FIXED_LEN_BYTE_ARRAY:
 		if typ.TypeLength == nil {
 			return nil, errors.Errorf("type %s with nil type len", typ.Type)
 		}
-		return getFixedLenByteArrayValuesDecoder(pageEncoding, int(*typ.TypeLength), dictValues)
+		return getFixedLenByteArrayValuesDecoder(pageEncoding, int(*typ.TypeLength), dictValues, zeroCopy)
 	case parquet.Type_FLOAT:
 		switch pageEncoding {
 		case parquet.Encoding_PLAIN:
@@ -195,7 +198,7 @@ func getValuesDecoder(pageEncoding parquet.Encoding, typ *parquet.SchemaElement,
 	return nil, errors.Errorf("unsupported encoding %s for %s type", pageEncoding, typ.Type)
 }
 
-func createDataReader(r io.Reader, codec parquet.CompressionCodec, compressedSize int32, uncompressedSize int32) (io.Reader, error) {
+func createDataReader(r io.Reader, codec parquet.CompressionCodec, compressedSize int32, uncompressedSize int32) (*pageBuffer, error) {
 	if compressedSize < 0 || uncompressedSize < 0 {
 		return nil, errors.New("invalid page data size")
 	}
@@ -203,38 +206,57 @@ func createDataReader(r io.Reader, codec parquet.CompressionCodec, compressedSiz
 	return newBlockReader(r, codec, compressedSize, uncompressedSize)
 }
 
-func readPages(r *offsetReader, col *Column, chunkMeta *parquet.ColumnMetaData, dDecoder, rDecoder getLevelDecoder) ([]pageReader, error) {
+// readPages reads the pages of a column chunk. Data pages that start before dataOffset are skipped.
+// If the column chunk is encrypted, the pages are decrypted using dec.
+func readPages(r *offsetReader, col *Column, chunkMeta *parquet.ColumnMetaData, dDecoder, rDecoder getLevelDecoder, zeroCopy bool, dataOffset int64, dec *columnDecryptor) ([]pageReader, *dictPageReader, error) {
 	var (
 		dictPage *dictPageReader
 		pages    []pageReader
+		// the ordinal of the next data page, which is part of the AAD of encrypted pages
+		ordinal int
+		first   = true
 	)
 
 	for {
 		if chunkMeta.TotalCompressedSize-r.Count() <= 0 {
 			break
 		}
-		ph := &parquet.PageHeader{}
-		if err := readThrift(ph, r); err != nil {
-			return nil, err
+		pageOffset := r.offset
+		// the type of an encrypted page header needs to be known before decrypting it
+		ph, err := readPageHeader(r, dec, first && chunkMeta.DictionaryPageOffset != nil, ordinal, chunkMeta.TotalCompressedSize-r.Count())
+		if err != nil {
+			return nil, nil, err
+		}
+		first = false
+
+		if ph.Type != parquet.PageType_DICTIONARY_PAGE && pageOffset < dataOffset {
+			if _, err := r.Seek(int64(ph.CompressedPageSize), io.SeekCurrent); err != nil {
+				return nil, nil, err
+			}
+			ordinal++
+			continue
+		}
+
+		data, err := pageDataReader(r, dec, ph, ordinal)
+		if err != nil {
+			return nil, nil, err
 		}
 
 		if ph.Type == parquet.PageType_DICTIONARY_PAGE {
 			if dictPage != nil {
-				return nil, errors.New("there should be only one dictionary")
+				return nil, nil, errors.New("there should be only one dictionary")
 			}
 			p := &dictPageReader{}
 			de, err := getDictValuesDecoder(col.Element())
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 			if err := p.init(de); err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 
-			// re-use the value dictionary store
-			p.values = col.getColumnStore().values.values
-			if err := p.read(r, ph, chunkMeta.Codec); err != nil {
-				return nil, err
+			if err := p.read(data, ph, chunkMeta.Codec); err != nil {
+				return nil, nil, err
 			}
 
 			dictPage = p
@@ -242,8 +264,13 @@ func readPages(r *offsetReader, col *Column, chunkMeta *parquet.ColumnMetaData,
 			// if we have a DictionaryPageOffset we should return to DataPageOffset
 			if chunkMeta.DictionaryPageOffset != nil {
 				if *chunkMeta.DictionaryPageOffset != r.offset {
-					if _, err := r.Seek(chunkMeta.DataPageOffset, io.SeekStart); err != nil {
-						return nil, err
+					offset := chunkMeta.DataPageOffset
+					// the ordinals of encrypted data pages are only known if no data page is left out
+					if dataOffset > offset && dec == nil {
+						offset = dataOffset
+					}
+					if _, err := r.Seek(offset, io.SeekStart); err != nil {
+						return nil, nil, err
 					}
 				}
 			}
@@ -261,31 +288,145 @@ func readPages(r *offsetReader, col *Column, chunkMeta *parquet.ColumnMetaData,
 				ph: ph,
 			}
 		default:
-			return nil, errors.Errorf("DATA_PAGE or DATA_PAGE_V2 type supported, but was %s", ph.Type)
+			return nil, nil, errors.Errorf("DATA_PAGE or DATA_PAGE_V2 type supported, but was %s", ph.Type)
 		}
 		var dictValue []interface{}
 		if dictPage != nil {
 			dictValue = dictPage.values
 		}
 		var fn = func(typ parquet.Encoding) (valuesDecoder, error) {
-			return getValuesDecoder(typ, col.Element(), dictValue)
+			return getValuesDecoder(typ, col.Element(), dictValue, zeroCopy)
 		}
 		if err := p.init(dDecoder, rDecoder, fn); err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
-		if err := p.read(r, ph, chunkMeta.Codec); err != nil {
-			return nil, err
+		if err := p.read(data, ph, chunkMeta.Codec); err != nil {
+			return nil, nil, err
 		}
 		pages = append(pages, p)
+		ordinal++
+	}
+
+	return pages, dictPage, nil
+}
+
+// readPageHeader reads the header of a page, and decrypts it if the column chunk is encrypted.
+func readPageHeader(r io.Reader, dec *columnDecryptor, dictionary bool, ordinal int, maxLen int64) (*parquet.PageHeader, error) {
+	if dec != nil {
+		return dec.readPageHeader(r, maxLen, dictionary, ordinal)
+	}
+
+	ph := &parquet.PageHeader{}
+	if err := readThrift(ph, r); err != nil {
+		return nil, err
+	}
+
+	return ph, nil
+}
+
+// pageDataReader returns the reader for the data of a page. If the column chunk is encrypted, the
+// data is decrypted and the compressed page size of the header is set to its decrypted size.
+func pageDataReader(r io.Reader, dec *columnDecryptor, ph *parquet.PageHeader, ordinal int) (io.Reader, error) {
+	if dec != nil {
+		return dec.readPage(r, ph, ordinal)
+	}
+
+	return r, nil
+}
+
+// readDictionaryPage reads only the dictionary page of a column chunk without touching any of its
+// data pages. It returns nil if the column chunk doesn't start with a dictionary page.
+func readDictionaryPage(r io.ReadSeeker, col *Column, chunk *parquet.ColumnChunk, dec *columnDecryptor) (*dictPageReader, error) {
+	if chunk.MetaData == nil {
+		return nil, errors.Errorf("missing meta data for Column %d", col.Index())
+	}
+
+	offset := chunk.MetaData.DataPageOffset
+	if chunk.MetaData.DictionaryPageOffset != nil {
+		offset = *chunk.MetaData.DictionaryPageOffset
+	}
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	if dec != nil && chunk.MetaData.DictionaryPageOffset == nil {
+		// the first page of an encrypted column chunk can only be decrypted if its type is known
+		return nil, nil
+	}
+
+	ph, err := readPageHeader(r, dec, true, 0, chunk.MetaData.TotalCompressedSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if ph.Type != parquet.PageType_DICTIONARY_PAGE {
+		return nil, nil
+	}
+
+	data, err := pageDataReader(r, dec, ph, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	de, err := getDictValuesDecoder(col.Element())
+	if err != nil {
+		return nil, err
 	}
 
-	return pages, nil
+	p := &dictPageReader{}
+	if err := p.init(de); err != nil {
+		return nil, err
+	}
+
+	if err := p.read(data, ph, chunk.MetaData.Codec); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// onlyDictionaryDataPages returns true if all data pages of the column chunk are known to be
+// dictionary encoded.
+func onlyDictionaryDataPages(meta *parquet.ColumnMetaData) bool {
+	if meta.EncodingStats != nil {
+		for _, s := range meta.EncodingStats {
+			if s.PageType != parquet.PageType_DATA_PAGE && s.PageType != parquet.PageType_DATA_PAGE_V2 {
+				continue
+			}
+			if s.Count > 0 && s.Encoding != parquet.Encoding_RLE_DICTIONARY && s.Encoding != parquet.Encoding_PLAIN_DICTIONARY {
+				return false
+			}
+		}
+		return true
+	}
+
+	// Without encoding stats, the encodings are only conclusive for the deprecated PLAIN_DICTIONARY,
+	// as otherwise PLAIN could either be the encoding of the dictionary page or of a data page.
+	hasDict := false
+	for _, enc := range meta.Encodings {
+		switch enc {
+		case parquet.Encoding_RLE, parquet.Encoding_BIT_PACKED:
+		case parquet.Encoding_PLAIN_DICTIONARY:
+			hasDict = true
+		default:
+			return false
+		}
+	}
+
+	return hasDict
 }
 
 func skipChunk(r io.Seeker, col *Column, chunk *parquet.ColumnChunk) error {
 	if chunk.FilePath != nil {
-		return fmt.Errorf("nyi: data is in another file: '%s'", *chunk.FilePath)
+		// the column chunk is stored in another file, so there is nothing to skip in r
+		return nil
+	}
+
+	if chunk.MetaData == nil && chunk.CryptoMetadata != nil {
+		// the meta data of encrypted column chunks is only decrypted if they are selected, but
+		// there is no need to seek past them as the selected column chunks are read by offset
+		return nil
 	}
 
 	c := col.Index()
@@ -311,21 +452,25 @@ func skipChunk(r io.Seeker, col *Column, chunk *parquet.ColumnChunk) error {
 	return err
 }
 
-func readChunk(r io.ReadSeeker, col *Column, chunk *parquet.ColumnChunk) ([]pageReader, error) {
-	if chunk.FilePath != nil {
-		return nil, fmt.Errorf("nyi: data is in another file: '%s'", *chunk.FilePath)
-	}
+// readChunk reads all pages of a column chunk. The pages need to be released once their values
+// are decoded.
+func readChunk(r io.ReadSeeker, col *Column, chunk *parquet.ColumnChunk, zeroCopy bool, dec *columnDecryptor) ([]pageReader, *dictPageReader, error) {
+	return readChunkFrom(r, col, chunk, zeroCopy, 0, dec)
+}
 
+// readChunkFrom works like readChunk, but skips all data pages that start before dataOffset. The
+// dictionary page is always read.
+func readChunkFrom(r io.ReadSeeker, col *Column, chunk *parquet.ColumnChunk, zeroCopy bool, dataOffset int64, dec *columnDecryptor) ([]pageReader, *dictPageReader, error) {
 	c := col.Index()
 	// chunk.FileOffset is useless so ChunkMetaData is required here
 	// as we cannot read it from r
 	// see https://issues.apache.org/jira/browse/PARQUET-291
 	if chunk.MetaData == nil {
-		return nil, errors.Errorf("missing meta data for Column %c", c)
+		return nil, nil, errors.Errorf("missing meta data for Column %c", c)
 	}
 
 	if typ := *col.Element().Type; chunk.MetaData.Type != typ {
-		return nil, errors.Errorf("wrong type in Column chunk metadata, expected %s was %s",
+		return nil, nil, errors.Errorf("wrong type in Column chunk metadata, expected %s was %s",
 			typ, chunk.MetaData.Type)
 	}
 
@@ -336,7 +481,7 @@ func readChunk(r io.ReadSeeker, col *Column, chunk *parquet.ColumnChunk) ([]page
 	// Seek to the beginning of the first Page
 	_, err := r.Seek(offset, io.SeekStart)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	reader := &offsetReader{
@@ -374,14 +519,22 @@ func readChunk(r io.ReadSeeker, col *Column, chunk *parquet.ColumnChunk) ([]page
 			return &levelDecoderWrapper{decoder: constDecoder(0), max: col.MaxDefinitionLevel()}, nil
 		}
 	}
-	return readPages(reader, col, chunk.MetaData, dDecoder, rDecoder)
+	return readPages(reader, col, chunk.MetaData, dDecoder, rDecoder, zeroCopy, dataOffset, dec)
 }
 
-func readPageData(col *Column, pages []pageReader) error {
+// readPageData decodes the values of the pages into the column store. In zero-copy mode, the
+// values may refer to the page buffers, so the column store keeps the pages until it is reset.
+// Otherwise, the pages are released right away.
+func readPageData(col *Column, pages []pageReader, zeroCopy bool) error {
 	s := col.getColumnStore()
 	for i := range pages {
 		data := make([]interface{}, pages[i].numValues())
 		n, dl, rl, err := pages[i].readValues(data)
+		if zeroCopy {
+			s.pages = append(s.pages, pages[i])
+		} else {
+			pages[i].release()
+		}
 		if err != nil {
 			return err
 		}
@@ -394,14 +547,85 @@ func readPageData(col *Column, pages []pageReader) error {
 		s.rLevels.appendArray(rl)
 		s.dLevels.appendArray(dl)
 
-		s.values.values = append(s.values.values, data...)
+		// only the non-null values are filled, nulls are only represented in the definition levels
+		notNull := 0
+		for j := 0; j < n; j++ {
+			if d, _ := dl.at(j); d == int32(col.MaxDefinitionLevel()) {
+				notNull++
+			}
+		}
+		s.values.values = append(s.values.values, data[:notNull]...)
 		s.values.noDictMode = true
 	}
 
 	return nil
 }
 
-func readRowGroup(r io.ReadSeeker, schema SchemaReader, rowGroups *parquet.RowGroup) error {
+// chunkOffset returns the offset of the first page of a column chunk.
+func chunkOffset(md *parquet.ColumnMetaData) int64 {
+	if md.DictionaryPageOffset != nil {
+		return *md.DictionaryPageOffset
+	}
+
+	return md.DataPageOffset
+}
+
+// readOffsetIndex reads the offset index of a column chunk, and decrypts it if the column chunk is
+// encrypted. It returns nil if the column chunk has no offset index.
+func readOffsetIndex(r io.ReadSeeker, chunk *parquet.ColumnChunk, dec *columnDecryptor) (*parquet.OffsetIndex, error) {
+	if chunk.OffsetIndexOffset == nil || chunk.OffsetIndexLength == nil || *chunk.OffsetIndexLength <= 0 {
+		return nil, nil
+	}
+
+	if _, err := r.Seek(*chunk.OffsetIndexOffset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	// read the offset index at once, as thrift reads in very small pieces
+	buf := make([]byte, *chunk.OffsetIndexLength)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, errors.Wrap(err, "reading offset index failed")
+	}
+
+	if dec != nil {
+		var err error
+		if buf, err = dec.decryptOffsetIndex(buf); err != nil {
+			return nil, err
+		}
+	}
+
+	oi := &parquet.OffsetIndex{}
+	if err := readThrift(oi, bytes.NewReader(buf)); err != nil {
+		return nil, errors.Wrap(err, "reading offset index failed")
+	}
+
+	return oi, nil
+}
+
+// pageForRow returns the location of the data page that contains the row with the provided index
+// within the row group, or nil if the offset index is not usable.
+func pageForRow(oi *parquet.OffsetIndex, row int64) *parquet.PageLocation {
+	var loc *parquet.PageLocation
+	for _, l := range oi.PageLocations {
+		if l == nil || l.Offset < 0 || (loc != nil && l.FirstRowIndex < loc.FirstRowIndex) {
+			return nil
+		}
+		if l.FirstRowIndex > row {
+			break
+		}
+		loc = l
+	}
+
+	return loc
+}
+
+// readRowGroup reads the selected column chunks of a row group into the column stores, positioned
+// at the row with index firstRow within the row group. If a column chunk has an offset index, only
+// the data pages starting with the one that contains firstRow are read. The rows before firstRow
+// are skipped using the levels only. The column chunks with a decryptor, by column index, are
+// decrypted. The data of the column chunks is read from the reader returned by source, which
+// differs from r for column chunks stored in external files.
+func readRowGroup(r io.ReadSeeker, source func(*parquet.ColumnChunk) (io.ReadSeeker, error), schema SchemaReader, rowGroups *parquet.RowGroup, zeroCopy bool, firstRow int64, decryptors []*columnDecryptor) error {
 	dataCols := schema.Columns()
 	schema.resetData()
 	schema.setNumRecords(rowGroups.NumRows)
@@ -418,11 +642,41 @@ func readRowGroup(r io.ReadSeeker, schema SchemaReader, rowGroups *parquet.RowGr
 			c.data.skipped = true
 			continue
 		}
-		pages, err := readChunk(r, c, chunk)
+
+		var (
+			dataOffset int64
+			skip       = firstRow
+			dec        *columnDecryptor
+		)
+		if idx < len(decryptors) {
+			dec = decryptors[idx]
+		}
+		if firstRow > 0 {
+			oi, err := readOffsetIndex(r, chunk, dec)
+			if err != nil {
+				return err
+			}
+			if oi != nil {
+				if loc := pageForRow(oi, firstRow); loc != nil {
+					dataOffset = loc.Offset
+					skip = firstRow - loc.FirstRowIndex
+				}
+			}
+		}
+
+		cr, err := source(chunk)
 		if err != nil {
 			return err
 		}
-		if err := readPageData(c, pages); err != nil {
+
+		pages, _, err := readChunkFrom(cr, c, chunk, zeroCopy, dataOffset, dec)
+		if err != nil {
+			return err
+		}
+		if err := readPageData(c, pages, zeroCopy); err != nil {
+			return err
+		}
+		if err := c.data.skipRecords(skip, int32(c.MaxDefinitionLevel())); err != nil {
 			return err
 		}
 	}
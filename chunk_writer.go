@@ -1,6 +1,9 @@
 package goparquet
 
 import (
+	"bytes"
+	"io"
+	"math/bits"
 	"sort"
 
 	"github.com/sagia-inneractive/parquet-go/parquet"
@@ -62,7 +65,13 @@ func getInt32ValuesEncoder(pageEncoding parquet.Encoding, typ *parquet.SchemaEle
 	case parquet.Encoding_PLAIN:
 		return &int32PlainEncoder{unSigned: unSigned}, nil
 	case parquet.Encoding_DELTA_BINARY_PACKED:
-		return &int32DeltaBPEncoder{unSigned: unSigned}, nil
+		return &int32DeltaBPEncoder{
+			deltaBitPackEncoder32: deltaBitPackEncoder32{
+				blockSize:      128,
+				miniBlockCount: 4,
+			},
+			unSigned: unSigned,
+		}, nil
 	case parquet.Encoding_RLE_DICTIONARY:
 		return &dictEncoder{
 			dictStore: *store,
@@ -86,7 +95,13 @@ func getInt64ValuesEncoder(pageEncoding parquet.Encoding, typ *parquet.SchemaEle
 	case parquet.Encoding_PLAIN:
 		return &int64PlainEncoder{unSigned: unSigned}, nil
 	case parquet.Encoding_DELTA_BINARY_PACKED:
-		return &int64DeltaBPEncoder{unSigned: unSigned}, nil
+		return &int64DeltaBPEncoder{
+			deltaBitPackEncoder64: deltaBitPackEncoder64{
+				blockSize:      128,
+				miniBlockCount: 4,
+			},
+			unSigned: unSigned,
+		}, nil
 	case parquet.Encoding_RLE_DICTIONARY:
 		return &dictEncoder{
 			dictStore: *store,
@@ -200,64 +215,287 @@ func getDictValuesEncoder(typ *parquet.SchemaElement) (valuesEncoder, error) {
 	return nil, errors.Errorf("type %s is not supported for dict value encoder", typ)
 }
 
-func writeChunk(w writePos, schema SchemaWriter, col *Column, codec parquet.CompressionCodec, pageFn newDataPageFunc, kvMetaData map[string]string) (*parquet.ColumnChunk, error) {
+// dataPage contains the part of a column chunk's buffered data that is written as a single data page.
+type dataPage struct {
+	levelStart, levelEnd int
+	valueStart, valueEnd int
+
+	rLevels, dLevels *packedArray
+
+	// values is used for pages written in the column's own encoding, indices
+	// and dictLen for pages written with dictionary encoding.
+	values  []interface{}
+	indices []int32
+	dictLen int
+
+	numValues int32
+	numNulls  int32
+	numRows   int32
+
+	dictionary bool
+}
+
+// splitDataPages splits the buffered data of a column into pages of roughly maxPageSize bytes. Pages
+// always start at a record boundary, i.e. a record is never split across multiple pages.
+func splitDataPages(col *Column, maxPageSize int64) []*dataPage {
+	var (
+		store = col.data
+		maxD  = int32(col.MaxDefinitionLevel())
+		pages []*dataPage
+		cur   = &dataPage{}
+		size  int64
+		pos   int
+	)
+
+	count := store.rLevels.count
+	for i := 0; i < count; i++ {
+		rl, _ := store.rLevels.at(i)
+		dl, _ := store.dLevels.at(i)
+		if rl == 0 {
+			if maxPageSize > 0 && size >= maxPageSize && i > cur.levelStart {
+				cur.levelEnd, cur.valueEnd = i, pos
+				pages = append(pages, cur)
+				cur = &dataPage{levelStart: i, valueStart: pos}
+				size = 0
+			}
+			cur.numRows++
+		}
+		if dl == maxD {
+			size += int64(store.sizeOf(store.values.valueAt(pos)))
+			pos++
+		} else {
+			cur.numNulls++
+		}
+	}
+	cur.levelEnd, cur.valueEnd = count, pos
+
+	return append(pages, cur)
+}
+
+// planDictionary decides which pages are written with dictionary encoding. Pages are dictionary
+// encoded until the dictionary would grow beyond maxDictSize, all following pages fall back to
+// the column's own encoding. No dictionary is used at all if it doesn't pay off for the first
+// page with values. It returns the number of values in the dictionary.
+func planDictionary(col *Column, pages []*dataPage, maxDictSize int64) int {
+	store := col.data
+	if !store.allowDict || store.values.numValues() == 0 {
+		return 0
+	}
+
+	var (
+		dictLen  int
+		dictSize int64
+	)
+	for _, p := range pages {
+		newLen, newSize := dictLen, dictSize
+		var plainSize int64
+		for _, idx := range store.values.data[p.valueStart:p.valueEnd] {
+			// values are added to the dictionary store in order of their first
+			// appearance, so the dictionary of the first pages is always a prefix.
+			for int(idx) >= newLen {
+				newSize += int64(store.sizeOf(store.values.distinctValue(int32(newLen))))
+				newLen++
+			}
+			plainSize += int64(store.sizeOf(store.values.distinctValue(idx)))
+		}
+
+		if newSize > maxDictSize {
+			break
+		}
+
+		if dictLen == 0 && newLen > 0 {
+			count := int64(p.valueEnd - p.valueStart)
+			indexSize := count*int64(bits.Len(uint(newLen)))/8 + 1
+			if indexSize+newSize >= plainSize {
+				break
+			}
+		}
+
+		p.dictionary = true
+		dictLen, dictSize = newLen, newSize
+	}
+
+	if dictLen == 0 {
+		for _, p := range pages {
+			p.dictionary = false
+		}
+	}
+
+	return dictLen
+}
+
+// sortDictionary sorts the dictionary values and returns them together with a mapping from the
+// original to the new index of each value. Types without a defined sort order are left unsorted
+// and no mapping is returned.
+func sortDictionary(col *Column, values []interface{}) ([]interface{}, []int32) {
+	switch col.data.parquetType() {
+	case parquet.Type_BOOLEAN, parquet.Type_INT96:
+		return values, nil
+	}
+
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		c, _ := compareValues(values[order[i]], values[order[j]])
+		return c < 0
+	})
+
+	sorted := make([]interface{}, len(values))
+	remap := make([]int32, len(values))
+	for newIdx, oldIdx := range order {
+		sorted[newIdx] = values[oldIdx]
+		remap[oldIdx] = int32(newIdx)
+	}
+
+	return sorted, remap
+}
+
+// encodePageValues writes the page's values either as dictionary indices or using the column's encoding.
+func encodePageValues(w io.Writer, col *Column, page *dataPage, dictionary bool) error {
+	if dictionary {
+		return writeDictIndices(w, page.dictLen, page.indices)
+	}
+
+	encoder, err := getValuesEncoder(col.data.encoding(), col.Element(), nil)
+	if err != nil {
+		return err
+	}
+
+	return encodeValue(w, encoder, page.values)
+}
+
+func appendEncoding(encodings []parquet.Encoding, enc parquet.Encoding) []parquet.Encoding {
+	for _, e := range encodings {
+		if e == enc {
+			return encodings
+		}
+	}
+
+	return append(encodings, enc)
+}
+
+func pageEncodingStats(stats []*parquet.PageEncodingStats, typ parquet.PageType, enc parquet.Encoding) []*parquet.PageEncodingStats {
+	for _, s := range stats {
+		if s.PageType == typ && s.Encoding == enc {
+			s.Count++
+			return stats
+		}
+	}
+
+	return append(stats, &parquet.PageEncodingStats{PageType: typ, Encoding: enc, Count: 1})
+}
+
+// writeChunk writes the column chunk of a column and returns its meta data together with the
+// locations of its data pages.
+func writeChunk(w writePos, fw *FileWriter, col *Column, kvMetaData map[string]string) (*parquet.ColumnChunk, *parquet.OffsetIndex, error) {
 	pos := w.Pos() // Save the position before writing data
 	chunkOffset := pos
 	var (
 		dictPageOffset *int64
-		useDict        bool
 		// NOTE :
 		// This is documentation on these two field :
 		//  - TotalUncompressedSize: total byte size of all uncompressed pages in this column chunk (including the headers) *
 		//  - TotalCompressedSize: total byte size of all compressed pages in this column chunk (including the headers) *
 		// the including header part is confusing. for uncompressed size, we can use the position, but for the compressed
 		// the only value we have doesn't contain the header
-		totalComp   int64
-		totalUnComp int64
+		totalUnComp   int64
+		encodingStats []*parquet.PageEncodingStats
+		offsetIndex   = &parquet.OffsetIndex{}
+		firstRow      int64
 	)
-	if col.data.useDictionary() {
-		useDict = true
+
+	var (
+		enc      = fw.encryptor.column(col.Index())
+		rowGroup = int16(len(fw.rowGroups))
+		column   = int16(col.Index())
+	)
+
+	pages := splitDataPages(col, fw.maxPageSize)
+	dictLen := planDictionary(col, pages, fw.maxDictSize)
+	var remap []int32
+
+	if dictLen > 0 {
 		tmp := pos // make a copy, do not use the pos here
 		dictPageOffset = &tmp
-		dict := &dictPageWriter{}
-		if err := dict.init(schema, col, codec); err != nil {
-			return nil, err
+		dict := &dictPageWriter{values: col.data.values.distinctValues(dictLen)}
+		if fw.sortDictionaries {
+			dict.values, remap = sortDictionary(col, dict.values)
+			dict.sorted = remap != nil
+		}
+		if err := dict.init(col, fw.codec); err != nil {
+			return nil, nil, err
 		}
-		compSize, unCompSize, err := dict.write(w)
+		compSize, unCompSize, err := writePage(w, enc, rowGroup, column, 0, dict.write)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
-		totalComp = w.Pos() - pos
 		// Header size plus the rLevel and dLevel size
-		headerSize := totalComp - int64(compSize)
+		headerSize := w.Pos() - pos - int64(compSize)
 		totalUnComp = int64(unCompSize) + headerSize
+		encodingStats = pageEncodingStats(encodingStats, parquet.PageType_DICTIONARY_PAGE, parquet.Encoding_PLAIN)
 		pos = w.Pos() // Move position for data pos
 	}
 
-	page := pageFn(useDict)
-
-	if err := page.init(schema, col, codec); err != nil {
-		return nil, err
+	dataPageOffset := pos
+	encodings := []parquet.Encoding{parquet.Encoding_RLE}
+	if dictLen > 0 {
+		// In dictionary we use PLAIN for the dictionary page, not the column encoding
+		encodings = append(encodings, parquet.Encoding_PLAIN, parquet.Encoding_RLE_DICTIONARY)
 	}
 
-	compSize, unCompSize, err := page.write(w)
-	if err != nil {
-		return nil, err
-	}
+	for i, p := range pages {
+		p.rLevels = col.data.rLevels.subArray(p.levelStart, p.levelEnd)
+		p.dLevels = col.data.dLevels.subArray(p.levelStart, p.levelEnd)
+		p.numValues = int32(p.levelEnd - p.levelStart)
+		if p.dictionary {
+			p.indices = col.data.values.data[p.valueStart:p.valueEnd]
+			if remap != nil {
+				indices := make([]int32, len(p.indices))
+				for i, idx := range p.indices {
+					indices[i] = remap[idx]
+				}
+				p.indices = indices
+			}
+			p.dictLen = dictLen
+		} else {
+			p.values = col.data.values.valueRange(p.valueStart, p.valueEnd)
+		}
 
-	totalComp += w.Pos() - pos
-	// Header size plus the rLevel and dLevel size
-	headerSize := totalComp - int64(compSize)
-	totalUnComp += int64(unCompSize) + headerSize
+		page := fw.newPage(p.dictionary)
+		if err := page.init(col, fw.codec); err != nil {
+			return nil, nil, err
+		}
 
-	encodings := make([]parquet.Encoding, 0, 3)
-	encodings = append(encodings,
-		parquet.Encoding_RLE,
-		col.data.encoding(),
-	)
-	if useDict {
-		encodings[1] = parquet.Encoding_PLAIN // In dictionary we use PLAIN for the data, not the column encoding
-		encodings = append(encodings, parquet.Encoding_RLE_DICTIONARY)
+		compSize, unCompSize, err := writePage(w, enc, rowGroup, column, i, func(w io.Writer) (int, int, error) {
+			return page.write(w, p)
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Header size plus the rLevel and dLevel size
+		headerSize := w.Pos() - pos - int64(compSize)
+		totalUnComp += int64(unCompSize) + headerSize
+		offsetIndex.PageLocations = append(offsetIndex.PageLocations, &parquet.PageLocation{
+			Offset:             pos,
+			CompressedPageSize: int32(w.Pos() - pos),
+			FirstRowIndex:      firstRow,
+		})
+		firstRow += int64(p.numRows)
+		pos = w.Pos()
+
+		pageType := parquet.PageType_DATA_PAGE
+		if _, ok := page.(*dataPageWriterV2); ok {
+			pageType = parquet.PageType_DATA_PAGE_V2
+		}
+		enc := col.data.encoding()
+		if p.dictionary {
+			enc = parquet.Encoding_RLE_DICTIONARY
+		}
+		encodingStats = pageEncodingStats(encodingStats, pageType, enc)
+		encodings = appendEncoding(encodings, enc)
 	}
 
 	keyValueMetaData := make([]*parquet.KeyValue, 0, len(kvMetaData))
@@ -280,22 +518,22 @@ func writeChunk(w writePos, schema SchemaWriter, col *Column, codec parquet.Comp
 	}
 
 	ch := &parquet.ColumnChunk{
-		FilePath:   nil, // No support for external
+		FilePath:   nil, // set by writeRowGroup for external column chunks
 		FileOffset: chunkOffset,
 		MetaData: &parquet.ColumnMetaData{
 			Type:                  col.data.parquetType(),
 			Encodings:             encodings,
 			PathInSchema:          col.pathArray(),
-			Codec:                 codec,
+			Codec:                 fw.codec,
 			NumValues:             int64(col.data.values.numValues() + col.data.values.nullValueCount()),
 			TotalUncompressedSize: totalUnComp,
-			TotalCompressedSize:   totalComp,
+			TotalCompressedSize:   w.Pos() - chunkOffset,
 			KeyValueMetadata:      keyValueMetaData,
-			DataPageOffset:        pos,
+			DataPageOffset:        dataPageOffset,
 			IndexPageOffset:       nil,
 			DictionaryPageOffset:  dictPageOffset,
 			Statistics:            stats,
-			EncodingStats:         nil,
+			EncodingStats:         encodingStats,
 		},
 		OffsetIndexOffset: nil,
 		OffsetIndexLength: nil,
@@ -303,20 +541,103 @@ func writeChunk(w writePos, schema SchemaWriter, col *Column, codec parquet.Comp
 		ColumnIndexLength: nil,
 	}
 
-	return ch, nil
+	if enc != nil {
+		if err := enc.encryptColumnChunk(ch, rowGroup, column); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return ch, offsetIndex, nil
+}
+
+// writePage writes a page using write, and encrypts it if the column is encrypted. It returns the
+// compressed and uncompressed size of the page data.
+func writePage(w io.Writer, enc *columnEncryptor, rowGroup, column int16, page int, write func(io.Writer) (int, int, error)) (int, int, error) {
+	if enc == nil {
+		return write(w)
+	}
+
+	buf := &bytes.Buffer{}
+	_, unCompSize, err := write(buf)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	compSize, err := enc.writePage(w, buf.Bytes(), rowGroup, column, page)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return compSize, unCompSize, nil
 }
 
-func writeRowGroup(w writePos, schema SchemaWriter, codec parquet.CompressionCodec, pageFn newDataPageFunc, h *flushRowGroupOptionHandle) ([]*parquet.ColumnChunk, error) {
-	dataCols := schema.Columns()
-	var res = make([]*parquet.ColumnChunk, 0, len(dataCols))
+func writeRowGroup(w writePos, fw *FileWriter, h *flushRowGroupOptionHandle) ([]*parquet.ColumnChunk, []*parquet.OffsetIndex, error) {
+	dataCols := fw.Columns()
+	var (
+		res     = make([]*parquet.ColumnChunk, 0, len(dataCols))
+		indexes = make([]*parquet.OffsetIndex, 0, len(dataCols))
+	)
+	for name := range fw.external {
+		if col := fw.GetColumnByName(name); col == nil || col.Element().Type == nil {
+			return nil, nil, errors.Errorf("external column %q doesn't exist", name)
+		}
+	}
+
 	for _, ci := range dataCols {
-		ch, err := writeChunk(w, schema, ci, codec, pageFn, h.getMetaData(ci.FlatName()))
+		cw := w
+		ext := fw.external[ci.FlatName()]
+		if ext != nil {
+			cw = ext.w
+			if cw.Pos() == 0 {
+				if err := writeFull(cw, magic); err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+
+		ch, oi, err := writeChunk(cw, fw, ci, h.getMetaData(ci.FlatName()))
 		if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+		if ext != nil {
+			path := ext.path
+			ch.FilePath = &path
 		}
 
 		res = append(res, ch)
+		indexes = append(indexes, oi)
+	}
+
+	return res, indexes, nil
+}
+
+// writeOffsetIndexes writes the offset indexes of all column chunks and sets their offset and
+// length in the column chunks' meta data. Row groups without offset indexes are skipped. The
+// offset indexes of encrypted columns are encrypted.
+func writeOffsetIndexes(w writePos, rowGroups []*parquet.RowGroup, indexes [][]*parquet.OffsetIndex, e *fileEncryptor) error {
+	for i, rg := range rowGroups {
+		if indexes[i] == nil {
+			continue
+		}
+		for j, ch := range rg.Columns {
+			pos := w.Pos()
+			if enc := e.column(j); enc != nil {
+				module, err := enc.encryptOffsetIndex(indexes[i][j], int16(i), int16(j))
+				if err != nil {
+					return err
+				}
+				if err := writeFull(w, module); err != nil {
+					return err
+				}
+			} else if err := writeThrift(indexes[i][j], w); err != nil {
+				return err
+			}
+
+			length := int32(w.Pos() - pos)
+			ch.OffsetIndexOffset = &pos
+			ch.OffsetIndexLength = &length
+		}
 	}
 
-	return res, nil
+	return nil
 }
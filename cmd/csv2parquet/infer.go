@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sagia-inneractive/parquet-go/cmd/internal/decimal"
+)
+
+// timestampLayouts are the layouts of the values that are inferred as timestamps. Values without
+// time zone are interpreted as UTC.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+}
+
+const dateLayout = "2006-01-02"
+
+// maxInferredDecimalPrecision is the largest precision of inferred decimal columns. Columns
+// that require a larger precision are inferred as double.
+const maxInferredDecimalPrecision = 18
+
+var leadingZeroRegexp = regexp.MustCompile(`^[+-]?0[0-9]`)
+
+// columnReport describes how the type of a column was determined.
+type columnReport struct {
+	Name     string
+	Type     string
+	Source   string
+	Nulls    int
+	Examples int
+}
+
+// inferTypes determines the type of all columns that have no type hint from the sampled
+// records. The types are added to the types map. It returns a report of the types of all
+// columns.
+func inferTypes(header []string, sample [][]string, types map[string]string) []columnReport {
+	report := make([]columnReport, 0, len(header))
+	for idx, field := range header {
+		values := make([]string, 0, len(sample))
+		nulls := 0
+		for _, record := range sample {
+			if idx >= len(record) || record[idx] == "" {
+				nulls++
+				continue
+			}
+			values = append(values, record[idx])
+		}
+
+		source := "type hint"
+		if types[field] == "" {
+			types[field] = inferType(values)
+			source = "inferred"
+		}
+
+		report = append(report, columnReport{
+			Name:     field,
+			Type:     types[field],
+			Source:   source,
+			Nulls:    nulls,
+			Examples: len(values),
+		})
+	}
+
+	return report
+}
+
+// inferType returns the narrowest type that all values can be converted to. Columns without
+// any values are strings.
+func inferType(values []string) string {
+	if len(values) == 0 {
+		return "string"
+	}
+
+	checks := []struct {
+		typ   string
+		check func(string) bool
+	}{
+		{"boolean", isBoolean},
+		{"int", isInt},
+		{"date", isDate},
+		{"timestamp", isTimestamp},
+	}
+
+	for _, c := range checks {
+		if all(values, c.check) {
+			return c.typ
+		}
+	}
+
+	if precision, scale, ok := decimalPrecision(values); ok {
+		if precision <= maxInferredDecimalPrecision {
+			return fmt.Sprintf("decimal(%d,%d)", precision, scale)
+		}
+		return "double"
+	}
+
+	if all(values, isDouble) {
+		return "double"
+	}
+
+	return "string"
+}
+
+func all(values []string, check func(string) bool) bool {
+	for _, v := range values {
+		if !check(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// isBoolean returns true for the words strconv.ParseBool accepts, which booleanHandler uses, but
+// not for 1, 0, t and f, so that such values are inferred as integers or strings.
+func isBoolean(s string) bool {
+	switch s {
+	case "true", "True", "TRUE", "false", "False", "FALSE":
+		return true
+	}
+	return false
+}
+
+// isInt returns true for integers without leading zeros, so that values like zip codes stay
+// strings.
+func isInt(s string) bool {
+	digits := strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+	if len(digits) > 1 && digits[0] == '0' {
+		return false
+	}
+	_, err := strconv.ParseInt(s, 10, 64)
+	return err == nil
+}
+
+func isDate(s string) bool {
+	_, err := time.Parse(dateLayout, s)
+	return err == nil
+}
+
+func isTimestamp(s string) bool {
+	_, err := parseTimestamp(s)
+	return err == nil
+}
+
+func isDouble(s string) bool {
+	if !strings.ContainsAny(s, "0123456789") {
+		// ParseFloat also accepts values like "inf" or "NaN"
+		return false
+	}
+	if leadingZeroRegexp.MatchString(s) {
+		return false
+	}
+	if _, fraction, ok := decimal.Split(s); ok && fraction == "" && !isInt(s) {
+		// integers that are too large for an int64 would lose precision
+		return false
+	}
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// decimalPrecision returns the precision and scale of a decimal that all values fit into. It
+// returns false if not all values are decimal numbers, or if none of them has a fractional
+// part.
+func decimalPrecision(values []string) (precision, scale int, ok bool) {
+	intDigits, fraction := 0, false
+	for _, v := range values {
+		intPart, frac, ok := decimal.Split(v)
+		if !ok {
+			return 0, 0, false
+		}
+		if digits := len(strings.TrimLeft(intPart, "0")); digits > intDigits {
+			intDigits = digits
+		}
+		if frac != "" {
+			fraction = true
+			if len(frac) > scale {
+				scale = len(frac)
+			}
+		}
+	}
+
+	if !fraction {
+		return 0, 0, false
+	}
+
+	precision = intDigits + scale
+	if precision == 0 {
+		precision = 1
+	}
+	return precision, scale, true
+}
+
+func parseTimestamp(s string) (time.Time, error) {
+	var err error
+	for _, layout := range timestampLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// writeReport writes a table of the types of all columns.
+func writeReport(w io.Writer, report []columnReport, sampled int) error {
+	if _, err := fmt.Fprintf(w, "Column types, inferred from %d sampled records:\n", sampled); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "COLUMN\tTYPE\tSOURCE\tVALUES\tNULLS")
+	for _, c := range report {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\n", c.Name, c.Type, c.Source, c.Examples, c.Nulls)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInferType(t *testing.T) {
+	tests := map[string]struct {
+		Values       []string
+		ExpectedType string
+	}{
+		"empty":             {nil, "string"},
+		"boolean":           {[]string{"true", "FALSE", "True"}, "boolean"},
+		"boolean-numbers":   {[]string{"1", "0"}, "int"},
+		"boolean-casing":    {[]string{"true", "tRUE"}, "string"},
+		"int":               {[]string{"1", "-20", "+300"}, "int"},
+		"int-leading-zeros": {[]string{"1", "0123"}, "string"},
+		"int-too-large":     {[]string{"1", "99999999999999999999"}, "string"},
+		"decimal":           {[]string{"1.5", "-20", "0.125"}, "decimal(5,3)"},
+		"decimal-fraction":  {[]string{"0.5"}, "decimal(1,1)"},
+		"decimal-too-large": {[]string{"1234567890.123456789"}, "double"},
+		"double":            {[]string{"1.5", "2e10", "-3"}, "double"},
+		"double-nan":        {[]string{"1.5", "NaN"}, "string"},
+		"double-zeros":      {[]string{"1e5", "0123"}, "string"},
+		"date":              {[]string{"2020-05-01", "1999-12-31"}, "date"},
+		"timestamp":         {[]string{"2020-05-01T12:00:00Z", "2020-05-01 12:00:00", "2020-05-01 12:00:00.123456"}, "timestamp"},
+		"date-and-time":     {[]string{"2020-05-01", "2020-05-01 12:00:00"}, "string"},
+		"string":            {[]string{"1", "foo"}, "string"},
+	}
+
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			require.Equal(t, tt.ExpectedType, inferType(tt.Values))
+		})
+	}
+}
@@ -12,9 +12,11 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	goparquet "github.com/sagia-inneractive/parquet-go"
+	"github.com/sagia-inneractive/parquet-go/cmd/internal/decimal"
 	"github.com/sagia-inneractive/parquet-go/parquet"
 	"github.com/sagia-inneractive/parquet-go/parquetschema"
 )
@@ -29,6 +31,8 @@ func main() {
 	compressionCodec := flag.String("compression", "snappy", "compression algorithm; allowed values: "+strings.Join(validCompressionCodecs(), ", "))
 	delimiter := flag.String("delimiter", ",", "CSV field delimiter")
 	creator := flag.String("created-by", "csv2parquet", "value to set for CreatedBy field of parquet file")
+	sampleSize := flag.Int("sample-size", 1000, "number of records to infer the types of columns without type hint from; if value is 0, then all columns without type hint are strings")
+	reportFile := flag.String("report", "", "file to write a report of the column types to; use - for standard error")
 	verbose := flag.Bool("v", false, "enable verbose logging")
 	flag.Parse()
 
@@ -54,6 +58,10 @@ func main() {
 		}
 	}
 
+	if *sampleSize < 0 {
+		log.Fatalf("Invalid sample size %d", *sampleSize)
+	}
+
 	if *verbose {
 		printLog = log.Printf
 	}
@@ -69,6 +77,7 @@ func main() {
 	if err != nil {
 		log.Fatalf("Couldn't open input file: %v", err)
 	}
+	defer f.Close()
 
 	csvReader := csv.NewReader(f)
 
@@ -76,17 +85,27 @@ func main() {
 		csvReader.Comma = delimiterRune
 	}
 
-	records, err := csvReader.ReadAll()
+	header, err := csvReader.Read()
+	if err == io.EOF {
+		log.Fatalf("Input file is empty")
+	}
 	if err != nil {
-		log.Fatalf("Reading CSV content failed: %v", err)
+		log.Fatalf("Reading CSV header failed: %v", err)
 	}
 
-	f.Close()
+	sample, err := readSample(csvReader, *sampleSize)
+	if err != nil {
+		log.Fatalf("Reading CSV content failed: %v", err)
+	}
 
-	header := records[0]
-	records = records[1:]
+	printLog("Read %d records to infer the column types", len(sample))
 
-	printLog("Finished reading %s, got %d records", *inputFile, len(records))
+	report := inferTypes(header, sample, types)
+	if *reportFile != "" {
+		if err := writeReportFile(*reportFile, report, len(sample)); err != nil {
+			log.Fatalf("Couldn't write report: %v", err)
+		}
+	}
 
 	of, err := os.OpenFile(*outputFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 	if err != nil {
@@ -94,6 +113,7 @@ func main() {
 	}
 	defer of.Close()
 
+	records := &sampleReader{sample: sample, r: csvReader}
 	if err := writeParquetData(of, header, types, records, *creator, codec, *rowgroupSize); err != nil {
 		log.Fatalf("Couldn't write parquet data: %v", err)
 	}
@@ -101,7 +121,61 @@ func main() {
 	printLog("Finished generating output file %s", *outputFile)
 }
 
-func writeParquetData(of io.Writer, header []string, types map[string]string, records [][]string, creator string, codec parquet.CompressionCodec, rowgroupSize int64) error {
+// recordReader is a source of CSV records, like csv.Reader. Read returns io.EOF after the last
+// record.
+type recordReader interface {
+	Read() ([]string, error)
+}
+
+// readSample reads the first n records from r.
+func readSample(r recordReader, n int) ([][]string, error) {
+	sample := make([][]string, 0, n)
+	for len(sample) < n {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		sample = append(sample, record)
+	}
+	return sample, nil
+}
+
+// sampleReader returns the sampled records first, then the remaining records of r.
+type sampleReader struct {
+	sample [][]string
+	r      recordReader
+}
+
+func (s *sampleReader) Read() ([]string, error) {
+	if len(s.sample) > 0 {
+		record := s.sample[0]
+		s.sample = s.sample[1:]
+		return record, nil
+	}
+	return s.r.Read()
+}
+
+func writeReportFile(file string, report []columnReport, sampled int) error {
+	if file == "-" {
+		return writeReport(os.Stderr, report, sampled)
+	}
+
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+
+	if err := writeReport(f, report, sampled); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeParquetData(of io.Writer, header []string, types map[string]string, records recordReader, creator string, codec parquet.CompressionCodec, rowgroupSize int64) error {
 	schema, fieldHandlers, err := deriveSchema(header, types)
 	if err != nil {
 		return fmt.Errorf("generating schema failed: %w", err)
@@ -121,7 +195,15 @@ func writeParquetData(of io.Writer, header []string, types map[string]string, re
 
 	pqWriter := goparquet.NewFileWriter(of, writerOptions...)
 
-	for recordIndex, record := range records {
+	for recordIndex := 0; ; recordIndex++ {
+		record, err := records.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("reading input record %d failed: %w", recordIndex+1, err)
+		}
+
 		data := make(map[string]interface{})
 
 		if len(record) < len(header) {
@@ -133,7 +215,7 @@ func writeParquetData(of io.Writer, header []string, types map[string]string, re
 
 			v, err := handler(record[idx])
 			if err != nil {
-				return fmt.Errorf("in input record %d, couldn't convert value %q to type %s: %w", recordIndex+1, record[idx], types[fieldName], err)
+				return fmt.Errorf("in input record %d, couldn't convert value %q to type %s, use a type hint or a larger sample size: %w", recordIndex+1, record[idx], types[fieldName], err)
 			}
 			data[fieldName] = v
 		}
@@ -265,6 +347,21 @@ func createColumn(field, typ string) (col *parquetschema.ColumnDefinition, field
 		col.SchemaElement.LogicalType.INTEGER = &parquet.IntType{BitWidth: 64, IsSigned: true}
 		col.SchemaElement.ConvertedType = parquet.ConvertedTypePtr(parquet.ConvertedType_INT_64)
 		fieldHandler = intHandler(64)
+	case "date":
+		col.SchemaElement.Type = parquet.TypePtr(parquet.Type_INT32)
+		col.SchemaElement.LogicalType = parquet.NewLogicalType()
+		col.SchemaElement.LogicalType.DATE = &parquet.DateType{}
+		col.SchemaElement.ConvertedType = parquet.ConvertedTypePtr(parquet.ConvertedType_DATE)
+		fieldHandler = dateHandler
+	case "timestamp":
+		col.SchemaElement.Type = parquet.TypePtr(parquet.Type_INT64)
+		col.SchemaElement.LogicalType = parquet.NewLogicalType()
+		col.SchemaElement.LogicalType.TIMESTAMP = &parquet.TimestampType{
+			IsAdjustedToUTC: true,
+			Unit:            &parquet.TimeUnit{MICROS: &parquet.MicroSeconds{}},
+		}
+		col.SchemaElement.ConvertedType = parquet.ConvertedTypePtr(parquet.ConvertedType_TIMESTAMP_MICROS)
+		fieldHandler = timestampHandler
 	case "json":
 		col.SchemaElement.Type = parquet.TypePtr(parquet.Type_BYTE_ARRAY)
 		col.SchemaElement.LogicalType = parquet.NewLogicalType()
@@ -272,7 +369,21 @@ func createColumn(field, typ string) (col *parquetschema.ColumnDefinition, field
 		col.SchemaElement.ConvertedType = parquet.ConvertedTypePtr(parquet.ConvertedType_JSON)
 		fieldHandler = jsonHandler
 	default:
-		return nil, nil, fmt.Errorf("unsupported type %q", typ)
+		precision, scale, ok := parseDecimalType(typ)
+		if !ok {
+			return nil, nil, fmt.Errorf("unsupported type %q", typ)
+		}
+		col.SchemaElement.Type = parquet.TypePtr(parquet.Type_INT64)
+		if precision <= 9 {
+			col.SchemaElement.Type = parquet.TypePtr(parquet.Type_INT32)
+		}
+		col.SchemaElement.LogicalType = parquet.NewLogicalType()
+		col.SchemaElement.LogicalType.DECIMAL = &parquet.DecimalType{Precision: int32(precision), Scale: int32(scale)}
+		col.SchemaElement.ConvertedType = parquet.ConvertedTypePtr(parquet.ConvertedType_DECIMAL)
+		p, s := int32(precision), int32(scale)
+		col.SchemaElement.Precision = &p
+		col.SchemaElement.Scale = &s
+		fieldHandler = decimalHandler(precision, scale)
 	}
 
 	fieldHandler = optionalHandler(fieldHandler) // TODO: if we make repetition type configurable, change this to use correct handler.
@@ -287,8 +398,7 @@ func parseTypeHints(s string) (map[string]string, error) {
 		return typeMap, nil
 	}
 
-	hintsList := strings.Split(s, ",")
-	for _, hint := range hintsList {
+	for _, hint := range splitTypeHints(s) {
 		hint = strings.TrimSpace(hint)
 
 		hintFields := strings.Split(hint, "=")
@@ -309,6 +419,30 @@ func parseTypeHints(s string) (map[string]string, error) {
 	return typeMap, nil
 }
 
+// splitTypeHints splits the comma-separated list of type hints. Commas within parentheses, like
+// in decimal(10,2), don't separate type hints.
+func splitTypeHints(s string) []string {
+	var (
+		hints []string
+		depth int
+		start int
+	)
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				hints = append(hints, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(hints, s[start:])
+}
+
 var validTypes = map[string]bool{
 	"boolean":    true,
 	"int8":       true,
@@ -325,14 +459,45 @@ var validTypes = map[string]bool{
 	"string":     true,
 	"int":        true,
 	"json":       true,
+	"date":       true,
+	"timestamp":  true,
 	// TODO: support more data types
 }
 
+// maxDecimalPrecision is the largest precision of decimal columns, so that their values fit
+// into an int64.
+const maxDecimalPrecision = 18
+
+// parseDecimalType parses types of the form decimal(precision,scale).
+func parseDecimalType(typ string) (precision, scale int, ok bool) {
+	if !strings.HasPrefix(typ, "decimal(") || !strings.HasSuffix(typ, ")") {
+		return 0, 0, false
+	}
+
+	args := strings.Split(typ[len("decimal("):len(typ)-1], ",")
+	if len(args) != 2 {
+		return 0, 0, false
+	}
+
+	precision, err := strconv.Atoi(strings.TrimSpace(args[0]))
+	if err != nil || precision < 1 || precision > maxDecimalPrecision {
+		return 0, 0, false
+	}
+
+	scale, err = strconv.Atoi(strings.TrimSpace(args[1]))
+	if err != nil || scale < 0 || scale > precision {
+		return 0, 0, false
+	}
+
+	return precision, scale, true
+}
+
 func validTypeList() []string {
-	l := make([]string, 0, len(validTypes))
+	l := make([]string, 0, len(validTypes)+1)
 	for k := range validTypes {
 		l = append(l, k)
 	}
+	l = append(l, fmt.Sprintf("decimal(<precision>,<scale>) with a precision of up to %d", maxDecimalPrecision))
 	sort.Strings(l)
 	return l
 }
@@ -361,6 +526,9 @@ func lookupCompressionCodec(codec string) (parquet.CompressionCodec, error) {
 }
 
 func isValidType(t string) bool {
+	if _, _, ok := parseDecimalType(t); ok {
+		return true
+	}
 	return validTypes[t]
 }
 
@@ -416,6 +584,38 @@ func doubleHandler(s string) (interface{}, error) {
 	return f, err
 }
 
+func dateHandler(s string) (interface{}, error) {
+	t, err := time.Parse(dateLayout, s)
+	if err != nil {
+		return nil, err
+	}
+	return int32(t.Unix() / (24 * 60 * 60)), nil
+}
+
+func timestampHandler(s string) (interface{}, error) {
+	t, err := parseTimestamp(s)
+	if err != nil {
+		return nil, err
+	}
+	return t.Unix()*1000000 + int64(t.Nanosecond()/1000), nil
+}
+
+// decimalHandler converts decimal numbers into unscaled integers, which are int32 values for a
+// precision of up to 9 and int64 values otherwise.
+func decimalHandler(precision, scale int) func(string) (interface{}, error) {
+	return func(s string) (interface{}, error) {
+		i, err := decimal.Parse(s, precision, scale)
+		if err != nil {
+			return nil, err
+		}
+
+		if precision <= 9 {
+			return int32(i), nil
+		}
+		return i, nil
+	}
+}
+
 func jsonHandler(s string) (interface{}, error) {
 	data := []byte(s)
 	var obj interface{}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"bytes"
+	"encoding/csv"
+	"strings"
 	"testing"
 
 	goparquet "github.com/sagia-inneractive/parquet-go"
@@ -36,6 +38,18 @@ func TestParseTypeHints(t *testing.T) {
 			Input:     "foo=boolean=invalid",
 			ExpectErr: true,
 		},
+		"decimal": {
+			Input:          "foo=decimal(10,2),bar=date, baz = decimal(3, 0)",
+			ExpectedOutput: map[string]string{"foo": "decimal(10,2)", "bar": "date", "baz": "decimal(3, 0)"},
+		},
+		"decimal-too-large": {
+			Input:     "foo=decimal(19,2)",
+			ExpectErr: true,
+		},
+		"decimal-invalid-scale": {
+			Input:     "foo=decimal(4,5)",
+			ExpectErr: true,
+		},
 	}
 
 	for testName, tt := range tests {
@@ -77,6 +91,18 @@ func TestTypeHandlers(t *testing.T) {
 		"double":            {"4.2", doubleHandler, float64(4.2), false},
 		"json-simple":       {`{"hello":"world"}`, jsonHandler, []byte(`{"hello":"world"}`), false},
 		"json-invalid":      {`{"hello":"world`, jsonHandler, nil, true},
+		"date":              {"2020-05-01", dateHandler, int32(18383), false},
+		"date-before-epoch": {"1969-12-31", dateHandler, int32(-1), false},
+		"date-invalid":      {"2020-13-01", dateHandler, nil, true},
+		"timestamp":         {"2020-05-01 12:00:00.5", timestampHandler, int64(1588334400500000), false},
+		"timestamp-zone":    {"2020-05-01T14:00:00+02:00", timestampHandler, int64(1588334400000000), false},
+		"timestamp-invalid": {"2020-05-01 25:00:00", timestampHandler, nil, true},
+		"decimal-32":        {"-12.5", decimalHandler(5, 2), int32(-1250), false},
+		"decimal-64":        {"1234567890.12", decimalHandler(12, 2), int64(123456789012), false},
+		"decimal-fraction":  {".5", decimalHandler(5, 2), nil, true},
+		"decimal-zero":      {"000.0", decimalHandler(5, 2), int32(0), false},
+		"decimal-scale":     {"1.234", decimalHandler(5, 2), nil, true},
+		"decimal-precision": {"1234.5", decimalHandler(5, 2), nil, true},
 	}
 
 	for testName, tt := range tests {
@@ -209,6 +235,16 @@ func TestDeriveSchema(t *testing.T) {
 			ExpectedSchema: `message msg {
   optional binary x (JSON);
 }
+`,
+		},
+		"inferred-types": {
+			Header: []string{"a", "b", "c"},
+			Types:  map[string]string{"a": "date", "b": "timestamp", "c": "decimal(12,3)"},
+			ExpectedSchema: `message msg {
+  optional int32 a (DATE);
+  optional int64 b (TIMESTAMP(MICROS, true));
+  optional int64 c (DECIMAL(12, 3));
+}
 `,
 		},
 		"default-type": {
@@ -314,7 +350,7 @@ func TestWriteParquetData(t *testing.T) {
 				buf,
 				tt.Header,
 				tt.Types,
-				tt.Records,
+				&sampleReader{sample: tt.Records, r: csv.NewReader(strings.NewReader(""))},
 				"unit test",
 				parquet.CompressionCodec_SNAPPY,
 				150*1024*1024,
@@ -346,3 +382,80 @@ func TestWriteParquetData(t *testing.T) {
 		})
 	}
 }
+
+func TestConvertStreaming(t *testing.T) {
+	input := `id,name,price,day,zip,flag
+1,foo,1.50,2020-05-01,01234,true
+2,,2.25,2020-05-02,12345,
+3,bar,3,2020-05-03,,false
+4,baz,not a number,2020-05-04,12345,true
+`
+
+	csvReader := csv.NewReader(strings.NewReader(input))
+	header, err := csvReader.Read()
+	require.NoError(t, err)
+
+	sample, err := readSample(csvReader, 3)
+	require.NoError(t, err)
+	require.Len(t, sample, 3)
+
+	types := map[string]string{"zip": "string"}
+	report := inferTypes(header, sample, types)
+	require.Equal(t, []columnReport{
+		{Name: "id", Type: "int", Source: "inferred", Examples: 3},
+		{Name: "name", Type: "string", Source: "inferred", Nulls: 1, Examples: 2},
+		{Name: "price", Type: "decimal(3,2)", Source: "inferred", Examples: 3},
+		{Name: "day", Type: "date", Source: "inferred", Examples: 3},
+		{Name: "zip", Type: "string", Source: "type hint", Nulls: 1, Examples: 2},
+		{Name: "flag", Type: "boolean", Source: "inferred", Nulls: 1, Examples: 2},
+	}, report)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, writeReport(buf, report, len(sample)))
+	require.Equal(t, `Column types, inferred from 3 sampled records:
+COLUMN  TYPE          SOURCE     VALUES  NULLS
+id      int           inferred   3       0
+name    string        inferred   2       1
+price   decimal(3,2)  inferred   3       0
+day     date          inferred   3       0
+zip     string        type hint  2       1
+flag    boolean       inferred   2       1
+`, buf.String())
+
+	// the fourth record doesn't match the types inferred from the sample
+	err = writeParquetData(&bytes.Buffer{}, header, types, &sampleReader{sample: sample, r: csvReader}, "unit test", parquet.CompressionCodec_SNAPPY, 0)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "input record 4")
+
+	csvReader = csv.NewReader(strings.NewReader(input))
+	_, err = csvReader.Read()
+	require.NoError(t, err)
+	types["price"] = "string"
+
+	buf.Reset()
+	require.NoError(t, writeParquetData(buf, header, types, csvReader, "unit test", parquet.CompressionCodec_SNAPPY, 0))
+
+	pqReader, err := goparquet.NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, `message msg {
+  optional int64 id (INT(64, true));
+  optional binary name (STRING);
+  optional binary price (STRING);
+  optional int32 day (DATE);
+  optional binary zip (STRING);
+  optional boolean flag;
+}
+`, pqReader.GetSchemaDefinition().String())
+	require.Equal(t, int64(4), pqReader.NumRows())
+
+	row, err := pqReader.NextRow()
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"id":    int64(1),
+		"name":  []byte("foo"),
+		"price": []byte("1.50"),
+		"day":   int32(18383),
+		"zip":   []byte("01234"),
+		"flag":  true,
+	}, row)
+}
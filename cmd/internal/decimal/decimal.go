@@ -0,0 +1,53 @@
+// Package decimal parses the text representation of decimal numbers for the conversion tools.
+package decimal
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var numberRegexp = regexp.MustCompile(`^[+-]?([0-9]+)(?:\.([0-9]+))?$`)
+
+// Split returns the digits before and after the decimal point of a decimal number like -12.50,
+// and false if s is not a decimal number. Numbers without digits before the decimal point, like
+// .5, and numbers with exponents are not accepted.
+func Split(s string) (intPart, fraction string, ok bool) {
+	m := numberRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return "", "", false
+	}
+	return m[1], m[2], true
+}
+
+// Parse converts a decimal number into its unscaled integer value for the provided precision
+// and scale, e.g. -12.5 into -1250 for a scale of 2.
+func Parse(s string, precision, scale int) (int64, error) {
+	intPart, fraction, ok := Split(s)
+	if !ok {
+		return 0, errors.New("invalid decimal number")
+	}
+
+	intPart = strings.TrimLeft(intPart, "0")
+	if len(fraction) > scale {
+		return 0, fmt.Errorf("more than %d digits after the decimal point", scale)
+	}
+	if len(intPart) > precision-scale {
+		return 0, fmt.Errorf("more than %d digits before the decimal point", precision-scale)
+	}
+
+	digits := intPart + fraction + strings.Repeat("0", scale-len(fraction))
+	if digits == "" {
+		return 0, nil
+	}
+	i, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if strings.HasPrefix(s, "-") {
+		i = -i
+	}
+	return i, nil
+}
@@ -0,0 +1,53 @@
+package decimal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string]struct {
+		Input     string
+		Precision int
+		Scale     int
+		Expected  int64
+		ExpectErr bool
+	}{
+		"integer":         {"12", 5, 2, 1200, false},
+		"fraction":        {"-12.5", 5, 2, -1250, false},
+		"plus":            {"+0.05", 5, 2, 5, false},
+		"zero":            {"-0.00", 5, 2, 0, false},
+		"leading-zeros":   {"000123.4", 5, 2, 12340, false},
+		"scale-zero":      {"123", 3, 0, 123, false},
+		"max-precision":   {"12345678901234567.89", 19, 2, 1234567890123456789, false},
+		"too-many-digits": {"1234", 5, 2, 0, true},
+		"too-precise":     {"1.234", 5, 2, 0, true},
+		"no-int-part":     {".5", 5, 2, 0, true},
+		"exponent":        {"1e5", 10, 2, 0, true},
+		"empty":           {"", 5, 2, 0, true},
+		"text":            {"abc", 5, 2, 0, true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			i, err := Parse(tt.Input, tt.Precision, tt.Scale)
+			if tt.ExpectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.Expected, i)
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	intPart, fraction, ok := Split("-012.50")
+	require.True(t, ok)
+	require.Equal(t, "012", intPart)
+	require.Equal(t, "50", fraction)
+
+	_, _, ok = Split("1.")
+	require.False(t, ok)
+}
@@ -0,0 +1,593 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	goparquet "github.com/sagia-inneractive/parquet-go"
+	"github.com/sagia-inneractive/parquet-go/cmd/internal/decimal"
+	"github.com/sagia-inneractive/parquet-go/parquet"
+	"github.com/sagia-inneractive/parquet-go/parquetschema"
+)
+
+// errorMode determines how values that don't match the schema are handled.
+type errorMode int
+
+const (
+	// errorFail stops the conversion.
+	errorFail errorMode = iota
+	// errorSkip skips the record.
+	errorSkip
+	// errorNull stores null instead of the value if the column is optional, and skips the
+	// record otherwise.
+	errorNull
+)
+
+func parseErrorMode(s string) (errorMode, error) {
+	switch s {
+	case "fail":
+		return errorFail, nil
+	case "skip":
+		return errorSkip, nil
+	case "null":
+		return errorNull, nil
+	}
+	return 0, fmt.Errorf("invalid error mode %q", s)
+}
+
+const dateLayout = "2006-01-02"
+
+// converter converts decoded JSON records into rows of a schema.
+type converter struct {
+	schema *parquetschema.SchemaDefinition
+	// onError determines how values that don't match the schema are handled.
+	onError errorMode
+	// strictFields rejects records with fields that don't exist in the schema.
+	strictFields bool
+}
+
+// convertRecord converts a JSON object that was decoded with json.Decoder.UseNumber into a row.
+func (c *converter) convertRecord(v interface{}) (map[string]interface{}, error) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an object, got %s", jsonKind(v))
+	}
+	return c.convertObject(c.schema.RootColumn.Children, obj, "")
+}
+
+func (c *converter) convertObject(cols []*parquetschema.ColumnDefinition, obj map[string]interface{}, prefix string) (map[string]interface{}, error) {
+	if c.strictFields {
+		for k := range obj {
+			if findColumn(cols, k) == nil {
+				return nil, fmt.Errorf("%s: field doesn't exist in the schema", joinPath(prefix, k))
+			}
+		}
+	}
+
+	row := make(map[string]interface{}, len(cols))
+	for _, col := range cols {
+		name := col.SchemaElement.GetName()
+		path := joinPath(prefix, name)
+		optional := col.SchemaElement.GetRepetitionType() != parquet.FieldRepetitionType_REQUIRED
+
+		v, ok := obj[name]
+		if !ok || v == nil {
+			if !optional {
+				return nil, fmt.Errorf("%s: required value is missing", path)
+			}
+			continue
+		}
+
+		cv, err := c.convertField(col, v, path)
+		if err != nil {
+			if c.onError == errorNull && optional {
+				continue
+			}
+			return nil, err
+		}
+		if cv != nil {
+			row[name] = cv
+		}
+	}
+
+	return row, nil
+}
+
+func (c *converter) convertField(col *parquetschema.ColumnDefinition, v interface{}, path string) (interface{}, error) {
+	if col.SchemaElement.GetRepetitionType() != parquet.FieldRepetitionType_REPEATED {
+		return c.convertValue(col, v, path)
+	}
+
+	arr, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: expected an array, got %s", path, jsonKind(v))
+	}
+	return c.convertRepeated(col, arr, path)
+}
+
+// convertRepeated converts the values of a repeated column. Repeated groups are slices of maps,
+// repeated primitive columns are slices of the type of the column.
+func (c *converter) convertRepeated(col *parquetschema.ColumnDefinition, arr []interface{}, path string) (interface{}, error) {
+	if len(arr) == 0 {
+		return nil, nil
+	}
+
+	if col.SchemaElement.Type == nil {
+		groups := make([]map[string]interface{}, 0, len(arr))
+		for i, e := range arr {
+			obj, ok := e.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s[%d]: expected an object, got %s", path, i, jsonKind(e))
+			}
+			group, err := c.convertObject(col.Children, obj, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			groups = append(groups, group)
+		}
+		return groups, nil
+	}
+
+	var values reflect.Value
+	for i, e := range arr {
+		elemPath := fmt.Sprintf("%s[%d]", path, i)
+		if e == nil {
+			return nil, fmt.Errorf("%s: repeated values can't be null", elemPath)
+		}
+		v, err := convertPrimitive(col.SchemaElement, e, elemPath)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			values = reflect.MakeSlice(reflect.SliceOf(reflect.TypeOf(v)), 0, len(arr))
+		}
+		values = reflect.Append(values, reflect.ValueOf(v))
+	}
+	return values.Interface(), nil
+}
+
+func (c *converter) convertValue(col *parquetschema.ColumnDefinition, v interface{}, path string) (interface{}, error) {
+	elem := col.SchemaElement
+	if elem.Type != nil {
+		return convertPrimitive(elem, v, path)
+	}
+
+	switch {
+	case isList(elem) && len(col.Children) == 1:
+		return c.convertList(col, v, path)
+	case isMap(elem) && len(col.Children) == 1 && len(col.Children[0].Children) == 2:
+		return c.convertMap(col, v, path)
+	}
+
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: expected an object, got %s", path, jsonKind(v))
+	}
+	return c.convertObject(col.Children, obj, path)
+}
+
+// convertList converts an array into the value of a LIST group, which contains the list
+// elements in its repeated child.
+func (c *converter) convertList(col *parquetschema.ColumnDefinition, v interface{}, path string) (interface{}, error) {
+	arr, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: expected an array, got %s", path, jsonKind(v))
+	}
+
+	repeated := col.Children[0]
+	if repeated.SchemaElement.Type != nil || len(repeated.Children) != 1 {
+		// the two-level list representation, where the repeated child is the element itself
+		values, err := c.convertRepeated(repeated, arr, path)
+		if err != nil {
+			return nil, err
+		}
+		if values == nil {
+			return map[string]interface{}{}, nil
+		}
+		return map[string]interface{}{repeated.SchemaElement.GetName(): values}, nil
+	}
+
+	if len(arr) == 0 {
+		return map[string]interface{}{}, nil
+	}
+
+	element := repeated.Children[0]
+	elements := make([]map[string]interface{}, 0, len(arr))
+	for i, e := range arr {
+		elemPath := fmt.Sprintf("%s[%d]", path, i)
+		entry, err := c.convertEntry(element, e, elemPath)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, entry)
+	}
+
+	return map[string]interface{}{repeated.SchemaElement.GetName(): elements}, nil
+}
+
+// convertMap converts an object into the value of a MAP group, which contains the keys and
+// values in its repeated child.
+func (c *converter) convertMap(col *parquetschema.ColumnDefinition, v interface{}, path string) (interface{}, error) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: expected an object, got %s", path, jsonKind(v))
+	}
+
+	if len(obj) == 0 {
+		return map[string]interface{}{}, nil
+	}
+
+	keys := sortedKeys(obj)
+
+	repeated := col.Children[0]
+	keyCol, valueCol := repeated.Children[0], repeated.Children[1]
+
+	entries := make([]map[string]interface{}, 0, len(obj))
+	for _, k := range keys {
+		entryPath := fmt.Sprintf("%s[%q]", path, k)
+
+		key, err := mapKey(keyCol.SchemaElement, k)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", entryPath, err)
+		}
+		convertedKey, err := convertPrimitive(keyCol.SchemaElement, key, entryPath)
+		if err != nil {
+			return nil, err
+		}
+
+		entry, err := c.convertEntry(valueCol, obj[k], entryPath)
+		if err != nil {
+			return nil, err
+		}
+		entry[keyCol.SchemaElement.GetName()] = convertedKey
+		entries = append(entries, entry)
+	}
+
+	return map[string]interface{}{repeated.SchemaElement.GetName(): entries}, nil
+}
+
+// mapKey returns the JSON value a map key stands for, as keys of JSON objects are always
+// strings: booleans and numbers are read from their text representation, while dates,
+// timestamps and strings are kept as strings.
+func mapKey(elem *parquet.SchemaElement, k string) (interface{}, error) {
+	lt := elem.GetLogicalType()
+	if lt == nil {
+		lt = &parquet.LogicalType{}
+	}
+
+	switch elem.GetType() {
+	case parquet.Type_BOOLEAN:
+		b, err := strconv.ParseBool(k)
+		if err != nil {
+			return nil, fmt.Errorf("invalid boolean %q", k)
+		}
+		return b, nil
+	case parquet.Type_INT32:
+		if lt.DATE != nil || elem.GetConvertedType() == parquet.ConvertedType_DATE {
+			return k, nil
+		}
+	case parquet.Type_INT64:
+		if lt.TIMESTAMP != nil || elem.GetConvertedType() == parquet.ConvertedType_TIMESTAMP_MILLIS || elem.GetConvertedType() == parquet.ConvertedType_TIMESTAMP_MICROS {
+			return k, nil
+		}
+	case parquet.Type_INT96, parquet.Type_BYTE_ARRAY, parquet.Type_FIXED_LEN_BYTE_ARRAY:
+		return k, nil
+	}
+	return json.Number(k), nil
+}
+
+// convertEntry converts a list element or a map value into a map that only contains the value.
+func (c *converter) convertEntry(col *parquetschema.ColumnDefinition, v interface{}, path string) (map[string]interface{}, error) {
+	entry := make(map[string]interface{}, 2)
+	name := col.SchemaElement.GetName()
+	optional := col.SchemaElement.GetRepetitionType() != parquet.FieldRepetitionType_REQUIRED
+
+	if v == nil {
+		if !optional {
+			return nil, fmt.Errorf("%s: required value is missing", path)
+		}
+		return entry, nil
+	}
+
+	cv, err := c.convertField(col, v, path)
+	if err != nil {
+		if c.onError == errorNull && optional {
+			return entry, nil
+		}
+		return nil, err
+	}
+	if cv != nil {
+		entry[name] = cv
+	}
+	return entry, nil
+}
+
+// convertPrimitive converts a JSON value into the value of a primitive column. Dates,
+// timestamps, decimals and UUIDs are read from their string representation, JSON columns
+// contain the encoded value.
+func convertPrimitive(elem *parquet.SchemaElement, v interface{}, path string) (interface{}, error) {
+	ret, err := convertPrimitiveValue(elem, v)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return ret, nil
+}
+
+func convertPrimitiveValue(elem *parquet.SchemaElement, v interface{}) (interface{}, error) {
+	lt := elem.GetLogicalType()
+	if lt == nil {
+		lt = &parquet.LogicalType{}
+	}
+
+	switch elem.GetType() {
+	case parquet.Type_BOOLEAN:
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected a boolean, got %s", jsonKind(v))
+		}
+		return b, nil
+
+	case parquet.Type_INT32:
+		switch {
+		case lt.DATE != nil || elem.GetConvertedType() == parquet.ConvertedType_DATE:
+			t, err := parseTime(v, dateLayout)
+			if err != nil {
+				return nil, err
+			}
+			return int32(t.Unix() / (24 * 60 * 60)), nil
+		case lt.DECIMAL != nil || elem.GetConvertedType() == parquet.ConvertedType_DECIMAL:
+			i, err := parseDecimal(v, int(elem.GetPrecision()), int(elem.GetScale()))
+			return int32(i), err
+		case isUnsigned(elem):
+			n, err := parseNumber(v)
+			if err != nil {
+				return nil, err
+			}
+			// the column stores the bits of unsigned integers in signed ones
+			u, err := strconv.ParseUint(n, 10, bitSize(elem))
+			return int32(uint32(u)), err
+		}
+		n, err := parseNumber(v)
+		if err != nil {
+			return nil, err
+		}
+		i, err := strconv.ParseInt(n, 10, bitSize(elem))
+		return int32(i), err
+
+	case parquet.Type_INT64:
+		switch {
+		case lt.TIMESTAMP != nil || elem.GetConvertedType() == parquet.ConvertedType_TIMESTAMP_MILLIS || elem.GetConvertedType() == parquet.ConvertedType_TIMESTAMP_MICROS:
+			if _, ok := v.(json.Number); ok {
+				// numbers are stored as they are
+				break
+			}
+			t, err := parseTime(v, time.RFC3339Nano)
+			if err != nil {
+				return nil, err
+			}
+			return timestampValue(elem, t), nil
+		case lt.DECIMAL != nil || elem.GetConvertedType() == parquet.ConvertedType_DECIMAL:
+			return parseDecimal(v, int(elem.GetPrecision()), int(elem.GetScale()))
+		case isUnsigned(elem):
+			n, err := parseNumber(v)
+			if err != nil {
+				return nil, err
+			}
+			u, err := strconv.ParseUint(n, 10, 64)
+			return int64(u), err
+		}
+		n, err := parseNumber(v)
+		if err != nil {
+			return nil, err
+		}
+		return strconv.ParseInt(n, 10, 64)
+
+	case parquet.Type_INT96:
+		t, err := parseTime(v, time.RFC3339Nano)
+		if err != nil {
+			return nil, err
+		}
+		return goparquet.TimeToInt96(t), nil
+
+	case parquet.Type_FLOAT:
+		n, err := parseNumber(v)
+		if err != nil {
+			return nil, err
+		}
+		f, err := strconv.ParseFloat(n, 32)
+		return float32(f), err
+
+	case parquet.Type_DOUBLE:
+		n, err := parseNumber(v)
+		if err != nil {
+			return nil, err
+		}
+		return strconv.ParseFloat(n, 64)
+
+	case parquet.Type_BYTE_ARRAY:
+		switch {
+		case lt.JSON != nil || elem.GetConvertedType() == parquet.ConvertedType_JSON:
+			return json.Marshal(v)
+		case lt.DECIMAL != nil || elem.GetConvertedType() == parquet.ConvertedType_DECIMAL:
+			return nil, errors.New("decimals stored as byte arrays are not supported")
+		}
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a string, got %s", jsonKind(v))
+		}
+		return []byte(s), nil
+
+	case parquet.Type_FIXED_LEN_BYTE_ARRAY:
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a string, got %s", jsonKind(v))
+		}
+		switch {
+		case lt.UUID != nil:
+			return parseUUID(s)
+		case lt.DECIMAL != nil || elem.GetConvertedType() == parquet.ConvertedType_DECIMAL:
+			return nil, errors.New("decimals stored as fixed length byte arrays are not supported")
+		}
+		if len(s) != int(elem.GetTypeLength()) {
+			return nil, fmt.Errorf("expected a string of length %d, got length %d", elem.GetTypeLength(), len(s))
+		}
+		return []byte(s), nil
+	}
+
+	return nil, fmt.Errorf("unsupported type %s", elem.GetType())
+}
+
+func parseNumber(v interface{}) (string, error) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return "", fmt.Errorf("expected a number, got %s", jsonKind(v))
+	}
+	return n.String(), nil
+}
+
+func parseTime(v interface{}, layout string) (time.Time, error) {
+	s, ok := v.(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("expected a string, got %s", jsonKind(v))
+	}
+	return time.Parse(layout, s)
+}
+
+func timestampValue(elem *parquet.SchemaElement, t time.Time) int64 {
+	var unit *parquet.TimeUnit
+	if lt := elem.GetLogicalType(); lt != nil && lt.TIMESTAMP != nil {
+		unit = lt.TIMESTAMP.Unit
+	}
+
+	switch {
+	case unit != nil && unit.NANOS != nil:
+		return t.UnixNano()
+	case unit != nil && unit.MILLIS != nil, unit == nil && elem.GetConvertedType() == parquet.ConvertedType_TIMESTAMP_MILLIS:
+		return t.Unix()*1000 + int64(t.Nanosecond()/1000000)
+	}
+	return t.Unix()*1000000 + int64(t.Nanosecond()/1000)
+}
+
+// parseDecimal converts a decimal number, provided as number or string, into its unscaled
+// integer value.
+func parseDecimal(v interface{}, precision, scale int) (int64, error) {
+	var s string
+	switch x := v.(type) {
+	case json.Number:
+		s = x.String()
+	case string:
+		s = x
+	default:
+		return 0, fmt.Errorf("expected a decimal number, got %s", jsonKind(v))
+	}
+
+	i, err := decimal.Parse(s, precision, scale)
+	if err != nil {
+		return 0, fmt.Errorf("decimal number %q: %w", s, err)
+	}
+	return i, nil
+}
+
+// parseUUID parses a UUID in its canonical form, e.g. 123e4567-e89b-12d3-a456-426614174000.
+func parseUUID(s string) ([]byte, error) {
+	hex := strings.Replace(s, "-", "", -1)
+	if len(hex) != 32 || len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return nil, fmt.Errorf("invalid UUID %q", s)
+	}
+
+	b := make([]byte, 16)
+	for i := range b {
+		v, err := strconv.ParseUint(hex[2*i:2*i+2], 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid UUID %q", s)
+		}
+		b[i] = byte(v)
+	}
+	return b, nil
+}
+
+func isList(elem *parquet.SchemaElement) bool {
+	return elem.GetConvertedType() == parquet.ConvertedType_LIST || (elem.LogicalType != nil && elem.LogicalType.IsSetLIST())
+}
+
+func isMap(elem *parquet.SchemaElement) bool {
+	return elem.GetConvertedType() == parquet.ConvertedType_MAP || (elem.LogicalType != nil && elem.LogicalType.IsSetMAP())
+}
+
+func isUnsigned(elem *parquet.SchemaElement) bool {
+	if lt := elem.GetLogicalType(); lt != nil && lt.IsSetINTEGER() {
+		return !lt.INTEGER.IsSigned
+	}
+
+	switch elem.GetConvertedType() {
+	case parquet.ConvertedType_UINT_8, parquet.ConvertedType_UINT_16, parquet.ConvertedType_UINT_32, parquet.ConvertedType_UINT_64:
+		return true
+	}
+	return false
+}
+
+// bitSize returns the bit width of an INT32 column, which is smaller than 32 for INT(8) and
+// INT(16) columns.
+func bitSize(elem *parquet.SchemaElement) int {
+	if lt := elem.GetLogicalType(); lt != nil && lt.IsSetINTEGER() && lt.INTEGER.BitWidth < 32 {
+		return int(lt.INTEGER.BitWidth)
+	}
+
+	switch elem.GetConvertedType() {
+	case parquet.ConvertedType_INT_8, parquet.ConvertedType_UINT_8:
+		return 8
+	case parquet.ConvertedType_INT_16, parquet.ConvertedType_UINT_16:
+		return 16
+	}
+	return 32
+}
+
+func findColumn(cols []*parquetschema.ColumnDefinition, name string) *parquetschema.ColumnDefinition {
+	for _, c := range cols {
+		if c.SchemaElement.GetName() == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func sortedKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// jsonKind returns the kind of a decoded JSON value for error messages.
+func jsonKind(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "a boolean"
+	case json.Number, float64:
+		return "a number"
+	case string:
+		return "a string"
+	case []interface{}:
+		return "an array"
+	case map[string]interface{}:
+		return "an object"
+	}
+	return fmt.Sprintf("%T", v)
+}
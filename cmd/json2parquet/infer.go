@@ -0,0 +1,297 @@
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sagia-inneractive/parquet-go/parquet"
+	"github.com/sagia-inneractive/parquet-go/parquetschema"
+)
+
+// kind is the kind of the values of a field, as far as it is known from the sampled records.
+type kind int
+
+const (
+	// kindNull is the kind of fields that only contained nulls so far.
+	kindNull kind = iota
+	kindBool
+	kindInt
+	kindFloat
+	kindString
+	kindObject
+	kindArray
+	// kindJSON is the kind of fields whose values have conflicting kinds. They are stored as
+	// JSON-encoded strings.
+	kindJSON
+)
+
+// identifierRegexp matches the field names that can be used as column names. Objects with
+// other keys are stored as maps.
+var identifierRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// node describes the values of a field in the sampled records.
+type node struct {
+	kind kind
+
+	// dates and timestamps are true if all strings are dates or timestamps respectively.
+	dates      bool
+	timestamps bool
+
+	// names contains the names of the fields of objects in order of their first appearance.
+	names  []string
+	fields map[string]*node
+
+	// elem describes the elements of arrays.
+	elem *node
+}
+
+// inferrer determines a schema from sampled records.
+type inferrer struct {
+	root *node
+	// maxFields is the number of distinct fields after which objects are stored as maps.
+	maxFields int
+	// maps contains the paths of objects that are always stored as maps.
+	maps map[string]bool
+}
+
+func newInferrer(maxFields int, maps []string) *inferrer {
+	in := &inferrer{
+		root:      &node{kind: kindObject, fields: make(map[string]*node)},
+		maxFields: maxFields,
+		maps:      make(map[string]bool, len(maps)),
+	}
+	for _, path := range maps {
+		in.maps[path] = true
+	}
+	return in
+}
+
+// add adds a decoded record to the sample.
+func (in *inferrer) add(record map[string]interface{}) {
+	in.root = merge(in.root, newNode(record))
+}
+
+func newNode(v interface{}) *node {
+	switch x := v.(type) {
+	case bool:
+		return &node{kind: kindBool}
+	case json.Number:
+		if strings.ContainsAny(x.String(), ".eE") {
+			return &node{kind: kindFloat}
+		}
+		if _, err := x.Int64(); err != nil {
+			// integers that are too large for an int64 would lose precision as double
+			return &node{kind: kindJSON}
+		}
+		return &node{kind: kindInt}
+	case string:
+		return &node{kind: kindString, dates: isDate(x), timestamps: isTimestamp(x)}
+	case map[string]interface{}:
+		n := &node{kind: kindObject, fields: make(map[string]*node, len(x))}
+		for _, name := range sortedKeys(x) {
+			n.names = append(n.names, name)
+			n.fields[name] = newNode(x[name])
+		}
+		return n
+	case []interface{}:
+		n := &node{kind: kindArray}
+		for _, e := range x {
+			n.elem = merge(n.elem, newNode(e))
+		}
+		return n
+	}
+	return &node{kind: kindNull}
+}
+
+// merge combines the descriptions of two sets of values of the same field.
+func merge(a, b *node) *node {
+	switch {
+	case a == nil || a.kind == kindNull:
+		return b
+	case b == nil || b.kind == kindNull:
+		return a
+	case a.kind == kindJSON || b.kind == kindJSON:
+		return &node{kind: kindJSON}
+	}
+
+	if a.kind != b.kind {
+		if (a.kind == kindInt || a.kind == kindFloat) && (b.kind == kindInt || b.kind == kindFloat) {
+			return &node{kind: kindFloat}
+		}
+		return &node{kind: kindJSON}
+	}
+
+	switch a.kind {
+	case kindString:
+		a.dates = a.dates && b.dates
+		a.timestamps = a.timestamps && b.timestamps
+	case kindObject:
+		for _, name := range b.names {
+			if _, ok := a.fields[name]; !ok {
+				a.names = append(a.names, name)
+			}
+			a.fields[name] = merge(a.fields[name], b.fields[name])
+		}
+	case kindArray:
+		a.elem = merge(a.elem, b.elem)
+	}
+	return a
+}
+
+// schema returns the schema of the sampled records. All columns are optional.
+func (in *inferrer) schema() *parquetschema.SchemaDefinition {
+	root := &parquetschema.ColumnDefinition{
+		SchemaElement: &parquet.SchemaElement{
+			Name: "msg",
+		},
+	}
+	for _, name := range in.root.names {
+		root.Children = append(root.Children, in.column(name, in.root.fields[name], name))
+	}
+	return parquetschema.SchemaDefinitionFromColumnDefinition(root)
+}
+
+func (in *inferrer) column(name string, n *node, path string) *parquetschema.ColumnDefinition {
+	col := &parquetschema.ColumnDefinition{
+		SchemaElement: &parquet.SchemaElement{
+			Name:           name,
+			RepetitionType: parquet.FieldRepetitionTypePtr(parquet.FieldRepetitionType_OPTIONAL),
+		},
+	}
+	elem := col.SchemaElement
+
+	switch n.kind {
+	case kindBool:
+		elem.Type = parquet.TypePtr(parquet.Type_BOOLEAN)
+	case kindInt:
+		elem.Type = parquet.TypePtr(parquet.Type_INT64)
+	case kindFloat:
+		elem.Type = parquet.TypePtr(parquet.Type_DOUBLE)
+	case kindString:
+		switch {
+		case n.dates:
+			elem.Type = parquet.TypePtr(parquet.Type_INT32)
+			elem.LogicalType = &parquet.LogicalType{DATE: &parquet.DateType{}}
+			elem.ConvertedType = parquet.ConvertedTypePtr(parquet.ConvertedType_DATE)
+		case n.timestamps:
+			elem.Type = parquet.TypePtr(parquet.Type_INT64)
+			elem.LogicalType = &parquet.LogicalType{TIMESTAMP: &parquet.TimestampType{
+				IsAdjustedToUTC: true,
+				Unit:            &parquet.TimeUnit{MICROS: &parquet.MicroSeconds{}},
+			}}
+			elem.ConvertedType = parquet.ConvertedTypePtr(parquet.ConvertedType_TIMESTAMP_MICROS)
+		default:
+			setString(elem)
+		}
+	case kindObject:
+		switch {
+		case len(n.names) == 0:
+			// objects without any fields can't be stored as groups
+			setJSON(elem)
+		case in.isMap(n, path):
+			in.setMap(col, n, path)
+		default:
+			for _, fieldName := range n.names {
+				col.Children = append(col.Children, in.column(fieldName, n.fields[fieldName], path+"."+fieldName))
+			}
+		}
+	case kindArray:
+		elemNode := n.elem
+		if elemNode == nil {
+			elemNode = &node{kind: kindNull}
+		}
+		elem.LogicalType = &parquet.LogicalType{LIST: &parquet.ListType{}}
+		elem.ConvertedType = parquet.ConvertedTypePtr(parquet.ConvertedType_LIST)
+		col.Children = []*parquetschema.ColumnDefinition{
+			{
+				SchemaElement: &parquet.SchemaElement{
+					Name:           "list",
+					RepetitionType: parquet.FieldRepetitionTypePtr(parquet.FieldRepetitionType_REPEATED),
+				},
+				Children: []*parquetschema.ColumnDefinition{
+					in.column("element", elemNode, path+".element"),
+				},
+			},
+		}
+	case kindJSON:
+		setJSON(elem)
+	default:
+		// fields that were always null are stored as strings
+		setString(elem)
+	}
+
+	return col
+}
+
+// isMap returns true if the object is stored as a map, because it was configured so, one of its
+// keys can't be used as a column name, or it has too many distinct keys.
+func (in *inferrer) isMap(n *node, path string) bool {
+	if in.maps[path] || len(n.names) > in.maxFields {
+		return true
+	}
+	for _, name := range n.names {
+		if !identifierRegexp.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// setMap turns col into a map with string keys, whose values combine all fields of the object.
+func (in *inferrer) setMap(col *parquetschema.ColumnDefinition, n *node, path string) {
+	var value *node
+	for _, name := range n.names {
+		value = merge(value, n.fields[name])
+	}
+	if value == nil {
+		value = &node{kind: kindNull}
+	}
+
+	key := &parquetschema.ColumnDefinition{
+		SchemaElement: &parquet.SchemaElement{
+			Name:           "key",
+			RepetitionType: parquet.FieldRepetitionTypePtr(parquet.FieldRepetitionType_REQUIRED),
+		},
+	}
+	setString(key.SchemaElement)
+
+	col.SchemaElement.LogicalType = &parquet.LogicalType{MAP: &parquet.MapType{}}
+	col.SchemaElement.ConvertedType = parquet.ConvertedTypePtr(parquet.ConvertedType_MAP)
+	col.Children = []*parquetschema.ColumnDefinition{
+		{
+			SchemaElement: &parquet.SchemaElement{
+				Name:           "key_value",
+				RepetitionType: parquet.FieldRepetitionTypePtr(parquet.FieldRepetitionType_REPEATED),
+				ConvertedType:  parquet.ConvertedTypePtr(parquet.ConvertedType_MAP_KEY_VALUE),
+			},
+			Children: []*parquetschema.ColumnDefinition{
+				key,
+				in.column("value", value, path+".value"),
+			},
+		},
+	}
+}
+
+func setString(elem *parquet.SchemaElement) {
+	elem.Type = parquet.TypePtr(parquet.Type_BYTE_ARRAY)
+	elem.LogicalType = &parquet.LogicalType{STRING: &parquet.StringType{}}
+	elem.ConvertedType = parquet.ConvertedTypePtr(parquet.ConvertedType_UTF8)
+}
+
+func setJSON(elem *parquet.SchemaElement) {
+	elem.Type = parquet.TypePtr(parquet.Type_BYTE_ARRAY)
+	elem.LogicalType = &parquet.LogicalType{JSON: &parquet.JsonType{}}
+	elem.ConvertedType = parquet.ConvertedTypePtr(parquet.ConvertedType_JSON)
+}
+
+func isDate(s string) bool {
+	_, err := time.Parse(dateLayout, s)
+	return err == nil
+}
+
+func isTimestamp(s string) bool {
+	_, err := time.Parse(time.RFC3339Nano, s)
+	return err == nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInferSchema(t *testing.T) {
+	tests := map[string]struct {
+		Input     string
+		Maps      []string
+		MaxFields int
+		Expected  string
+		ExpectErr bool
+	}{
+		"primitives": {
+			Input: `{"id": 1, "name": "a", "ok": true, "score": 1, "day": "2020-01-02", "ts": "2020-01-02T03:04:05Z"}
+{"id": 2, "score": 1.5, "ok": null}`,
+			Expected: `message msg {
+  optional int32 day (DATE);
+  optional int64 id;
+  optional binary name (STRING);
+  optional boolean ok;
+  optional double score;
+  optional int64 ts (TIMESTAMP(MICROS, true));
+}
+`,
+		},
+		"conflicts": {
+			Input: `{"mixed": 1, "day": "2020-01-02", "big": 123456789012345678901234, "nothing": null, "empty": {}}
+{"mixed": "x", "day": "yesterday"}`,
+			Expected: `message msg {
+  optional binary big (JSON);
+  optional binary day (STRING);
+  optional binary empty (JSON);
+  optional binary mixed (JSON);
+  optional binary nothing (STRING);
+}
+`,
+		},
+		"nested": {
+			Input: `{"addr": {"city": "B"}, "tags": ["x"], "points": [{"x": 1}]}
+{"addr": {"street": "S"}, "tags": [], "points": [{"y": 2.5}, null]}`,
+			Expected: `message msg {
+  optional group addr {
+    optional binary city (STRING);
+    optional binary street (STRING);
+  }
+  optional group points (LIST) {
+    repeated group list {
+      optional group element {
+        optional int64 x;
+        optional double y;
+      }
+    }
+  }
+  optional group tags (LIST) {
+    repeated group list {
+      optional binary element (STRING);
+    }
+  }
+}
+`,
+		},
+		"maps": {
+			Input:     `{"attrs": {"a-b": 1, "c": 2.5}, "forced": {"a": "x"}, "many": {"a": true, "b": false, "c": true}}`,
+			Maps:      []string{"forced"},
+			MaxFields: 2,
+			Expected: `message msg {
+  optional group attrs (MAP) {
+    repeated group key_value (MAP_KEY_VALUE) {
+      required binary key (STRING);
+      optional double value;
+    }
+  }
+  optional group forced (MAP) {
+    repeated group key_value (MAP_KEY_VALUE) {
+      required binary key (STRING);
+      optional binary value (STRING);
+    }
+  }
+  optional group many (MAP) {
+    repeated group key_value (MAP_KEY_VALUE) {
+      required binary key (STRING);
+      optional boolean value;
+    }
+  }
+}
+`,
+		},
+		"invalid-column-name": {
+			Input:     `{"a b": 1}`,
+			ExpectErr: true,
+		},
+		"no-fields": {
+			Input:     `{}`,
+			ExpectErr: true,
+		},
+	}
+
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			r, err := newJSONReader(strings.NewReader(tt.Input))
+			require.NoError(t, err)
+			sample, err := readSample(r, 100)
+			require.NoError(t, err)
+
+			maxFields := tt.MaxFields
+			if maxFields == 0 {
+				maxFields = 100
+			}
+			schema, err := inferSchema(sample, maxFields, tt.Maps)
+			if tt.ExpectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.Expected, schema.String())
+		})
+	}
+}
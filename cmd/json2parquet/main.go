@@ -0,0 +1,370 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	goparquet "github.com/sagia-inneractive/parquet-go"
+	"github.com/sagia-inneractive/parquet-go/parquet"
+	"github.com/sagia-inneractive/parquet-go/parquetschema"
+)
+
+var printLog = func(string, ...interface{}) {}
+
+func main() {
+	inputFile := flag.String("input", "", "JSON file input, either newline-delimited JSON objects or an array of objects; use - for standard input")
+	outputFile := flag.String("output", "", "output parquet file")
+	schemaFile := flag.String("schema", "", "file that contains the parquet schema definition; if not set, the schema is inferred from the first records")
+	sampleSize := flag.Int("sample-size", 1000, "number of records to infer the schema from")
+	writeSchemaFile := flag.String("write-schema", "", "file to write the schema definition to; use - for standard error")
+	onError := flag.String("on-error", "fail", "what to do with records that don't match the schema; allowed values: fail, skip, null (store null instead of invalid optional values)")
+	unknownFields := flag.String("unknown-fields", "ignore", "what to do with fields that don't exist in the schema; allowed values: ignore, fail")
+	maps := flag.String("maps", "", "comma-separated list of dot-separated paths of objects that are inferred as maps instead of groups")
+	maxFields := flag.Int("max-fields", 100, "number of distinct fields after which objects are inferred as maps instead of groups")
+	rowgroupSize := flag.Int64("rowgroup-size", 100*1024*1024, "row group size in bytes; if value is 0, then the row group size is unbounded")
+	compressionCodec := flag.String("compression", "snappy", "compression algorithm; allowed values: "+strings.Join(validCompressionCodecs(), ", "))
+	creator := flag.String("created-by", "json2parquet", "value to set for CreatedBy field of parquet file")
+	verbose := flag.Bool("v", false, "enable verbose logging")
+	flag.Parse()
+
+	if *inputFile == "" {
+		log.Fatalf("Empty input file parameter")
+	}
+
+	if *outputFile == "" {
+		log.Fatalf("Empty output file parameter")
+	}
+
+	codec, err := lookupCompressionCodec(*compressionCodec)
+	if err != nil {
+		log.Fatalf("Invalid compression codec %q: %v", *compressionCodec, err)
+	}
+
+	mode, err := parseErrorMode(*onError)
+	if err != nil {
+		log.Fatalf("Invalid -on-error value: %v", err)
+	}
+
+	if *unknownFields != "ignore" && *unknownFields != "fail" {
+		log.Fatalf("Invalid -unknown-fields value %q", *unknownFields)
+	}
+
+	if *sampleSize < 1 {
+		log.Fatalf("Invalid sample size %d", *sampleSize)
+	}
+
+	if *verbose {
+		printLog = log.Printf
+	}
+
+	var in io.Reader = os.Stdin
+	if *inputFile != "-" {
+		printLog("Opening %s...", *inputFile)
+
+		f, err := os.Open(*inputFile)
+		if err != nil {
+			log.Fatalf("Couldn't open input file: %v", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	records, err := newJSONReader(in)
+	if err != nil {
+		log.Fatalf("Reading input failed: %v", err)
+	}
+
+	var (
+		schema *parquetschema.SchemaDefinition
+		sample []map[string]interface{}
+	)
+	if *schemaFile != "" {
+		schema, err = readSchemaFile(*schemaFile)
+		if err != nil {
+			log.Fatalf("Couldn't read schema: %v", err)
+		}
+	} else {
+		sample, err = readSample(records, *sampleSize)
+		if err != nil {
+			log.Fatalf("Reading input failed: %v", err)
+		}
+
+		printLog("Read %d records to infer the schema", len(sample))
+
+		schema, err = inferSchema(sample, *maxFields, splitList(*maps))
+		if err != nil {
+			log.Fatalf("Inferring schema failed: %v", err)
+		}
+	}
+
+	if *writeSchemaFile != "" {
+		if err := writeSchema(*writeSchemaFile, schema); err != nil {
+			log.Fatalf("Couldn't write schema: %v", err)
+		}
+	}
+
+	of, err := os.OpenFile(*outputFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		log.Fatalf("Couldn't open output file: %v", err)
+	}
+	defer of.Close()
+
+	c := &converter{
+		schema:       schema,
+		onError:      mode,
+		strictFields: *unknownFields == "fail",
+	}
+	skipped, err := writeParquetData(of, c, &sampleReader{sample: sample, r: records}, *creator, codec, *rowgroupSize)
+	if err != nil {
+		log.Fatalf("Couldn't write parquet data: %v", err)
+	}
+
+	if skipped > 0 {
+		log.Printf("Skipped %d records that didn't match the schema", skipped)
+	}
+
+	printLog("Finished generating output file %s", *outputFile)
+}
+
+// recordReader is a source of decoded JSON records. Read returns io.EOF after the last record.
+type recordReader interface {
+	Read() (map[string]interface{}, error)
+}
+
+// jsonReader reads records from newline-delimited JSON, or from a JSON array of objects.
+type jsonReader struct {
+	dec   *json.Decoder
+	array bool
+	index int
+}
+
+// newJSONReader returns a reader for the records of r. Numbers are decoded as json.Number, so
+// that integers don't lose precision.
+func newJSONReader(r io.Reader) (*jsonReader, error) {
+	br := bufio.NewReader(r)
+
+	var first byte
+	for {
+		b, err := br.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+			first = b
+			if err := br.UnreadByte(); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	jr := &jsonReader{dec: json.NewDecoder(br), array: first == '['}
+	jr.dec.UseNumber()
+	if jr.array {
+		if _, err := jr.dec.Token(); err != nil {
+			return nil, err
+		}
+	}
+	return jr, nil
+}
+
+func (r *jsonReader) Read() (map[string]interface{}, error) {
+	if r.array && !r.dec.More() {
+		if _, err := r.dec.Token(); err != nil {
+			return nil, fmt.Errorf("reading end of array failed: %w", err)
+		}
+		return nil, io.EOF
+	}
+
+	r.index++
+
+	var v interface{}
+	if err := r.dec.Decode(&v); err != nil {
+		if err == io.EOF && !r.array {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("decoding record %d failed: %w", r.index, err)
+	}
+
+	record, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("record %d is %s instead of an object", r.index, jsonKind(v))
+	}
+	return record, nil
+}
+
+// readSample reads the first n records from r. Together with sampleReader, it works like its
+// counterpart in csv2parquet, but for JSON objects instead of CSV records. The two can't be
+// shared without giving up the types of the records.
+func readSample(r recordReader, n int) ([]map[string]interface{}, error) {
+	sample := make([]map[string]interface{}, 0, n)
+	for len(sample) < n {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		sample = append(sample, record)
+	}
+	return sample, nil
+}
+
+// sampleReader returns the sampled records first, then the remaining records of r.
+type sampleReader struct {
+	sample []map[string]interface{}
+	r      recordReader
+}
+
+func (s *sampleReader) Read() (map[string]interface{}, error) {
+	if len(s.sample) > 0 {
+		record := s.sample[0]
+		s.sample = s.sample[1:]
+		return record, nil
+	}
+	return s.r.Read()
+}
+
+// inferSchema returns the schema of the sampled records. Objects whose paths are listed in maps
+// are stored as maps.
+func inferSchema(sample []map[string]interface{}, maxFields int, maps []string) (*parquetschema.SchemaDefinition, error) {
+	in := newInferrer(maxFields, maps)
+	for _, record := range sample {
+		in.add(record)
+	}
+
+	schema := in.schema()
+	if len(schema.RootColumn.Children) == 0 {
+		return nil, errors.New("no fields found in the sampled records")
+	}
+	for _, col := range schema.RootColumn.Children {
+		if !identifierRegexp.MatchString(col.SchemaElement.GetName()) {
+			return nil, fmt.Errorf("field name %q can't be used as column name, provide a schema instead", col.SchemaElement.GetName())
+		}
+	}
+
+	if err := schema.ValidateStrict(); err != nil {
+		return nil, fmt.Errorf("validation of inferred schema failed: %w", err)
+	}
+	return schema, nil
+}
+
+func readSchemaFile(file string) (*parquetschema.SchemaDefinition, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := parquetschema.ParseSchemaDefinition(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("parsing schema definition failed: %w", err)
+	}
+	return schema, nil
+}
+
+func writeSchema(file string, schema *parquetschema.SchemaDefinition) error {
+	if file == "-" {
+		_, err := fmt.Fprint(os.Stderr, schema.String())
+		return err
+	}
+	return ioutil.WriteFile(file, []byte(schema.String()), 0644)
+}
+
+// writeParquetData converts all records and writes them to of. It returns the number of
+// records that were skipped because they didn't match the schema.
+func writeParquetData(of io.Writer, c *converter, records recordReader, creator string, codec parquet.CompressionCodec, rowgroupSize int64) (int, error) {
+	printLog("Parquet schema: %s", c.schema.String())
+
+	writerOptions := []goparquet.FileWriterOption{
+		goparquet.WithCreator(creator),
+		goparquet.WithSchemaDefinition(c.schema),
+		goparquet.WithCompressionCodec(codec),
+	}
+
+	if rowgroupSize > 0 {
+		writerOptions = append(writerOptions, goparquet.WithMaxRowGroupSize(rowgroupSize))
+	}
+
+	pqWriter := goparquet.NewFileWriter(of, writerOptions...)
+
+	skipped := 0
+	for recordIndex := 0; ; recordIndex++ {
+		record, err := records.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return skipped, fmt.Errorf("reading input record %d failed: %w", recordIndex+1, err)
+		}
+
+		data, err := c.convertRecord(record)
+		if err != nil {
+			if c.onError != errorFail {
+				printLog("Skipping input record %d: %v", recordIndex+1, err)
+				skipped++
+				continue
+			}
+			return skipped, fmt.Errorf("in input record %d, %v", recordIndex+1, err)
+		}
+
+		if err := pqWriter.AddData(data); err != nil {
+			return skipped, fmt.Errorf("in input record %d, adding data failed: %w", recordIndex+1, err)
+		}
+	}
+
+	if err := pqWriter.Close(); err != nil {
+		return skipped, fmt.Errorf("closing parquet writer failed: %w", err)
+	}
+
+	return skipped, nil
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	var l []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			l = append(l, v)
+		}
+	}
+	return l
+}
+
+func validCompressionCodecs() []string {
+	registeredCodecs := goparquet.GetRegisteredBlockCompressors()
+
+	l := make([]string, 0, len(registeredCodecs))
+	for k := range registeredCodecs {
+		l = append(l, strings.ToLower(k.String()))
+	}
+	sort.Strings(l)
+	return l
+}
+
+func lookupCompressionCodec(codec string) (parquet.CompressionCodec, error) {
+	registeredCodecs := goparquet.GetRegisteredBlockCompressors()
+
+	for c := range registeredCodecs {
+		if strings.ToLower(c.String()) == codec {
+			return c, nil
+		}
+	}
+
+	return parquet.CompressionCodec_UNCOMPRESSED, errors.New("unsupported compression codec")
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	goparquet "github.com/sagia-inneractive/parquet-go"
+	"github.com/sagia-inneractive/parquet-go/parquet"
+	"github.com/sagia-inneractive/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+func readRecords(t *testing.T, input string) []map[string]interface{} {
+	r, err := newJSONReader(strings.NewReader(input))
+	require.NoError(t, err)
+	records, err := readSample(r, 100)
+	require.NoError(t, err)
+	return records
+}
+
+func TestJSONReader(t *testing.T) {
+	tests := map[string]struct {
+		Input     string
+		Expected  int
+		ExpectErr bool
+	}{
+		"ndjson":             {Input: "{\"a\": 1}\n{\"a\": 2}\n\n{\"a\": 3}\n", Expected: 3},
+		"array":              {Input: "  \n[{\"a\": 1}, {\"a\": 2}]", Expected: 2},
+		"empty":              {Input: "", Expected: 0},
+		"empty-array":        {Input: "[]", Expected: 0},
+		"no-object":          {Input: "{\"a\": 1}\n2\n", ExpectErr: true},
+		"malformed":          {Input: "{\"a\": 1}\n{\"a\": \n", ExpectErr: true},
+		"unterminated-array": {Input: "[{\"a\": 1}", ExpectErr: true},
+	}
+
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			r, err := newJSONReader(strings.NewReader(tt.Input))
+			require.NoError(t, err)
+			records, err := readSample(r, 100)
+			if tt.ExpectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, records, tt.Expected)
+		})
+	}
+
+	// numbers are decoded without losing precision
+	records := readRecords(t, `{"a": 9007199254740993}`)
+	v, err := convertPrimitive(&parquet.SchemaElement{Type: parquet.TypePtr(parquet.Type_INT64)}, records[0]["a"], "a")
+	require.NoError(t, err)
+	require.Equal(t, int64(9007199254740993), v)
+}
+
+const convertSchema = `message msg {
+  required int64 id;
+  optional binary name (STRING);
+  optional int32 small (INT(8, true));
+  optional int64 count (INT(64, false));
+  optional int32 ucount (INT(32, false));
+  optional int32 tiny (UINT_8);
+  optional float ratio;
+  optional int32 day (DATE);
+  optional int64 ts (TIMESTAMP(MILLIS, true));
+  optional int64 price (DECIMAL(10, 2));
+  optional binary extra (JSON);
+  optional fixed_len_byte_array(16) uuid (UUID);
+  optional int96 legacy;
+  repeated int64 numbers;
+  optional group address {
+    required binary city (STRING);
+    optional binary zip (STRING);
+  }
+  repeated group items {
+    optional int64 qty;
+  }
+  optional group tags (LIST) {
+    repeated group list {
+      optional binary element (STRING);
+    }
+  }
+  optional group scores (MAP) {
+    repeated group key_value (MAP_KEY_VALUE) {
+      required binary key (STRING);
+      required double value;
+    }
+  }
+  optional group codes (MAP) {
+    repeated group key_value (MAP_KEY_VALUE) {
+      required int32 key;
+      optional binary value (STRING);
+    }
+  }
+  optional group flags (MAP) {
+    repeated group key_value (MAP_KEY_VALUE) {
+      required boolean key;
+      optional int64 value;
+    }
+  }
+  optional group days (MAP) {
+    repeated group key_value (MAP_KEY_VALUE) {
+      required int32 key (DATE);
+      optional int64 value;
+    }
+  }
+  optional group times (MAP) {
+    repeated group key_value (MAP_KEY_VALUE) {
+      required int64 key (TIMESTAMP(MILLIS, true));
+      optional int64 value;
+    }
+  }
+}`
+
+func TestConvertRecord(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(convertSchema)
+	require.NoError(t, err)
+
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 6000000, time.UTC)
+
+	tests := map[string]struct {
+		Input        string
+		Mode         errorMode
+		StrictFields bool
+		Expected     map[string]interface{}
+		ExpectErr    bool
+	}{
+		"all-types": {
+			Input: `{"id": 1, "name": "a", "small": -3, "count": 18446744073709551615, "ratio": 0.5, "day": "1970-01-11",
+				"ts": "2020-01-02T03:04:05.006Z", "price": "-12.3", "extra": {"x": [1, null]}, "uuid": "123e4567-e89b-12d3-a456-426614174000",
+				"legacy": "2020-01-02T03:04:05.006Z", "numbers": [1, 2], "address": {"city": "B"}, "items": [{"qty": 1}, {}],
+				"tags": ["x", null], "scores": {"b": 2, "a": 1.5}, "codes": {"10": "ten", "2": null}, "unknown": 1}`,
+			Expected: map[string]interface{}{
+				"id":      int64(1),
+				"name":    []byte("a"),
+				"small":   int32(-3),
+				"count":   int64(-1),
+				"ratio":   float32(0.5),
+				"day":     int32(10),
+				"ts":      ts.UnixNano() / 1000000,
+				"price":   int64(-1230),
+				"extra":   []byte(`{"x":[1,null]}`),
+				"uuid":    []byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00},
+				"legacy":  goparquet.TimeToInt96(ts),
+				"numbers": []int64{1, 2},
+				"address": map[string]interface{}{"city": []byte("B")},
+				"items":   []map[string]interface{}{{"qty": int64(1)}, {}},
+				"tags": map[string]interface{}{"list": []map[string]interface{}{
+					{"element": []byte("x")},
+					{},
+				}},
+				"scores": map[string]interface{}{"key_value": []map[string]interface{}{
+					{"key": []byte("a"), "value": 1.5},
+					{"key": []byte("b"), "value": float64(2)},
+				}},
+				"codes": map[string]interface{}{"key_value": []map[string]interface{}{
+					{"key": int32(10), "value": []byte("ten")},
+					{"key": int32(2)},
+				}},
+			},
+		},
+		"nulls": {
+			Input:    `{"id": 1, "name": null, "numbers": [], "tags": []}`,
+			Expected: map[string]interface{}{"id": int64(1), "tags": map[string]interface{}{}},
+		},
+		"missing-required": {
+			Input:     `{"name": "a"}`,
+			ExpectErr: true,
+		},
+		"missing-required-nested": {
+			Input:     `{"id": 1, "address": {"zip": "1"}}`,
+			ExpectErr: true,
+		},
+		"invalid-type": {
+			Input:     `{"id": 1, "name": 1}`,
+			ExpectErr: true,
+		},
+		"out-of-range": {
+			Input:     `{"id": 1, "small": 128}`,
+			ExpectErr: true,
+		},
+		"invalid-decimal": {
+			Input:     `{"id": 1, "price": 1.234}`,
+			ExpectErr: true,
+		},
+		"invalid-uuid": {
+			Input:     `{"id": 1, "uuid": "123"}`,
+			ExpectErr: true,
+		},
+		"unsigned": {
+			Input: `{"id": 1, "count": 9223372036854775808, "ucount": 4294967295, "tiny": 255}`,
+			Expected: map[string]interface{}{
+				"id":     int64(1),
+				"count":  int64(-9223372036854775808),
+				"ucount": int32(-1),
+				"tiny":   int32(255),
+			},
+		},
+		"negative-unsigned": {
+			Input:     `{"id": 1, "ucount": -1}`,
+			ExpectErr: true,
+		},
+		"unsigned-out-of-range": {
+			Input:     `{"id": 1, "tiny": 256}`,
+			ExpectErr: true,
+		},
+		"null-in-repeated": {
+			Input:     `{"id": 1, "numbers": [1, null]}`,
+			ExpectErr: true,
+		},
+		"map-keys": {
+			Input: `{"id": 1, "flags": {"true": 1, "false": 0}, "days": {"1970-01-11": 1}, "times": {"2020-01-02T03:04:05.006Z": 2}}`,
+			Expected: map[string]interface{}{
+				"id": int64(1),
+				"flags": map[string]interface{}{"key_value": []map[string]interface{}{
+					{"key": false, "value": int64(0)},
+					{"key": true, "value": int64(1)},
+				}},
+				"days": map[string]interface{}{"key_value": []map[string]interface{}{
+					{"key": int32(10), "value": int64(1)},
+				}},
+				"times": map[string]interface{}{"key_value": []map[string]interface{}{
+					{"key": ts.UnixNano() / 1000000, "value": int64(2)},
+				}},
+			},
+		},
+		"invalid-map-key": {
+			Input:     `{"id": 1, "codes": {"x": "y"}}`,
+			ExpectErr: true,
+		},
+		"invalid-boolean-map-key": {
+			Input:     `{"id": 1, "flags": {"yes": 1}}`,
+			ExpectErr: true,
+		},
+		"null-mode": {
+			Input:    `{"id": 1, "name": 1, "small": 128, "address": {"zip": "1"}, "tags": ["x", 2], "scores": {"a": "x"}}`,
+			Mode:     errorNull,
+			Expected: map[string]interface{}{"id": int64(1), "tags": map[string]interface{}{"list": []map[string]interface{}{{"element": []byte("x")}, {}}}},
+		},
+		"null-mode-required": {
+			Input:     `{"id": "1"}`,
+			Mode:      errorNull,
+			ExpectErr: true,
+		},
+		"unknown-fields": {
+			Input:        `{"id": 1, "address": {"city": "B", "country": "C"}}`,
+			StrictFields: true,
+			ExpectErr:    true,
+		},
+	}
+
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			c := &converter{schema: sd, onError: tt.Mode, strictFields: tt.StrictFields}
+			row, err := c.convertRecord(readRecords(t, tt.Input)[0])
+			if tt.ExpectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.Expected, row)
+		})
+	}
+}
+
+func TestWriteParquetData(t *testing.T) {
+	input := `[
+		{"id": 1, "name": "a", "addr": {"city": "B"}, "tags": ["x", "y"], "attrs": {"a-b": 1}},
+		{"id": 2, "addr": null, "tags": [], "attrs": {}},
+		{"id": "3"},
+		{"id": 4, "name": "d", "tags": [null]}
+	]`
+
+	records, err := newJSONReader(strings.NewReader(input))
+	require.NoError(t, err)
+	sample, err := readSample(records, 2)
+	require.NoError(t, err)
+	schema, err := inferSchema(sample, 100, nil)
+	require.NoError(t, err)
+
+	c := &converter{schema: schema, onError: errorSkip}
+	var buf bytes.Buffer
+	skipped, err := writeParquetData(&buf, c, &sampleReader{sample: sample, r: records}, "json2parquet", parquet.CompressionCodec_SNAPPY, 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, skipped)
+
+	r, err := goparquet.NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, int64(3), r.NumRows())
+
+	var rows []map[string]interface{}
+	for {
+		row, err := r.NextRow()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		rows = append(rows, row)
+	}
+
+	require.Equal(t, []map[string]interface{}{
+		{
+			"id":    int64(1),
+			"name":  []byte("a"),
+			"addr":  map[string]interface{}{"city": []byte("B")},
+			"tags":  map[string]interface{}{"list": []map[string]interface{}{{"element": []byte("x")}, {"element": []byte("y")}}},
+			"attrs": map[string]interface{}{"key_value": []map[string]interface{}{{"key": []byte("a-b"), "value": int64(1)}}},
+		},
+		{
+			"id":    int64(2),
+			"tags":  map[string]interface{}{},
+			"attrs": map[string]interface{}{},
+		},
+		{
+			"id":   int64(4),
+			"name": []byte("d"),
+			"tags": map[string]interface{}{"list": []map[string]interface{}{{}}},
+		},
+	}, rows)
+
+	// conversion errors stop the conversion by default
+	records, err = newJSONReader(strings.NewReader(input))
+	require.NoError(t, err)
+	c.onError = errorFail
+	_, err = writeParquetData(&bytes.Buffer{}, c, records, "json2parquet", parquet.CompressionCodec_SNAPPY, 0)
+	require.EqualError(t, err, "in input record 3, id: expected a number, got a string")
+}
+
+func TestWriteParquetDataUnsigned(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(`message msg {
+		required int32 a (INT(32, false));
+		optional int64 b (UINT_64);
+	}`)
+	require.NoError(t, err)
+
+	records, err := newJSONReader(strings.NewReader(`[
+		{"a": 1, "b": 2},
+		{"a": 2147483648, "b": 9223372036854775808},
+		{"a": 4294967295, "b": 18446744073709551615}
+	]`))
+	require.NoError(t, err)
+
+	c := &converter{schema: sd, onError: errorFail}
+	var buf bytes.Buffer
+	skipped, err := writeParquetData(&buf, c, records, "json2parquet", parquet.CompressionCodec_SNAPPY, 0)
+	require.NoError(t, err)
+	require.Equal(t, 0, skipped)
+
+	r, err := goparquet.NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+
+	var a []uint32
+	var b []uint64
+	for {
+		row, err := r.NextRow()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		a = append(a, row["a"].(uint32))
+		b = append(b, row["b"].(uint64))
+	}
+	require.Equal(t, []uint32{1, 2147483648, 4294967295}, a)
+	require.Equal(t, []uint64{2, 9223372036854775808, 18446744073709551615}, b)
+}
@@ -0,0 +1,684 @@
+package cmds
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"time"
+
+	goparquet "github.com/sagia-inneractive/parquet-go"
+	"github.com/sagia-inneractive/parquet-go/parquet"
+	"github.com/sagia-inneractive/parquet-go/parquetschema"
+)
+
+// arrowBatchSize is the number of rows per record batch of the Arrow IPC stream.
+const arrowBatchSize = 64 * 1024
+
+// Values of the Arrow flatbuffers schema, see Schema.fbs and Message.fbs in the Arrow format
+// specification.
+const (
+	arrowMetadataV5 = 4
+
+	arrowHeaderSchema      = 1
+	arrowHeaderRecordBatch = 3
+
+	arrowTypeInt             = 2
+	arrowTypeFloatingPoint   = 3
+	arrowTypeBinary          = 4
+	arrowTypeUtf8            = 5
+	arrowTypeBool            = 6
+	arrowTypeDecimal         = 7
+	arrowTypeDate            = 8
+	arrowTypeTime            = 9
+	arrowTypeTimestamp       = 10
+	arrowTypeList            = 12
+	arrowTypeStruct          = 13
+	arrowTypeFixedSizeBinary = 15
+	arrowTypeMap             = 17
+
+	arrowPrecisionSingle = 1
+	arrowPrecisionDouble = 2
+
+	arrowTimeUnitMilli = 1
+	arrowTimeUnitMicro = 2
+	arrowTimeUnitNano  = 3
+
+	arrowMaxDecimalPrecision = 38
+)
+
+// arrowArray builds the data of a column of a record batch.
+type arrowArray interface {
+	// appendValue appends a value as returned by the FileReader, nil appends null.
+	appendValue(v interface{}) error
+	// field returns the flatbuffers Field of the array.
+	field(name string, nullable bool) *fbTable
+	// write adds the field nodes and buffers of the array and its children to the body.
+	write(b *arrowBody)
+	reset()
+}
+
+// arrowBody collects the field nodes and buffers of a record batch.
+type arrowBody struct {
+	nodes   []byte
+	buffers []byte
+	data    []byte
+}
+
+func (b *arrowBody) addNode(length, nulls int) {
+	var node [16]byte
+	binary.LittleEndian.PutUint64(node[0:], uint64(length))
+	binary.LittleEndian.PutUint64(node[8:], uint64(nulls))
+	b.nodes = append(b.nodes, node[:]...)
+}
+
+func (b *arrowBody) addBuffer(data []byte) {
+	var buf [16]byte
+	binary.LittleEndian.PutUint64(buf[0:], uint64(len(b.data)))
+	binary.LittleEndian.PutUint64(buf[8:], uint64(len(data)))
+	b.buffers = append(b.buffers, buf[:]...)
+
+	b.data = append(b.data, data...)
+	for len(b.data)%8 != 0 {
+		b.data = append(b.data, 0)
+	}
+}
+
+// validity is the validity bitmap of an array.
+type validity struct {
+	bits  []byte
+	n     int
+	nulls int
+}
+
+func (v *validity) add(valid bool) {
+	if v.n%8 == 0 {
+		v.bits = append(v.bits, 0)
+	}
+	if valid {
+		v.bits[v.n/8] |= 1 << uint(v.n%8)
+	} else {
+		v.nulls++
+	}
+	v.n++
+}
+
+func (v *validity) write(b *arrowBody) {
+	b.addNode(v.n, v.nulls)
+	b.addBuffer(v.bits)
+}
+
+func (v *validity) reset() {
+	v.bits, v.n, v.nulls = v.bits[:0], 0, 0
+}
+
+func newField(name string, nullable bool, typeID byte, typ *fbTable, children ...*fbTable) *fbTable {
+	if typ == nil {
+		typ = &fbTable{}
+	}
+	return (&fbTable{}).
+		addOffset(0, fbString(name)).
+		addBool(1, nullable).
+		addUint8(2, typeID).
+		addOffset(3, typ).
+		addOffset(5, fbTables(children))
+}
+
+// boolArray is an array of booleans.
+type boolArray struct {
+	validity
+	values validity
+}
+
+func (a *boolArray) appendValue(v interface{}) error {
+	if v == nil {
+		a.add(false)
+		a.values.add(false)
+		return nil
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return fmt.Errorf("unexpected value of type %T for boolean", v)
+	}
+	a.add(true)
+	a.values.add(b)
+	return nil
+}
+
+func (a *boolArray) field(name string, nullable bool) *fbTable {
+	return newField(name, nullable, arrowTypeBool, nil)
+}
+
+func (a *boolArray) write(b *arrowBody) {
+	a.validity.write(b)
+	b.addBuffer(a.values.bits)
+}
+
+func (a *boolArray) reset() {
+	a.validity.reset()
+	a.values.reset()
+}
+
+// fixedArray is an array of values with a fixed width, encoded by encode.
+type fixedArray struct {
+	validity
+	width  int
+	data   []byte
+	typeID byte
+	typ    *fbTable
+	encode func(v interface{}, dst []byte) error
+}
+
+func (a *fixedArray) appendValue(v interface{}) error {
+	start := len(a.data)
+	a.data = append(a.data, make([]byte, a.width)...)
+	if v == nil {
+		a.add(false)
+		return nil
+	}
+	if err := a.encode(v, a.data[start:]); err != nil {
+		return err
+	}
+	a.add(true)
+	return nil
+}
+
+func (a *fixedArray) field(name string, nullable bool) *fbTable {
+	return newField(name, nullable, a.typeID, a.typ)
+}
+
+func (a *fixedArray) write(b *arrowBody) {
+	a.validity.write(b)
+	b.addBuffer(a.data)
+}
+
+func (a *fixedArray) reset() {
+	a.validity.reset()
+	a.data = a.data[:0]
+}
+
+// binaryArray is an array of variable length binary values or strings.
+type binaryArray struct {
+	validity
+	offsets []byte
+	data    []byte
+	typeID  byte
+}
+
+func (a *binaryArray) appendValue(v interface{}) error {
+	if len(a.offsets) == 0 {
+		a.offsets = append(a.offsets, 0, 0, 0, 0)
+	}
+	if v != nil {
+		b, ok := v.([]byte)
+		if !ok {
+			return fmt.Errorf("unexpected value of type %T for binary", v)
+		}
+		a.data = append(a.data, b...)
+	}
+	a.add(v != nil)
+	a.offsets = appendUint32(a.offsets, uint32(len(a.data)))
+	return nil
+}
+
+func (a *binaryArray) field(name string, nullable bool) *fbTable {
+	return newField(name, nullable, a.typeID, nil)
+}
+
+func (a *binaryArray) write(b *arrowBody) {
+	if len(a.offsets) == 0 {
+		a.offsets = append(a.offsets, 0, 0, 0, 0)
+	}
+	a.validity.write(b)
+	b.addBuffer(a.offsets)
+	b.addBuffer(a.data)
+}
+
+func (a *binaryArray) reset() {
+	a.validity.reset()
+	a.offsets, a.data = a.offsets[:0], a.data[:0]
+}
+
+// listArray is an array of lists or maps, whose elements are stored in the child array. The
+// items function returns the elements of a value. Repeated columns have no null values, their
+// missing values are empty lists.
+type listArray struct {
+	validity
+	offsets   []byte
+	count     int
+	child     arrowArray
+	childName string
+	nullable  bool
+	isMap     bool
+	repeated  bool
+	items     func(v interface{}) ([]interface{}, error)
+}
+
+func (a *listArray) appendValue(v interface{}) error {
+	if len(a.offsets) == 0 {
+		a.offsets = append(a.offsets, 0, 0, 0, 0)
+	}
+	if v != nil {
+		items, err := a.items(v)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if err := a.child.appendValue(item); err != nil {
+				return err
+			}
+		}
+		a.count += len(items)
+	}
+	a.add(v != nil || a.repeated)
+	a.offsets = appendUint32(a.offsets, uint32(a.count))
+	return nil
+}
+
+func (a *listArray) field(name string, nullable bool) *fbTable {
+	child := a.child.field(a.childName, a.nullable)
+	if a.isMap {
+		return newField(name, nullable, arrowTypeMap, (&fbTable{}).addBool(0, false), child)
+	}
+	return newField(name, nullable, arrowTypeList, nil, child)
+}
+
+func (a *listArray) write(b *arrowBody) {
+	if len(a.offsets) == 0 {
+		a.offsets = append(a.offsets, 0, 0, 0, 0)
+	}
+	a.validity.write(b)
+	b.addBuffer(a.offsets)
+	a.child.write(b)
+}
+
+func (a *listArray) reset() {
+	a.validity.reset()
+	a.offsets, a.count = a.offsets[:0], 0
+	a.child.reset()
+}
+
+// structArray is an array of groups, whose fields are stored in the child arrays.
+type structArray struct {
+	validity
+	names     []string
+	nullables []bool
+	children  []arrowArray
+}
+
+func (a *structArray) appendValue(v interface{}) error {
+	var m map[string]interface{}
+	if v != nil {
+		var ok bool
+		if m, ok = v.(map[string]interface{}); !ok {
+			return fmt.Errorf("unexpected value of type %T for group", v)
+		}
+	}
+
+	for i, child := range a.children {
+		if err := child.appendValue(m[a.names[i]]); err != nil {
+			return fmt.Errorf("%s: %w", a.names[i], err)
+		}
+	}
+	a.add(v != nil)
+	return nil
+}
+
+func (a *structArray) field(name string, nullable bool) *fbTable {
+	children := make([]*fbTable, len(a.children))
+	for i, child := range a.children {
+		children[i] = child.field(a.names[i], a.nullables[i])
+	}
+	return newField(name, nullable, arrowTypeStruct, nil, children...)
+}
+
+func (a *structArray) write(b *arrowBody) {
+	a.validity.write(b)
+	for _, child := range a.children {
+		child.write(b)
+	}
+}
+
+func (a *structArray) reset() {
+	a.validity.reset()
+	for _, child := range a.children {
+		child.reset()
+	}
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+// newArrowArray returns the array for the values of a column, and whether the values can be
+// null. Repeated columns become lists, LIST groups lists of their elements, MAP groups maps and
+// other groups structs.
+func newArrowArray(col *parquetschema.ColumnDefinition) (arrowArray, bool, error) {
+	elem := col.SchemaElement
+	if elem.GetRepetitionType() == parquet.FieldRepetitionType_REPEATED {
+		element := *col
+		required := *elem
+		required.RepetitionType = parquet.FieldRepetitionTypePtr(parquet.FieldRepetitionType_REQUIRED)
+		element.SchemaElement = &required
+
+		child, _, err := newArrowArray(&element)
+		if err != nil {
+			return nil, false, err
+		}
+		return &listArray{child: child, childName: elem.GetName(), repeated: true, items: repeatedValues}, false, nil
+	}
+
+	nullable := elem.GetRepetitionType() == parquet.FieldRepetitionType_OPTIONAL
+
+	if elem.Type != nil {
+		a, err := newPrimitiveArray(elem)
+		return a, nullable, err
+	}
+
+	switch {
+	case isListColumn(col):
+		element := listElement(col)
+		child, childNullable, err := newArrowArray(element)
+		if err != nil {
+			return nil, false, err
+		}
+		items := func(v interface{}) ([]interface{}, error) {
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("unexpected value of type %T for list", v)
+			}
+			return listItems(col, m)
+		}
+		return &listArray{child: child, childName: element.SchemaElement.GetName(), nullable: childNullable, items: items}, nullable, nil
+
+	case isMapColumn(col):
+		kv := col.Children[0]
+		key, _, err := newArrowArray(kv.Children[0])
+		if err != nil {
+			return nil, false, err
+		}
+		value, valueNullable, err := newArrowArray(kv.Children[1])
+		if err != nil {
+			return nil, false, err
+		}
+		entries := &structArray{
+			names:     []string{kv.Children[0].SchemaElement.GetName(), kv.Children[1].SchemaElement.GetName()},
+			nullables: []bool{false, valueNullable},
+			children:  []arrowArray{key, value},
+		}
+		items := func(v interface{}) ([]interface{}, error) {
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("unexpected value of type %T for map", v)
+			}
+			if m[kv.SchemaElement.GetName()] == nil {
+				return nil, nil
+			}
+			return repeatedValues(m[kv.SchemaElement.GetName()])
+		}
+		return &listArray{child: entries, childName: "entries", isMap: true, items: items}, nullable, nil
+	}
+
+	s := &structArray{}
+	for _, c := range col.Children {
+		child, childNullable, err := newArrowArray(c)
+		if err != nil {
+			return nil, false, err
+		}
+		s.names = append(s.names, c.SchemaElement.GetName())
+		s.nullables = append(s.nullables, childNullable)
+		s.children = append(s.children, child)
+	}
+	return s, nullable, nil
+}
+
+// newPrimitiveArray returns the array for a primitive column. Logical types are mapped to their
+// Arrow counterparts, INT96 values become nanosecond timestamps.
+func newPrimitiveArray(elem *parquet.SchemaElement) (arrowArray, error) {
+	lt := elem.GetLogicalType()
+	if lt == nil {
+		lt = &parquet.LogicalType{}
+	}
+	ct := elem.GetConvertedType()
+	if elem.ConvertedType == nil {
+		ct = -1
+	}
+
+	switch {
+	case lt.DECIMAL != nil || ct == parquet.ConvertedType_DECIMAL:
+		if elem.GetPrecision() > arrowMaxDecimalPrecision {
+			return nil, fmt.Errorf("decimal precision %d is too large", elem.GetPrecision())
+		}
+		typ := (&fbTable{}).addInt32(0, elem.GetPrecision()).addInt32(1, elem.GetScale()).addInt32(2, 128)
+		return &fixedArray{width: 16, typeID: arrowTypeDecimal, typ: typ, encode: encodeDecimal128}, nil
+
+	case lt.DATE != nil || ct == parquet.ConvertedType_DATE:
+		typ := (&fbTable{}).addInt16(0, 0)
+		return &fixedArray{width: 4, typeID: arrowTypeDate, typ: typ, encode: encodeInt}, nil
+
+	case lt.TIMESTAMP != nil || ct == parquet.ConvertedType_TIMESTAMP_MILLIS || ct == parquet.ConvertedType_TIMESTAMP_MICROS:
+		typ := (&fbTable{}).addInt16(0, arrowTimeUnit(timeUnit(elem)))
+		if lt.TIMESTAMP == nil || lt.TIMESTAMP.IsAdjustedToUTC {
+			typ.addOffset(1, fbString("UTC"))
+		}
+		return &fixedArray{width: 8, typeID: arrowTypeTimestamp, typ: typ, encode: encodeInt}, nil
+
+	case lt.TIME != nil || ct == parquet.ConvertedType_TIME_MILLIS || ct == parquet.ConvertedType_TIME_MICROS:
+		width := 8
+		if elem.GetType() == parquet.Type_INT32 {
+			width = 4
+		}
+		typ := (&fbTable{}).addInt16(0, arrowTimeUnit(timeUnit(elem))).addInt32(1, int32(8*width))
+		return &fixedArray{width: width, typeID: arrowTypeTime, typ: typ, encode: encodeInt}, nil
+	}
+
+	switch elem.GetType() {
+	case parquet.Type_BOOLEAN:
+		return &boolArray{}, nil
+	case parquet.Type_INT32, parquet.Type_INT64:
+		width := 8
+		if elem.GetType() == parquet.Type_INT32 {
+			width = 4
+		}
+		signed := !(lt.INTEGER != nil && !lt.INTEGER.IsSigned) && ct != parquet.ConvertedType_UINT_8 && ct != parquet.ConvertedType_UINT_16 && ct != parquet.ConvertedType_UINT_32 && ct != parquet.ConvertedType_UINT_64
+		typ := (&fbTable{}).addInt32(0, int32(8*width)).addBool(1, signed)
+		return &fixedArray{width: width, typeID: arrowTypeInt, typ: typ, encode: encodeInt}, nil
+	case parquet.Type_INT96:
+		typ := (&fbTable{}).addInt16(0, arrowTimeUnitNano).addOffset(1, fbString("UTC"))
+		return &fixedArray{width: 8, typeID: arrowTypeTimestamp, typ: typ, encode: encodeInt96}, nil
+	case parquet.Type_FLOAT:
+		typ := (&fbTable{}).addInt16(0, arrowPrecisionSingle)
+		return &fixedArray{width: 4, typeID: arrowTypeFloatingPoint, typ: typ, encode: encodeFloat}, nil
+	case parquet.Type_DOUBLE:
+		typ := (&fbTable{}).addInt16(0, arrowPrecisionDouble)
+		return &fixedArray{width: 8, typeID: arrowTypeFloatingPoint, typ: typ, encode: encodeFloat}, nil
+	case parquet.Type_BYTE_ARRAY:
+		if isTextColumn(elem) {
+			return &binaryArray{typeID: arrowTypeUtf8}, nil
+		}
+		return &binaryArray{typeID: arrowTypeBinary}, nil
+	case parquet.Type_FIXED_LEN_BYTE_ARRAY:
+		width := int(elem.GetTypeLength())
+		typ := (&fbTable{}).addInt32(0, elem.GetTypeLength())
+		return &fixedArray{width: width, typeID: arrowTypeFixedSizeBinary, typ: typ, encode: func(v interface{}, dst []byte) error {
+			b, ok := v.([]byte)
+			if !ok || len(b) != width {
+				return fmt.Errorf("unexpected value of type %T for fixed length byte array", v)
+			}
+			copy(dst, b)
+			return nil
+		}}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported type %s", elem.GetType())
+}
+
+func arrowTimeUnit(unit time.Duration) int16 {
+	switch unit {
+	case time.Millisecond:
+		return arrowTimeUnitMilli
+	case time.Nanosecond:
+		return arrowTimeUnitNano
+	}
+	return arrowTimeUnitMicro
+}
+
+func encodeInt(v interface{}, dst []byte) error {
+	var u uint64
+	switch x := v.(type) {
+	case int32:
+		u = uint64(uint32(x))
+	case uint32:
+		u = uint64(x)
+	case int64:
+		u = uint64(x)
+	case uint64:
+		u = x
+	default:
+		return fmt.Errorf("unexpected value of type %T for integer", v)
+	}
+	if len(dst) == 4 {
+		binary.LittleEndian.PutUint32(dst, uint32(u))
+	} else {
+		binary.LittleEndian.PutUint64(dst, u)
+	}
+	return nil
+}
+
+func encodeFloat(v interface{}, dst []byte) error {
+	switch x := v.(type) {
+	case float32:
+		binary.LittleEndian.PutUint32(dst, math.Float32bits(x))
+	case float64:
+		binary.LittleEndian.PutUint64(dst, math.Float64bits(x))
+	default:
+		return fmt.Errorf("unexpected value of type %T for floating point number", v)
+	}
+	return nil
+}
+
+func encodeInt96(v interface{}, dst []byte) error {
+	b, ok := v.([12]byte)
+	if !ok {
+		return fmt.Errorf("unexpected value of type %T for int96", v)
+	}
+	binary.LittleEndian.PutUint64(dst, uint64(goparquet.Int96ToTime(b).UnixNano()))
+	return nil
+}
+
+// encodeDecimal128 encodes a decimal as 128 bit little-endian two's complement integer.
+func encodeDecimal128(v interface{}, dst []byte) error {
+	unscaled, err := decimalValue(v)
+	if err != nil {
+		return err
+	}
+
+	u := unscaled
+	if unscaled.Sign() < 0 {
+		u = new(big.Int).Add(unscaled, new(big.Int).Lsh(big.NewInt(1), 128))
+	}
+	b := u.Bytes()
+	if len(b) > 16 {
+		return fmt.Errorf("decimal %s doesn't fit into 128 bits", unscaled)
+	}
+	for i := range b {
+		dst[i] = b[len(b)-1-i]
+	}
+	return nil
+}
+
+// arrowRowWriter writes rows as Arrow IPC stream: a schema message followed by record batches
+// and an end-of-stream marker.
+type arrowRowWriter struct {
+	w      io.Writer
+	schema *structArray
+	rows   int
+}
+
+func newArrowRowWriter(w io.Writer, sd *parquetschema.SchemaDefinition) (*arrowRowWriter, error) {
+	root, _, err := newArrowArray(&parquetschema.ColumnDefinition{
+		SchemaElement: &parquet.SchemaElement{Name: sd.RootColumn.SchemaElement.GetName()},
+		Children:      sd.RootColumn.Children,
+	})
+	if err != nil {
+		return nil, err
+	}
+	a := &arrowRowWriter{w: w, schema: root.(*structArray)}
+
+	fields := make([]*fbTable, len(a.schema.children))
+	for i, child := range a.schema.children {
+		fields[i] = child.field(a.schema.names[i], a.schema.nullables[i])
+	}
+	schema := (&fbTable{}).addInt16(0, 0).addOffset(1, fbTables(fields))
+
+	return a, a.writeMessage(arrowHeaderSchema, schema, nil)
+}
+
+func (a *arrowRowWriter) writeRow(row map[string]interface{}) error {
+	for i, child := range a.schema.children {
+		if err := child.appendValue(row[a.schema.names[i]]); err != nil {
+			return fmt.Errorf("%s: %w", a.schema.names[i], err)
+		}
+	}
+	a.rows++
+
+	if a.rows == arrowBatchSize {
+		return a.flush()
+	}
+	return nil
+}
+
+func (a *arrowRowWriter) flush() error {
+	body := &arrowBody{}
+	for _, child := range a.schema.children {
+		child.write(body)
+	}
+
+	batch := (&fbTable{}).
+		addInt64(0, int64(a.rows)).
+		addOffset(1, fbStructs{n: len(body.nodes) / 16, data: body.nodes}).
+		addOffset(2, fbStructs{n: len(body.buffers) / 16, data: body.buffers})
+	if err := a.writeMessage(arrowHeaderRecordBatch, batch, body.data); err != nil {
+		return err
+	}
+
+	a.schema.reset()
+	a.rows = 0
+	return nil
+}
+
+func (a *arrowRowWriter) writeMessage(headerType byte, header *fbTable, body []byte) error {
+	msg := (&fbTable{}).
+		addInt16(0, arrowMetadataV5).
+		addUint8(1, headerType).
+		addOffset(2, header).
+		addInt64(3, int64(len(body)))
+	meta := encodeFlatbuffer(msg)
+	for len(meta)%8 != 0 {
+		meta = append(meta, 0)
+	}
+
+	prefix := make([]byte, 8)
+	binary.LittleEndian.PutUint32(prefix, 0xFFFFFFFF)
+	binary.LittleEndian.PutUint32(prefix[4:], uint32(len(meta)))
+	for _, b := range [][]byte{prefix, meta, body} {
+		if _, err := a.w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *arrowRowWriter) close() error {
+	if a.rows > 0 {
+		if err := a.flush(); err != nil {
+			return err
+		}
+	}
+
+	_, err := a.w.Write([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0, 0, 0, 0})
+	return err
+}
@@ -0,0 +1,227 @@
+package cmds
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fbTestTable reads a table of a flatbuffer.
+type fbTestTable struct {
+	buf []byte
+	pos int
+}
+
+func fbRoot(buf []byte) fbTestTable {
+	return fbTestTable{buf: buf, pos: int(binary.LittleEndian.Uint32(buf))}
+}
+
+func (t fbTestTable) fieldPos(slot int) int {
+	vtable := t.pos - int(int32(binary.LittleEndian.Uint32(t.buf[t.pos:])))
+	if 4+2*slot >= int(binary.LittleEndian.Uint16(t.buf[vtable:])) {
+		return 0
+	}
+	off := int(binary.LittleEndian.Uint16(t.buf[vtable+4+2*slot:]))
+	if off == 0 {
+		return 0
+	}
+	return t.pos + off
+}
+
+func (t fbTestTable) scalar(slot, size int) uint64 {
+	pos := t.fieldPos(slot)
+	if pos == 0 {
+		return 0
+	}
+	var b [8]byte
+	copy(b[:], t.buf[pos:pos+size])
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+func (t fbTestTable) target(slot int) int {
+	pos := t.fieldPos(slot)
+	if pos == 0 {
+		return 0
+	}
+	return pos + int(binary.LittleEndian.Uint32(t.buf[pos:]))
+}
+
+func (t fbTestTable) table(slot int) fbTestTable {
+	return fbTestTable{buf: t.buf, pos: t.target(slot)}
+}
+
+func (t fbTestTable) string(slot int) string {
+	pos := t.target(slot)
+	if pos == 0 {
+		return ""
+	}
+	n := int(binary.LittleEndian.Uint32(t.buf[pos:]))
+	return string(t.buf[pos+4 : pos+4+n])
+}
+
+func (t fbTestTable) tables(slot int) []fbTestTable {
+	pos := t.target(slot)
+	n := int(binary.LittleEndian.Uint32(t.buf[pos:]))
+	ret := make([]fbTestTable, n)
+	for i := range ret {
+		elem := pos + 4 + 4*i
+		ret[i] = fbTestTable{buf: t.buf, pos: elem + int(binary.LittleEndian.Uint32(t.buf[elem:]))}
+	}
+	return ret
+}
+
+// structs returns the pairs of int64 values of a vector of FieldNode or Buffer structs.
+func (t fbTestTable) structs(slot int) [][2]int64 {
+	pos := t.target(slot)
+	n := int(binary.LittleEndian.Uint32(t.buf[pos:]))
+	ret := make([][2]int64, n)
+	for i := range ret {
+		elem := pos + 4 + 16*i
+		ret[i] = [2]int64{int64(binary.LittleEndian.Uint64(t.buf[elem:])), int64(binary.LittleEndian.Uint64(t.buf[elem+8:]))}
+	}
+	return ret
+}
+
+// describeField returns the name, type and children of a Field, e.g. "tags:12(element:5)".
+func describeField(f fbTestTable) string {
+	s := f.string(0)
+	if f.scalar(1, 1) == 0 {
+		s += "!"
+	}
+	s += ":" + string(rune('0'+f.scalar(2, 1)/10)) + string(rune('0'+f.scalar(2, 1)%10))
+	children := f.tables(5)
+	if len(children) > 0 {
+		s += "("
+		for i, c := range children {
+			if i > 0 {
+				s += ","
+			}
+			s += describeField(c)
+		}
+		s += ")"
+	}
+	return s
+}
+
+type arrowTestMessage struct {
+	header     fbTestTable
+	headerType int
+	body       []byte
+}
+
+func readArrowStream(t *testing.T, data []byte) []arrowTestMessage {
+	var msgs []arrowTestMessage
+	for {
+		require.True(t, len(data) >= 8)
+		require.Equal(t, uint32(0xFFFFFFFF), binary.LittleEndian.Uint32(data))
+		size := int(binary.LittleEndian.Uint32(data[4:]))
+		if size == 0 {
+			require.Len(t, data, 8)
+			return msgs
+		}
+		require.Equal(t, 0, (8+size)%8)
+
+		meta := data[8 : 8+size]
+		msg := fbRoot(meta)
+		require.Equal(t, uint64(arrowMetadataV5), msg.scalar(0, 2))
+		bodyLength := int(msg.scalar(3, 8))
+		require.Equal(t, 0, bodyLength%8)
+
+		msgs = append(msgs, arrowTestMessage{
+			header:     msg.table(2),
+			headerType: int(msg.scalar(1, 1)),
+			body:       data[8+size : 8+size+bodyLength],
+		})
+		data = data[8+size+bodyLength:]
+	}
+}
+
+func TestExportArrow(t *testing.T) {
+	file := writeExportFile(t)
+	defer os.RemoveAll(filepath.Dir(file))
+
+	var buf bytes.Buffer
+	require.NoError(t, exportFile(&buf, file, "arrow", -1, nil, ""))
+
+	msgs := readArrowStream(t, buf.Bytes())
+	require.Len(t, msgs, 2)
+
+	schema := msgs[0]
+	require.Equal(t, arrowHeaderSchema, schema.headerType)
+	require.Empty(t, schema.body)
+
+	var fields []string
+	for _, f := range schema.header.tables(1) {
+		fields = append(fields, describeField(f))
+	}
+	require.Equal(t, []string{
+		"id!:02",
+		"name:05",
+		"ts:10",
+		"day:08",
+		"price:07",
+		"uuid:15",
+		"legacy:10",
+		"raw:04",
+		"score:03",
+		"address:13(city:05,zip:02)",
+		"tags:12(element:05)",
+		"attrs:17(entries!:13(key!:05,value:02))",
+		"numbers!:12(numbers!:02)",
+	}, fields)
+
+	fieldTables := schema.header.tables(1)
+	require.Equal(t, "UTC", fieldTables[2].table(3).string(1))
+	require.Equal(t, uint64(arrowTimeUnitMicro), fieldTables[2].table(3).scalar(0, 2))
+	require.Equal(t, uint64(10), fieldTables[4].table(3).scalar(0, 4))
+	require.Equal(t, uint64(2), fieldTables[4].table(3).scalar(1, 4))
+
+	batch := msgs[1]
+	require.Equal(t, arrowHeaderRecordBatch, batch.headerType)
+	require.Equal(t, uint64(2), batch.header.scalar(0, 8))
+
+	nodes := batch.header.structs(1)
+	buffers := batch.header.structs(2)
+	require.Len(t, nodes, 20)
+	require.Len(t, buffers, 43)
+
+	bufferData := func(i int) []byte {
+		return batch.body[buffers[i][0] : buffers[i][0]+buffers[i][1]]
+	}
+	for _, b := range buffers {
+		require.Equal(t, int64(0), b[0]%8)
+	}
+
+	// id
+	require.Equal(t, [2]int64{2, 0}, nodes[0])
+	require.Equal(t, []byte{1, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0}, bufferData(1))
+
+	// name
+	require.Equal(t, [2]int64{2, 1}, nodes[1])
+	require.Equal(t, []byte{0x01}, bufferData(2))
+	require.Equal(t, []byte{0, 0, 0, 0, 6, 0, 0, 0, 6, 0, 0, 0}, bufferData(3))
+	require.Equal(t, "a, \"b\"", string(bufferData(4)))
+
+	// price is a 128 bit decimal
+	require.Equal(t, []byte{
+		0x4b, 0xfb, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	}, bufferData(10))
+
+	// tags: the list of the first row contains a null element
+	require.Equal(t, [2]int64{2, 1}, nodes[12])
+	require.Equal(t, [2]int64{2, 1}, nodes[13])
+	require.Equal(t, []byte{0, 0, 0, 0, 2, 0, 0, 0, 2, 0, 0, 0}, bufferData(27))
+	require.Equal(t, []byte{0x01}, bufferData(28))
+	require.Equal(t, "x", string(bufferData(30)))
+
+	// numbers is a list that is empty for the second row
+	require.Equal(t, [2]int64{2, 0}, nodes[18])
+	require.Equal(t, [2]int64{2, 0}, nodes[19])
+	require.Equal(t, []byte{0, 0, 0, 0, 2, 0, 0, 0, 2, 0, 0, 0}, bufferData(40))
+	require.Equal(t, []byte{1, 0, 0, 0, 2, 0, 0, 0}, bufferData(42))
+}
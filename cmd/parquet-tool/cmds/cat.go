@@ -7,7 +7,14 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	catColumns  *[]string
+	catDetailed *bool
+)
+
 func init() {
+	catColumns = catCmd.PersistentFlags().StringSliceP("columns", "c", nil, "The columns to print, e.g. address.*, attrs.key_value.key or events[].type")
+	catDetailed = catCmd.PersistentFlags().BoolP("detailed", "d", false, "Print the detailed schema of the columns before the records")
 	rootCmd.AddCommand(catCmd)
 }
 
@@ -20,7 +27,7 @@ var catCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		if err := catFile(os.Stdout, args[0], -1); err != nil {
+		if err := catFile(os.Stdout, args[0], -1, *catColumns, *catDetailed); err != nil {
 			log.Fatal(err)
 		}
 	},
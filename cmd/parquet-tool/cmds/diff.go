@@ -0,0 +1,514 @@
+package cmds
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	goparquet "github.com/sagia-inneractive/parquet-go"
+	"github.com/sagia-inneractive/parquet-go/parquet"
+	"github.com/sagia-inneractive/parquet-go/parquetschema"
+	"github.com/spf13/cobra"
+)
+
+// The exit codes of diff if the files aren't identical.
+const (
+	diffDifferent = 1
+	diffFailed    = 2
+)
+
+var (
+	diffKeys    *[]string
+	diffColumns *[]string
+	diffMaxRows *int
+)
+
+func init() {
+	diffKeys = diffCmd.PersistentFlags().StringSliceP("key", "k", nil, "The columns that identify a row, to compare the rows independent of their order")
+	diffColumns = diffCmd.PersistentFlags().StringSliceP("columns", "c", nil, "The columns to compare, e.g. address.*, attrs.key_value.key or events[].type")
+	diffMaxRows = diffCmd.PersistentFlags().IntP("max-rows", "n", 10, "The number of differing rows to print, -1 for all")
+	rootCmd.AddCommand(diffCmd)
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff file-name.parquet other-file-name.parquet",
+	Short: "Compare the schema and the content of two parquet files",
+	Long: `Compare the schema, the number of rows and the content of two parquet files. The rows are
+compared in the order they are stored, or by the values of the key columns if they are provided
+with --key. In that case, all rows of the second file are held in memory.
+
+The first differing rows and the number of mismatches per column are printed. The exit code is
+0 if the files are identical, 1 if differences were found and 2 if the files couldn't be
+compared at all.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 2 {
+			_ = cmd.Usage()
+			os.Exit(diffFailed)
+		}
+
+		differences, err := diffFiles(os.Stdout, args[0], args[1], *diffKeys, *diffColumns, *diffMaxRows)
+		if err != nil {
+			log.Print(err)
+			os.Exit(diffFailed)
+		}
+		if differences > 0 {
+			os.Exit(diffDifferent)
+		}
+	},
+}
+
+// differ compares the rows of two files and collects the differences.
+type differ struct {
+	w       io.Writer
+	names   [2]string
+	columns []string
+	elems   map[string]*parquet.SchemaElement
+	// repeated contains the columns that can have multiple values in a row
+	repeated map[string]bool
+	maxRows  int
+
+	differentRows int
+	onlyRows      [2]int
+	mismatches    map[string]int
+}
+
+// diffFiles compares the files at addressA and addressB and writes the differences to w. It
+// returns the number of differences, which is the number of schema differences plus the number
+// of rows that differ or only exist in one of the files.
+func diffFiles(w io.Writer, addressA, addressB string, keys, columns []string, maxRows int) (int, error) {
+	readerA, closeA, err := openDiffFile(addressA)
+	if err != nil {
+		return 0, err
+	}
+	defer closeA()
+
+	readerB, closeB, err := openDiffFile(addressB)
+	if err != nil {
+		return 0, err
+	}
+	defer closeB()
+
+	d := &differ{
+		w:          w,
+		names:      [2]string{filepath.Base(addressA), filepath.Base(addressB)},
+		elems:      make(map[string]*parquet.SchemaElement),
+		repeated:   make(map[string]bool),
+		maxRows:    maxRows,
+		mismatches: make(map[string]int),
+	}
+	if d.names[0] == d.names[1] {
+		d.names = [2]string{addressA, addressB}
+	}
+
+	sdA, sdB := readerA.GetSchemaDefinition(), readerB.GetSchemaDefinition()
+	schemaDiffs := d.diffSchemas(sdA, sdB)
+
+	if len(columns) == 0 {
+		columns = []string{"*"}
+	}
+	paths, err := goparquet.ResolveColumnPaths(sdA, columns...)
+	if err != nil {
+		return 0, err
+	}
+	for _, p := range paths {
+		if col := readerB.GetColumnByName(p); col != nil {
+			d.columns = append(d.columns, p)
+			d.elems[p] = col.Element()
+			d.repeated[p] = col.MaxRepetitionLevel() > 0 || readerA.GetColumnByName(p).MaxRepetitionLevel() > 0
+		}
+	}
+	if len(d.columns) == 0 {
+		return 0, fmt.Errorf("the files have no columns in common")
+	}
+
+	var keyPaths []string
+	if len(keys) > 0 {
+		if keyPaths, err = goparquet.ResolveColumnPaths(sdA, keys...); err != nil {
+			return 0, err
+		}
+		for _, k := range keyPaths {
+			colA, colB := readerA.GetColumnByName(k), readerB.GetColumnByName(k)
+			if colB == nil {
+				return 0, fmt.Errorf("key column %q doesn't exist in %s", k, d.names[1])
+			}
+			if colA.MaxRepetitionLevel() > 0 || colB.MaxRepetitionLevel() > 0 {
+				return 0, fmt.Errorf("key column %q is repeated", k)
+			}
+		}
+	}
+
+	// the files are opened again to only read the compared columns
+	if readerA, closeA, err = openDiffFile(addressA, d.columns...); err != nil {
+		return 0, err
+	}
+	defer closeA()
+	if readerB, closeB, err = openDiffFile(addressB, d.columns...); err != nil {
+		return 0, err
+	}
+	defer closeB()
+
+	_, _ = fmt.Fprintf(w, "rows: %d in %s, %d in %s\n", readerA.NumRows(), d.names[0], readerB.NumRows(), d.names[1])
+
+	if len(keyPaths) > 0 {
+		err = d.diffRowsByKey(readerA, readerB, sdA, sdB, keyPaths)
+	} else {
+		err = d.diffRows(readerA, readerB, sdA, sdB)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if err := d.printSummary(); err != nil {
+		return 0, err
+	}
+
+	return schemaDiffs + d.differentRows + d.onlyRows[0] + d.onlyRows[1], nil
+}
+
+// openDiffFile opens the file at address to read the provided columns, or all columns if there
+// are none. The returned function closes the file.
+func openDiffFile(address string, columns ...string) (*goparquet.FileReader, func(), error) {
+	fl, err := os.Open(address)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can not open the file: %q", err)
+	}
+
+	reader, err := goparquet.NewFileReaderWithOptions(fl,
+		goparquet.WithColumns(columns...),
+		goparquet.WithExternalFileOpener(externalFileOpener(address)),
+	)
+	if err != nil {
+		fl.Close()
+		return nil, nil, fmt.Errorf("failed to read the parquet header of %s: %q", address, err)
+	}
+
+	return reader, func() {
+		reader.Close()
+		fl.Close()
+	}, nil
+}
+
+// diffSchemas prints the columns and groups that only exist in one of the schemas or whose
+// definitions differ, and returns their number.
+func (d *differ) diffSchemas(sdA, sdB *parquetschema.SchemaDefinition) int {
+	var pathsA []string
+	defsA, defsB := make(map[string]string), make(map[string]string)
+	describeColumns(sdA.RootColumn.Children, "", defsA, &pathsA)
+	var pathsB []string
+	describeColumns(sdB.RootColumn.Children, "", defsB, &pathsB)
+
+	var diffs []string
+	for _, p := range pathsA {
+		b, ok := defsB[p]
+		switch {
+		case !ok:
+			diffs = append(diffs, fmt.Sprintf("%s: only in %s", p, d.names[0]))
+		case b != defsA[p]:
+			diffs = append(diffs, fmt.Sprintf("%s: %s in %s, %s in %s", p, defsA[p], d.names[0], b, d.names[1]))
+		}
+	}
+	for _, p := range pathsB {
+		if _, ok := defsA[p]; !ok {
+			diffs = append(diffs, fmt.Sprintf("%s: only in %s", p, d.names[1]))
+		}
+	}
+
+	if len(diffs) == 0 {
+		_, _ = fmt.Fprintln(d.w, "schema: identical")
+		return 0
+	}
+	_, _ = fmt.Fprintln(d.w, "schema: different")
+	for _, diff := range diffs {
+		_, _ = fmt.Fprintf(d.w, "  %s\n", diff)
+	}
+	return len(diffs)
+}
+
+// describeColumns adds a description of the definition of every column and group to defs, by
+// path, and the paths in schema order to paths.
+func describeColumns(cols []*parquetschema.ColumnDefinition, prefix string, defs map[string]string, paths *[]string) {
+	for _, col := range cols {
+		elem := col.SchemaElement
+		path := prefix + elem.GetName()
+
+		desc := []string{strings.ToLower(elem.GetRepetitionType().String())}
+		if elem.Type == nil {
+			desc = append(desc, "group")
+		} else {
+			typ := strings.ToLower(elem.GetType().String())
+			if elem.TypeLength != nil {
+				typ += "(" + strconv.Itoa(int(*elem.TypeLength)) + ")"
+			}
+			desc = append(desc, typ)
+		}
+		if elem.LogicalType != nil {
+			desc = append(desc, logicalTypeString(elem.LogicalType))
+		} else if elem.ConvertedType != nil {
+			desc = append(desc, elem.GetConvertedType().String())
+		}
+
+		defs[path] = strings.Join(desc, " ")
+		*paths = append(*paths, path)
+		describeColumns(col.Children, path+".", defs, paths)
+	}
+}
+
+// diffRows compares the rows of the files in the order they are stored.
+func (d *differ) diffRows(readerA, readerB *goparquet.FileReader, sdA, sdB *parquetschema.SchemaDefinition) error {
+	for i := 1; ; i++ {
+		rowA, errA := readDiffRow(readerA, sdA)
+		if errA != nil && errA != io.EOF {
+			return fmt.Errorf("reading record %d of %s failed: %w", i, d.names[0], errA)
+		}
+		rowB, errB := readDiffRow(readerB, sdB)
+		if errB != nil && errB != io.EOF {
+			return fmt.Errorf("reading record %d of %s failed: %w", i, d.names[1], errB)
+		}
+
+		label := "row " + strconv.Itoa(i)
+		switch {
+		case errA == io.EOF && errB == io.EOF:
+			return nil
+		case errB == io.EOF:
+			d.onlyIn(0, label)
+		case errA == io.EOF:
+			d.onlyIn(1, label)
+		default:
+			d.compareRows(label, rowA, rowB)
+		}
+	}
+}
+
+// diffRowsByKey compares the rows of the files with the same values of the key columns.
+func (d *differ) diffRowsByKey(readerA, readerB *goparquet.FileReader, sdA, sdB *parquetschema.SchemaDefinition, keys []string) error {
+	var (
+		order []string
+		rowsB = make(map[string]map[string][]interface{})
+		seen  = make(map[string]bool)
+	)
+	for i := 1; ; i++ {
+		row, err := readDiffRow(readerB, sdB)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("reading record %d of %s failed: %w", i, d.names[1], err)
+		}
+		key := d.rowKey(keys, row)
+		if _, ok := rowsB[key]; ok {
+			d.report("%s: duplicate key in %s", key, d.names[1])
+			d.onlyRows[1]++
+			continue
+		}
+		rowsB[key] = row
+		order = append(order, key)
+	}
+
+	for i := 1; ; i++ {
+		row, err := readDiffRow(readerA, sdA)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("reading record %d of %s failed: %w", i, d.names[0], err)
+		}
+		key := d.rowKey(keys, row)
+		if seen[key] {
+			d.report("%s: duplicate key in %s", key, d.names[0])
+			d.onlyRows[0]++
+			continue
+		}
+		seen[key] = true
+
+		rowB, ok := rowsB[key]
+		if !ok {
+			d.onlyIn(0, key)
+			continue
+		}
+		d.compareRows(key, row, rowB)
+	}
+
+	for _, key := range order {
+		if !seen[key] {
+			d.onlyIn(1, key)
+		}
+	}
+	return nil
+}
+
+// rowKey returns the label of a row that consists of the values of the key columns.
+func (d *differ) rowKey(keys []string, row map[string][]interface{}) string {
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+d.formatValues(k, row[k]))
+	}
+	return "key " + strings.Join(parts, ", ")
+}
+
+func (d *differ) onlyIn(file int, label string) {
+	d.onlyRows[file]++
+	d.report("%s: only in %s", label, d.names[file])
+}
+
+// compareRows compares the values of all columns of two rows.
+func (d *differ) compareRows(label string, rowA, rowB map[string][]interface{}) {
+	var diffs []string
+	for _, col := range d.columns {
+		if valuesEqual(rowA[col], rowB[col]) {
+			continue
+		}
+		d.mismatches[col]++
+		diffs = append(diffs, fmt.Sprintf("  %s: %s != %s", col, d.formatValues(col, rowA[col]), d.formatValues(col, rowB[col])))
+	}
+	if len(diffs) == 0 {
+		return
+	}
+
+	d.differentRows++
+	d.report("%s:\n%s", label, strings.Join(diffs, "\n"))
+}
+
+// report prints a differing row unless the maximum number of rows has been printed already.
+func (d *differ) report(format string, args ...interface{}) {
+	if d.maxRows >= 0 && d.differentRows+d.onlyRows[0]+d.onlyRows[1] > d.maxRows {
+		return
+	}
+	_, _ = fmt.Fprintf(d.w, format+"\n", args...)
+}
+
+// formatValues formats the values of a column in a row. The values of repeated columns are
+// enclosed in brackets.
+func (d *differ) formatValues(col string, values []interface{}) string {
+	elem := d.elems[col]
+	strs := make([]string, 0, len(values))
+	for _, v := range values {
+		switch {
+		case v == nil:
+			strs = append(strs, "null")
+		case isTextColumn(elem):
+			strs = append(strs, strconv.Quote(formatValue(elem, v)))
+		default:
+			strs = append(strs, formatValue(elem, v))
+		}
+	}
+	if len(strs) == 1 && !d.repeated[col] {
+		return strs[0]
+	}
+	return "[" + strings.Join(strs, ", ") + "]"
+}
+
+func (d *differ) printSummary() error {
+	_, _ = fmt.Fprintf(d.w, "\n%d differing rows, %d rows only in %s, %d rows only in %s\n",
+		d.differentRows, d.onlyRows[0], d.names[0], d.onlyRows[1], d.names[1])
+
+	if len(d.mismatches) == 0 {
+		return nil
+	}
+
+	_, _ = fmt.Fprintln(d.w)
+	tw := tabwriter.NewWriter(d.w, 0, 8, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "COLUMN\tMISMATCHES")
+	for _, col := range d.columns {
+		if n := d.mismatches[col]; n > 0 {
+			_, _ = fmt.Fprintf(tw, "%s\t%d\n", col, n)
+		}
+	}
+	return tw.Flush()
+}
+
+// readDiffRow reads the next row and returns the values of its data columns, by flat name.
+func readDiffRow(reader *goparquet.FileReader, sd *parquetschema.SchemaDefinition) (map[string][]interface{}, error) {
+	row, err := reader.NextRow()
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string][]interface{})
+	flattenRow(sd.RootColumn.Children, "", row, values)
+	return values, nil
+}
+
+// flattenRow collects the values of the data columns of the row. A null value is collected for
+// every data column of a missing optional group or value, so that values of different entries of
+// a repeated group don't shift into each other. Missing repeated groups and values have no
+// entries.
+func flattenRow(cols []*parquetschema.ColumnDefinition, prefix string, row map[string]interface{}, values map[string][]interface{}) {
+	for _, col := range cols {
+		name := col.SchemaElement.GetName()
+		path := prefix + name
+		v, ok := row[name]
+		repeated := col.SchemaElement.GetRepetitionType() == parquet.FieldRepetitionType_REPEATED
+
+		if col.SchemaElement.Type != nil {
+			switch {
+			case (!ok || v == nil) && repeated:
+			case !ok || v == nil:
+				values[path] = append(values[path], nil)
+			case repeated:
+				rv := reflect.ValueOf(v)
+				if rv.Kind() != reflect.Slice {
+					values[path] = append(values[path], v)
+					continue
+				}
+				for i := 0; i < rv.Len(); i++ {
+					values[path] = append(values[path], rv.Index(i).Interface())
+				}
+			default:
+				values[path] = append(values[path], v)
+			}
+			continue
+		}
+
+		switch x := v.(type) {
+		case map[string]interface{}:
+			flattenRow(col.Children, path+".", x, values)
+		case []map[string]interface{}:
+			for _, entry := range x {
+				flattenRow(col.Children, path+".", entry, values)
+			}
+		default:
+			if !repeated {
+				flattenRow(col.Children, path+".", nil, values)
+			}
+		}
+	}
+}
+
+// valuesEqual compares the values of a column. NaN is considered equal to NaN.
+func valuesEqual(a, b []interface{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		switch x := a[i].(type) {
+		case []byte:
+			y, ok := b[i].([]byte)
+			if !ok || !bytes.Equal(x, y) {
+				return false
+			}
+		case float32:
+			y, ok := b[i].(float32)
+			if !ok || (x != y && !(math.IsNaN(float64(x)) && math.IsNaN(float64(y)))) {
+				return false
+			}
+		case float64:
+			y, ok := b[i].(float64)
+			if !ok || (x != y && !(math.IsNaN(x) && math.IsNaN(y))) {
+				return false
+			}
+		default:
+			if !reflect.DeepEqual(a[i], b[i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,147 @@
+package cmds
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	goparquet "github.com/sagia-inneractive/parquet-go"
+	"github.com/sagia-inneractive/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+func writeDiffFile(t *testing.T, file, schema string, rows []map[string]interface{}) {
+	sd, err := parquetschema.ParseSchemaDefinition(schema)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	fw := goparquet.NewFileWriter(&buf, goparquet.WithSchemaDefinition(sd))
+	for _, row := range rows {
+		require.NoError(t, fw.AddData(row))
+	}
+	require.NoError(t, fw.Close())
+	require.NoError(t, ioutil.WriteFile(file, buf.Bytes(), 0644))
+}
+
+func TestDiffFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "diff")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	const schema = `message test {
+  required int64 id;
+  optional binary name (STRING);
+  optional group tags (LIST) {
+    repeated group list {
+      optional binary element (STRING);
+    }
+  }
+}`
+	row := func(id int64, name string, tags ...string) map[string]interface{} {
+		data := map[string]interface{}{"id": id}
+		if name != "" {
+			data["name"] = []byte(name)
+		}
+		if len(tags) > 0 {
+			var list []map[string]interface{}
+			for _, tag := range tags {
+				list = append(list, map[string]interface{}{"element": []byte(tag)})
+			}
+			data["tags"] = map[string]interface{}{"list": list}
+		}
+		return data
+	}
+
+	fileA := filepath.Join(dir, "a.parquet")
+	writeDiffFile(t, fileA, schema, []map[string]interface{}{
+		row(1, "one", "x"),
+		row(2, "two"),
+		row(3, "three", "x", "y"),
+		row(4, ""),
+		row(5, "five"),
+	})
+
+	var buf bytes.Buffer
+	differences, err := diffFiles(&buf, fileA, fileA, nil, nil, 10)
+	require.NoError(t, err)
+	require.Equal(t, 0, differences)
+	require.Equal(t, fmt.Sprintf(`schema: identical
+rows: 5 in %s, 5 in %s
+
+0 differing rows, 0 rows only in %s, 0 rows only in %s
+`, fileA, fileA, fileA, fileA), buf.String())
+
+	fileB := filepath.Join(dir, "b.parquet")
+	writeDiffFile(t, fileB, schema, []map[string]interface{}{
+		row(2, "two"),
+		row(1, "one", "x"),
+		row(3, "drei", "x", "z"),
+		row(6, "six"),
+		row(4, ""),
+	})
+
+	buf.Reset()
+	differences, err = diffFiles(&buf, fileA, fileB, []string{"id"}, nil, 10)
+	require.NoError(t, err)
+	require.Equal(t, 3, differences)
+	require.Equal(t, `schema: identical
+rows: 5 in a.parquet, 5 in b.parquet
+key id=3:
+  name: "three" != "drei"
+  tags.list.element: ["x", "y"] != ["x", "z"]
+key id=5: only in a.parquet
+key id=6: only in b.parquet
+
+1 differing rows, 1 rows only in a.parquet, 1 rows only in b.parquet
+
+COLUMN             MISMATCHES
+name               1
+tags.list.element  1
+`, buf.String())
+
+	// in order, only the ids of the third row match
+	buf.Reset()
+	differences, err = diffFiles(&buf, fileA, fileB, nil, []string{"id"}, 1)
+	require.NoError(t, err)
+	require.Equal(t, 4, differences)
+	require.Equal(t, `schema: identical
+rows: 5 in a.parquet, 5 in b.parquet
+row 1:
+  id: 1 != 2
+
+4 differing rows, 0 rows only in a.parquet, 0 rows only in b.parquet
+
+COLUMN  MISMATCHES
+id      4
+`, buf.String())
+
+	fileC := filepath.Join(dir, "c.parquet")
+	writeDiffFile(t, fileC, `message test {
+  required int64 id;
+  optional int32 name;
+  optional int32 extra;
+}`, []map[string]interface{}{
+		{"id": int64(1), "extra": int32(1)},
+	})
+
+	buf.Reset()
+	differences, err = diffFiles(&buf, fileA, fileC, nil, []string{"id"}, 0)
+	require.NoError(t, err)
+	require.Equal(t, 9, differences)
+	require.Equal(t, `schema: different
+  name: optional byte_array STRING in a.parquet, optional int32 in c.parquet
+  tags: only in a.parquet
+  tags.list: only in a.parquet
+  tags.list.element: only in a.parquet
+  extra: only in c.parquet
+rows: 5 in a.parquet, 1 in c.parquet
+
+0 differing rows, 4 rows only in a.parquet, 0 rows only in c.parquet
+`, buf.String())
+
+	_, err = diffFiles(&buf, fileA, fileC, []string{"tags.list.element"}, nil, 0)
+	require.EqualError(t, err, `key column "tags.list.element" doesn't exist in c.parquet`)
+}
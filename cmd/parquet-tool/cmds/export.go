@@ -0,0 +1,681 @@
+package cmds
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/big"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	goparquet "github.com/sagia-inneractive/parquet-go"
+	"github.com/sagia-inneractive/parquet-go/parquet"
+	"github.com/sagia-inneractive/parquet-go/parquetschema"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFormat  *string
+	exportColumns *[]string
+	exportRecords *int
+	exportOutput  *string
+	exportNested  *string
+)
+
+func init() {
+	exportFormat = exportCmd.PersistentFlags().StringP("format", "f", "csv", "The output format, valid values are csv, ndjson and arrow")
+	exportColumns = exportCmd.PersistentFlags().StringSliceP("columns", "c", nil, "The columns to export, e.g. address.*, attrs.key_value.key or events[].type")
+	exportRecords = exportCmd.PersistentFlags().IntP("records", "n", -1, "The number of records to export, -1 exports all records")
+	exportOutput = exportCmd.PersistentFlags().StringP("output", "o", "", "The file to write to, standard output if it's empty")
+	exportNested = exportCmd.PersistentFlags().String("nested", "flatten", "How nested fields are written to CSV, valid values are flatten (one column per field of a group, JSON for lists and maps) and json (one JSON column per top-level field)")
+	rootCmd.AddCommand(exportCmd)
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export file-name.parquet",
+	Short: "Export the parquet file content as CSV, newline-delimited JSON or Arrow IPC stream",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			_ = cmd.Usage()
+			os.Exit(1)
+		}
+
+		var w io.Writer = os.Stdout
+		if *exportOutput != "" {
+			out, err := os.Create(*exportOutput)
+			if err != nil {
+				log.Fatalf("Can not create the output file: %q", err)
+			}
+			defer out.Close()
+			w = out
+		}
+
+		if err := exportFile(w, args[0], *exportFormat, *exportRecords, *exportColumns, *exportNested); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+// rowWriter writes exported rows in one of the output formats.
+type rowWriter interface {
+	writeRow(row map[string]interface{}) error
+	close() error
+}
+
+func exportFile(w io.Writer, address, format string, n int, columns []string, nested string) error {
+	fl, err := os.Open(address)
+	if err != nil {
+		return fmt.Errorf("can not open the file: %q", err)
+	}
+	defer fl.Close()
+
+	reader, err := goparquet.NewFileReaderWithOptions(fl,
+		goparquet.WithProjection(columns...),
+		goparquet.WithExternalFileOpener(externalFileOpener(address)),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to read the parquet header: %q", err)
+	}
+	defer reader.Close()
+
+	sd := reader.GetSchemaDefinition()
+	if len(columns) > 0 {
+		paths, err := goparquet.ResolveColumnPaths(sd, columns...)
+		if err != nil {
+			return err
+		}
+		sd = projectSchema(sd, paths)
+	}
+
+	rw, err := newRowWriter(w, sd, format, nested)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; (n == -1) || i < n; i++ {
+		row, err := reader.NextRow()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("reading record %d failed: %w", i+1, err)
+		}
+
+		if err := rw.writeRow(row); err != nil {
+			return fmt.Errorf("writing record %d failed: %w", i+1, err)
+		}
+	}
+
+	return rw.close()
+}
+
+// newRowWriter returns the writer for the output format. nested is the mode of nested fields in
+// CSV, flatten or json.
+func newRowWriter(w io.Writer, sd *parquetschema.SchemaDefinition, format, nested string) (rowWriter, error) {
+	switch format {
+	case "csv":
+		if nested != "flatten" && nested != "json" {
+			return nil, fmt.Errorf("invalid nested mode %q", nested)
+		}
+		return newCSVRowWriter(w, sd, nested == "flatten"), nil
+	case "ndjson":
+		return &ndjsonRowWriter{w: w, sd: sd}, nil
+	case "arrow":
+		return newArrowRowWriter(w, sd)
+	}
+	return nil, fmt.Errorf("invalid format %q", format)
+}
+
+// projectSchema returns the part of the schema that contains the selected data columns.
+func projectSchema(sd *parquetschema.SchemaDefinition, paths []string) *parquetschema.SchemaDefinition {
+	selected := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		selected[p] = true
+	}
+
+	var project func(col *parquetschema.ColumnDefinition, path string) *parquetschema.ColumnDefinition
+	project = func(col *parquetschema.ColumnDefinition, path string) *parquetschema.ColumnDefinition {
+		if col.SchemaElement.Type != nil {
+			if selected[path] {
+				return col
+			}
+			return nil
+		}
+
+		ret := &parquetschema.ColumnDefinition{SchemaElement: col.SchemaElement}
+		for _, c := range col.Children {
+			if pc := project(c, path+"."+c.SchemaElement.GetName()); pc != nil {
+				ret.Children = append(ret.Children, pc)
+			}
+		}
+		if len(ret.Children) == 0 {
+			return nil
+		}
+		return ret
+	}
+
+	root := &parquetschema.ColumnDefinition{SchemaElement: sd.RootColumn.SchemaElement}
+	for _, c := range sd.RootColumn.Children {
+		if pc := project(c, c.SchemaElement.GetName()); pc != nil {
+			root.Children = append(root.Children, pc)
+		}
+	}
+	return parquetschema.SchemaDefinitionFromColumnDefinition(root)
+}
+
+// jsonObject is a JSON object whose fields are written in the order of the schema.
+type jsonObject []jsonField
+
+type jsonField struct {
+	name  string
+	value interface{}
+}
+
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(f.name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// exportRow converts a row into a JSON object with the fields of the schema.
+func exportRow(cols []*parquetschema.ColumnDefinition, row map[string]interface{}) (jsonObject, error) {
+	obj := make(jsonObject, 0, len(cols))
+	for _, col := range cols {
+		v, err := exportValue(col, row[col.SchemaElement.GetName()])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", col.SchemaElement.GetName(), err)
+		}
+		obj = append(obj, jsonField{name: col.SchemaElement.GetName(), value: v})
+	}
+	return obj, nil
+}
+
+// exportValue converts the value of a column as returned by the FileReader into a value that
+// can be encoded as JSON: groups become objects, lists and repeated columns become arrays, maps
+// with string keys become objects, and primitive values are rendered according to their logical
+// type.
+func exportValue(col *parquetschema.ColumnDefinition, v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	if col.SchemaElement.GetRepetitionType() != parquet.FieldRepetitionType_REPEATED {
+		return exportElement(col, v)
+	}
+
+	values, err := repeatedValues(v)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]interface{}, 0, len(values))
+	for _, e := range values {
+		ev, err := exportElement(col, e)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, ev)
+	}
+	return ret, nil
+}
+
+func exportElement(col *parquetschema.ColumnDefinition, v interface{}) (interface{}, error) {
+	elem := col.SchemaElement
+	if elem.Type != nil {
+		return exportPrimitive(elem, v)
+	}
+
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected value of type %T for group", v)
+	}
+
+	switch {
+	case isListColumn(col):
+		items, err := listItems(col, m)
+		if err != nil {
+			return nil, err
+		}
+		element := listElement(col)
+		ret := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			ev, err := exportValue(element, item)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, ev)
+		}
+		return ret, nil
+
+	case isMapColumn(col):
+		kv := col.Children[0]
+		keyCol, valueCol := kv.Children[0], kv.Children[1]
+		entries, _ := m[kv.SchemaElement.GetName()].([]map[string]interface{})
+
+		stringKeys := keyCol.SchemaElement.GetType() == parquet.Type_BYTE_ARRAY
+		obj := make(jsonObject, 0, len(entries))
+		pairs := make([]interface{}, 0, len(entries))
+		for _, entry := range entries {
+			key, err := exportValue(keyCol, entry[keyCol.SchemaElement.GetName()])
+			if err != nil {
+				return nil, err
+			}
+			value, err := exportValue(valueCol, entry[valueCol.SchemaElement.GetName()])
+			if err != nil {
+				return nil, err
+			}
+			if s, ok := key.(string); ok && stringKeys {
+				obj = append(obj, jsonField{name: s, value: value})
+				continue
+			}
+			pairs = append(pairs, jsonObject{{name: "key", value: key}, {name: "value", value: value}})
+		}
+		if stringKeys {
+			return obj, nil
+		}
+		return pairs, nil
+	}
+
+	return exportRow(col.Children, m)
+}
+
+// isListColumn returns true for LIST groups.
+func isListColumn(col *parquetschema.ColumnDefinition) bool {
+	elem := col.SchemaElement
+	isList := elem.GetConvertedType() == parquet.ConvertedType_LIST || (elem.LogicalType != nil && elem.LogicalType.IsSetLIST())
+	return isList && len(col.Children) == 1 && col.Children[0].SchemaElement.GetRepetitionType() == parquet.FieldRepetitionType_REPEATED
+}
+
+// isMapColumn returns true for MAP groups.
+func isMapColumn(col *parquetschema.ColumnDefinition) bool {
+	elem := col.SchemaElement
+	isMap := elem.GetConvertedType() == parquet.ConvertedType_MAP || elem.GetConvertedType() == parquet.ConvertedType_MAP_KEY_VALUE || (elem.LogicalType != nil && elem.LogicalType.IsSetMAP())
+	return isMap && len(col.Children) == 1 && len(col.Children[0].Children) == 2
+}
+
+// listElement returns the column of the elements of a LIST group. In the standard three-level
+// representation, it is the only child of the repeated group, otherwise the repeated column is
+// the element itself.
+func listElement(col *parquetschema.ColumnDefinition) *parquetschema.ColumnDefinition {
+	repeated := col.Children[0]
+	if repeated.SchemaElement.Type == nil && len(repeated.Children) == 1 {
+		return repeated.Children[0]
+	}
+
+	element := *repeated
+	elem := *repeated.SchemaElement
+	elem.RepetitionType = parquet.FieldRepetitionTypePtr(parquet.FieldRepetitionType_REQUIRED)
+	element.SchemaElement = &elem
+	return &element
+}
+
+// listItems returns the values of the elements of a LIST group.
+func listItems(col *parquetschema.ColumnDefinition, m map[string]interface{}) ([]interface{}, error) {
+	repeated := col.Children[0]
+	v, ok := m[repeated.SchemaElement.GetName()]
+	if !ok || v == nil {
+		return nil, nil
+	}
+
+	values, err := repeatedValues(v)
+	if err != nil {
+		return nil, err
+	}
+	if repeated.SchemaElement.Type != nil || len(repeated.Children) != 1 {
+		return values, nil
+	}
+
+	name := repeated.Children[0].SchemaElement.GetName()
+	for i, e := range values {
+		entry, _ := e.(map[string]interface{})
+		values[i] = entry[name]
+	}
+	return values, nil
+}
+
+// repeatedValues returns the values of a repeated column, which the FileReader returns as slice
+// of the values' type.
+func repeatedValues(v interface{}) ([]interface{}, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("unexpected value of type %T for repeated column", v)
+	}
+
+	values := make([]interface{}, rv.Len())
+	for i := range values {
+		values[i] = rv.Index(i).Interface()
+	}
+	return values, nil
+}
+
+// exportPrimitive renders a primitive value: dates, times and timestamps as RFC 3339 strings,
+// decimals as strings, UUIDs in their canonical form, strings and JSON as they are, and other
+// binary values base64 encoded. The result is a string, bool, int64, uint64, float32, float64 or
+// json.RawMessage.
+func exportPrimitive(elem *parquet.SchemaElement, v interface{}) (interface{}, error) {
+	lt := elem.GetLogicalType()
+	if lt == nil {
+		lt = &parquet.LogicalType{}
+	}
+	ct := elem.GetConvertedType()
+
+	switch {
+	case lt.DECIMAL != nil || (elem.ConvertedType != nil && ct == parquet.ConvertedType_DECIMAL):
+		unscaled, err := decimalValue(v)
+		if err != nil {
+			return nil, err
+		}
+		return formatDecimal(unscaled, int(elem.GetScale())), nil
+
+	case lt.DATE != nil || (elem.ConvertedType != nil && ct == parquet.ConvertedType_DATE):
+		days, ok := v.(int32)
+		if !ok {
+			break
+		}
+		return time.Unix(int64(days)*24*60*60, 0).UTC().Format("2006-01-02"), nil
+
+	case lt.TIMESTAMP != nil || (elem.ConvertedType != nil && (ct == parquet.ConvertedType_TIMESTAMP_MILLIS || ct == parquet.ConvertedType_TIMESTAMP_MICROS)):
+		i, ok := v.(int64)
+		if !ok {
+			break
+		}
+		return timestampFromUnit(i, timeUnit(elem)).Format(time.RFC3339Nano), nil
+
+	case lt.TIME != nil || (elem.ConvertedType != nil && (ct == parquet.ConvertedType_TIME_MILLIS || ct == parquet.ConvertedType_TIME_MICROS)):
+		var i int64
+		switch x := v.(type) {
+		case int32:
+			i = int64(x)
+		case int64:
+			i = x
+		default:
+			return nil, fmt.Errorf("unexpected value of type %T for time", v)
+		}
+		return timestampFromUnit(i, timeUnit(elem)).Format("15:04:05.999999999"), nil
+
+	case lt.UUID != nil:
+		b, ok := v.([]byte)
+		if !ok || len(b) != 16 {
+			break
+		}
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+
+	case lt.JSON != nil || (elem.ConvertedType != nil && ct == parquet.ConvertedType_JSON):
+		b, ok := v.([]byte)
+		if ok && json.Valid(b) {
+			return json.RawMessage(b), nil
+		}
+	}
+
+	switch x := v.(type) {
+	case bool, int64, uint64:
+		return x, nil
+	case int32:
+		return int64(x), nil
+	case uint32:
+		return uint64(x), nil
+	case float32:
+		if math.IsNaN(float64(x)) || math.IsInf(float64(x), 0) {
+			return strconv.FormatFloat(float64(x), 'g', -1, 32), nil
+		}
+		return x, nil
+	case float64:
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return strconv.FormatFloat(x, 'g', -1, 64), nil
+		}
+		return x, nil
+	case [12]byte:
+		return goparquet.Int96ToTime(x).UTC().Format(time.RFC3339Nano), nil
+	case []byte:
+		if isTextColumn(elem) {
+			return string(x), nil
+		}
+		// encoding/json encodes byte slices as base64 strings
+		return x, nil
+	}
+
+	return nil, fmt.Errorf("unexpected value of type %T for column of type %s", v, elem.GetType())
+}
+
+// isTextColumn returns true for binary columns that contain UTF-8 text.
+func isTextColumn(elem *parquet.SchemaElement) bool {
+	if lt := elem.GetLogicalType(); lt != nil && (lt.STRING != nil || lt.ENUM != nil || lt.JSON != nil) {
+		return true
+	}
+	if elem.ConvertedType == nil {
+		return false
+	}
+	switch elem.GetConvertedType() {
+	case parquet.ConvertedType_UTF8, parquet.ConvertedType_ENUM, parquet.ConvertedType_JSON:
+		return true
+	}
+	return false
+}
+
+// timeUnit returns the unit of a TIME or TIMESTAMP column as a duration.
+func timeUnit(elem *parquet.SchemaElement) time.Duration {
+	if lt := elem.GetLogicalType(); lt != nil {
+		var unit *parquet.TimeUnit
+		switch {
+		case lt.TIMESTAMP != nil:
+			unit = lt.TIMESTAMP.Unit
+		case lt.TIME != nil:
+			unit = lt.TIME.Unit
+		}
+		switch {
+		case unit == nil:
+		case unit.NANOS != nil:
+			return time.Nanosecond
+		case unit.MICROS != nil:
+			return time.Microsecond
+		case unit.MILLIS != nil:
+			return time.Millisecond
+		}
+	}
+
+	switch elem.GetConvertedType() {
+	case parquet.ConvertedType_TIMESTAMP_MILLIS, parquet.ConvertedType_TIME_MILLIS:
+		return time.Millisecond
+	}
+	return time.Microsecond
+}
+
+func timestampFromUnit(v int64, unit time.Duration) time.Time {
+	perSecond := int64(time.Second / unit)
+	sec, frac := v/perSecond, v%perSecond
+	if frac < 0 {
+		sec, frac = sec-1, frac+perSecond
+	}
+	return time.Unix(sec, frac*int64(unit)).UTC()
+}
+
+// decimalValue returns the unscaled value of a decimal, which is stored as INT32, INT64 or as
+// big-endian two's complement in a byte array.
+func decimalValue(v interface{}) (*big.Int, error) {
+	switch x := v.(type) {
+	case int32:
+		return big.NewInt(int64(x)), nil
+	case int64:
+		return big.NewInt(x), nil
+	case []byte:
+		i := new(big.Int).SetBytes(x)
+		if len(x) > 0 && x[0]&0x80 != 0 {
+			i.Sub(i, new(big.Int).Lsh(big.NewInt(1), uint(8*len(x))))
+		}
+		return i, nil
+	}
+	return nil, fmt.Errorf("unexpected value of type %T for decimal", v)
+}
+
+func formatDecimal(unscaled *big.Int, scale int) string {
+	s := new(big.Int).Abs(unscaled).String()
+	if scale > 0 {
+		if len(s) <= scale {
+			s = strings.Repeat("0", scale-len(s)+1) + s
+		}
+		s = s[:len(s)-scale] + "." + s[len(s)-scale:]
+	}
+	if unscaled.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// ndjsonRowWriter writes every row as a JSON object on its own line.
+type ndjsonRowWriter struct {
+	w  io.Writer
+	sd *parquetschema.SchemaDefinition
+}
+
+func (n *ndjsonRowWriter) writeRow(row map[string]interface{}) error {
+	obj, err := exportRow(n.sd.RootColumn.Children, row)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	_, err = n.w.Write(append(b, '\n'))
+	return err
+}
+
+func (n *ndjsonRowWriter) close() error {
+	return nil
+}
+
+// csvColumn is a column of the CSV output, which contains the value at path.
+type csvColumn struct {
+	name string
+	path []string
+	col  *parquetschema.ColumnDefinition
+}
+
+// csvRowWriter writes rows as CSV records with a header. Nested values that don't have their own
+// columns are written as JSON.
+type csvRowWriter struct {
+	w          *csv.Writer
+	columns    []csvColumn
+	headerDone bool
+}
+
+// newCSVRowWriter returns a CSV writer. If flatten is true, every field of a group that is not
+// repeated gets its own column named by its dotted path.
+func newCSVRowWriter(w io.Writer, sd *parquetschema.SchemaDefinition, flatten bool) *csvRowWriter {
+	var columns []csvColumn
+	var add func(cols []*parquetschema.ColumnDefinition, path []string)
+	add = func(cols []*parquetschema.ColumnDefinition, path []string) {
+		for _, col := range cols {
+			p := append(append([]string(nil), path...), col.SchemaElement.GetName())
+			if flatten && col.SchemaElement.Type == nil && col.SchemaElement.GetRepetitionType() != parquet.FieldRepetitionType_REPEATED && !isListColumn(col) && !isMapColumn(col) {
+				add(col.Children, p)
+				continue
+			}
+			columns = append(columns, csvColumn{name: strings.Join(p, "."), path: p, col: col})
+		}
+	}
+	add(sd.RootColumn.Children, nil)
+
+	return &csvRowWriter{w: csv.NewWriter(w), columns: columns}
+}
+
+func (c *csvRowWriter) writeHeader() error {
+	c.headerDone = true
+	header := make([]string, len(c.columns))
+	for i, col := range c.columns {
+		header[i] = col.name
+	}
+	return c.w.Write(header)
+}
+
+func (c *csvRowWriter) writeRow(row map[string]interface{}) error {
+	if !c.headerDone {
+		if err := c.writeHeader(); err != nil {
+			return err
+		}
+	}
+
+	record := make([]string, len(c.columns))
+	for i, col := range c.columns {
+		var v interface{} = row
+		for _, name := range col.path {
+			m, _ := v.(map[string]interface{})
+			v = m[name]
+		}
+
+		ev, err := exportValue(col.col, v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", col.name, err)
+		}
+		if record[i], err = formatCell(ev); err != nil {
+			return fmt.Errorf("%s: %w", col.name, err)
+		}
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvRowWriter) close() error {
+	if !c.headerDone {
+		if err := c.writeHeader(); err != nil {
+			return err
+		}
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// formatCell formats an exported value as CSV field. Null is an empty field.
+func formatCell(v interface{}) (string, error) {
+	switch x := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return x, nil
+	case bool:
+		return strconv.FormatBool(x), nil
+	case int64:
+		return strconv.FormatInt(x, 10), nil
+	case uint64:
+		return strconv.FormatUint(x, 10), nil
+	case float32:
+		return strconv.FormatFloat(float64(x), 'g', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(x, 'g', -1, 64), nil
+	case json.RawMessage:
+		return string(x), nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	if s := string(b); len(s) > 1 && s[0] == '"' {
+		// base64 encoded binary values are written without quotes
+		return strconv.Unquote(s)
+	}
+	return string(b), nil
+}
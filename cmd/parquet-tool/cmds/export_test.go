@@ -0,0 +1,169 @@
+package cmds
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	goparquet "github.com/sagia-inneractive/parquet-go"
+	"github.com/sagia-inneractive/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+const exportSchema = `message test {
+  required int64 id;
+  optional binary name (STRING);
+  optional int64 ts (TIMESTAMP(MICROS, true));
+  optional int32 day (DATE);
+  optional int64 price (DECIMAL(10, 2));
+  optional fixed_len_byte_array(16) uuid (UUID);
+  optional int96 legacy;
+  optional binary raw;
+  optional double score;
+  optional group address {
+    optional binary city (STRING);
+    optional int32 zip;
+  }
+  optional group tags (LIST) {
+    repeated group list {
+      optional binary element (STRING);
+    }
+  }
+  optional group attrs (MAP) {
+    repeated group key_value (MAP_KEY_VALUE) {
+      required binary key (STRING);
+      optional int64 value;
+    }
+  }
+  repeated int32 numbers;
+}`
+
+func writeExportFile(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "export")
+	require.NoError(t, err)
+
+	sd, err := parquetschema.ParseSchemaDefinition(exportSchema)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	fw := goparquet.NewFileWriter(&buf, goparquet.WithSchemaDefinition(sd))
+
+	ts := time.Date(2020, 5, 1, 12, 30, 0, 123456000, time.UTC)
+	require.NoError(t, fw.AddData(map[string]interface{}{
+		"id":      int64(1),
+		"name":    []byte("a, \"b\""),
+		"ts":      ts.UnixNano() / 1000,
+		"day":     int32(18383),
+		"price":   int64(-1205),
+		"uuid":    []byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00},
+		"legacy":  goparquet.TimeToInt96(ts),
+		"raw":     []byte{0xff, 0x00},
+		"score":   1.5,
+		"address": map[string]interface{}{"city": []byte("Berlin")},
+		"tags": map[string]interface{}{"list": []map[string]interface{}{
+			{"element": []byte("x")},
+			{},
+		}},
+		"attrs": map[string]interface{}{"key_value": []map[string]interface{}{
+			{"key": []byte("k"), "value": int64(3)},
+		}},
+		"numbers": []int32{1, 2},
+	}))
+	require.NoError(t, fw.AddData(map[string]interface{}{
+		"id": int64(2),
+	}))
+	require.NoError(t, fw.Close())
+
+	file := filepath.Join(dir, "test.parquet")
+	require.NoError(t, ioutil.WriteFile(file, buf.Bytes(), 0644))
+	return file
+}
+
+func TestExportFile(t *testing.T) {
+	file := writeExportFile(t)
+	defer os.RemoveAll(filepath.Dir(file))
+
+	tests := map[string]struct {
+		format   string
+		n        int
+		columns  []string
+		nested   string
+		expected string
+	}{
+		"ndjson": {
+			format: "ndjson",
+			n:      -1,
+			expected: `{"id":1,"name":"a, \"b\"","ts":"2020-05-01T12:30:00.123456Z","day":"2020-05-01","price":"-12.05","uuid":"123e4567-e89b-12d3-a456-426614174000","legacy":"2020-05-01T12:30:00.123456Z","raw":"/wA=","score":1.5,"address":{"city":"Berlin","zip":null},"tags":["x",null],"attrs":{"k":3},"numbers":[1,2]}
+{"id":2,"name":null,"ts":null,"day":null,"price":null,"uuid":null,"legacy":null,"raw":null,"score":null,"address":null,"tags":null,"attrs":null,"numbers":null}
+`,
+		},
+		"csv-flatten": {
+			format: "csv",
+			n:      -1,
+			nested: "flatten",
+			expected: `id,name,ts,day,price,uuid,legacy,raw,score,address.city,address.zip,tags,attrs,numbers
+1,"a, ""b""",2020-05-01T12:30:00.123456Z,2020-05-01,-12.05,123e4567-e89b-12d3-a456-426614174000,2020-05-01T12:30:00.123456Z,/wA=,1.5,Berlin,,"[""x"",null]","{""k"":3}","[1,2]"
+2,,,,,,,,,,,,,
+`,
+		},
+		"csv-json": {
+			format:  "csv",
+			n:       1,
+			columns: []string{"id", "address.*"},
+			nested:  "json",
+			expected: `id,address
+1,"{""city"":""Berlin"",""zip"":null}"
+`,
+		},
+		"projection-and-limit": {
+			format:  "ndjson",
+			n:       1,
+			columns: []string{"tags[]", "id"},
+			expected: `{"id":1,"tags":["x",null]}
+`,
+		},
+		"empty": {
+			format:  "csv",
+			n:       0,
+			columns: []string{"id"},
+			nested:  "flatten",
+			expected: `id
+`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, exportFile(&buf, file, tt.format, tt.n, tt.columns, tt.nested))
+			require.Equal(t, tt.expected, buf.String())
+		})
+	}
+
+	require.Error(t, exportFile(&bytes.Buffer{}, file, "xml", -1, nil, ""))
+	require.Error(t, exportFile(&bytes.Buffer{}, file, "csv", -1, nil, "invalid"))
+	require.Error(t, exportFile(&bytes.Buffer{}, file, "csv", -1, []string{"foo"}, "flatten"))
+}
+
+func TestFormatDecimal(t *testing.T) {
+	data := []struct {
+		unscaled interface{}
+		scale    int
+		expected string
+	}{
+		{int32(12345), 2, "123.45"},
+		{int32(-5), 2, "-0.05"},
+		{int64(7), 0, "7"},
+		{[]byte{0xff, 0x85}, 1, "-12.3"},
+		{[]byte{0x01, 0x00}, 3, "0.256"},
+	}
+
+	for _, tt := range data {
+		unscaled, err := decimalValue(tt.unscaled)
+		require.NoError(t, err)
+		require.Equal(t, tt.expected, formatDecimal(unscaled, tt.scale))
+	}
+}
@@ -0,0 +1,164 @@
+package cmds
+
+import (
+	"encoding/binary"
+)
+
+// fbTable is a flatbuffers table that is built in memory and serialized with encodeFlatbuffer.
+// Only the small subset of flatbuffers that is needed for the Arrow IPC metadata is supported.
+type fbTable struct {
+	fields []fbField
+}
+
+// fbField is a field of a table, either a scalar of the given size or an offset to an object.
+type fbField struct {
+	slot   int
+	size   int
+	scalar uint64
+	obj    interface{}
+}
+
+// fbString is a string object.
+type fbString string
+
+// fbTables is a vector of tables.
+type fbTables []*fbTable
+
+// fbStructs is a vector of structs whose serialized form is data. The structs must be aligned
+// to 8 bytes.
+type fbStructs struct {
+	n    int
+	data []byte
+}
+
+func (t *fbTable) addScalar(slot, size int, v uint64) *fbTable {
+	t.fields = append(t.fields, fbField{slot: slot, size: size, scalar: v})
+	return t
+}
+
+func (t *fbTable) addBool(slot int, b bool) *fbTable {
+	if b {
+		return t.addScalar(slot, 1, 1)
+	}
+	return t.addScalar(slot, 1, 0)
+}
+
+func (t *fbTable) addUint8(slot int, v uint8) *fbTable {
+	return t.addScalar(slot, 1, uint64(v))
+}
+
+func (t *fbTable) addInt16(slot int, v int16) *fbTable {
+	return t.addScalar(slot, 2, uint64(uint16(v)))
+}
+
+func (t *fbTable) addInt32(slot int, v int32) *fbTable {
+	return t.addScalar(slot, 4, uint64(uint32(v)))
+}
+
+func (t *fbTable) addInt64(slot int, v int64) *fbTable {
+	return t.addScalar(slot, 8, uint64(v))
+}
+
+func (t *fbTable) addOffset(slot int, obj interface{}) *fbTable {
+	t.fields = append(t.fields, fbField{slot: slot, size: 4, obj: obj})
+	return t
+}
+
+// encodeFlatbuffer serializes the table as the root of a flatbuffer. Unlike the official
+// builders, the buffer is written front to back: every object is written after the table or
+// vector that refers to it, so that all offsets are positive. Positions are aligned relative
+// to the start of the buffer, which must be aligned to 8 bytes when it is read.
+func encodeFlatbuffer(root *fbTable) []byte {
+	e := &fbEncoder{buf: make([]byte, 4, 256)}
+	pos := e.writeTable(root)
+	binary.LittleEndian.PutUint32(e.buf, uint32(pos))
+	return e.buf
+}
+
+type fbEncoder struct {
+	buf []byte
+}
+
+func (e *fbEncoder) align(n int) {
+	for len(e.buf)%n != 0 {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+func (e *fbEncoder) putScalar(size int, v uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	e.buf = append(e.buf, b[:size]...)
+}
+
+func (e *fbEncoder) writeTable(t *fbTable) int {
+	numSlots := 0
+	for _, f := range t.fields {
+		if f.slot >= numSlots {
+			numSlots = f.slot + 1
+		}
+	}
+
+	e.align(2)
+	vtablePos := len(e.buf)
+	e.putScalar(2, uint64(4+2*numSlots))
+	e.buf = append(e.buf, make([]byte, 2+2*numSlots)...)
+
+	e.align(8)
+	tablePos := len(e.buf)
+	e.putScalar(4, uint64(uint32(tablePos-vtablePos)))
+
+	positions := make([]int, len(t.fields))
+	for i, f := range t.fields {
+		e.align(f.size)
+		positions[i] = len(e.buf)
+		e.putScalar(f.size, f.scalar)
+		binary.LittleEndian.PutUint16(e.buf[vtablePos+4+2*f.slot:], uint16(positions[i]-tablePos))
+	}
+	binary.LittleEndian.PutUint16(e.buf[vtablePos+2:], uint16(len(e.buf)-tablePos))
+
+	for i, f := range t.fields {
+		if f.obj == nil {
+			continue
+		}
+		pos := e.writeObject(f.obj)
+		binary.LittleEndian.PutUint32(e.buf[positions[i]:], uint32(pos-positions[i]))
+	}
+
+	return tablePos
+}
+
+func (e *fbEncoder) writeObject(obj interface{}) int {
+	switch o := obj.(type) {
+	case *fbTable:
+		return e.writeTable(o)
+	case fbString:
+		e.align(4)
+		pos := len(e.buf)
+		e.putScalar(4, uint64(len(o)))
+		e.buf = append(e.buf, o...)
+		e.buf = append(e.buf, 0)
+		return pos
+	case fbTables:
+		e.align(4)
+		pos := len(e.buf)
+		e.putScalar(4, uint64(len(o)))
+		e.buf = append(e.buf, make([]byte, 4*len(o))...)
+		for i, t := range o {
+			elemPos := pos + 4 + 4*i
+			tablePos := e.writeTable(t)
+			binary.LittleEndian.PutUint32(e.buf[elemPos:], uint32(tablePos-elemPos))
+		}
+		return pos
+	case fbStructs:
+		// the length precedes the structs, which are aligned to 8 bytes
+		for len(e.buf)%8 != 4 {
+			e.buf = append(e.buf, 0)
+		}
+		pos := len(e.buf)
+		e.putScalar(4, uint64(o.n))
+		e.buf = append(e.buf, o.data...)
+		return pos
+	}
+	panic("unsupported flatbuffers object")
+}
@@ -7,10 +7,16 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var recordCount *int
+var (
+	recordCount  *int
+	headColumns  *[]string
+	headDetailed *bool
+)
 
 func init() {
 	recordCount = headCmd.PersistentFlags().IntP("records", "n", 5, "The number of records to show")
+	headColumns = headCmd.PersistentFlags().StringSliceP("columns", "c", nil, "The columns to print, e.g. address.*, attrs.key_value.key or events[].type")
+	headDetailed = headCmd.PersistentFlags().BoolP("detailed", "d", false, "Print the detailed schema of the columns before the records")
 	rootCmd.AddCommand(headCmd)
 }
 
@@ -23,7 +29,7 @@ var headCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		if err := catFile(os.Stdout, args[0], *recordCount); err != nil {
+		if err := catFile(os.Stdout, args[0], *recordCount, *headColumns, *headDetailed); err != nil {
 			log.Fatal(err)
 		}
 	},
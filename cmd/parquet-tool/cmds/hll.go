@@ -0,0 +1,104 @@
+package cmds
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+// hllPrecision is the number of bits of the hash that select the register, which results in
+// 2^14 registers and a standard error of about 0.8%.
+const hllPrecision = 14
+
+// hyperLogLog estimates the number of distinct values using the HyperLogLog algorithm of
+// Flajolet et al., with the linear counting correction for small cardinalities.
+type hyperLogLog struct {
+	registers []uint8
+	buf       []byte
+}
+
+func newHyperLogLog() *hyperLogLog {
+	return &hyperLogLog{registers: make([]uint8, 1<<hllPrecision)}
+}
+
+// add adds a value as returned by FileReader.ReadColumnValues.
+func (h *hyperLogLog) add(v interface{}) {
+	x := h.hash(v)
+	idx := x >> (64 - hllPrecision)
+	rank := uint8(bits.LeadingZeros64(x<<hllPrecision|1<<(hllPrecision-1)) + 1)
+	if rank > h.registers[idx] {
+		h.registers[idx] = rank
+	}
+}
+
+// estimate returns the estimated number of distinct values that were added.
+func (h *hyperLogLog) estimate() int64 {
+	m := float64(len(h.registers))
+	var (
+		sum   float64
+		zeros int
+	)
+	for _, r := range h.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	e := 0.7213 / (1 + 1.079/m) * m * m / sum
+	if e <= 2.5*m && zeros > 0 {
+		e = m * math.Log(m/float64(zeros))
+	}
+	return int64(e + 0.5)
+}
+
+func (h *hyperLogLog) hash(v interface{}) uint64 {
+	b := h.buf[:0]
+	switch x := v.(type) {
+	case []byte:
+		b = x
+	case [12]byte:
+		b = append(b, x[:]...)
+	case bool:
+		if x {
+			b = append(b, 1)
+		} else {
+			b = append(b, 0)
+		}
+	case int32:
+		b = appendUint64(b, uint64(x))
+	case int64:
+		b = appendUint64(b, uint64(x))
+	case uint32:
+		b = appendUint64(b, uint64(x))
+	case uint64:
+		b = appendUint64(b, x)
+	case float32:
+		b = appendUint64(b, uint64(math.Float32bits(x)))
+	case float64:
+		b = appendUint64(b, math.Float64bits(x))
+	default:
+		b = append(b, fmt.Sprint(v)...)
+	}
+	if _, ok := v.([]byte); !ok {
+		h.buf = b
+	}
+
+	f := fnv.New64a()
+	_, _ = f.Write(b)
+
+	// FNV doesn't distribute the bits of similar values well enough, so the hash is mixed with
+	// the finalizer of SplitMix64
+	x := f.Sum64()
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	var tmp [8]byte
+	binary.LittleEndian.PutUint64(tmp[:], v)
+	return append(b, tmp[:]...)
+}
@@ -0,0 +1,360 @@
+package cmds
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	goparquet "github.com/sagia-inneractive/parquet-go"
+	"github.com/sagia-inneractive/parquet-go/parquet"
+	"github.com/spf13/cobra"
+)
+
+var (
+	inspectColumns *[]string
+	inspectJSON    *bool
+)
+
+func init() {
+	inspectColumns = inspectCmd.PersistentFlags().StringSliceP("columns", "c", nil, "The columns to inspect, e.g. address.*, attrs.key_value.key or events[].type")
+	inspectJSON = inspectCmd.PersistentFlags().Bool("json", false, "Print the page layout as JSON instead of a table")
+	rootCmd.AddCommand(inspectCmd)
+}
+
+var inspectCmd = &cobra.Command{
+	Use:   "inspect file-name.parquet",
+	Short: "Print the page layout of the column chunks of the parquet file",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			_ = cmd.Usage()
+			os.Exit(1)
+		}
+
+		if err := inspectFile(os.Stdout, args[0], *inspectColumns, *inspectJSON); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+// chunkLayout describes a column chunk and its pages.
+type chunkLayout struct {
+	RowGroup              int           `json:"row_group"`
+	Column                string        `json:"column"`
+	Codec                 string        `json:"codec"`
+	Offset                int64         `json:"offset"`
+	TotalCompressedSize   int64         `json:"total_compressed_size"`
+	TotalUncompressedSize int64         `json:"total_uncompressed_size"`
+	NumValues             int64         `json:"num_values"`
+	Pages                 []*pageLayout `json:"pages"`
+}
+
+// pageLayout describes a page. The fields that don't apply to the type of the page are omitted.
+type pageLayout struct {
+	Offset                  int64           `json:"offset"`
+	Type                    string          `json:"type"`
+	HeaderSize              int64           `json:"header_size"`
+	CompressedSize          int32           `json:"compressed_size"`
+	UncompressedSize        int32           `json:"uncompressed_size"`
+	NumValues               int32           `json:"num_values"`
+	NumNulls                *int32          `json:"num_nulls,omitempty"`
+	NumRows                 *int32          `json:"num_rows,omitempty"`
+	Encoding                string          `json:"encoding"`
+	DefinitionLevelEncoding string          `json:"definition_level_encoding,omitempty"`
+	RepetitionLevelEncoding string          `json:"repetition_level_encoding,omitempty"`
+	Sorted                  *bool           `json:"sorted,omitempty"`
+	Statistics              *pageStatistics `json:"statistics,omitempty"`
+	CRC                     string          `json:"crc"`
+}
+
+type pageStatistics struct {
+	Min           *string `json:"min,omitempty"`
+	Max           *string `json:"max,omitempty"`
+	NullCount     *int64  `json:"null_count,omitempty"`
+	DistinctCount *int64  `json:"distinct_count,omitempty"`
+}
+
+func inspectFile(w io.Writer, address string, columns []string, asJSON bool) error {
+	fl, err := os.Open(address)
+	if err != nil {
+		return fmt.Errorf("can not open the file: %q", err)
+	}
+	defer fl.Close()
+
+	reader, err := goparquet.NewFileReaderWithOptions(fl,
+		goparquet.WithExternalFileOpener(externalFileOpener(address)),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to read the parquet header: %q", err)
+	}
+	defer reader.Close()
+
+	if len(columns) == 0 {
+		columns = []string{"*"}
+	}
+	paths, err := goparquet.ResolveColumnPaths(reader.GetSchemaDefinition(), columns...)
+	if err != nil {
+		return err
+	}
+
+	var chunks []*chunkLayout
+	for rg, rowGroup := range reader.RowGroups() {
+		for _, path := range paths {
+			col := reader.GetColumnByName(path)
+			chunk := rowGroup.Columns[col.Index()]
+			if chunk.MetaData == nil {
+				return fmt.Errorf("column %q in row group %d has no meta data", path, rg)
+			}
+
+			pages, err := reader.ReadPageInfo(rg, path)
+			if err != nil {
+				return fmt.Errorf("reading row group %d failed: %w", rg, err)
+			}
+
+			layout := &chunkLayout{
+				RowGroup:              rg,
+				Column:                path,
+				Codec:                 chunk.MetaData.Codec.String(),
+				Offset:                chunkStart(chunk.MetaData),
+				TotalCompressedSize:   chunk.MetaData.TotalCompressedSize,
+				TotalUncompressedSize: chunk.MetaData.TotalUncompressedSize,
+				NumValues:             chunk.MetaData.NumValues,
+			}
+			for _, p := range pages {
+				pl, err := newPageLayout(col.Element(), p)
+				if err != nil {
+					return fmt.Errorf("page at offset %d of column %q: %w", p.Offset, path, err)
+				}
+				layout.Pages = append(layout.Pages, pl)
+			}
+			chunks = append(chunks, layout)
+		}
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(chunks)
+	}
+
+	return printLayout(w, chunks)
+}
+
+// chunkStart returns the offset of the first page of a column chunk.
+func chunkStart(md *parquet.ColumnMetaData) int64 {
+	if md.DictionaryPageOffset != nil {
+		return *md.DictionaryPageOffset
+	}
+	return md.DataPageOffset
+}
+
+func newPageLayout(elem *parquet.SchemaElement, p *goparquet.PageInfo) (*pageLayout, error) {
+	ph := p.Header
+	pl := &pageLayout{
+		Offset:           p.Offset,
+		Type:             ph.Type.String(),
+		HeaderSize:       p.HeaderSize,
+		CompressedSize:   ph.CompressedPageSize,
+		UncompressedSize: ph.UncompressedPageSize,
+		CRC:              p.CRC.String(),
+	}
+
+	var (
+		stats *parquet.Statistics
+		err   error
+	)
+	switch {
+	case ph.Type == parquet.PageType_DICTIONARY_PAGE && ph.DictionaryPageHeader != nil:
+		pl.Type = "dict"
+		pl.NumValues = ph.DictionaryPageHeader.NumValues
+		pl.Encoding = ph.DictionaryPageHeader.Encoding.String()
+		sorted := ph.DictionaryPageHeader.GetIsSorted()
+		pl.Sorted = &sorted
+	case ph.Type == parquet.PageType_DATA_PAGE && ph.DataPageHeader != nil:
+		h := ph.DataPageHeader
+		pl.Type = "v1"
+		pl.NumValues = h.NumValues
+		pl.Encoding = h.Encoding.String()
+		pl.DefinitionLevelEncoding = h.DefinitionLevelEncoding.String()
+		pl.RepetitionLevelEncoding = h.RepetitionLevelEncoding.String()
+		stats = h.Statistics
+	case ph.Type == parquet.PageType_DATA_PAGE_V2 && ph.DataPageHeaderV2 != nil:
+		h := ph.DataPageHeaderV2
+		pl.Type = "v2"
+		pl.NumValues = h.NumValues
+		pl.NumNulls = &h.NumNulls
+		pl.NumRows = &h.NumRows
+		pl.Encoding = h.Encoding.String()
+		// the levels of v2 data pages are always RLE encoded
+		pl.DefinitionLevelEncoding = parquet.Encoding_RLE.String()
+		pl.RepetitionLevelEncoding = parquet.Encoding_RLE.String()
+		stats = h.Statistics
+	}
+
+	if stats != nil {
+		pl.Statistics, err = newPageStatistics(elem, stats)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return pl, nil
+}
+
+func newPageStatistics(elem *parquet.SchemaElement, stats *parquet.Statistics) (*pageStatistics, error) {
+	ps := &pageStatistics{
+		NullCount:     stats.NullCount,
+		DistinctCount: stats.DistinctCount,
+	}
+
+	minValue, maxValue := stats.MinValue, stats.MaxValue
+	if minValue == nil && maxValue == nil {
+		minValue, maxValue = stats.Min, stats.Max
+	}
+
+	for _, s := range []struct {
+		b   []byte
+		dst **string
+	}{{minValue, &ps.Min}, {maxValue, &ps.Max}} {
+		if s.b == nil {
+			continue
+		}
+		v, err := statisticValue(elem, s.b)
+		if err != nil {
+			return nil, err
+		}
+		*s.dst = &v
+	}
+
+	return ps, nil
+}
+
+// statisticValue decodes a plain encoded minimum or maximum value and formats it like export does.
+func statisticValue(elem *parquet.SchemaElement, b []byte) (string, error) {
+	v, err := decodeStatistic(elem, b)
+	if err != nil {
+		return "", err
+	}
+
+	ev, err := exportPrimitive(elem, v)
+	if err != nil {
+		return "", err
+	}
+	return formatCell(ev)
+}
+
+// decodeStatistic decodes a plain encoded minimum or maximum value into a value of the same type
+// as the values read from the column.
+func decodeStatistic(elem *parquet.SchemaElement, b []byte) (interface{}, error) {
+	var v interface{}
+	switch elem.GetType() {
+	case parquet.Type_BOOLEAN:
+		if len(b) == 1 {
+			v = b[0] != 0
+		}
+	case parquet.Type_INT32:
+		if len(b) == 4 {
+			if isUnsignedColumn(elem) {
+				v = binary.LittleEndian.Uint32(b)
+			} else {
+				v = int32(binary.LittleEndian.Uint32(b))
+			}
+		}
+	case parquet.Type_INT64:
+		if len(b) == 8 {
+			if isUnsignedColumn(elem) {
+				v = binary.LittleEndian.Uint64(b)
+			} else {
+				v = int64(binary.LittleEndian.Uint64(b))
+			}
+		}
+	case parquet.Type_INT96:
+		if len(b) == 12 {
+			var x [12]byte
+			copy(x[:], b)
+			v = x
+		}
+	case parquet.Type_FLOAT:
+		if len(b) == 4 {
+			v = math.Float32frombits(binary.LittleEndian.Uint32(b))
+		}
+	case parquet.Type_DOUBLE:
+		if len(b) == 8 {
+			v = math.Float64frombits(binary.LittleEndian.Uint64(b))
+		}
+	default:
+		v = b
+	}
+	if v == nil {
+		return nil, fmt.Errorf("invalid statistics value of %d bytes for type %s", len(b), elem.GetType())
+	}
+	return v, nil
+}
+
+// isUnsignedColumn returns true for integer columns that contain unsigned values.
+func isUnsignedColumn(elem *parquet.SchemaElement) bool {
+	if lt := elem.GetLogicalType(); lt != nil && lt.INTEGER != nil {
+		return !lt.INTEGER.IsSigned
+	}
+	if elem.ConvertedType == nil {
+		return false
+	}
+	switch elem.GetConvertedType() {
+	case parquet.ConvertedType_UINT_8, parquet.ConvertedType_UINT_16, parquet.ConvertedType_UINT_32, parquet.ConvertedType_UINT_64:
+		return true
+	}
+	return false
+}
+
+func printLayout(w io.Writer, chunks []*chunkLayout) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	for i, c := range chunks {
+		if i > 0 {
+			_, _ = fmt.Fprintln(tw)
+		}
+		_, _ = fmt.Fprintf(tw, "row group %d, column %s: offset %d, compressed %d, uncompressed %d, values %d, codec %s\n",
+			c.RowGroup, c.Column, c.Offset, c.TotalCompressedSize, c.TotalUncompressedSize, c.NumValues, c.Codec)
+		_, _ = fmt.Fprintln(tw, "OFFSET\tTYPE\tHEADER\tCOMPRESSED\tUNCOMPRESSED\tVALUES\tNULLS\tROWS\tENCODING\tDEF\tREP\tMIN\tMAX\tCRC")
+		for _, p := range c.Pages {
+			var min, max string
+			nulls := optionalInt(p.NumNulls)
+			if p.Statistics != nil {
+				min, max = optionalString(p.Statistics.Min), optionalString(p.Statistics.Max)
+				if p.NumNulls == nil && p.Statistics.NullCount != nil {
+					nulls = strconv.FormatInt(*p.Statistics.NullCount, 10)
+				}
+			}
+			_, _ = fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%d\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				p.Offset, p.Type, p.HeaderSize, p.CompressedSize, p.UncompressedSize, p.NumValues,
+				nulls, optionalInt(p.NumRows), p.Encoding, orDash(p.DefinitionLevelEncoding),
+				orDash(p.RepetitionLevelEncoding), orDash(min), orDash(max), p.CRC)
+		}
+	}
+	return tw.Flush()
+}
+
+func optionalInt(i *int32) string {
+	if i == nil {
+		return "-"
+	}
+	return strconv.Itoa(int(*i))
+}
+
+func optionalString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
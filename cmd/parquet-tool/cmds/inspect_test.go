@@ -0,0 +1,88 @@
+package cmds
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sagia-inneractive/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspectFile(t *testing.T) {
+	file := writeExportFile(t)
+	defer os.RemoveAll(filepath.Dir(file))
+
+	var buf bytes.Buffer
+	require.NoError(t, inspectFile(&buf, file, []string{"id", "tags[]"}, true))
+
+	var chunks []*chunkLayout
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &chunks))
+	require.Len(t, chunks, 2)
+	require.Equal(t, "id", chunks[0].Column)
+	require.Equal(t, "tags.list.element", chunks[1].Column)
+
+	for _, c := range chunks {
+		require.Equal(t, 0, c.RowGroup)
+		var size int64
+		offset := c.Offset
+		for _, p := range c.Pages {
+			require.Equal(t, offset, p.Offset)
+			require.Equal(t, "none", p.CRC)
+			offset += p.HeaderSize + int64(p.CompressedSize)
+			size += p.HeaderSize + int64(p.CompressedSize)
+		}
+		require.Equal(t, c.TotalCompressedSize, size)
+	}
+
+	require.Equal(t, "v1", chunks[0].Pages[len(chunks[0].Pages)-1].Type)
+	require.Equal(t, int32(2), chunks[0].Pages[len(chunks[0].Pages)-1].NumValues)
+	// two elements in the first row, and a null list in the second row
+	require.Equal(t, int64(3), chunks[1].NumValues)
+
+	buf.Reset()
+	require.NoError(t, inspectFile(&buf, file, nil, false))
+	out := buf.String()
+	require.Contains(t, out, "row group 0, column attrs.key_value.key:")
+	require.Equal(t, 15, strings.Count(out, "OFFSET"))
+
+	require.Error(t, inspectFile(&buf, file, []string{"foo"}, false))
+}
+
+func TestStatisticValue(t *testing.T) {
+	schema, err := parquetschema.ParseSchemaDefinition(`message test {
+  required int32 a;
+  required int32 b (INT(32, false));
+  required int64 c (DECIMAL(10, 2));
+  required binary d (STRING);
+  required double e;
+  required boolean f;
+}`)
+	require.NoError(t, err)
+
+	data := []struct {
+		column   string
+		value    []byte
+		expected string
+	}{
+		{"a", []byte{0xfe, 0xff, 0xff, 0xff}, "-2"},
+		{"b", []byte{0xfe, 0xff, 0xff, 0xff}, "4294967294"},
+		{"c", []byte{0x39, 0x30, 0, 0, 0, 0, 0, 0}, "123.45"},
+		{"d", []byte("abc"), "abc"},
+		{"e", []byte{0, 0, 0, 0, 0, 0, 0xf8, 0x3f}, "1.5"},
+		{"f", []byte{1}, "true"},
+	}
+
+	for _, tt := range data {
+		elem := schema.SubSchema(tt.column).SchemaElement()
+		v, err := statisticValue(elem, tt.value)
+		require.NoError(t, err)
+		require.Equal(t, tt.expected, v, tt.column)
+	}
+
+	_, err = statisticValue(schema.SubSchema("a").SchemaElement(), []byte{1})
+	require.Error(t, err)
+}
@@ -0,0 +1,61 @@
+package cmds
+
+import (
+	"io"
+	"log"
+	"os"
+
+	goparquet "github.com/sagia-inneractive/parquet-go"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mergeOutput       *string
+	mergeRowGroupSize *string
+)
+
+func init() {
+	mergeOutput = mergeCmd.PersistentFlags().StringP("output", "o", "", "The file to write the merged content to")
+	mergeRowGroupSize = mergeCmd.PersistentFlags().StringP("row-group-size", "r", "", "Re-encode the rows into row groups of this uncompressed size instead of copying the row groups")
+	rootCmd.AddCommand(mergeCmd)
+}
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge -o output.parquet file-name.parquet [file-name.parquet...]",
+	Short: "Merge parquet files with identical schemas into a single file",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 || *mergeOutput == "" {
+			_ = cmd.Usage()
+			os.Exit(1)
+		}
+
+		var opts []goparquet.MergeOption
+		if *mergeRowGroupSize != "" {
+			rgSize, err := humanToByte(*mergeRowGroupSize)
+			if err != nil {
+				log.Fatalf("Invalid row group size: %q", *mergeRowGroupSize)
+			}
+			opts = append(opts, goparquet.WithReencoding(goparquet.WithMaxRowGroupSize(rgSize)))
+		}
+
+		inputs := make([]io.ReadSeeker, 0, len(args))
+		for _, arg := range args {
+			fl, err := os.Open(arg)
+			if err != nil {
+				log.Fatalf("Can not open the file: %q", err)
+			}
+			defer fl.Close()
+			inputs = append(inputs, fl)
+		}
+
+		out, err := os.Create(*mergeOutput)
+		if err != nil {
+			log.Fatalf("Can not create the output file: %q", err)
+		}
+		defer out.Close()
+
+		if err := goparquet.MergeFilesWithOptions(out, inputs, opts...); err != nil {
+			log.Fatalf("Merging files failed: %q", err)
+		}
+	},
+}
@@ -0,0 +1,165 @@
+package cmds
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	goparquet "github.com/sagia-inneractive/parquet-go"
+	"github.com/spf13/cobra"
+)
+
+var (
+	queryColumns *[]string
+	queryWhere   *string
+	queryLimit   *int
+	queryFormat  *string
+	queryNested  *string
+	queryVerbose *bool
+)
+
+func init() {
+	queryColumns = queryCmd.PersistentFlags().StringSliceP("columns", "c", nil, "The columns to print, e.g. address.*, attrs.key_value.key or events[].type")
+	queryWhere = queryCmd.PersistentFlags().StringP("where", "w", "", "The condition the rows have to match, e.g. \"country = 'DE' AND ts > '2020-01-01'\"")
+	queryLimit = queryCmd.PersistentFlags().IntP("limit", "n", -1, "The maximum number of rows to print, -1 prints all matching rows")
+	queryFormat = queryCmd.PersistentFlags().StringP("format", "f", "csv", "The output format, valid values are csv, ndjson and arrow")
+	queryNested = queryCmd.PersistentFlags().String("nested", "flatten", "How nested fields are written to CSV, valid values are flatten and json")
+	queryVerbose = queryCmd.PersistentFlags().BoolP("verbose", "v", false, "Print the number of row groups that were read and of rows that matched to standard error")
+	rootCmd.AddCommand(queryCmd)
+}
+
+var queryCmd = &cobra.Command{
+	Use:   "query [-c columns] [-w condition] file-name.parquet",
+	Short: "Print the rows of the parquet file that match a condition",
+	Long: `Print the selected columns of the rows of the parquet file that match a condition.
+
+The condition compares columns, referenced by their dotted path, with values, and can be
+combined with AND, OR, NOT and parentheses:
+
+  =, !=, <>, <, <=, >, >=   compare with a number, a 'string', TRUE or FALSE
+  IS NULL, IS NOT NULL      check for null values
+  [NOT] IN (v1, v2, ...)    compare with a list of values
+  [NOT] LIKE 'pattern'      match strings, % matches any text and _ a single character
+
+Dates and timestamps are compared with strings like '2020-01-01' or '2020-01-01T12:00:00Z',
+decimals with numbers. Repeated columns can't be used in conditions. Row groups whose
+statistics prove that none of their rows match are not read at all.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			_ = cmd.Usage()
+			os.Exit(1)
+		}
+
+		opts := &queryOptions{
+			columns: *queryColumns,
+			where:   *queryWhere,
+			limit:   *queryLimit,
+			format:  *queryFormat,
+			nested:  *queryNested,
+		}
+		if *queryVerbose {
+			opts.info = os.Stderr
+		}
+		if err := queryFile(os.Stdout, args[0], opts); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+// queryOptions are the settings of a query.
+type queryOptions struct {
+	columns []string
+	where   string
+	limit   int
+	format  string
+	nested  string
+	// info receives the number of row groups that were read and of rows that matched, if it is
+	// not nil.
+	info io.Writer
+}
+
+func queryFile(w io.Writer, address string, opts *queryOptions) error {
+	fl, err := os.Open(address)
+	if err != nil {
+		return fmt.Errorf("can not open the file: %q", err)
+	}
+	defer fl.Close()
+
+	reader, err := goparquet.NewFileReaderWithOptions(fl, goparquet.WithExternalFileOpener(externalFileOpener(address)))
+	if err != nil {
+		return fmt.Errorf("failed to read the parquet header: %q", err)
+	}
+	defer reader.Close()
+
+	columns := opts.columns
+	if len(columns) == 0 {
+		columns = []string{"*"}
+	}
+	paths, err := goparquet.ResolveColumnPaths(reader.GetSchemaDefinition(), columns...)
+	if err != nil {
+		return err
+	}
+	sd := projectSchema(reader.GetSchemaDefinition(), paths)
+
+	var where queryExpr
+	read := append([]string(nil), paths...)
+	if opts.where != "" {
+		if where, err = parseQuery(reader, opts.where); err != nil {
+			return fmt.Errorf("invalid condition: %w", err)
+		}
+		where.columns(&read)
+	}
+
+	rw, err := newRowWriter(w, sd, opts.format, opts.nested)
+	if err != nil {
+		return err
+	}
+
+	// the reader is created again to only read the printed columns and those of the condition
+	reader, err = goparquet.NewFileReaderWithOptions(fl,
+		goparquet.WithColumns(read...),
+		goparquet.WithExternalFileOpener(externalFileOpener(address)),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to read the parquet header: %q", err)
+	}
+	defer reader.Close()
+
+	var readGroups, matched int
+	for i, rg := range reader.RowGroups() {
+		if opts.limit >= 0 && matched >= opts.limit {
+			break
+		}
+		if where != nil && !where.mayMatch(rg) {
+			continue
+		}
+		readGroups++
+
+		if err := reader.SeekToRowGroup(i); err != nil {
+			return err
+		}
+		for j := int64(0); j < rg.NumRows && (opts.limit < 0 || matched < opts.limit); j++ {
+			row, err := reader.NextRow()
+			if err != nil {
+				return fmt.Errorf("reading record %d of row group %d failed: %w", j+1, i, err)
+			}
+			if where != nil && where.eval(row) != triTrue {
+				continue
+			}
+			matched++
+			if err := rw.writeRow(row); err != nil {
+				return fmt.Errorf("writing record failed: %w", err)
+			}
+		}
+	}
+
+	if err := rw.close(); err != nil {
+		return err
+	}
+
+	if opts.info != nil {
+		_, _ = fmt.Fprintf(opts.info, "read %d of %d row groups, %d rows matched\n", readGroups, reader.RowGroupCount(), matched)
+	}
+	return nil
+}
@@ -0,0 +1,898 @@
+package cmds
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	goparquet "github.com/sagia-inneractive/parquet-go"
+	"github.com/sagia-inneractive/parquet-go/parquet"
+)
+
+// The conditions of query are a small expression language over column paths:
+//
+//   condition  = or
+//   or         = and { OR and }
+//   and        = not { AND not }
+//   not        = NOT not | "(" condition ")" | predicate
+//   predicate  = column ( op literal | IS [NOT] NULL | [NOT] IN "(" literal { "," literal } ")" | [NOT] LIKE string )
+//   op         = "=" | "!=" | "<>" | "<" | "<=" | ">" | ">="
+//   literal    = string | number | TRUE | FALSE
+//
+// Columns are referenced by their dotted path and can be quoted with double quotes. Strings are
+// enclosed in single quotes, a single quote in a string is written as two single quotes.
+// Keywords are case-insensitive. Like in SQL, comparisons with null values are unknown, and only
+// rows for which the condition is true are selected.
+
+// tri is the result of a condition: true, false or unknown if a value is null.
+type tri int8
+
+const (
+	triFalse tri = iota
+	triTrue
+	triUnknown
+)
+
+func triOf(b bool) tri {
+	if b {
+		return triTrue
+	}
+	return triFalse
+}
+
+func (t tri) not() tri {
+	switch t {
+	case triTrue:
+		return triFalse
+	case triFalse:
+		return triTrue
+	}
+	return triUnknown
+}
+
+// queryExpr is a condition of a query.
+type queryExpr interface {
+	// eval evaluates the condition for a row.
+	eval(row map[string]interface{}) tri
+	// mayMatch returns false if the statistics of the row group prove that no row of the row
+	// group matches the condition.
+	mayMatch(rg *parquet.RowGroup) bool
+	// columns adds the paths of the columns the condition uses to paths.
+	columns(paths *[]string)
+}
+
+type andExpr struct{ left, right queryExpr }
+
+func (e *andExpr) eval(row map[string]interface{}) tri {
+	l := e.left.eval(row)
+	if l == triFalse {
+		return triFalse
+	}
+	r := e.right.eval(row)
+	switch {
+	case r == triFalse:
+		return triFalse
+	case l == triTrue && r == triTrue:
+		return triTrue
+	}
+	return triUnknown
+}
+
+func (e *andExpr) mayMatch(rg *parquet.RowGroup) bool {
+	return e.left.mayMatch(rg) && e.right.mayMatch(rg)
+}
+
+func (e *andExpr) columns(paths *[]string) {
+	e.left.columns(paths)
+	e.right.columns(paths)
+}
+
+type orExpr struct{ left, right queryExpr }
+
+func (e *orExpr) eval(row map[string]interface{}) tri {
+	l := e.left.eval(row)
+	if l == triTrue {
+		return triTrue
+	}
+	r := e.right.eval(row)
+	switch {
+	case r == triTrue:
+		return triTrue
+	case l == triFalse && r == triFalse:
+		return triFalse
+	}
+	return triUnknown
+}
+
+func (e *orExpr) mayMatch(rg *parquet.RowGroup) bool {
+	return e.left.mayMatch(rg) || e.right.mayMatch(rg)
+}
+
+func (e *orExpr) columns(paths *[]string) {
+	e.left.columns(paths)
+	e.right.columns(paths)
+}
+
+type notExpr struct{ expr queryExpr }
+
+func (e *notExpr) eval(row map[string]interface{}) tri {
+	return e.expr.eval(row).not()
+}
+
+// mayMatch can't use the statistics, as they only prove that no row matches the inner condition.
+func (e *notExpr) mayMatch(rg *parquet.RowGroup) bool {
+	return true
+}
+
+func (e *notExpr) columns(paths *[]string) {
+	e.expr.columns(paths)
+}
+
+// queryColumn is a column used in a condition. Its values are converted to a common type with
+// the literals they are compared to: int64, uint64, float64, bool, []byte or *big.Rat.
+type queryColumn struct {
+	path    string
+	col     *goparquet.Column
+	convert func(v interface{}) interface{}
+}
+
+// value returns the converted value of the column in the row, or nil if it is null.
+func (c *queryColumn) value(row map[string]interface{}) interface{} {
+	var v interface{} = row
+	for _, name := range strings.Split(c.path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[name]
+	}
+	if v == nil {
+		return nil
+	}
+	return c.convert(v)
+}
+
+// chunkStatistics returns the statistics of the column chunk in the row group, or nil if there
+// are none.
+func (c *queryColumn) chunkStatistics(rg *parquet.RowGroup) (*parquet.ColumnMetaData, *parquet.Statistics) {
+	if c.col.Index() >= len(rg.Columns) {
+		return nil, nil
+	}
+	md := rg.Columns[c.col.Index()].MetaData
+	if md == nil || md.Statistics == nil {
+		return nil, nil
+	}
+	return md, md.Statistics
+}
+
+// minMax returns the converted minimum and maximum value of the column chunk in the row group.
+// It returns false if they are unknown. allNull is true if the column chunk only contains nulls.
+func (c *queryColumn) minMax(rg *parquet.RowGroup) (min, max interface{}, allNull, ok bool) {
+	md, stats := c.chunkStatistics(rg)
+	if stats == nil {
+		return nil, nil, false, false
+	}
+	if stats.NullCount != nil && *stats.NullCount == md.NumValues {
+		return nil, nil, true, true
+	}
+
+	elem := c.col.Element()
+	if !hasSortOrder(elem) {
+		return nil, nil, false, false
+	}
+	minValue, maxValue := stats.MinValue, stats.MaxValue
+	if minValue == nil && maxValue == nil && !isUnsignedColumn(elem) && md.Type != parquet.Type_BYTE_ARRAY && md.Type != parquet.Type_FIXED_LEN_BYTE_ARRAY {
+		// the deprecated min and max are only reliable for signed comparisons
+		minValue, maxValue = stats.Min, stats.Max
+	}
+	if minValue == nil || maxValue == nil {
+		return nil, nil, false, false
+	}
+
+	rawMin, err := decodeStatistic(elem, minValue)
+	if err != nil || isNaN(rawMin) {
+		return nil, nil, false, false
+	}
+	rawMax, err := decodeStatistic(elem, maxValue)
+	if err != nil || isNaN(rawMax) {
+		return nil, nil, false, false
+	}
+	min, max = c.convert(rawMin), c.convert(rawMax)
+	if min == nil || max == nil {
+		return nil, nil, false, false
+	}
+	return min, max, false, true
+}
+
+type compareExpr struct {
+	col *queryColumn
+	op  string
+	lit interface{}
+}
+
+func (e *compareExpr) eval(row map[string]interface{}) tri {
+	v := e.col.value(row)
+	if v == nil || isNaN(v) {
+		return triUnknown
+	}
+	return triOf(compareResult(e.op, compareQueryValues(v, e.lit)))
+}
+
+func compareResult(op string, c int) bool {
+	switch op {
+	case "=":
+		return c == 0
+	case "!=", "<>":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return false
+}
+
+func (e *compareExpr) mayMatch(rg *parquet.RowGroup) bool {
+	min, max, allNull, ok := e.col.minMax(rg)
+	if !ok {
+		return true
+	}
+	if allNull {
+		return false
+	}
+
+	switch e.op {
+	case "=":
+		return compareQueryValues(min, e.lit) <= 0 && compareQueryValues(max, e.lit) >= 0
+	case "!=", "<>":
+		return compareQueryValues(min, e.lit) != 0 || compareQueryValues(max, e.lit) != 0
+	case "<", "<=":
+		return compareResult(e.op, compareQueryValues(min, e.lit))
+	case ">", ">=":
+		return compareResult(e.op, compareQueryValues(max, e.lit))
+	}
+	return true
+}
+
+func (e *compareExpr) columns(paths *[]string) {
+	*paths = append(*paths, e.col.path)
+}
+
+type inExpr struct {
+	col  *queryColumn
+	lits []interface{}
+}
+
+func (e *inExpr) eval(row map[string]interface{}) tri {
+	v := e.col.value(row)
+	if v == nil || isNaN(v) {
+		return triUnknown
+	}
+	for _, lit := range e.lits {
+		if compareQueryValues(v, lit) == 0 {
+			return triTrue
+		}
+	}
+	return triFalse
+}
+
+func (e *inExpr) mayMatch(rg *parquet.RowGroup) bool {
+	for _, lit := range e.lits {
+		if (&compareExpr{col: e.col, op: "=", lit: lit}).mayMatch(rg) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *inExpr) columns(paths *[]string) {
+	*paths = append(*paths, e.col.path)
+}
+
+type likeExpr struct {
+	col *queryColumn
+	re  *regexp.Regexp
+	// prefix is the part of the pattern before the first wildcard
+	prefix []byte
+}
+
+func (e *likeExpr) eval(row map[string]interface{}) tri {
+	v, ok := e.col.value(row).([]byte)
+	if !ok {
+		return triUnknown
+	}
+	return triOf(e.re.Match(v))
+}
+
+func (e *likeExpr) mayMatch(rg *parquet.RowGroup) bool {
+	min, max, allNull, ok := e.col.minMax(rg)
+	if !ok || len(e.prefix) == 0 {
+		return !allNull
+	}
+	if allNull {
+		return false
+	}
+	// all matching values start with the prefix, so it has to be within the range of the
+	// prefixes of the minimum and maximum
+	truncate := func(b []byte) []byte {
+		if len(b) > len(e.prefix) {
+			return b[:len(e.prefix)]
+		}
+		return b
+	}
+	return bytes.Compare(truncate(min.([]byte)), e.prefix) <= 0 && bytes.Compare(truncate(max.([]byte)), e.prefix) >= 0
+}
+
+func (e *likeExpr) columns(paths *[]string) {
+	*paths = append(*paths, e.col.path)
+}
+
+type nullExpr struct {
+	col    *queryColumn
+	isNull bool
+}
+
+func (e *nullExpr) eval(row map[string]interface{}) tri {
+	return triOf((e.col.value(row) == nil) == e.isNull)
+}
+
+func (e *nullExpr) mayMatch(rg *parquet.RowGroup) bool {
+	md, stats := e.col.chunkStatistics(rg)
+	if stats == nil || stats.NullCount == nil {
+		return true
+	}
+	if e.isNull {
+		return *stats.NullCount > 0
+	}
+	return *stats.NullCount < md.NumValues
+}
+
+func (e *nullExpr) columns(paths *[]string) {
+	*paths = append(*paths, e.col.path)
+}
+
+// compareQueryValues compares two values of the same type.
+func compareQueryValues(a, b interface{}) int {
+	if x, ok := a.(*big.Rat); ok {
+		return x.Cmp(b.(*big.Rat))
+	}
+	return compareValues(a, b)
+}
+
+type queryTokenKind int
+
+const (
+	tokenEOF queryTokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOperator
+)
+
+type queryToken struct {
+	kind queryTokenKind
+	text string
+	pos  int
+}
+
+// keyword returns the upper-case text of an identifier, to match keywords.
+func (t queryToken) keyword() string {
+	if t.kind != tokenIdent || strings.HasPrefix(t.text, `"`) {
+		return ""
+	}
+	return strings.ToUpper(t.text)
+}
+
+func (t queryToken) String() string {
+	if t.kind == tokenEOF {
+		return "end of condition"
+	}
+	return fmt.Sprintf("%q at position %d", t.text, t.pos+1)
+}
+
+func tokenizeQuery(s string) ([]queryToken, error) {
+	var tokens []queryToken
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '\'':
+			j := i + 1
+			for {
+				if j >= len(s) {
+					return nil, fmt.Errorf("unterminated string at position %d", i+1)
+				}
+				if s[j] == '\'' {
+					if j+1 < len(s) && s[j+1] == '\'' {
+						j += 2
+						continue
+					}
+					break
+				}
+				j++
+			}
+			tokens = append(tokens, queryToken{kind: tokenString, text: s[i : j+1], pos: i})
+			i = j + 1
+		case c == '"':
+			j := strings.IndexByte(s[i+1:], '"')
+			if j < 0 {
+				return nil, fmt.Errorf("unterminated column name at position %d", i+1)
+			}
+			tokens = append(tokens, queryToken{kind: tokenIdent, text: s[i : i+j+2], pos: i})
+			i += j + 2
+		case c == '(' || c == ')' || c == ',' || c == '=':
+			tokens = append(tokens, queryToken{kind: tokenOperator, text: s[i : i+1], pos: i})
+			i++
+		case c == '<' || c == '>' || c == '!':
+			j := i + 1
+			if j < len(s) && (s[j] == '=' || (c == '<' && s[j] == '>')) {
+				j++
+			}
+			if s[i:j] == "!" {
+				return nil, fmt.Errorf("unexpected character '!' at position %d", i+1)
+			}
+			tokens = append(tokens, queryToken{kind: tokenOperator, text: s[i:j], pos: i})
+			i = j
+		case c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9'):
+			j := i + 1
+			for j < len(s) && (isNumberChar(s[j]) || ((s[j] == '-' || s[j] == '+') && (s[j-1] == 'e' || s[j-1] == 'E'))) {
+				j++
+			}
+			tokens = append(tokens, queryToken{kind: tokenNumber, text: s[i:j], pos: i})
+			i = j
+		case isIdentChar(c) && !(c >= '0' && c <= '9'):
+			j := i + 1
+			for j < len(s) && (isIdentChar(s[j]) || s[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, queryToken{kind: tokenIdent, text: s[i:j], pos: i})
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected character %q at position %d", c, i+1)
+		}
+	}
+	return append(tokens, queryToken{kind: tokenEOF, pos: len(s)}), nil
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func isNumberChar(c byte) bool {
+	return (c >= '0' && c <= '9') || c == '.' || c == 'e' || c == 'E'
+}
+
+// queryParser parses a condition and resolves its columns in the schema of the file.
+type queryParser struct {
+	reader *goparquet.FileReader
+	tokens []queryToken
+	pos    int
+}
+
+// parseQuery parses the condition s for the columns of the file.
+func parseQuery(reader *goparquet.FileReader, s string) (queryExpr, error) {
+	tokens, err := tokenizeQuery(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{reader: reader, tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %s", tok)
+	}
+	return expr, nil
+}
+
+func (p *queryParser) peek() queryToken {
+	return p.tokens[p.pos]
+}
+
+func (p *queryParser) next() queryToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *queryParser) expect(text string) error {
+	if tok := p.next(); tok.kind != tokenOperator || tok.text != text {
+		return fmt.Errorf("expected %q, got %s", text, tok)
+	}
+	return nil
+}
+
+func (p *queryParser) parseOr() (queryExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().keyword() == "OR" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (queryExpr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().keyword() == "AND" {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &andExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseNot() (queryExpr, error) {
+	tok := p.peek()
+	switch {
+	case tok.keyword() == "NOT":
+		p.next()
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{expr: expr}, nil
+	case tok.kind == tokenOperator && tok.text == "(":
+		p.next()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return expr, nil
+	}
+	return p.parsePredicate()
+}
+
+func (p *queryParser) parsePredicate() (queryExpr, error) {
+	tok := p.next()
+	if tok.kind != tokenIdent || tok.keyword() == "AND" || tok.keyword() == "OR" {
+		return nil, fmt.Errorf("expected a column, got %s", tok)
+	}
+	path := strings.Trim(tok.text, `"`)
+	col := p.reader.GetColumnByName(path)
+	if col == nil {
+		return nil, fmt.Errorf("column %q not found", path)
+	}
+	if col.MaxRepetitionLevel() > 0 {
+		return nil, fmt.Errorf("column %q is repeated, which is not supported in conditions", path)
+	}
+
+	op := p.next()
+	switch {
+	case op.kind == tokenOperator && op.text != "(" && op.text != ")" && op.text != ",":
+		lit, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		qc, value, err := newQueryColumn(path, col, lit)
+		if err != nil {
+			return nil, err
+		}
+		return &compareExpr{col: qc, op: op.text, lit: value}, nil
+
+	case op.keyword() == "IS":
+		isNull := true
+		if p.peek().keyword() == "NOT" {
+			p.next()
+			isNull = false
+		}
+		if tok := p.next(); tok.keyword() != "NULL" {
+			return nil, fmt.Errorf("expected NULL, got %s", tok)
+		}
+		return &nullExpr{col: &queryColumn{path: path, col: col, convert: identity}, isNull: isNull}, nil
+
+	case op.keyword() == "NOT":
+		expr, err := p.parseSetPredicate(path, col, p.next())
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{expr: expr}, nil
+	}
+	return p.parseSetPredicate(path, col, op)
+}
+
+// parseSetPredicate parses the IN and LIKE predicates.
+func (p *queryParser) parseSetPredicate(path string, col *goparquet.Column, op queryToken) (queryExpr, error) {
+	switch op.keyword() {
+	case "IN":
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		var (
+			qc   *queryColumn
+			lits []interface{}
+		)
+		for {
+			lit, err := p.parseLiteral()
+			if err != nil {
+				return nil, err
+			}
+			c, value, err := newQueryColumn(path, col, lit)
+			if err != nil {
+				return nil, err
+			}
+			if qc == nil {
+				qc = c
+			} else if reflect.TypeOf(value) != reflect.TypeOf(lits[0]) {
+				return nil, fmt.Errorf("the values in the IN list of column %q have different types", path)
+			}
+			lits = append(lits, value)
+			if tok := p.next(); tok.text == ")" {
+				break
+			} else if tok.text != "," {
+				return nil, fmt.Errorf("expected \",\" or \")\", got %s", tok)
+			}
+		}
+		return &inExpr{col: qc, lits: lits}, nil
+
+	case "LIKE":
+		tok := p.next()
+		if tok.kind != tokenString {
+			return nil, fmt.Errorf("expected a pattern, got %s", tok)
+		}
+		elem := col.Element()
+		if elem.GetType() != parquet.Type_BYTE_ARRAY && elem.GetType() != parquet.Type_FIXED_LEN_BYTE_ARRAY {
+			return nil, fmt.Errorf("LIKE is not supported for column %q of type %s", path, elem.GetType())
+		}
+		re, prefix := likePattern(unquoteQueryString(tok.text))
+		return &likeExpr{col: &queryColumn{path: path, col: col, convert: identity}, re: re, prefix: prefix}, nil
+	}
+	return nil, fmt.Errorf("expected an operator, got %s", op)
+}
+
+func (p *queryParser) parseLiteral() (queryToken, error) {
+	tok := p.next()
+	switch {
+	case tok.kind == tokenString, tok.kind == tokenNumber:
+		return tok, nil
+	case tok.keyword() == "TRUE", tok.keyword() == "FALSE":
+		return tok, nil
+	}
+	return tok, fmt.Errorf("expected a value, got %s", tok)
+}
+
+func unquoteQueryString(s string) string {
+	return strings.Replace(s[1:len(s)-1], "''", "'", -1)
+}
+
+// likePattern returns the regular expression of a LIKE pattern, in which % matches any number of
+// characters and _ a single character, and the part of the pattern before the first wildcard.
+func likePattern(pattern string) (*regexp.Regexp, []byte) {
+	var (
+		sb     strings.Builder
+		prefix = -1
+	)
+	sb.WriteString(`(?s)^`)
+	for i, r := range pattern {
+		switch r {
+		case '%':
+			sb.WriteString(`.*`)
+		case '_':
+			sb.WriteString(`.`)
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+			continue
+		}
+		if prefix < 0 {
+			prefix = i
+		}
+	}
+	sb.WriteString(`$`)
+	if prefix < 0 {
+		prefix = len(pattern)
+	}
+	return regexp.MustCompile(sb.String()), []byte(pattern[:prefix])
+}
+
+func identity(v interface{}) interface{} {
+	return v
+}
+
+// newQueryColumn returns the column with the conversion of its values to the type of the
+// literal, and the converted literal.
+func newQueryColumn(path string, col *goparquet.Column, lit queryToken) (*queryColumn, interface{}, error) {
+	elem := col.Element()
+	qc := &queryColumn{path: path, col: col}
+	invalid := func() (*queryColumn, interface{}, error) {
+		return nil, nil, fmt.Errorf("column %q of type %s can't be compared with %s", path, elem.GetType(), lit)
+	}
+
+	lt := elem.GetLogicalType()
+	if lt == nil {
+		lt = &parquet.LogicalType{}
+	}
+	ct := elem.GetConvertedType()
+	hasCT := elem.ConvertedType != nil
+
+	var text string
+	switch lit.kind {
+	case tokenString:
+		text = unquoteQueryString(lit.text)
+	case tokenNumber:
+		text = lit.text
+	}
+
+	switch {
+	case elem.GetType() == parquet.Type_BOOLEAN:
+		if lit.keyword() != "TRUE" && lit.keyword() != "FALSE" {
+			return invalid()
+		}
+		qc.convert = identity
+		return qc, lit.keyword() == "TRUE", nil
+
+	case lit.kind == tokenIdent:
+		return invalid()
+
+	case lt.DECIMAL != nil || (hasCT && ct == parquet.ConvertedType_DECIMAL):
+		value, ok := new(big.Rat).SetString(text)
+		if !ok {
+			return invalid()
+		}
+		scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(elem.GetScale())), nil)
+		qc.convert = func(v interface{}) interface{} {
+			unscaled, err := decimalValue(v)
+			if err != nil {
+				return nil
+			}
+			return new(big.Rat).SetFrac(unscaled, scale)
+		}
+		return qc, value, nil
+
+	case elem.GetType() == parquet.Type_INT96:
+		if lit.kind != tokenString {
+			return invalid()
+		}
+		t, err := parseQueryTime(text)
+		if err != nil {
+			return nil, nil, err
+		}
+		qc.convert = func(v interface{}) interface{} {
+			x, ok := v.([12]byte)
+			if !ok {
+				return nil
+			}
+			return goparquet.Int96ToTime(x).UnixNano()
+		}
+		return qc, t.UnixNano(), nil
+
+	case elem.GetType() == parquet.Type_INT32 || elem.GetType() == parquet.Type_INT64:
+		if lit.kind == tokenString {
+			var value int64
+			switch {
+			case lt.DATE != nil || (hasCT && ct == parquet.ConvertedType_DATE):
+				t, err := time.Parse("2006-01-02", text)
+				if err != nil {
+					return nil, nil, fmt.Errorf("invalid date %q", text)
+				}
+				value = t.Unix() / (24 * 60 * 60)
+			case lt.TIMESTAMP != nil || (hasCT && (ct == parquet.ConvertedType_TIMESTAMP_MILLIS || ct == parquet.ConvertedType_TIMESTAMP_MICROS)):
+				t, err := parseQueryTime(text)
+				if err != nil {
+					return nil, nil, err
+				}
+				value = t.UnixNano() / int64(timeUnit(elem))
+			default:
+				return invalid()
+			}
+			qc.convert = intQueryValue
+			return qc, value, nil
+		}
+
+		if isUnsignedColumn(elem) {
+			if value, err := strconv.ParseUint(text, 10, 64); err == nil {
+				qc.convert = uintQueryValue
+				return qc, value, nil
+			}
+		} else if value, err := strconv.ParseInt(text, 10, 64); err == nil {
+			qc.convert = intQueryValue
+			return qc, value, nil
+		}
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return invalid()
+		}
+		qc.convert = floatQueryValue
+		return qc, value, nil
+
+	case elem.GetType() == parquet.Type_FLOAT || elem.GetType() == parquet.Type_DOUBLE:
+		if lit.kind != tokenNumber {
+			return invalid()
+		}
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return invalid()
+		}
+		qc.convert = floatQueryValue
+		return qc, value, nil
+
+	case elem.GetType() == parquet.Type_BYTE_ARRAY || elem.GetType() == parquet.Type_FIXED_LEN_BYTE_ARRAY:
+		if lit.kind != tokenString {
+			return invalid()
+		}
+		qc.convert = identity
+		return qc, []byte(text), nil
+	}
+	return invalid()
+}
+
+// parseQueryTime parses a timestamp literal. Timestamps without a time zone are in UTC.
+func parseQueryTime(s string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02 15:04:05.999999999", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q", s)
+}
+
+func intQueryValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case int32:
+		return int64(x)
+	case int64:
+		return x
+	case uint32:
+		return int64(x)
+	}
+	return nil
+}
+
+func uintQueryValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case uint32:
+		return uint64(x)
+	case uint64:
+		return x
+	case int32:
+		return uint64(uint32(x))
+	case int64:
+		return uint64(x)
+	}
+	return nil
+}
+
+func floatQueryValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case int32:
+		return float64(x)
+	case int64:
+		return float64(x)
+	case uint32:
+		return float64(x)
+	case uint64:
+		return float64(x)
+	case float32:
+		return float64(x)
+	case float64:
+		return x
+	}
+	return nil
+}
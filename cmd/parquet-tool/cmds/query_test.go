@@ -0,0 +1,147 @@
+package cmds
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	goparquet "github.com/sagia-inneractive/parquet-go"
+	"github.com/sagia-inneractive/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+func writeQueryFile(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "query")
+	require.NoError(t, err)
+
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+  required int64 id;
+  required binary country (STRING);
+  required int64 ts (TIMESTAMP(MICROS, true));
+  optional int64 price (DECIMAL(10, 2));
+  optional group address {
+    optional binary city (STRING);
+  }
+  optional group tags (LIST) {
+    repeated group list {
+      optional binary element (STRING);
+    }
+  }
+}`)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	fw := goparquet.NewFileWriter(&buf, goparquet.WithSchemaDefinition(sd))
+	countries := []string{"DE", "US", "DE", "FR"}
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for rg, country := range countries {
+		for i := 0; i < 100; i++ {
+			id := int64(rg*100 + i)
+			data := map[string]interface{}{
+				"id":      id,
+				"country": []byte(country),
+				"ts":      start.Add(time.Duration(id)*time.Hour).UnixNano() / 1000,
+			}
+			if id%10 != 0 {
+				data["price"] = id * 25
+			}
+			if id%2 == 0 {
+				data["address"] = map[string]interface{}{"city": []byte(fmt.Sprintf("city-%d", id%7))}
+			}
+			require.NoError(t, fw.AddData(data))
+		}
+		require.NoError(t, fw.FlushRowGroup())
+	}
+	require.NoError(t, fw.Close())
+
+	file := filepath.Join(dir, "test.parquet")
+	require.NoError(t, ioutil.WriteFile(file, buf.Bytes(), 0644))
+	return file
+}
+
+func TestQueryFile(t *testing.T) {
+	file := writeQueryFile(t)
+	defer os.RemoveAll(filepath.Dir(file))
+
+	query := func(where string, limit int) (ids []string, info string) {
+		var buf, infoBuf bytes.Buffer
+		require.NoError(t, queryFile(&buf, file, &queryOptions{
+			columns: []string{"id"},
+			where:   where,
+			limit:   limit,
+			format:  "csv",
+			nested:  "flatten",
+			info:    &infoBuf,
+		}), where)
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.Equal(t, "id", lines[0])
+		return lines[1:], infoBuf.String()
+	}
+
+	tests := []struct {
+		where string
+		limit int
+		ids   string
+		info  string
+	}{
+		{"id >= 250 AND id < 253", -1, "250,251,252", "read 1 of 4 row groups, 3 rows matched\n"},
+		{"country = 'DE' AND (id = 5 OR id = 205)", -1, "5,205", "read 2 of 4 row groups, 2 rows matched\n"},
+		{"id IN (7, 399, 1000)", -1, "7,399", "read 2 of 4 row groups, 2 rows matched\n"},
+		{"ts <= '2020-01-01T03:00:00Z'", -1, "0,1,2,3", "read 1 of 4 row groups, 4 rows matched\n"},
+		{"ts > '2020-01-17 14:00:00'", -1, "399", "read 1 of 4 row groups, 1 rows matched\n"},
+		{"price > 1.5 AND id < 10", -1, "7,8,9", "read 1 of 4 row groups, 3 rows matched\n"},
+		{"price IS NULL AND id >= 370", -1, "370,380,390", "read 1 of 4 row groups, 3 rows matched\n"},
+		{"NOT price IS NOT NULL AND id < 30", -1, "0,10,20", "read 1 of 4 row groups, 3 rows matched\n"},
+		{"NOT id > 2", -1, "0,1,2", "read 4 of 4 row groups, 3 rows matched\n"},
+		{"price != 0.25 AND id < 3", -1, "2", "read 1 of 4 row groups, 1 rows matched\n"},
+		{"address.city LIKE '%-6' AND id < 30", -1, "6,20", "read 1 of 4 row groups, 2 rows matched\n"},
+		{"country = 'FR'", 2, "300,301", "read 4 of 4 row groups, 2 rows matched\n"},
+		{"id < 0", -1, "", "read 0 of 4 row groups, 0 rows matched\n"},
+	}
+	for _, test := range tests {
+		ids, info := query(test.where, test.limit)
+		require.Equal(t, test.ids, strings.Join(ids, ","), test.where)
+		require.Equal(t, test.info, info, test.where)
+	}
+
+	ids, info := query("country NOT LIKE 'D_' AND country not in ('US') AND \"id\" <= 301", -1)
+	require.Equal(t, []string{"300", "301"}, ids)
+	require.Equal(t, "read 4 of 4 row groups, 2 rows matched\n", info)
+
+	ids, info = query("", 3)
+	require.Equal(t, []string{"0", "1", "2"}, ids)
+	require.Equal(t, "read 1 of 4 row groups, 3 rows matched\n", info)
+
+	var buf bytes.Buffer
+	require.NoError(t, queryFile(&buf, file, &queryOptions{
+		columns: []string{"id", "address.*"},
+		where:   "id = 4",
+		limit:   -1,
+		format:  "ndjson",
+	}))
+	require.Equal(t, `{"id":4,"address":{"city":"city-4"}}`+"\n", buf.String())
+
+	errs := map[string]string{
+		"foo = 1":                 `invalid condition: column "foo" not found`,
+		"tags.list.element = 'x'": `invalid condition: column "tags.list.element" is repeated, which is not supported in conditions`,
+		"country = 1":             `invalid condition: column "country" of type BYTE_ARRAY can't be compared with "1" at position 11`,
+		"id = 'x'":                `invalid condition: column "id" of type INT64 can't be compared with "'x'" at position 6`,
+		"id = 1 AND":              `invalid condition: expected a column, got end of condition`,
+		"(id = 1":                 `invalid condition: expected ")", got end of condition`,
+		"id = 1 id":               `invalid condition: unexpected "id" at position 8`,
+		"country = 'DE":           `invalid condition: unterminated string at position 11`,
+		"ts > 'yesterday'":        `invalid condition: invalid timestamp "yesterday"`,
+		"id IN (1, 2.5)":          `invalid condition: the values in the IN list of column "id" have different types`,
+		"id LIKE '1%'":            `invalid condition: LIKE is not supported for column "id" of type INT64`,
+		"id ! 1":                  `invalid condition: unexpected character '!' at position 4`,
+	}
+	for where, msg := range errs {
+		err := queryFile(&buf, file, &queryOptions{where: where, limit: -1, format: "csv", nested: "flatten"})
+		require.EqualError(t, err, msg, where)
+	}
+}
@@ -5,23 +5,48 @@ import (
 	"io"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 	"text/tabwriter"
 
 	goparquet "github.com/sagia-inneractive/parquet-go"
 )
 
-func catFile(w io.Writer, address string, n int) error {
+// catFile prints the first n records of the file, or all of them if n is -1. If detailed is true,
+// the detailed schema of the printed columns precedes the records.
+func catFile(w io.Writer, address string, n int, columns []string, detailed bool) error {
 	fl, err := os.Open(address)
 	if err != nil {
 		return fmt.Errorf("can not open the file: %q", err)
 	}
 	defer fl.Close()
 
-	reader, err := goparquet.NewFileReader(fl)
+	reader, err := goparquet.NewFileReaderWithOptions(fl,
+		goparquet.WithProjection(columns...),
+		goparquet.WithExternalFileOpener(externalFileOpener(address)),
+	)
 	if err != nil {
 		return fmt.Errorf("failed to read the parquet header: %q", err)
 	}
+	defer reader.Close()
+
+	if detailed {
+		var selected map[string]bool
+		if len(columns) > 0 {
+			paths, err := goparquet.ResolveColumnPaths(reader.GetSchemaDefinition(), columns...)
+			if err != nil {
+				return err
+			}
+			selected = make(map[string]bool, len(paths))
+			for _, p := range paths {
+				selected[p] = true
+			}
+		}
+		if err := printDetailedSchema(w, newSchemaTree(reader, selected)); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintln(w)
+	}
 
 	for i := 0; (n == -1) || i < n; i++ {
 		data, err := reader.NextRow()
@@ -40,6 +65,17 @@ func catFile(w io.Writer, address string, n int) error {
 	return nil
 }
 
+// externalFileOpener opens the files that column chunks of the file at address refer to, relative
+// to the directory of the file.
+func externalFileOpener(address string) func(string) (io.ReadSeeker, error) {
+	return func(path string) (io.ReadSeeker, error) {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(address), path)
+		}
+		return os.Open(path)
+	}
+}
+
 func printPrimitive(w io.Writer, ident, name string, v interface{}) {
 	_, _ = fmt.Fprintln(w, ident+name+" = "+fmt.Sprint(v))
 }
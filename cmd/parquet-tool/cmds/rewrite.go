@@ -0,0 +1,313 @@
+package cmds
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	goparquet "github.com/sagia-inneractive/parquet-go"
+	"github.com/sagia-inneractive/parquet-go/parquet"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rewriteOutput             *string
+	rewriteCompression        *string
+	rewriteRowGroupSize       *string
+	rewritePageSize           *string
+	rewritePageVersion        *int
+	rewriteMaxDictionarySize  *string
+	rewriteSortedDictionaries *bool
+	rewriteEncodings          *[]string
+	rewriteColumns            *[]string
+	rewriteDrop               *[]string
+	rewriteSetMeta            *[]string
+	rewriteDeleteMeta         *[]string
+)
+
+func init() {
+	rewriteOutput = rewriteCmd.PersistentFlags().StringP("output", "o", "", "The file to write the rewritten content to")
+	rewriteCompression = rewriteCmd.PersistentFlags().String("compression", "", "Compression method, valid values are Snappy, Gzip and None, keeps the compression of the file if it's empty")
+	rewriteRowGroupSize = rewriteCmd.PersistentFlags().StringP("row-group-size", "r", "128MB", "Uncompressed row group size")
+	rewritePageSize = rewriteCmd.PersistentFlags().String("page-size", "1MB", "Uncompressed data page size")
+	rewritePageVersion = rewriteCmd.PersistentFlags().Int("page-version", 1, "The version of the data pages, valid values are 1 and 2")
+	rewriteMaxDictionarySize = rewriteCmd.PersistentFlags().String("max-dictionary-size", "1MB", "The maximum size of the dictionary of a column chunk, 0 disables dictionary encoding")
+	rewriteSortedDictionaries = rewriteCmd.PersistentFlags().Bool("sorted-dictionaries", false, "Sort the values of dictionary pages")
+	rewriteEncodings = rewriteCmd.PersistentFlags().StringSlice("encoding", nil, "The encoding of columns as column=encoding, e.g. id=DELTA_BINARY_PACKED or name=DELTA_BYTE_ARRAY")
+	rewriteColumns = rewriteCmd.PersistentFlags().StringSliceP("columns", "c", nil, "The columns to keep, e.g. address.*, attrs.key_value.key or events[].type")
+	rewriteDrop = rewriteCmd.PersistentFlags().StringSlice("drop", nil, "The columns to remove")
+	rewriteSetMeta = rewriteCmd.PersistentFlags().StringArray("set-meta", nil, "Set a key-value meta data entry as key=value")
+	rewriteDeleteMeta = rewriteCmd.PersistentFlags().StringArray("delete-meta", nil, "Delete a key-value meta data entry")
+	rootCmd.AddCommand(rewriteCmd)
+}
+
+var rewriteCmd = &cobra.Command{
+	Use:   "rewrite -o output.parquet file-name.parquet",
+	Short: "Rewrite the parquet file with different settings",
+	Long: `Rewrite the parquet file with a different compression, row group size, page size, page
+version, dictionary policy or column encodings. Columns can be selected or dropped and the
+key-value meta data can be edited. Everything else, including the schema of the remaining
+columns, is preserved.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 || *rewriteOutput == "" {
+			_ = cmd.Usage()
+			os.Exit(1)
+		}
+
+		rgSize, err := humanToByte(*rewriteRowGroupSize)
+		if err != nil {
+			log.Fatalf("Invalid row group size: %q", *rewriteRowGroupSize)
+		}
+
+		pageSize, err := humanToByte(*rewritePageSize)
+		if err != nil {
+			log.Fatalf("Invalid page size: %q", *rewritePageSize)
+		}
+
+		dictSize, err := humanToByte(*rewriteMaxDictionarySize)
+		if err != nil {
+			log.Fatalf("Invalid dictionary size: %q", *rewriteMaxDictionarySize)
+		}
+
+		opts := &rewriteOptions{
+			compression:        *rewriteCompression,
+			rowGroupSize:       rgSize,
+			pageSize:           pageSize,
+			pageVersion:        *rewritePageVersion,
+			maxDictionarySize:  dictSize,
+			sortedDictionaries: *rewriteSortedDictionaries,
+			encodings:          *rewriteEncodings,
+			columns:            *rewriteColumns,
+			drop:               *rewriteDrop,
+			setMeta:            *rewriteSetMeta,
+			deleteMeta:         *rewriteDeleteMeta,
+		}
+		if err := rewriteFile(*rewriteOutput, args[0], opts); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+// rewriteOptions are the settings of the rewritten file.
+type rewriteOptions struct {
+	compression        string
+	rowGroupSize       int64
+	pageSize           int64
+	pageVersion        int
+	maxDictionarySize  int64
+	sortedDictionaries bool
+	encodings          []string
+	columns            []string
+	drop               []string
+	setMeta            []string
+	deleteMeta         []string
+}
+
+func rewriteFile(output, address string, opts *rewriteOptions) error {
+	fl, err := os.Open(address)
+	if err != nil {
+		return fmt.Errorf("can not open the file: %q", err)
+	}
+	defer fl.Close()
+
+	reader, err := goparquet.NewFileReaderWithOptions(fl, goparquet.WithExternalFileOpener(externalFileOpener(address)))
+	if err != nil {
+		return fmt.Errorf("failed to read the parquet header: %q", err)
+	}
+	defer reader.Close()
+
+	paths, err := rewriteColumnPaths(reader, opts.columns, opts.drop)
+	if err != nil {
+		return err
+	}
+	sd := projectSchema(reader.GetSchemaDefinition(), paths)
+
+	// the reader is created again to only read the remaining columns
+	reader, err = goparquet.NewFileReaderWithOptions(fl,
+		goparquet.WithColumns(paths...),
+		goparquet.WithExternalFileOpener(externalFileOpener(address)),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to read the parquet header: %q", err)
+	}
+	defer reader.Close()
+
+	writerOpts := []goparquet.FileWriterOption{
+		goparquet.WithSchemaDefinition(sd),
+		goparquet.WithMaxRowGroupSize(opts.rowGroupSize),
+		goparquet.WithMaxPageSize(opts.pageSize),
+		goparquet.WithMaxDictionarySize(opts.maxDictionarySize),
+	}
+
+	codec, err := rewriteCodec(reader, opts.compression)
+	if err != nil {
+		return err
+	}
+	writerOpts = append(writerOpts, goparquet.WithCompressionCodec(codec))
+
+	switch opts.pageVersion {
+	case 1:
+	case 2:
+		writerOpts = append(writerOpts, goparquet.WithDataPageV2())
+	default:
+		return fmt.Errorf("invalid page version %d", opts.pageVersion)
+	}
+
+	if opts.sortedDictionaries {
+		writerOpts = append(writerOpts, goparquet.WithSortedDictionaries())
+	}
+
+	for _, e := range opts.encodings {
+		parts := strings.SplitN(e, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid column encoding %q, it should be column=encoding", e)
+		}
+		enc, err := parquet.EncodingFromString(strings.ToUpper(parts[1]))
+		if err != nil {
+			return fmt.Errorf("invalid encoding %q", parts[1])
+		}
+		cols, err := goparquet.ResolveColumnPaths(sd, parts[0])
+		if err != nil {
+			return err
+		}
+		writerOpts = append(writerOpts, goparquet.WithColumnEncoding(enc, opts.maxDictionarySize > 0, cols...))
+	}
+
+	kv, err := rewriteMetaData(reader.MetaData(), opts.setMeta, opts.deleteMeta)
+	if err != nil {
+		return err
+	}
+	writerOpts = append(writerOpts, goparquet.WithMetaData(kv))
+
+	return writeOutputFile(output, func(w io.Writer) error {
+		writer := goparquet.NewFileWriter(w, writerOpts...)
+		for i := 0; ; i++ {
+			row, err := reader.NextRow()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("reading record %d failed: %w", i+1, err)
+			}
+			if err := writer.AddData(row); err != nil {
+				return fmt.Errorf("writing record %d failed: %w", i+1, err)
+			}
+		}
+		return writer.Close()
+	})
+}
+
+// writeOutputFile writes the output file using write. The content is written to a temporary
+// file in the same directory, which is renamed to output on success and removed otherwise, so
+// that a failure never leaves an incomplete file at output.
+func writeOutputFile(output string, write func(w io.Writer) error) (err error) {
+	mode := os.FileMode(0644)
+	if fi, err := os.Stat(output); err == nil {
+		mode = fi.Mode().Perm()
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(output), "."+filepath.Base(output)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("can not create the output file: %q", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+			_ = os.Remove(f.Name())
+		}
+	}()
+
+	if err := write(f); err != nil {
+		return err
+	}
+	if err := f.Chmod(mode); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), output)
+}
+
+// rewriteColumnPaths returns the data columns that are selected by columns, or all columns if
+// it is empty, and not dropped.
+func rewriteColumnPaths(reader *goparquet.FileReader, columns, drop []string) ([]string, error) {
+	sd := reader.GetSchemaDefinition()
+	if len(columns) == 0 {
+		columns = []string{"*"}
+	}
+	paths, err := goparquet.ResolveColumnPaths(sd, columns...)
+	if err != nil {
+		return nil, err
+	}
+	if len(drop) == 0 {
+		return paths, nil
+	}
+
+	dropped, err := goparquet.ResolveColumnPaths(sd, drop...)
+	if err != nil {
+		return nil, err
+	}
+	isDropped := make(map[string]bool, len(dropped))
+	for _, p := range dropped {
+		isDropped[p] = true
+	}
+
+	var ret []string
+	for _, p := range paths {
+		if !isDropped[p] {
+			ret = append(ret, p)
+		}
+	}
+	if len(ret) == 0 {
+		return nil, fmt.Errorf("no columns left to write")
+	}
+	return ret, nil
+}
+
+// rewriteCodec returns the compression codec for the name, or the codec of the first column
+// chunk of the file if it is empty.
+func rewriteCodec(reader *goparquet.FileReader, name string) (parquet.CompressionCodec, error) {
+	switch strings.ToUpper(name) {
+	case "":
+		for _, rg := range reader.RowGroups() {
+			for _, chunk := range rg.Columns {
+				if chunk.MetaData != nil {
+					return chunk.MetaData.Codec, nil
+				}
+			}
+		}
+		return parquet.CompressionCodec_UNCOMPRESSED, nil
+	case "SNAPPY":
+		return parquet.CompressionCodec_SNAPPY, nil
+	case "GZIP":
+		return parquet.CompressionCodec_GZIP, nil
+	case "NONE":
+		return parquet.CompressionCodec_UNCOMPRESSED, nil
+	}
+	return 0, fmt.Errorf("invalid compression codec: %q", name)
+}
+
+// rewriteMetaData returns a copy of the key-value meta data kv with the entries of set, provided
+// as key=value, added and the keys in del removed.
+func rewriteMetaData(kv map[string]string, set, del []string) (map[string]string, error) {
+	ret := make(map[string]string, len(kv)+len(set))
+	for k, v := range kv {
+		ret[k] = v
+	}
+	for _, k := range del {
+		delete(ret, k)
+	}
+	for _, s := range set {
+		parts := strings.SplitN(s, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid meta data entry %q, it should be key=value", s)
+		}
+		ret[parts[0]] = parts[1]
+	}
+	return ret, nil
+}
@@ -0,0 +1,108 @@
+package cmds
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	goparquet "github.com/sagia-inneractive/parquet-go"
+	"github.com/sagia-inneractive/parquet-go/parquet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteFile(t *testing.T) {
+	file := writeExportFile(t)
+	defer os.RemoveAll(filepath.Dir(file))
+
+	output := filepath.Join(filepath.Dir(file), "rewritten.parquet")
+	opts := &rewriteOptions{
+		compression:       "gzip",
+		rowGroupSize:      128 * 1024 * 1024,
+		pageSize:          1024 * 1024,
+		pageVersion:       2,
+		maxDictionarySize: 0,
+		encodings:         []string{"id=DELTA_BINARY_PACKED", "name=delta_byte_array"},
+		drop:              []string{"address.*", "raw"},
+		setMeta:           []string{"owner=archive", "note=a=b"},
+	}
+	require.NoError(t, rewriteFile(output, file, opts))
+
+	in, err := os.Open(file)
+	require.NoError(t, err)
+	defer in.Close()
+	inReader, err := goparquet.NewFileReader(in)
+	require.NoError(t, err)
+
+	out, err := os.Open(output)
+	require.NoError(t, err)
+	defer out.Close()
+	outReader, err := goparquet.NewFileReader(out)
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]string{"owner": "archive", "note": "a=b"}, outReader.MetaData())
+	require.Nil(t, outReader.GetColumnByName("address.city"))
+	require.Nil(t, outReader.GetColumnByName("raw"))
+	require.Equal(t, inReader.GetSchemaDefinition().SubSchema("attrs").String(), outReader.GetSchemaDefinition().SubSchema("attrs").String())
+	require.Len(t, outReader.Columns(), 12)
+
+	for i := 0; i < 2; i++ {
+		expected, err := inReader.NextRow()
+		require.NoError(t, err)
+		delete(expected, "address")
+		delete(expected, "raw")
+		row, err := outReader.NextRow()
+		require.NoError(t, err)
+		require.Equal(t, expected, row)
+	}
+
+	for _, col := range outReader.Columns() {
+		md := outReader.RowGroups()[0].Columns[col.Index()].MetaData
+		require.Equal(t, parquet.CompressionCodec_GZIP, md.Codec)
+		require.Nil(t, md.DictionaryPageOffset, col.FlatName())
+
+		pages, err := outReader.ReadPageInfo(0, col.FlatName())
+		require.NoError(t, err)
+		for _, p := range pages {
+			require.Equal(t, parquet.PageType_DATA_PAGE_V2, p.Header.Type)
+		}
+	}
+	require.Contains(t, outReader.RowGroups()[0].Columns[0].MetaData.Encodings, parquet.Encoding_DELTA_BINARY_PACKED)
+	require.Contains(t, outReader.RowGroups()[0].Columns[1].MetaData.Encodings, parquet.Encoding_DELTA_BYTE_ARRAY)
+
+	opts = &rewriteOptions{rowGroupSize: 1024, pageSize: 1024, pageVersion: 1, maxDictionarySize: 1024, columns: []string{"id"}}
+	require.NoError(t, rewriteFile(output, file, opts))
+	out2, err := os.Open(output)
+	require.NoError(t, err)
+	defer out2.Close()
+	outReader, err = goparquet.NewFileReader(out2)
+	require.NoError(t, err)
+	require.Len(t, outReader.Columns(), 1)
+	require.Equal(t, parquet.CompressionCodec_UNCOMPRESSED, outReader.RowGroups()[0].Columns[0].MetaData.Codec)
+	require.Empty(t, outReader.MetaData())
+
+	require.EqualError(t, rewriteFile(output, file, &rewriteOptions{pageVersion: 3}), "invalid page version 3")
+	require.EqualError(t, rewriteFile(output, file, &rewriteOptions{pageVersion: 1, drop: []string{"*"}}), "no columns left to write")
+	require.Error(t, rewriteFile(output, file, &rewriteOptions{pageVersion: 1, encodings: []string{"score=DELTA_BINARY_PACKED"}}))
+
+	// a failed rewrite neither changes an existing output file nor leaves a new or temporary one
+	before, err := ioutil.ReadFile(output)
+	require.NoError(t, err)
+	require.Error(t, rewriteFile(output, file, &rewriteOptions{pageVersion: 1, rowGroupSize: 1024, pageSize: 1024, encodings: []string{"score=DELTA_BINARY_PACKED"}}))
+	after, err := ioutil.ReadFile(output)
+	require.NoError(t, err)
+	require.Equal(t, before, after)
+
+	missing := filepath.Join(filepath.Dir(file), "missing.parquet")
+	require.Error(t, rewriteFile(missing, file, &rewriteOptions{pageVersion: 1, rowGroupSize: 1024, pageSize: 1024, encodings: []string{"score=DELTA_BINARY_PACKED"}}))
+	_, err = os.Stat(missing)
+	require.True(t, os.IsNotExist(err))
+
+	entries, err := ioutil.ReadDir(filepath.Dir(file))
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	require.ElementsMatch(t, []string{filepath.Base(file), filepath.Base(output)}, names)
+}
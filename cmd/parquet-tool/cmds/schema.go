@@ -1,15 +1,29 @@
 package cmds
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
 
 	goparquet "github.com/sagia-inneractive/parquet-go"
+	"github.com/sagia-inneractive/parquet-go/parquet"
+	"github.com/sagia-inneractive/parquet-go/parquetschema"
 	"github.com/spf13/cobra"
 )
 
+var (
+	schemaDetailed *bool
+	schemaJSON     *bool
+)
+
 func init() {
+	schemaDetailed = schemaCmd.PersistentFlags().BoolP("detailed", "d", false, "Print the details of every column, including its levels, encodings and compression")
+	schemaJSON = schemaCmd.PersistentFlags().Bool("json", false, "Print the detailed schema as JSON tree")
 	rootCmd.AddCommand(schemaCmd)
 }
 
@@ -21,17 +35,214 @@ var schemaCmd = &cobra.Command{
 			_ = cmd.Usage()
 			os.Exit(1)
 		}
-		fl, err := os.Open(args[0])
-		if err != nil {
-			log.Fatalf("Can not open the file: %q", err)
+
+		if err := schemaFile(os.Stdout, args[0], *schemaDetailed, *schemaJSON); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func schemaFile(w io.Writer, address string, detailed, asJSON bool) error {
+	fl, err := os.Open(address)
+	if err != nil {
+		return fmt.Errorf("can not open the file: %q", err)
+	}
+	defer fl.Close()
+
+	reader, err := goparquet.NewFileReader(fl)
+	if err != nil {
+		return fmt.Errorf("failed to read the parquet header: %q", err)
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(newSchemaTree(reader, nil))
+	}
+
+	_, _ = fmt.Fprint(w, reader.GetSchemaDefinition())
+	if detailed {
+		_, _ = fmt.Fprintln(w)
+		return printDetailedSchema(w, newSchemaTree(reader, nil))
+	}
+	return nil
+}
+
+// schemaNode is a column of the detailed schema. Groups only have the fields that apply to them.
+type schemaNode struct {
+	Name               string        `json:"name"`
+	Path               string        `json:"path,omitempty"`
+	Repetition         string        `json:"repetition,omitempty"`
+	Type               string        `json:"type,omitempty"`
+	TypeLength         *int32        `json:"type_length,omitempty"`
+	LogicalType        string        `json:"logical_type,omitempty"`
+	ConvertedType      string        `json:"converted_type,omitempty"`
+	FieldID            *int32        `json:"field_id,omitempty"`
+	MaxDefinitionLevel uint16        `json:"max_definition_level"`
+	MaxRepetitionLevel uint16        `json:"max_repetition_level"`
+	Encodings          []string      `json:"encodings,omitempty"`
+	Compression        []string      `json:"compression,omitempty"`
+	Children           []*schemaNode `json:"children,omitempty"`
+}
+
+// newSchemaTree returns the detailed schema of the file. If selected isn't nil, only the data
+// columns whose flat names it contains are part of the tree.
+func newSchemaTree(reader *goparquet.FileReader, selected map[string]bool) *schemaNode {
+	sd := reader.GetSchemaDefinition()
+	root := &schemaNode{Name: sd.RootColumn.SchemaElement.GetName()}
+	root.Children = newSchemaNodes(reader, sd.RootColumn.Children, "", 0, 0, selected)
+	return root
+}
+
+func newSchemaNodes(reader *goparquet.FileReader, cols []*parquetschema.ColumnDefinition, prefix string, maxD, maxR uint16, selected map[string]bool) []*schemaNode {
+	var nodes []*schemaNode
+	for _, col := range cols {
+		elem := col.SchemaElement
+		node := &schemaNode{
+			Name:               elem.GetName(),
+			Path:               prefix + elem.GetName(),
+			Repetition:         elem.GetRepetitionType().String(),
+			FieldID:            elem.FieldID,
+			MaxDefinitionLevel: maxD,
+			MaxRepetitionLevel: maxR,
+		}
+		switch elem.GetRepetitionType() {
+		case parquet.FieldRepetitionType_OPTIONAL:
+			node.MaxDefinitionLevel++
+		case parquet.FieldRepetitionType_REPEATED:
+			node.MaxDefinitionLevel++
+			node.MaxRepetitionLevel++
+		}
+		if elem.LogicalType != nil {
+			node.LogicalType = logicalTypeString(elem.LogicalType)
+		}
+		if elem.ConvertedType != nil {
+			node.ConvertedType = elem.GetConvertedType().String()
 		}
-		defer fl.Close()
 
-		reader, err := goparquet.NewFileReader(fl)
-		if err != nil {
-			log.Fatalf("Failed to read the parquet header: %q", err)
+		if elem.Type == nil {
+			node.Children = newSchemaNodes(reader, col.Children, node.Path+".", node.MaxDefinitionLevel, node.MaxRepetitionLevel, selected)
+			if len(node.Children) > 0 {
+				nodes = append(nodes, node)
+			}
+			continue
 		}
 
-		fmt.Print(reader.GetSchemaDefinition())
-	},
+		if selected != nil && !selected[node.Path] {
+			continue
+		}
+
+		node.Type = elem.GetType().String()
+		node.TypeLength = elem.TypeLength
+		if c := reader.GetColumnByName(node.Path); c != nil {
+			node.MaxDefinitionLevel = c.MaxDefinitionLevel()
+			node.MaxRepetitionLevel = c.MaxRepetitionLevel()
+			node.Encodings, node.Compression = chunkEncodings(reader, c)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// chunkEncodings returns the encodings and compression codecs of the column chunks of a data
+// column in all row groups, in the order they first appear.
+func chunkEncodings(reader *goparquet.FileReader, col *goparquet.Column) (encodings, codecs []string) {
+	seen := make(map[string]bool)
+	add := func(list *[]string, s string) {
+		if !seen[s] {
+			seen[s] = true
+			*list = append(*list, s)
+		}
+	}
+
+	for _, rg := range reader.RowGroups() {
+		if col.Index() >= len(rg.Columns) || rg.Columns[col.Index()].MetaData == nil {
+			continue
+		}
+		md := rg.Columns[col.Index()].MetaData
+		for _, enc := range md.Encodings {
+			add(&encodings, enc.String())
+		}
+		add(&codecs, md.Codec.String())
+	}
+	return encodings, codecs
+}
+
+// logicalTypeString formats a logical type like the schema definition language.
+func logicalTypeString(lt *parquet.LogicalType) string {
+	switch {
+	case lt.STRING != nil:
+		return "STRING"
+	case lt.MAP != nil:
+		return "MAP"
+	case lt.LIST != nil:
+		return "LIST"
+	case lt.ENUM != nil:
+		return "ENUM"
+	case lt.DECIMAL != nil:
+		return fmt.Sprintf("DECIMAL(%d, %d)", lt.DECIMAL.Precision, lt.DECIMAL.Scale)
+	case lt.DATE != nil:
+		return "DATE"
+	case lt.TIME != nil:
+		return fmt.Sprintf("TIME(%s, %t)", timeUnitString(lt.TIME.Unit), lt.TIME.IsAdjustedToUTC)
+	case lt.TIMESTAMP != nil:
+		return fmt.Sprintf("TIMESTAMP(%s, %t)", timeUnitString(lt.TIMESTAMP.Unit), lt.TIMESTAMP.IsAdjustedToUTC)
+	case lt.INTEGER != nil:
+		return fmt.Sprintf("INT(%d, %t)", lt.INTEGER.BitWidth, lt.INTEGER.IsSigned)
+	case lt.UNKNOWN != nil:
+		return "NULL"
+	case lt.JSON != nil:
+		return "JSON"
+	case lt.BSON != nil:
+		return "BSON"
+	case lt.UUID != nil:
+		return "UUID"
+	}
+	return "UNKNOWN"
+}
+
+func timeUnitString(unit *parquet.TimeUnit) string {
+	switch {
+	case unit == nil:
+		return "UNKNOWN"
+	case unit.NANOS != nil:
+		return "NANOS"
+	case unit.MICROS != nil:
+		return "MICROS"
+	case unit.MILLIS != nil:
+		return "MILLIS"
+	}
+	return "UNKNOWN"
+}
+
+// printDetailedSchema prints a table with the details of all data columns of the tree.
+func printDetailedSchema(w io.Writer, root *schemaNode) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "COLUMN\tREPETITION\tTYPE\tLOGICAL\tCONVERTED\tD\tR\tFIELD ID\tENCODINGS\tCOMPRESSION")
+
+	var printNodes func(nodes []*schemaNode)
+	printNodes = func(nodes []*schemaNode) {
+		for _, n := range nodes {
+			if n.Type == "" {
+				printNodes(n.Children)
+				continue
+			}
+
+			typ := n.Type
+			if n.TypeLength != nil {
+				typ += "(" + strconv.Itoa(int(*n.TypeLength)) + ")"
+			}
+			fieldID := "-"
+			if n.FieldID != nil {
+				fieldID = strconv.Itoa(int(*n.FieldID))
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\t%d\t%s\t%s\t%s\n",
+				n.Path, n.Repetition, typ, orDash(n.LogicalType), orDash(n.ConvertedType),
+				n.MaxDefinitionLevel, n.MaxRepetitionLevel, fieldID,
+				orDash(strings.Join(n.Encodings, ",")), orDash(strings.Join(n.Compression, ",")))
+		}
+	}
+	printNodes(root.Children)
+
+	return tw.Flush()
 }
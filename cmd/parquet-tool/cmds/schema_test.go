@@ -0,0 +1,89 @@
+package cmds
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	goparquet "github.com/sagia-inneractive/parquet-go"
+	"github.com/sagia-inneractive/parquet-go/parquet"
+	"github.com/sagia-inneractive/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "schema")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+  required int64 id = 1;
+  optional group tags (LIST) {
+    repeated group list {
+      optional binary element (STRING) = 3;
+    }
+  }
+  optional fixed_len_byte_array(16) uuid (UUID);
+}`)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	fw := goparquet.NewFileWriter(&buf, goparquet.WithSchemaDefinition(sd), goparquet.WithCompressionCodec(parquet.CompressionCodec_SNAPPY))
+	require.NoError(t, fw.AddData(map[string]interface{}{
+		"id":   int64(1),
+		"tags": map[string]interface{}{"list": []map[string]interface{}{{"element": []byte("x")}}},
+	}))
+	require.NoError(t, fw.Close())
+
+	file := filepath.Join(dir, "test.parquet")
+	require.NoError(t, ioutil.WriteFile(file, buf.Bytes(), 0644))
+
+	buf.Reset()
+	require.NoError(t, schemaFile(&buf, file, false, false))
+	require.Equal(t, sd.String(), buf.String())
+
+	buf.Reset()
+	require.NoError(t, schemaFile(&buf, file, true, false))
+	lines := strings.Split(strings.TrimSuffix(strings.TrimPrefix(buf.String(), sd.String()+"\n"), "\n"), "\n")
+	require.Len(t, lines, 4)
+	require.Equal(t, []string{"COLUMN", "REPETITION", "TYPE", "LOGICAL", "CONVERTED", "D", "R", "FIELD", "ID", "ENCODINGS", "COMPRESSION"}, strings.Fields(lines[0]))
+	require.Equal(t, []string{"id", "REQUIRED", "INT64", "-", "-", "0", "0", "1", "RLE,PLAIN", "SNAPPY"}, strings.Fields(lines[1]))
+	require.Equal(t, []string{"tags.list.element", "OPTIONAL", "BYTE_ARRAY", "STRING", "UTF8", "3", "1", "3", "RLE,PLAIN", "SNAPPY"}, strings.Fields(lines[2]))
+	require.Equal(t, []string{"uuid", "OPTIONAL", "FIXED_LEN_BYTE_ARRAY(16)", "UUID", "-", "1", "0", "-", "RLE,PLAIN", "SNAPPY"}, strings.Fields(lines[3]))
+
+	buf.Reset()
+	require.NoError(t, schemaFile(&buf, file, false, true))
+	var root schemaNode
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &root))
+	require.Equal(t, "test", root.Name)
+	require.Len(t, root.Children, 3)
+
+	tags := root.Children[1]
+	require.Equal(t, "tags", tags.Name)
+	require.Equal(t, "LIST", tags.ConvertedType)
+	require.Nil(t, tags.FieldID)
+	require.Equal(t, uint16(1), tags.MaxDefinitionLevel)
+	require.Empty(t, tags.Type)
+	require.Len(t, tags.Children, 1)
+
+	list := tags.Children[0]
+	require.Equal(t, "REPEATED", list.Repetition)
+	require.Equal(t, uint16(2), list.MaxDefinitionLevel)
+	require.Equal(t, uint16(1), list.MaxRepetitionLevel)
+
+	element := list.Children[0]
+	require.Equal(t, "tags.list.element", element.Path)
+	require.Equal(t, "BYTE_ARRAY", element.Type)
+	require.Equal(t, []string{"SNAPPY"}, element.Compression)
+
+	buf.Reset()
+	require.NoError(t, catFile(&buf, file, 1, []string{"tags[]"}, true))
+	lines = strings.Split(buf.String(), "\n")
+	require.True(t, strings.HasPrefix(lines[0], "COLUMN"))
+	require.True(t, strings.HasPrefix(lines[1], "tags.list.element"))
+	require.Equal(t, "", lines[2])
+}
@@ -0,0 +1,318 @@
+package cmds
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	goparquet "github.com/sagia-inneractive/parquet-go"
+	"github.com/sagia-inneractive/parquet-go/parquet"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsColumns  *[]string
+	statsSort     *string
+	statsJSON     *bool
+	statsDistinct *bool
+)
+
+func init() {
+	statsColumns = statsCmd.PersistentFlags().StringSliceP("columns", "c", nil, "The columns to report, e.g. address.*, attrs.key_value.key or events[].type")
+	statsSort = statsCmd.PersistentFlags().StringP("sort", "s", "schema", "The order of the columns, valid values are schema, name, compressed, uncompressed, ratio, nulls and distinct")
+	statsJSON = statsCmd.PersistentFlags().Bool("json", false, "Print the report as JSON instead of a table")
+	statsDistinct = statsCmd.PersistentFlags().Bool("distinct", false, "Estimate the number of distinct values of the columns by reading all their values")
+	rootCmd.AddCommand(statsCmd)
+}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats file-name.parquet",
+	Short: "Print the sizes and statistics of the columns of the parquet file",
+	Long: `Print the sizes and statistics of the columns of the parquet file, aggregated over all row
+groups: the compressed and uncompressed size, the compression ratio, the share of the file,
+the encodings, the number of nulls and the minimum and maximum values. Only the meta data of
+the file is read, unless --distinct is provided, which reads all values of the columns to
+estimate their number of distinct values using HyperLogLog. Without it, the distinct count
+shown is the largest distinct count of the statistics of the row groups, which is a lower
+bound (>=), if all row groups have one.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			_ = cmd.Usage()
+			os.Exit(1)
+		}
+
+		if err := statsFile(os.Stdout, args[0], *statsColumns, *statsSort, *statsJSON, *statsDistinct); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+// fileStats is the statistics report of a file.
+type fileStats struct {
+	NumRows          int64          `json:"num_rows"`
+	RowGroups        int            `json:"row_groups"`
+	CompressedSize   int64          `json:"compressed_size"`
+	UncompressedSize int64          `json:"uncompressed_size"`
+	Columns          []*columnStats `json:"columns"`
+}
+
+// columnStats contains the statistics of a data column, aggregated over all row groups. Null
+// counts, minimum and maximum values and distinct counts are omitted if not all row groups have
+// them. The distinct count estimate is only set if the values of the column were read.
+type columnStats struct {
+	Column             string   `json:"column"`
+	Type               string   `json:"type"`
+	CompressedSize     int64    `json:"compressed_size"`
+	UncompressedSize   int64    `json:"uncompressed_size"`
+	Ratio              float64  `json:"ratio"`
+	Share              float64  `json:"share"`
+	NumValues          int64    `json:"num_values"`
+	NullCount          *int64   `json:"null_count,omitempty"`
+	Min                *string  `json:"min,omitempty"`
+	Max                *string  `json:"max,omitempty"`
+	DistinctLowerBound *int64   `json:"distinct_count_lower_bound,omitempty"`
+	DistinctEstimate   *int64   `json:"distinct_count_estimate,omitempty"`
+	Encodings          []string `json:"encodings"`
+	Compression        []string `json:"compression"`
+}
+
+func statsFile(w io.Writer, address string, columns []string, sortBy string, asJSON, distinct bool) error {
+	less, err := columnStatsOrder(sortBy)
+	if err != nil {
+		return err
+	}
+
+	fl, err := os.Open(address)
+	if err != nil {
+		return fmt.Errorf("can not open the file: %q", err)
+	}
+	defer fl.Close()
+
+	reader, err := goparquet.NewFileReader(fl)
+	if err != nil {
+		return fmt.Errorf("failed to read the parquet header: %q", err)
+	}
+
+	if len(columns) == 0 {
+		columns = []string{"*"}
+	}
+	paths, err := goparquet.ResolveColumnPaths(reader.GetSchemaDefinition(), columns...)
+	if err != nil {
+		return err
+	}
+
+	fs := &fileStats{
+		NumRows:   reader.NumRows(),
+		RowGroups: reader.RowGroupCount(),
+	}
+	for _, rg := range reader.RowGroups() {
+		for _, chunk := range rg.Columns {
+			if chunk.MetaData != nil {
+				fs.CompressedSize += chunk.MetaData.TotalCompressedSize
+				fs.UncompressedSize += chunk.MetaData.TotalUncompressedSize
+			}
+		}
+	}
+
+	for _, path := range paths {
+		cs, err := newColumnStats(reader, reader.GetColumnByName(path), distinct)
+		if err != nil {
+			return fmt.Errorf("column %q: %w", path, err)
+		}
+		if fs.CompressedSize > 0 {
+			cs.Share = float64(cs.CompressedSize) / float64(fs.CompressedSize)
+		}
+		fs.Columns = append(fs.Columns, cs)
+	}
+
+	if less != nil {
+		sort.SliceStable(fs.Columns, func(i, j int) bool {
+			return less(fs.Columns[i], fs.Columns[j])
+		})
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(fs)
+	}
+
+	return printStats(w, fs)
+}
+
+// columnStatsOrder returns the function to sort the columns by. Sizes, ratios and counts are
+// sorted in descending order. It returns nil for the order of the schema.
+func columnStatsOrder(sortBy string) (func(a, b *columnStats) bool, error) {
+	count := func(c *int64) int64 {
+		if c == nil {
+			return -1
+		}
+		return *c
+	}
+	distinct := func(c *columnStats) int64 {
+		if c.DistinctEstimate != nil {
+			return *c.DistinctEstimate
+		}
+		return count(c.DistinctLowerBound)
+	}
+
+	switch sortBy {
+	case "", "schema":
+		return nil, nil
+	case "name":
+		return func(a, b *columnStats) bool { return a.Column < b.Column }, nil
+	case "compressed":
+		return func(a, b *columnStats) bool { return a.CompressedSize > b.CompressedSize }, nil
+	case "uncompressed":
+		return func(a, b *columnStats) bool { return a.UncompressedSize > b.UncompressedSize }, nil
+	case "ratio":
+		return func(a, b *columnStats) bool { return a.Ratio > b.Ratio }, nil
+	case "nulls":
+		return func(a, b *columnStats) bool { return count(a.NullCount) > count(b.NullCount) }, nil
+	case "distinct":
+		return func(a, b *columnStats) bool { return distinct(a) > distinct(b) }, nil
+	}
+	return nil, fmt.Errorf("invalid sort order %q", sortBy)
+}
+
+func newColumnStats(reader *goparquet.FileReader, col *goparquet.Column, estimateDistinct bool) (*columnStats, error) {
+	elem := col.Element()
+	cs := &columnStats{
+		Column: col.FlatName(),
+		Type:   elem.GetType().String(),
+	}
+	cs.Encodings, cs.Compression = chunkEncodings(reader, col)
+
+	var (
+		nulls, distinct       int64
+		hasNulls, hasDistinct = true, true
+		min, max              interface{}
+		hasMinMax             = hasSortOrder(elem)
+	)
+	for _, rg := range reader.RowGroups() {
+		if col.Index() >= len(rg.Columns) || rg.Columns[col.Index()].MetaData == nil {
+			hasNulls, hasDistinct, hasMinMax = false, false, false
+			continue
+		}
+		md := rg.Columns[col.Index()].MetaData
+		cs.CompressedSize += md.TotalCompressedSize
+		cs.UncompressedSize += md.TotalUncompressedSize
+		cs.NumValues += md.NumValues
+
+		stats := md.Statistics
+		if stats == nil {
+			hasNulls, hasDistinct, hasMinMax = false, false, false
+			continue
+		}
+
+		if stats.NullCount != nil {
+			nulls += *stats.NullCount
+		} else {
+			hasNulls = false
+		}
+
+		// the distinct values of the row groups may overlap, so the largest count is a lower bound
+		if stats.DistinctCount != nil {
+			if *stats.DistinctCount > distinct {
+				distinct = *stats.DistinctCount
+			}
+		} else {
+			hasDistinct = false
+		}
+
+		if !hasMinMax {
+			continue
+		}
+		minValue, maxValue := stats.MinValue, stats.MaxValue
+		if minValue == nil && maxValue == nil && !isUnsignedColumn(elem) && md.Type != parquet.Type_BYTE_ARRAY && md.Type != parquet.Type_FIXED_LEN_BYTE_ARRAY {
+			// the deprecated min and max are only reliable for signed comparisons
+			minValue, maxValue = stats.Min, stats.Max
+		}
+		if minValue == nil || maxValue == nil {
+			// only a column chunk that contains nothing but nulls has no minimum and maximum
+			if stats.NullCount == nil || *stats.NullCount != md.NumValues {
+				hasMinMax = false
+			}
+			continue
+		}
+
+		rgMin, err := decodeStatistic(elem, minValue)
+		if err != nil {
+			return nil, err
+		}
+		rgMax, err := decodeStatistic(elem, maxValue)
+		if err != nil {
+			return nil, err
+		}
+		if isNaN(rgMin) || isNaN(rgMax) {
+			hasMinMax = false
+			continue
+		}
+		if min == nil || compareValues(rgMin, min) < 0 {
+			min = rgMin
+		}
+		if max == nil || compareValues(rgMax, max) > 0 {
+			max = rgMax
+		}
+	}
+
+	if cs.CompressedSize > 0 {
+		cs.Ratio = float64(cs.UncompressedSize) / float64(cs.CompressedSize)
+	}
+	if hasNulls {
+		cs.NullCount = &nulls
+	}
+	if hasDistinct {
+		cs.DistinctLowerBound = &distinct
+	}
+	if estimateDistinct {
+		hll := newHyperLogLog()
+		for i := range reader.RowGroups() {
+			values, err := reader.ReadColumnValues(i, col.FlatName())
+			if err != nil {
+				return nil, fmt.Errorf("reading the values of row group %d failed: %w", i, err)
+			}
+			for _, v := range values.Values {
+				hll.add(v)
+			}
+		}
+		estimate := hll.estimate()
+		cs.DistinctEstimate = &estimate
+	}
+	if hasMinMax && min != nil {
+		minStr, maxStr := formatValue(elem, min), formatValue(elem, max)
+		cs.Min, cs.Max = &minStr, &maxStr
+	}
+
+	return cs, nil
+}
+
+func printStats(w io.Writer, fs *fileStats) error {
+	_, _ = fmt.Fprintf(w, "rows %d, row groups %d, compressed %d, uncompressed %d\n\n", fs.NumRows, fs.RowGroups, fs.CompressedSize, fs.UncompressedSize)
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "COLUMN\tTYPE\tCOMPRESSED\tUNCOMPRESSED\tRATIO\tSHARE\tVALUES\tNULLS\tDISTINCT\tMIN\tMAX\tENCODINGS\tCOMPRESSION")
+	for _, c := range fs.Columns {
+		distinct := "-"
+		if c.DistinctEstimate != nil {
+			distinct = "~" + strconv.FormatInt(*c.DistinctEstimate, 10)
+		} else if c.DistinctLowerBound != nil {
+			distinct = ">=" + strconv.FormatInt(*c.DistinctLowerBound, 10)
+		}
+		nulls := "-"
+		if c.NullCount != nil {
+			nulls = strconv.FormatInt(*c.NullCount, 10)
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%.2f\t%.1f%%\t%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			c.Column, c.Type, c.CompressedSize, c.UncompressedSize, c.Ratio, 100*c.Share, c.NumValues,
+			nulls, distinct, orDash(optionalString(c.Min)), orDash(optionalString(c.Max)),
+			orDash(strings.Join(c.Encodings, ",")), orDash(strings.Join(c.Compression, ",")))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,124 @@
+package cmds
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	goparquet "github.com/sagia-inneractive/parquet-go"
+	"github.com/sagia-inneractive/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stats")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+  required int64 id;
+  optional int32 day (DATE);
+  optional binary name (STRING);
+}`)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	fw := goparquet.NewFileWriter(&buf, goparquet.WithSchemaDefinition(sd))
+	for rg := 0; rg < 2; rg++ {
+		for i := 0; i < 100; i++ {
+			data := map[string]interface{}{"id": int64(rg*100 + i)}
+			if i%4 != 0 {
+				data["day"] = int32(18000 + rg*10 + i%10)
+				data["name"] = []byte(strings.Repeat("x", i))
+			}
+			require.NoError(t, fw.AddData(data))
+		}
+		require.NoError(t, fw.FlushRowGroup())
+	}
+	require.NoError(t, fw.Close())
+
+	file := filepath.Join(dir, "test.parquet")
+	require.NoError(t, ioutil.WriteFile(file, buf.Bytes(), 0644))
+
+	buf.Reset()
+	require.NoError(t, statsFile(&buf, file, nil, "compressed", true, false))
+
+	var fs fileStats
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &fs))
+	require.Equal(t, int64(200), fs.NumRows)
+	require.Equal(t, 2, fs.RowGroups)
+	require.Len(t, fs.Columns, 3)
+
+	var compressed int64
+	byName := make(map[string]*columnStats)
+	for i, c := range fs.Columns {
+		compressed += c.CompressedSize
+		byName[c.Column] = c
+		if i > 0 {
+			require.True(t, fs.Columns[i-1].CompressedSize >= c.CompressedSize)
+		}
+	}
+	require.Equal(t, fs.CompressedSize, compressed)
+	require.Equal(t, "name", fs.Columns[0].Column)
+
+	id := byName["id"]
+	require.Equal(t, int64(200), id.NumValues)
+	require.Equal(t, int64(0), *id.NullCount)
+	require.Equal(t, "0", *id.Min)
+	require.Equal(t, "199", *id.Max)
+
+	day := byName["day"]
+	require.Equal(t, int64(50), *day.NullCount)
+	require.Equal(t, "2019-04-14", *day.Min)
+	require.Equal(t, "2019-05-03", *day.Max)
+	require.InDelta(t, float64(day.CompressedSize)/float64(fs.CompressedSize), day.Share, 1e-9)
+
+	buf.Reset()
+	require.NoError(t, statsFile(&buf, file, []string{"day"}, "schema", false, false))
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 4)
+	require.True(t, strings.HasPrefix(lines[0], "rows 200, row groups 2, compressed "), lines[0])
+	require.Equal(t, "COLUMN", strings.Fields(lines[2])[0])
+	require.Equal(t, "day", strings.Fields(lines[3])[0])
+
+	require.Error(t, statsFile(&buf, file, nil, "size", false, false))
+
+	buf.Reset()
+	require.NoError(t, statsFile(&buf, file, nil, "distinct", true, true))
+	fs = fileStats{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &fs))
+	estimates := make(map[string]int64)
+	for _, c := range fs.Columns {
+		require.NotNil(t, c.DistinctEstimate, c.Column)
+		estimates[c.Column] = *c.DistinctEstimate
+	}
+	require.Equal(t, []string{"id", "name", "day"}, []string{fs.Columns[0].Column, fs.Columns[1].Column, fs.Columns[2].Column})
+	require.Equal(t, map[string]int64{"id": 200, "name": 75, "day": 20}, estimates)
+
+	buf.Reset()
+	require.NoError(t, statsFile(&buf, file, []string{"day"}, "schema", false, true))
+	lines = strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 4)
+	require.Contains(t, strings.Fields(lines[3]), "~20")
+}
+
+func TestHyperLogLog(t *testing.T) {
+	for _, n := range []int{0, 1, 1000, 100000} {
+		hll := newHyperLogLog()
+		for i := 0; i < n; i++ {
+			hll.add(int64(i))
+			hll.add(int64(i))
+			hll.add([]byte(fmt.Sprintf("value-%d", i%(n/2+1))))
+		}
+		expected := float64(n + n/2 + 1)
+		if n == 0 {
+			expected = 0
+		}
+		require.InDelta(t, expected, float64(hll.estimate()), expected*0.02, "%d values", n)
+	}
+}
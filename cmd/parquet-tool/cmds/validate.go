@@ -0,0 +1,483 @@
+package cmds
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	goparquet "github.com/sagia-inneractive/parquet-go"
+	"github.com/sagia-inneractive/parquet-go/parquet"
+	"github.com/spf13/cobra"
+)
+
+// The exit codes of validate if the file isn't valid.
+const (
+	validateProblems = 1
+	validateFailed   = 2
+)
+
+var validateCRC *bool
+
+func init() {
+	validateCRC = validateCmd.PersistentFlags().Bool("crc", false, "Verify the checksums of pages that have one")
+	rootCmd.AddCommand(validateCmd)
+}
+
+var validateCmd = &cobra.Command{
+	Use:   "validate file-name.parquet",
+	Short: "Check the integrity of the parquet file",
+	Long: `Check the integrity of the parquet file: the magic bytes, the footer, the schema, the
+location and size of all column chunks and their pages, the number of values and rows
+against the decoded levels, and the statistics against the decoded values.
+
+All problems are printed. The exit code is 0 if the file is valid, 1 if problems were
+found and 2 if the file couldn't be validated at all.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			_ = cmd.Usage()
+			os.Exit(validateFailed)
+		}
+
+		problems, err := validateFile(os.Stdout, args[0], *validateCRC)
+		if err != nil {
+			log.Print(err)
+			os.Exit(validateFailed)
+		}
+		if problems > 0 {
+			fmt.Printf("%d problem(s) found\n", problems)
+			os.Exit(validateProblems)
+		}
+		fmt.Println("OK")
+	},
+}
+
+// validator reports the problems found in a file.
+type validator struct {
+	w        io.Writer
+	problems int
+}
+
+func (v *validator) problemf(format string, args ...interface{}) {
+	v.problems++
+	_, _ = fmt.Fprintf(v.w, format+"\n", args...)
+}
+
+// chunkRange is the byte range of a column chunk in the file.
+type chunkRange struct {
+	name  string
+	start int64
+	end   int64
+}
+
+// validateFile checks the file at address and writes all problems it finds to w. It only returns
+// an error if the file can't be read at all.
+func validateFile(w io.Writer, address string, checkCRC bool) (int, error) {
+	fl, err := os.Open(address)
+	if err != nil {
+		return 0, fmt.Errorf("can not open the file: %q", err)
+	}
+	defer fl.Close()
+
+	st, err := fl.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	v := &validator{w: w}
+	footerStart, ok := v.checkMagic(fl, st.Size())
+	if !ok {
+		return v.problems, nil
+	}
+
+	reader, err := goparquet.NewFileReaderWithOptions(fl,
+		goparquet.WithExternalFileOpener(externalFileOpener(address)),
+	)
+	if err != nil {
+		v.problemf("reading the footer failed: %v", err)
+		return v.problems, nil
+	}
+	defer reader.Close()
+
+	if err := reader.GetSchemaDefinition().ValidateStrict(); err != nil {
+		v.problemf("invalid schema: %v", err)
+	}
+
+	var leaves []*goparquet.Column
+	collectLeaves(reader.Columns(), &leaves)
+
+	var numRows int64
+	for _, rg := range reader.RowGroups() {
+		numRows += rg.NumRows
+	}
+	if numRows != reader.NumRows() {
+		v.problemf("the file has %d rows, but its row groups have %d rows", reader.NumRows(), numRows)
+	}
+
+	var ranges []chunkRange
+	for i, rg := range reader.RowGroups() {
+		if len(rg.Columns) != len(leaves) {
+			v.problemf("row group %d: has %d column chunks, but the schema has %d columns", i, len(rg.Columns), len(leaves))
+			continue
+		}
+
+		for _, col := range leaves {
+			chunk := rg.Columns[col.Index()]
+			prefix := fmt.Sprintf("row group %d, column %s:", i, col.FlatName())
+			if chunk.MetaData == nil {
+				v.problemf("%s missing meta data", prefix)
+				continue
+			}
+
+			if chunk.FilePath == nil {
+				r, ok := v.checkChunkRange(prefix, chunk.MetaData, footerStart)
+				if ok {
+					ranges = append(ranges, r)
+				}
+			}
+			v.checkChunk(reader, prefix, i, rg, col, chunk.MetaData, checkCRC)
+		}
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].start < ranges[j].start
+	})
+	for i := 1; i < len(ranges); i++ {
+		if ranges[i].start < ranges[i-1].end {
+			v.problemf("%s overlaps with %s", ranges[i].name, ranges[i-1].name)
+		}
+	}
+
+	return v.problems, nil
+}
+
+// checkMagic checks the magic bytes at the start and the end of the file and the length of the
+// footer. It returns the offset of the footer.
+func (v *validator) checkMagic(r io.ReaderAt, size int64) (int64, bool) {
+	if size < 12 {
+		v.problemf("the file is too small to be a parquet file (%d bytes)", size)
+		return 0, false
+	}
+
+	header := make([]byte, 4)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		v.problemf("reading the magic header failed: %v", err)
+		return 0, false
+	}
+	if string(header) != "PAR1" && string(header) != "PARE" {
+		v.problemf("invalid magic header %q", header)
+	}
+
+	trailer := make([]byte, 8)
+	if _, err := r.ReadAt(trailer, size-8); err != nil {
+		v.problemf("reading the footer length failed: %v", err)
+		return 0, false
+	}
+	if magic := string(trailer[4:]); magic != string(header) {
+		v.problemf("invalid magic trailer %q", magic)
+		return 0, false
+	}
+
+	footerLen := int64(binary.LittleEndian.Uint32(trailer))
+	if footerLen > size-12 {
+		v.problemf("the footer length %d exceeds the file size %d", footerLen, size)
+		return 0, false
+	}
+
+	return size - 8 - footerLen, true
+}
+
+func (v *validator) checkChunkRange(prefix string, md *parquet.ColumnMetaData, footerStart int64) (chunkRange, bool) {
+	r := chunkRange{
+		name:  strings.TrimSuffix(prefix, ":"),
+		start: chunkStart(md),
+	}
+	r.end = r.start + md.TotalCompressedSize
+
+	if r.start < 4 || md.TotalCompressedSize <= 0 || r.end > footerStart {
+		v.problemf("%s the column chunk at offset %d with %d bytes is outside of the data of the file (4 to %d)", prefix, r.start, md.TotalCompressedSize, footerStart)
+		return r, false
+	}
+	if md.DictionaryPageOffset != nil && md.DataPageOffset <= *md.DictionaryPageOffset {
+		v.problemf("%s the data page offset %d is not after the dictionary page offset %d", prefix, md.DataPageOffset, *md.DictionaryPageOffset)
+	}
+	return r, true
+}
+
+func (v *validator) checkChunk(reader *goparquet.FileReader, prefix string, rowGroup int, rg *parquet.RowGroup, col *goparquet.Column, md *parquet.ColumnMetaData, checkCRC bool) {
+	if path := strings.Join(md.PathInSchema, "."); path != col.FlatName() {
+		v.problemf("%s the path in the meta data is %s", prefix, path)
+	}
+	if typ := col.Element().GetType(); md.Type != typ {
+		v.problemf("%s the type in the meta data is %s, but the schema says %s", prefix, md.Type, typ)
+		return
+	}
+
+	pages, err := reader.ReadPageInfo(rowGroup, col.FlatName())
+	if err != nil {
+		v.problemf("%s %v", prefix, err)
+		return
+	}
+	v.checkPages(prefix, rg, md, pages, checkCRC)
+
+	values, err := reader.ReadColumnValues(rowGroup, col.FlatName())
+	if err != nil {
+		v.problemf("%s %v", prefix, err)
+		return
+	}
+
+	if n := int64(len(values.DefinitionLevels)); n != md.NumValues {
+		v.problemf("%s the meta data has %d values, but %d levels were decoded", prefix, md.NumValues, n)
+	}
+	var rows int64
+	for _, rl := range values.RepetitionLevels {
+		if rl == 0 {
+			rows++
+		}
+	}
+	if rows != rg.NumRows {
+		v.problemf("%s the row group has %d rows, but the decoded levels have %d", prefix, rg.NumRows, rows)
+	}
+
+	if md.Statistics != nil {
+		v.checkStatistics(prefix, col, md.Statistics, values)
+	}
+}
+
+func (v *validator) checkPages(prefix string, rg *parquet.RowGroup, md *parquet.ColumnMetaData, pages []*goparquet.PageInfo, checkCRC bool) {
+	var (
+		compressed, uncompressed, numValues, numRows int64
+		v2, dataPageFound                            bool
+	)
+	for i, p := range pages {
+		ph := p.Header
+		compressed += p.HeaderSize + int64(ph.CompressedPageSize)
+		uncompressed += p.HeaderSize + int64(ph.UncompressedPageSize)
+
+		if checkCRC && p.CRC == goparquet.CRCInvalid {
+			v.problemf("%s the checksum of the page at offset %d doesn't match", prefix, p.Offset)
+		}
+
+		switch ph.Type {
+		case parquet.PageType_DICTIONARY_PAGE:
+			if i > 0 {
+				v.problemf("%s unexpected dictionary page at offset %d", prefix, p.Offset)
+			} else if md.DictionaryPageOffset == nil {
+				v.problemf("%s the column chunk has a dictionary page, but no dictionary page offset", prefix)
+			}
+			continue
+		case parquet.PageType_DATA_PAGE:
+			if ph.DataPageHeader == nil {
+				v.problemf("%s the data page at offset %d has no data page header", prefix, p.Offset)
+				continue
+			}
+			numValues += int64(ph.DataPageHeader.NumValues)
+		case parquet.PageType_DATA_PAGE_V2:
+			if ph.DataPageHeaderV2 == nil {
+				v.problemf("%s the data page at offset %d has no data page header", prefix, p.Offset)
+				continue
+			}
+			v2 = true
+			numValues += int64(ph.DataPageHeaderV2.NumValues)
+			numRows += int64(ph.DataPageHeaderV2.NumRows)
+		default:
+			v.problemf("%s unexpected page of type %s at offset %d", prefix, ph.Type, p.Offset)
+			continue
+		}
+
+		if !dataPageFound {
+			dataPageFound = true
+			if p.Offset != md.DataPageOffset {
+				v.problemf("%s the data page offset is %d, but the first data page starts at %d", prefix, md.DataPageOffset, p.Offset)
+			}
+		}
+	}
+
+	if len(pages) > 0 && pages[0].Header.Type != parquet.PageType_DICTIONARY_PAGE && md.DictionaryPageOffset != nil {
+		v.problemf("%s the dictionary page offset is %d, but the column chunk has no dictionary page", prefix, *md.DictionaryPageOffset)
+	}
+	if compressed != md.TotalCompressedSize {
+		v.problemf("%s the total compressed size is %d, but the pages have %d bytes", prefix, md.TotalCompressedSize, compressed)
+	}
+	if uncompressed != md.TotalUncompressedSize {
+		v.problemf("%s the total uncompressed size is %d, but the pages have %d bytes", prefix, md.TotalUncompressedSize, uncompressed)
+	}
+	if numValues != md.NumValues {
+		v.problemf("%s the meta data has %d values, but the data page headers have %d", prefix, md.NumValues, numValues)
+	}
+	if v2 && numRows != rg.NumRows {
+		v.problemf("%s the row group has %d rows, but the data page headers have %d", prefix, rg.NumRows, numRows)
+	}
+}
+
+func (v *validator) checkStatistics(prefix string, col *goparquet.Column, stats *parquet.Statistics, values *goparquet.ColumnValues) {
+	if stats.NullCount != nil {
+		var nulls int64
+		for _, dl := range values.DefinitionLevels {
+			if dl < int32(col.MaxDefinitionLevel()) {
+				nulls++
+			}
+		}
+		if nulls != *stats.NullCount {
+			v.problemf("%s the statistics have %d nulls, but the column chunk has %d", prefix, *stats.NullCount, nulls)
+		}
+	}
+
+	elem := col.Element()
+	if !hasSortOrder(elem) {
+		return
+	}
+
+	var min, max interface{}
+	for _, val := range values.Values {
+		if isNaN(val) {
+			continue
+		}
+		if min == nil || compareValues(val, min) < 0 {
+			min = val
+		}
+		if max == nil || compareValues(val, max) > 0 {
+			max = val
+		}
+	}
+	if min == nil {
+		return
+	}
+
+	// byte arrays may be truncated, so the statistics only need to be bounds of the values
+	for _, s := range []struct {
+		name   string
+		b      []byte
+		actual interface{}
+		sign   int
+	}{{"min", stats.MinValue, min, 1}, {"max", stats.MaxValue, max, -1}} {
+		if s.b == nil {
+			continue
+		}
+		sv, err := decodeStatistic(elem, s.b)
+		if err != nil {
+			v.problemf("%s invalid %s value: %v", prefix, s.name, err)
+			continue
+		}
+		if compareValues(sv, s.actual)*s.sign > 0 {
+			v.problemf("%s the %s value of the statistics is %s, but the column chunk contains %s", prefix, s.name, formatValue(elem, sv), formatValue(elem, s.actual))
+		}
+	}
+}
+
+// hasSortOrder returns false for columns whose statistics can't be checked, because their sort
+// order is undefined or not supported.
+func hasSortOrder(elem *parquet.SchemaElement) bool {
+	switch elem.GetType() {
+	case parquet.Type_INT96:
+		return false
+	case parquet.Type_BYTE_ARRAY, parquet.Type_FIXED_LEN_BYTE_ARRAY:
+		// decimals stored as byte arrays are compared as signed numbers
+		if lt := elem.GetLogicalType(); lt != nil && lt.DECIMAL != nil {
+			return false
+		}
+		if elem.ConvertedType != nil && (elem.GetConvertedType() == parquet.ConvertedType_DECIMAL || elem.GetConvertedType() == parquet.ConvertedType_INTERVAL) {
+			return false
+		}
+	}
+	return true
+}
+
+func isNaN(v interface{}) bool {
+	switch x := v.(type) {
+	case float32:
+		return math.IsNaN(float64(x))
+	case float64:
+		return math.IsNaN(x)
+	}
+	return false
+}
+
+// compareValues compares two values of the same column. Byte arrays are compared as unsigned
+// bytes.
+func compareValues(a, b interface{}) int {
+	switch x := a.(type) {
+	case bool:
+		y := b.(bool)
+		switch {
+		case x == y:
+			return 0
+		case !x:
+			return -1
+		}
+		return 1
+	case int32:
+		return compareInt64(int64(x), int64(b.(int32)))
+	case int64:
+		return compareInt64(x, b.(int64))
+	case uint32:
+		return compareUint64(uint64(x), uint64(b.(uint32)))
+	case uint64:
+		return compareUint64(x, b.(uint64))
+	case float32:
+		return compareFloat64(float64(x), float64(b.(float32)))
+	case float64:
+		return compareFloat64(x, b.(float64))
+	case []byte:
+		return bytes.Compare(x, b.([]byte))
+	}
+	panic(fmt.Sprintf("unsupported type %T", a))
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareUint64(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareFloat64(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// formatValue formats a value like export does.
+func formatValue(elem *parquet.SchemaElement, v interface{}) string {
+	ev, err := exportPrimitive(elem, v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	s, err := formatCell(ev)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return s
+}
+
+func collectLeaves(cols []*goparquet.Column, leaves *[]*goparquet.Column) {
+	for _, c := range cols {
+		if c.DataColumn() {
+			*leaves = append(*leaves, c)
+			continue
+		}
+		collectLeaves(c.Children(), leaves)
+	}
+}
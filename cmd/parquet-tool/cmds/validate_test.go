@@ -0,0 +1,56 @@
+package cmds
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	goparquet "github.com/sagia-inneractive/parquet-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateFile(t *testing.T) {
+	file := writeExportFile(t)
+	defer os.RemoveAll(filepath.Dir(file))
+
+	var buf bytes.Buffer
+	problems, err := validateFile(&buf, file, true)
+	require.NoError(t, err)
+	require.Equal(t, 0, problems, buf.String())
+
+	data, err := ioutil.ReadFile(file)
+	require.NoError(t, err)
+
+	fl, err := os.Open(file)
+	require.NoError(t, err)
+	r, err := goparquet.NewFileReader(fl)
+	require.NoError(t, err)
+	pages, err := r.ReadPageInfo(0, "id")
+	require.NoError(t, err)
+	require.NoError(t, fl.Close())
+
+	// the first value of the required id column starts right after the page header
+	corrupted := append([]byte(nil), data...)
+	corrupted[pages[0].Offset+pages[0].HeaderSize] = 5
+	corruptedFile := filepath.Join(filepath.Dir(file), "corrupted.parquet")
+	require.NoError(t, ioutil.WriteFile(corruptedFile, corrupted, 0644))
+
+	buf.Reset()
+	problems, err = validateFile(&buf, corruptedFile, false)
+	require.NoError(t, err)
+	require.Equal(t, 1, problems)
+	require.Equal(t, "row group 0, column id: the max value of the statistics is 2, but the column chunk contains 5\n", buf.String())
+
+	require.NoError(t, ioutil.WriteFile(corruptedFile, data[:len(data)-1], 0644))
+
+	buf.Reset()
+	problems, err = validateFile(&buf, corruptedFile, false)
+	require.NoError(t, err)
+	require.Equal(t, 1, problems)
+	require.Contains(t, buf.String(), "invalid magic trailer")
+
+	_, err = validateFile(&buf, filepath.Join(filepath.Dir(file), "does-not-exist.parquet"), false)
+	require.Error(t, err)
+}
@@ -0,0 +1,132 @@
+package goparquet
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sagia-inneractive/parquet-go/parquet"
+	"github.com/sagia-inneractive/parquet-go/parquetschema"
+)
+
+// ResolveColumnPaths resolves projection expressions against a schema definition and returns
+// the flat names of all selected data columns, in the order of the schema. A projection
+// expression is a dotted path, in which every element is either the name of a column, or a
+// wildcard "*" that matches all columns on that level. An element ending in "[]" refers to the
+// elements of a LIST or the key/value pairs of a MAP, i.e. "events[].type" is the same as
+// "events.list.element.type" in a standard LIST, and "attrs[].key" is the same as
+// "attrs.key_value.key" in a standard MAP. If an expression refers to a group, all data columns
+// within that group are selected. It is an error if an expression doesn't select any column.
+func ResolveColumnPaths(sd *parquetschema.SchemaDefinition, exprs ...string) ([]string, error) {
+	if sd == nil || sd.RootColumn == nil {
+		return nil, errors.New("schema definition is empty")
+	}
+
+	var (
+		ret  []string
+		seen = make(map[string]bool)
+	)
+	for _, expr := range exprs {
+		segments, err := parseColumnPath(expr)
+		if err != nil {
+			return nil, err
+		}
+
+		var paths []string
+		resolveColumnPath(sd.RootColumn.Children, "", segments, &paths)
+		if len(paths) == 0 {
+			return nil, errors.Errorf("projection %q doesn't match any column", expr)
+		}
+
+		for _, p := range paths {
+			if !seen[p] {
+				seen[p] = true
+				ret = append(ret, p)
+			}
+		}
+	}
+
+	return ret, nil
+}
+
+type pathSegment struct {
+	name     string
+	elements bool
+}
+
+func parseColumnPath(expr string) ([]pathSegment, error) {
+	parts := strings.Split(expr, ".")
+	segments := make([]pathSegment, 0, len(parts))
+	for _, part := range parts {
+		seg := pathSegment{name: part}
+		if strings.HasSuffix(part, "[]") {
+			seg.name = strings.TrimSuffix(part, "[]")
+			seg.elements = true
+		}
+		if seg.name == "" || strings.ContainsAny(seg.name, "[]") {
+			return nil, errors.Errorf("invalid projection %q", expr)
+		}
+		segments = append(segments, seg)
+	}
+
+	return segments, nil
+}
+
+func resolveColumnPath(cols []*parquetschema.ColumnDefinition, prefix string, segments []pathSegment, paths *[]string) {
+	seg := segments[0]
+	for _, col := range cols {
+		if seg.name != "*" && seg.name != col.SchemaElement.Name {
+			continue
+		}
+
+		path := col.SchemaElement.Name
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+
+		if seg.elements {
+			var ok bool
+			if col, path, ok = repeatedElement(col, path); !ok {
+				continue
+			}
+		}
+
+		if len(segments) == 1 {
+			collectDataColumns(col, path, paths)
+			continue
+		}
+
+		resolveColumnPath(col.Children, path, segments[1:], paths)
+	}
+}
+
+// repeatedElement returns the column that holds the repeated values of col. This is col itself if
+// it is repeated, the element of a LIST and the key_value group of a MAP.
+func repeatedElement(col *parquetschema.ColumnDefinition, path string) (*parquetschema.ColumnDefinition, string, bool) {
+	if col.SchemaElement.GetRepetitionType() == parquet.FieldRepetitionType_REPEATED {
+		return col, path, true
+	}
+
+	if len(col.Children) != 1 || col.Children[0].SchemaElement.GetRepetitionType() != parquet.FieldRepetitionType_REPEATED {
+		return nil, "", false
+	}
+
+	repeated := col.Children[0]
+	path += "." + repeated.SchemaElement.Name
+	if col.SchemaElement.GetConvertedType() == parquet.ConvertedType_LIST && len(repeated.Children) == 1 {
+		element := repeated.Children[0]
+		return element, path + "." + element.SchemaElement.Name, true
+	}
+
+	return repeated, path, true
+}
+
+func collectDataColumns(col *parquetschema.ColumnDefinition, path string, paths *[]string) {
+	if col.SchemaElement.Type != nil {
+		*paths = append(*paths, path)
+		return
+	}
+
+	for _, c := range col.Children {
+		collectDataColumns(c, path+"."+c.SchemaElement.Name, paths)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/pkg/errors"
 	"io"
 	"io/ioutil"
+	"sync"
 
 	"github.com/sagia-inneractive/parquet-go/parquet"
 	"github.com/golang/snappy"
@@ -23,11 +24,19 @@ type (
 		DecompressBlock([]byte) ([]byte, error)
 	}
 
+	// pooledDecompressor is implemented by block compressors that are able to decompress
+	// a block into a buffer provided by the caller.
+	pooledDecompressor interface {
+		decompressBlockInto(dst, block []byte) ([]byte, error)
+	}
+
 	plainCompressor  struct{}
 	snappyCompressor struct{}
 	gzipCompressor   struct{}
 )
 
+var gzipReaderPool sync.Pool
+
 func (plainCompressor) CompressBlock(block []byte) ([]byte, error) {
 	return block, nil
 }
@@ -44,6 +53,10 @@ func (snappyCompressor) DecompressBlock(block []byte) ([]byte, error) {
 	return snappy.Decode(nil, block)
 }
 
+func (snappyCompressor) decompressBlockInto(dst, block []byte) ([]byte, error) {
+	return snappy.Decode(dst, block)
+}
+
 func (gzipCompressor) CompressBlock(block []byte) ([]byte, error) {
 	buf := &bytes.Buffer{}
 	w := gzip.NewWriter(buf)
@@ -72,6 +85,45 @@ func (gzipCompressor) DecompressBlock(block []byte) ([]byte, error) {
 	return ret, r.Close()
 }
 
+func (gzipCompressor) decompressBlockInto(dst, block []byte) ([]byte, error) {
+	buf := bytes.NewReader(block)
+	r, ok := gzipReaderPool.Get().(*gzip.Reader)
+	if ok {
+		if err := r.Reset(buf); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		if r, err = gzip.NewReader(buf); err != nil {
+			return nil, err
+		}
+	}
+	defer gzipReaderPool.Put(r)
+
+	if dst == nil {
+		ret, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		return ret, r.Close()
+	}
+
+	n, err := io.ReadFull(r, dst)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+
+	// the block must not contain more data than the caller expects
+	if n == len(dst) {
+		var b [1]byte
+		if m, _ := r.Read(b[:]); m > 0 {
+			return nil, errors.New("decompressed data is larger than expected")
+		}
+	}
+
+	return dst[:n], r.Close()
+}
+
 func compressBlock(block []byte, method parquet.CompressionCodec) ([]byte, error) {
 	c, ok := compressors[method]
 	if !ok {
@@ -90,26 +142,75 @@ func decompressBlock(block []byte, method parquet.CompressionCodec) ([]byte, err
 	return c.DecompressBlock(block)
 }
 
-func newBlockReader(in io.Reader, codec parquet.CompressionCodec, compressedSize int32, uncompressedSize int32) (io.Reader, error) {
-	buf, err := ioutil.ReadAll(io.LimitReader(in, int64(compressedSize)))
+// readBlock reads size bytes from in. If possible, the data is read into a buffer from the pool.
+func readBlock(in io.Reader, size int) ([]byte, error) {
+	var (
+		buf = getBuffer(size)
+		n   int
+		err error
+	)
+	if buf != nil {
+		n, err = io.ReadFull(in, buf)
+		if err == io.ErrUnexpectedEOF || err == io.EOF {
+			err = nil
+		}
+		buf = buf[:n]
+	} else {
+		buf, err = ioutil.ReadAll(io.LimitReader(in, int64(size)))
+	}
 	if err != nil {
 		return nil, errors.Wrap(err, "read failed")
 	}
 
-	if len(buf) != int(compressedSize) {
-		return nil, errors.Errorf("compressed data must be %d byte but its %d byte", compressedSize, len(buf))
+	if len(buf) != size {
+		putBuffer(buf)
+		return nil, errors.Errorf("compressed data must be %d byte but its %d byte", size, len(buf))
+	}
+
+	return buf, nil
+}
+
+func newBlockReader(in io.Reader, codec parquet.CompressionCodec, compressedSize int32, uncompressedSize int32) (*pageBuffer, error) {
+	c, ok := compressors[codec]
+	if !ok {
+		return nil, errors.Errorf("decompression failed: method %q is not supported", codec.String())
 	}
 
-	res, err := decompressBlock(buf, codec)
+	buf, err := readBlock(in, int(compressedSize))
 	if err != nil {
-		return nil, errors.Wrap(err, "decompression failed")
+		return nil, err
+	}
+
+	page := &pageBuffer{}
+	switch d := c.(type) {
+	case plainCompressor:
+		page.data = buf
+		page.pooled = append(page.pooled, buf)
+	case pooledDecompressor:
+		res, err := d.decompressBlockInto(getBuffer(int(uncompressedSize)), buf)
+		putBuffer(buf)
+		if err != nil {
+			return nil, errors.Wrap(err, "decompression failed")
+		}
+		page.data = res
+		page.pooled = append(page.pooled, res)
+	default:
+		// the result of a custom block compressor may share memory with its input, so buf
+		// must not be returned to the pool.
+		res, err := c.DecompressBlock(buf)
+		if err != nil {
+			return nil, errors.Wrap(err, "decompression failed")
+		}
+		page.data = res
 	}
 
-	if len(res) != int(uncompressedSize) {
-		return nil, errors.Errorf("decompressed data must be %d byte but its %d byte", uncompressedSize, len(res))
+	if len(page.data) != int(uncompressedSize) {
+		n := len(page.data)
+		page.release()
+		return nil, errors.Errorf("decompressed data must be %d byte but its %d byte", uncompressedSize, n)
 	}
 
-	return bytes.NewReader(res), nil
+	return page, nil
 }
 
 // RegisterBlockCompressor is a function to to register additional block compressors to the package. By default,
@@ -1,6 +1,8 @@
 package goparquet
 
 import (
+	"bytes"
+	"io/ioutil"
 	"testing"
 
 	"github.com/sagia-inneractive/parquet-go/parquet"
@@ -30,3 +32,34 @@ occaecat cupidatat non proident, sunt in culpa qui officia deserunt mollit anim
 		assert.Equal(t, block, b2)
 	}
 }
+
+func TestBlockReader(t *testing.T) {
+	block := bytes.Repeat([]byte("lorem ipsum dolor sit amet, "), 100)
+
+	methods := []parquet.CompressionCodec{
+		parquet.CompressionCodec_GZIP,
+		parquet.CompressionCodec_SNAPPY,
+		parquet.CompressionCodec_UNCOMPRESSED,
+	}
+
+	for _, m := range methods {
+		b, err := compressBlock(block, m)
+		require.NoError(t, err)
+
+		// the second round reads into the buffers the first round returned to the pool
+		for i := 0; i < 2; i++ {
+			p, err := newBlockReader(bytes.NewReader(b), m, int32(len(b)), int32(len(block)))
+			require.NoError(t, err)
+			data, err := ioutil.ReadAll(p)
+			require.NoError(t, err)
+			assert.Equal(t, block, data)
+			p.release()
+		}
+
+		_, err = newBlockReader(bytes.NewReader(b), m, int32(len(b)), int32(len(block)-1))
+		assert.Error(t, err)
+
+		_, err = newBlockReader(bytes.NewReader(b[:len(b)-1]), m, int32(len(b)), int32(len(block)))
+		assert.Error(t, err)
+	}
+}
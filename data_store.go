@@ -1,7 +1,6 @@
 package goparquet
 
 import (
-	"math"
 	"math/bits"
 
 	"github.com/sagia-inneractive/parquet-go/parquet"
@@ -25,29 +24,15 @@ type ColumnStore struct {
 	enc     parquet.Encoding
 	readPos int
 
+	// pages holds the pages the values were read from in zero-copy mode, as the values may
+	// refer to their buffers.
+	pages []pageReader
+
 	allowDict bool
 
 	skipped bool
 }
 
-// useDictionary is simply a function to decide to use dictionary or not,
-func (cs *ColumnStore) useDictionary() bool {
-	if !cs.allowDict {
-		return false
-	}
-	if len(cs.values.data) > math.MaxInt16 {
-		return false
-	}
-
-	// There is no point for using dictionary if all values are nil
-	if len(cs.values.data) == 0 || len(cs.values.values) == 0 {
-		return false
-	}
-
-	dictLen, noDictLen := cs.values.sizes()
-	return dictLen < noDictLen
-}
-
 func (cs *ColumnStore) encoding() parquet.Encoding {
 	return cs.enc
 }
@@ -62,10 +47,14 @@ func (cs *ColumnStore) reset(rep parquet.FieldRepetitionType, maxR, maxD uint16)
 	}
 	cs.repTyp = rep
 	if cs.values == nil {
-		cs.values = &dictStore{}
+		cs.values = newDictStore(cs.typedColumnStore.parquetType())
 		cs.rLevels = &packedArray{}
 		cs.dLevels = &packedArray{}
 	}
+	for _, p := range cs.pages {
+		p.release()
+	}
+	cs.pages = nil
 	cs.values.init()
 	cs.rLevels.reset(bits.Len16(maxR))
 	cs.dLevels.reset(bits.Len16(maxD))
@@ -75,6 +64,15 @@ func (cs *ColumnStore) reset(rep parquet.FieldRepetitionType, maxR, maxD uint16)
 	cs.typedColumnStore.reset(rep)
 }
 
+// bufferedSize returns the approximate number of bytes used by the values and levels buffered
+// in the column store.
+func (cs *ColumnStore) bufferedSize() int64 {
+	if cs.values == nil {
+		return 0
+	}
+	return cs.values.memSize() + int64(len(cs.rLevels.data)+len(cs.dLevels.data))
+}
+
 func (cs *ColumnStore) appendRDLevel(rl, dl uint16) {
 	cs.rLevels.appendSingle(int32(rl))
 	cs.dLevels.appendSingle(int32(dl))
@@ -147,6 +145,35 @@ func (cs *ColumnStore) getRDLevelAt(pos int) (int32, int32, bool) {
 	return rl, dl, false
 }
 
+// skipRecords advances the read position by n records using the levels only. maxD is the maximum
+// definition level of the column, only levels with this definition level have a value.
+func (cs *ColumnStore) skipRecords(n int64, maxD int32) error {
+	if cs.skipped {
+		return nil
+	}
+
+	for ; n > 0; n-- {
+		_, dl, last := cs.getRDLevelAt(cs.readPos)
+		if last {
+			return errors.New("out of range")
+		}
+		for {
+			if dl == maxD {
+				cs.values.readPos++
+			}
+			cs.readPos++
+
+			var rl int32
+			rl, dl, last = cs.getRDLevelAt(cs.readPos)
+			if last || rl == 0 {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
 func (cs *ColumnStore) getNext() (v interface{}, err error) {
 	v, err = cs.values.getNextValue()
 	if err != nil {
@@ -254,6 +281,22 @@ func getValuesStore(typ *parquet.SchemaElement) (*ColumnStore, error) {
 	}
 }
 
+// checkEncoding returns an error if the column stores of type typ don't support the encoding enc.
+func checkEncoding(typ parquet.Type, enc parquet.Encoding) error {
+	if enc == parquet.Encoding_PLAIN {
+		return nil
+	}
+	switch {
+	case typ == parquet.Type_BOOLEAN && enc == parquet.Encoding_RLE:
+	case (typ == parquet.Type_INT32 || typ == parquet.Type_INT64) && enc == parquet.Encoding_DELTA_BINARY_PACKED:
+	case typ == parquet.Type_BYTE_ARRAY && enc == parquet.Encoding_DELTA_LENGTH_BYTE_ARRAY:
+	case (typ == parquet.Type_BYTE_ARRAY || typ == parquet.Type_FIXED_LEN_BYTE_ARRAY) && enc == parquet.Encoding_DELTA_BYTE_ARRAY:
+	default:
+		return errors.Errorf("encoding %q is not supported on this type", enc)
+	}
+	return nil
+}
+
 // NewBooleanStore creates new column store to store boolean values.
 func NewBooleanStore(enc parquet.Encoding, params *ColumnParameters) (*ColumnStore, error) {
 	switch enc {
@@ -343,7 +386,7 @@ func NewByteArrayStore(enc parquet.Encoding, allowDict bool, params *ColumnParam
 // If allowDict is false, a dictionary will never be used to encode the data.
 func NewFixedByteArrayStore(enc parquet.Encoding, allowDict bool, params *ColumnParameters) (*ColumnStore, error) {
 	switch enc {
-	case parquet.Encoding_PLAIN, parquet.Encoding_DELTA_LENGTH_BYTE_ARRAY, parquet.Encoding_DELTA_BYTE_ARRAY:
+	case parquet.Encoding_PLAIN, parquet.Encoding_DELTA_BYTE_ARRAY:
 	default:
 		return nil, errors.Errorf("encoding %q is not supported on this type", enc)
 	}
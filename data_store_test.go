@@ -1,6 +1,7 @@
 package goparquet
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -475,3 +476,50 @@ func TestZeroRL(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, data, read)
 }
+
+func TestCheckEncoding(t *testing.T) {
+	valid := []struct {
+		typ parquet.Type
+		enc parquet.Encoding
+	}{
+		{parquet.Type_BOOLEAN, parquet.Encoding_PLAIN},
+		{parquet.Type_BOOLEAN, parquet.Encoding_RLE},
+		{parquet.Type_INT32, parquet.Encoding_DELTA_BINARY_PACKED},
+		{parquet.Type_INT64, parquet.Encoding_DELTA_BINARY_PACKED},
+		{parquet.Type_INT96, parquet.Encoding_PLAIN},
+		{parquet.Type_FLOAT, parquet.Encoding_PLAIN},
+		{parquet.Type_DOUBLE, parquet.Encoding_PLAIN},
+		{parquet.Type_BYTE_ARRAY, parquet.Encoding_DELTA_LENGTH_BYTE_ARRAY},
+		{parquet.Type_BYTE_ARRAY, parquet.Encoding_DELTA_BYTE_ARRAY},
+		{parquet.Type_FIXED_LEN_BYTE_ARRAY, parquet.Encoding_DELTA_BYTE_ARRAY},
+	}
+	for _, tt := range valid {
+		assert.NoError(t, checkEncoding(tt.typ, tt.enc), "%s %s", tt.typ, tt.enc)
+	}
+
+	invalid := []struct {
+		typ parquet.Type
+		enc parquet.Encoding
+	}{
+		{parquet.Type_BOOLEAN, parquet.Encoding_DELTA_BINARY_PACKED},
+		{parquet.Type_INT32, parquet.Encoding_RLE},
+		{parquet.Type_INT32, parquet.Encoding_DELTA_BYTE_ARRAY},
+		{parquet.Type_INT64, parquet.Encoding_DELTA_LENGTH_BYTE_ARRAY},
+		{parquet.Type_INT96, parquet.Encoding_DELTA_BINARY_PACKED},
+		{parquet.Type_FLOAT, parquet.Encoding_DELTA_BINARY_PACKED},
+		{parquet.Type_DOUBLE, parquet.Encoding_BYTE_STREAM_SPLIT},
+		{parquet.Type_BYTE_ARRAY, parquet.Encoding_DELTA_BINARY_PACKED},
+		{parquet.Type_BYTE_ARRAY, parquet.Encoding_RLE},
+		{parquet.Type_FIXED_LEN_BYTE_ARRAY, parquet.Encoding_DELTA_LENGTH_BYTE_ARRAY},
+		{parquet.Type_FIXED_LEN_BYTE_ARRAY, parquet.Encoding_DELTA_BINARY_PACKED},
+		{parquet.Type_INT32, parquet.Encoding_RLE_DICTIONARY},
+		{parquet.Type_BYTE_ARRAY, parquet.Encoding_PLAIN_DICTIONARY},
+	}
+	for _, tt := range invalid {
+		assert.EqualError(t, checkEncoding(tt.typ, tt.enc), fmt.Sprintf("encoding %q is not supported on this type", tt.enc), "%s %s", tt.typ, tt.enc)
+	}
+
+	typeLength := int32(4)
+	_, err := NewFixedByteArrayStore(parquet.Encoding_DELTA_LENGTH_BYTE_ARRAY, false, &ColumnParameters{TypeLength: &typeLength})
+	assert.Error(t, err)
+}
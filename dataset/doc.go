@@ -0,0 +1,66 @@
+/*
+Package dataset reads and writes directories of parquet files as a single table. It works in
+conjunction with the goparquet package.
+
+A dataset is a directory that contains parquet files, either directly or in Hive-style partition
+directories named key=value. The partition keys become columns of the dataset, and their values
+are added to the rows read from the files in the partition directories. Files and directories
+whose names start with "_" or "." are ignored, like _SUCCESS markers or _metadata files.
+
+	r, err := dataset.Open(dataset.DirFS("/data/events"), ".",
+		dataset.WithFilter(dataset.And(
+			dataset.Equal("date", "2020-05-01"),
+			dataset.Greater("duration", 100),
+		)),
+	)
+	if err != nil {
+		// ...
+	}
+	defer r.Close()
+
+	for {
+		row, err := r.NextRow()
+		if err == io.EOF {
+			break
+		}
+		// ...
+	}
+
+The files of a dataset don't need to have the same schema. They are read with a schema that
+combines the schemas of all files, so columns that were added to later files are null for rows
+from earlier files.
+
+A filter is first evaluated against the partition values, so that no data is read from files in
+partitions that can't contain matching rows. Only their footers are read to determine the schema
+of the dataset. Row groups are skipped based on the statistics of their column chunks, and the
+rows of the remaining row groups are filtered individually.
+
+To read the rows of a dataset into Go objects, use the floor package:
+
+	fr := floor.NewRowReader(r)
+	for fr.Next() {
+		var ev event
+		if err := fr.Scan(&ev); err != nil {
+			// ...
+		}
+	}
+
+Datasets are written with a Writer, which routes each row to the file of its partition:
+
+	w, err := dataset.NewWriter(dataset.DirFS("/data"), "events", sd, []string{"date"},
+		dataset.WithMaxFileSize(256*1024*1024),
+		dataset.WithFileWriterOptions(goparquet.WithMaxRowGroupSize(64*1024*1024)),
+	)
+	if err != nil {
+		// ...
+	}
+	for _, row := range rows {
+		if err := w.AddData(row); err != nil {
+			// ...
+		}
+	}
+	if err := w.Close(); err != nil {
+		// ...
+	}
+*/
+package dataset
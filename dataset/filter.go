@@ -0,0 +1,446 @@
+package dataset
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sagia-inneractive/parquet-go/parquet"
+	"github.com/sagia-inneractive/parquet-go/parquetschema"
+)
+
+// Predicate filters the rows of a dataset. Predicates are evaluated against the partition values
+// of files and the statistics of row groups first, so that partitions and row groups that can't
+// contain any matching rows are not read at all. The rows of the remaining row groups are
+// filtered individually. Comparisons with null values never match. Predicates are created using
+// functions like Equal, Less or And.
+type Predicate interface {
+	// mayMatch returns false if no value within the ranges returned by lookup matches the
+	// predicate. For single values, the result is exact.
+	mayMatch(lookup rangeLookup) bool
+	// check validates the predicate against the schema of the dataset.
+	check(sd *parquetschema.SchemaDefinition) error
+}
+
+// valueRange is the range of the non-null values of a column. min and max are nil if the column
+// only contains null values.
+type valueRange struct {
+	min, max interface{}
+}
+
+// rangeLookup returns the range of values of a column, or false if the range is unknown.
+type rangeLookup func(column string) (valueRange, bool)
+
+type compareOp int
+
+const (
+	opEqual compareOp = iota
+	opLess
+	opLessOrEqual
+	opGreater
+	opGreaterOrEqual
+)
+
+func (op compareOp) String() string {
+	switch op {
+	case opEqual:
+		return "="
+	case opLess:
+		return "<"
+	case opLessOrEqual:
+		return "<="
+	case opGreater:
+		return ">"
+	default:
+		return ">="
+	}
+}
+
+type comparison struct {
+	column string
+	op     compareOp
+	values []interface{}
+}
+
+// Equal returns a predicate that matches the rows where column has the provided value. Columns
+// are referred to using dotted notation. Values can be integers, floating point numbers, strings,
+// byte slices and booleans.
+func Equal(column string, value interface{}) Predicate {
+	return &comparison{column: column, op: opEqual, values: []interface{}{value}}
+}
+
+// In returns a predicate that matches the rows where column has one of the provided values.
+func In(column string, values ...interface{}) Predicate {
+	return &comparison{column: column, op: opEqual, values: values}
+}
+
+// Less returns a predicate that matches the rows where column is less than value.
+func Less(column string, value interface{}) Predicate {
+	return &comparison{column: column, op: opLess, values: []interface{}{value}}
+}
+
+// LessOrEqual returns a predicate that matches the rows where column is less than or equal to
+// value.
+func LessOrEqual(column string, value interface{}) Predicate {
+	return &comparison{column: column, op: opLessOrEqual, values: []interface{}{value}}
+}
+
+// Greater returns a predicate that matches the rows where column is greater than value.
+func Greater(column string, value interface{}) Predicate {
+	return &comparison{column: column, op: opGreater, values: []interface{}{value}}
+}
+
+// GreaterOrEqual returns a predicate that matches the rows where column is greater than or equal
+// to value.
+func GreaterOrEqual(column string, value interface{}) Predicate {
+	return &comparison{column: column, op: opGreaterOrEqual, values: []interface{}{value}}
+}
+
+func (c *comparison) mayMatch(lookup rangeLookup) bool {
+	r, ok := lookup(c.column)
+	if !ok {
+		return true
+	}
+	if r.min == nil || r.max == nil {
+		return false
+	}
+
+	for _, v := range c.values {
+		v = normalizeValue(v)
+		switch c.op {
+		case opEqual:
+			if compareValues(r.min, v) <= 0 && compareValues(r.max, v) >= 0 {
+				return true
+			}
+		case opLess:
+			if compareValues(r.min, v) < 0 {
+				return true
+			}
+		case opLessOrEqual:
+			if compareValues(r.min, v) <= 0 {
+				return true
+			}
+		case opGreater:
+			if compareValues(r.max, v) > 0 {
+				return true
+			}
+		case opGreaterOrEqual:
+			if compareValues(r.max, v) >= 0 {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (c *comparison) check(sd *parquetschema.SchemaDefinition) error {
+	elem, err := filterColumn(sd, c.column)
+	if err != nil {
+		return err
+	}
+
+	if len(c.values) == 0 {
+		return errors.Errorf("no values to compare column %q with", c.column)
+	}
+
+	for _, v := range c.values {
+		if !comparable(elem, normalizeValue(v)) {
+			return errors.Errorf("can't compare column %q of type %s with %T", c.column, elem.GetType(), v)
+		}
+	}
+
+	return nil
+}
+
+func (c *comparison) String() string {
+	if len(c.values) == 1 {
+		return fmt.Sprintf("%s %s %v", c.column, c.op, c.values[0])
+	}
+	return fmt.Sprintf("%s in %v", c.column, c.values)
+}
+
+type logical struct {
+	and   bool
+	preds []Predicate
+}
+
+// And returns a predicate that matches the rows that match all provided predicates.
+func And(preds ...Predicate) Predicate {
+	return &logical{and: true, preds: preds}
+}
+
+// Or returns a predicate that matches the rows that match at least one of the provided
+// predicates.
+func Or(preds ...Predicate) Predicate {
+	return &logical{preds: preds}
+}
+
+func (l *logical) mayMatch(lookup rangeLookup) bool {
+	for _, p := range l.preds {
+		if p.mayMatch(lookup) != l.and {
+			return !l.and
+		}
+	}
+
+	return l.and
+}
+
+func (l *logical) check(sd *parquetschema.SchemaDefinition) error {
+	if len(l.preds) == 0 {
+		return errors.New("empty list of predicates")
+	}
+
+	for _, p := range l.preds {
+		if err := p.check(sd); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// filterColumn returns the schema element of a column that is used in a predicate. Only primitive
+// columns that are not repeated and not within a repeated group can be used.
+func filterColumn(sd *parquetschema.SchemaDefinition, column string) (*parquet.SchemaElement, error) {
+	cols := sd.RootColumn.Children
+	parts := strings.Split(column, ".")
+	for i, name := range parts {
+		c := findColumn(cols, name)
+		if c == nil {
+			return nil, errors.Errorf("column %q doesn't exist", column)
+		}
+		if c.SchemaElement.GetRepetitionType() == parquet.FieldRepetitionType_REPEATED {
+			return nil, errors.Errorf("column %q is repeated and can't be filtered on", column)
+		}
+		if i == len(parts)-1 {
+			if c.SchemaElement.Type == nil {
+				return nil, errors.Errorf("column %q is a group and can't be filtered on", column)
+			}
+			return c.SchemaElement, nil
+		}
+		cols = c.Children
+	}
+
+	return nil, errors.Errorf("column %q doesn't exist", column)
+}
+
+// isUnsigned returns true if the column is annotated as an unsigned integer.
+func isUnsigned(elem *parquet.SchemaElement) bool {
+	if elem.LogicalType != nil && elem.LogicalType.INTEGER != nil {
+		return !elem.LogicalType.INTEGER.IsSigned
+	}
+
+	switch elem.GetConvertedType() {
+	case parquet.ConvertedType_UINT_8, parquet.ConvertedType_UINT_16, parquet.ConvertedType_UINT_32, parquet.ConvertedType_UINT_64:
+		return true
+	}
+
+	return false
+}
+
+// normalizeValue converts values to int64, float64, string or bool, so they can be compared.
+// Unsigned integers that don't fit into an int64 become a float64.
+func normalizeValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case int:
+		return int64(x)
+	case int8:
+		return int64(x)
+	case int16:
+		return int64(x)
+	case int32:
+		return int64(x)
+	case int64:
+		return x
+	case uint:
+		return normalizeUint(uint64(x))
+	case uint8:
+		return int64(x)
+	case uint16:
+		return int64(x)
+	case uint32:
+		return int64(x)
+	case uint64:
+		return normalizeUint(x)
+	case float32:
+		return float64(x)
+	case float64:
+		return x
+	case []byte:
+		return string(x)
+	case string:
+		return x
+	case bool:
+		return x
+	}
+
+	return nil
+}
+
+func normalizeUint(v uint64) interface{} {
+	if v > math.MaxInt64 {
+		return float64(v)
+	}
+
+	return int64(v)
+}
+
+// comparable returns true if values of the column can be compared with v.
+func comparable(elem *parquet.SchemaElement, v interface{}) bool {
+	switch v.(type) {
+	case int64, float64:
+		switch elem.GetType() {
+		case parquet.Type_INT32, parquet.Type_INT64, parquet.Type_FLOAT, parquet.Type_DOUBLE:
+			return true
+		}
+	case string:
+		switch elem.GetType() {
+		case parquet.Type_BYTE_ARRAY, parquet.Type_FIXED_LEN_BYTE_ARRAY:
+			return true
+		}
+	case bool:
+		return elem.GetType() == parquet.Type_BOOLEAN
+	}
+
+	return false
+}
+
+// compareValues compares two normalized values of comparable types.
+func compareValues(a, b interface{}) int {
+	switch x := a.(type) {
+	case int64:
+		switch y := b.(type) {
+		case int64:
+			return compareInt64(x, y)
+		case float64:
+			return compareFloat64(float64(x), y)
+		}
+	case float64:
+		switch y := b.(type) {
+		case int64:
+			return compareFloat64(x, float64(y))
+		case float64:
+			return compareFloat64(x, y)
+		}
+	case string:
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y)
+		}
+	case bool:
+		if y, ok := b.(bool); ok {
+			switch {
+			case x == y:
+				return 0
+			case y:
+				return -1
+			default:
+				return 1
+			}
+		}
+	}
+
+	// values of other types are checked before, so this is never reached
+	panic(fmt.Sprintf("can't compare %T with %T", a, b))
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareFloat64(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// statisticsRange returns the range of the values of a column chunk from its statistics. It
+// returns false if the column chunk has no usable statistics. The minimum and maximum values of
+// unsigned integer columns are not used, as they may have been computed using signed ordering.
+func statisticsRange(chunk *parquet.ColumnChunk, unsigned bool) (valueRange, bool) {
+	md := chunk.MetaData
+	if md == nil || md.Statistics == nil {
+		return valueRange{}, false
+	}
+
+	stats := md.Statistics
+	if stats.NullCount != nil && *stats.NullCount == md.NumValues {
+		return valueRange{}, true
+	}
+
+	if unsigned {
+		return valueRange{}, false
+	}
+
+	minValue, maxValue := stats.MinValue, stats.MaxValue
+	if minValue == nil || maxValue == nil {
+		// the deprecated min and max are only reliable for signed comparisons
+		if md.Type == parquet.Type_BYTE_ARRAY || md.Type == parquet.Type_FIXED_LEN_BYTE_ARRAY {
+			return valueRange{}, false
+		}
+		minValue, maxValue = stats.Min, stats.Max
+	}
+
+	min, ok := decodeStatistic(md.Type, minValue)
+	if !ok {
+		return valueRange{}, false
+	}
+	max, ok := decodeStatistic(md.Type, maxValue)
+	if !ok {
+		return valueRange{}, false
+	}
+
+	return valueRange{min: min, max: max}, true
+}
+
+// decodeStatistic decodes the plain encoded minimum or maximum value of a column chunk.
+func decodeStatistic(typ parquet.Type, b []byte) (interface{}, bool) {
+	switch typ {
+	case parquet.Type_BOOLEAN:
+		if len(b) != 1 {
+			return nil, false
+		}
+		return b[0] != 0, true
+	case parquet.Type_INT32:
+		if len(b) != 4 {
+			return nil, false
+		}
+		return int64(int32(binary.LittleEndian.Uint32(b))), true
+	case parquet.Type_INT64:
+		if len(b) != 8 {
+			return nil, false
+		}
+		return int64(binary.LittleEndian.Uint64(b)), true
+	case parquet.Type_FLOAT:
+		if len(b) != 4 {
+			return nil, false
+		}
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b))), true
+	case parquet.Type_DOUBLE:
+		if len(b) != 8 {
+			return nil, false
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b)), true
+	case parquet.Type_BYTE_ARRAY, parquet.Type_FIXED_LEN_BYTE_ARRAY:
+		if b == nil {
+			return nil, false
+		}
+		return string(b), true
+	}
+
+	return nil, false
+}
@@ -0,0 +1,145 @@
+package dataset
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/sagia-inneractive/parquet-go/parquet"
+	"github.com/sagia-inneractive/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPredicateMayMatch(t *testing.T) {
+	ranges := map[string]valueRange{
+		"i":    {min: int64(10), max: int64(20)},
+		"f":    {min: 1.5, max: 2.5},
+		"s":    {min: "b", max: "d"},
+		"b":    {min: true, max: true},
+		"null": {},
+	}
+	lookup := func(column string) (valueRange, bool) {
+		r, ok := ranges[column]
+		return r, ok
+	}
+
+	tests := []struct {
+		pred     Predicate
+		expected bool
+	}{
+		{Equal("i", 10), true},
+		{Equal("i", int32(21)), false},
+		{Equal("i", 15.5), true},
+		{In("i", 1, 2, uint64(20)), true},
+		{In("i", 1, 2, 3), false},
+		{Less("i", 10), false},
+		{LessOrEqual("i", 10), true},
+		{Greater("i", 20), false},
+		{GreaterOrEqual("i", uint8(20)), true},
+		{Greater("i", uint64(math.MaxUint64)), false},
+		{Less("f", 2), true},
+		{Greater("f", float32(2.5)), false},
+		{Equal("s", "c"), true},
+		{Equal("s", []byte("a")), false},
+		{Greater("s", "d"), false},
+		{Greater("s", "cz"), true},
+		{Equal("b", false), false},
+		{Equal("b", true), true},
+		{Equal("null", 1), false},
+		{Less("null", 1), false},
+		{Equal("unknown", 1), true},
+		{And(Equal("i", 10), Equal("s", "b")), true},
+		{And(Equal("i", 10), Equal("s", "a")), false},
+		{Or(Equal("i", 9), Equal("s", "a")), false},
+		{Or(Equal("i", 9), Equal("unknown", "a")), true},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, tt.pred.mayMatch(lookup), "%v", tt.pred)
+	}
+}
+
+func TestPredicateCheck(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(`message msg {
+  required int32 a;
+  optional binary b (STRING);
+  optional boolean c;
+  optional int96 d;
+  repeated int64 e;
+  optional group f {
+    required double g;
+    repeated group h {
+      required int64 i;
+    }
+  }
+}`)
+	require.NoError(t, err)
+
+	valid := []Predicate{
+		Equal("a", 1),
+		Less("a", 1.5),
+		In("b", "x", []byte("y")),
+		Equal("c", true),
+		Greater("f.g", 1),
+		And(Equal("a", 1), Or(Equal("b", "x"), Equal("c", false))),
+	}
+	for _, p := range valid {
+		require.NoError(t, p.check(sd), "%v", p)
+	}
+
+	invalid := []Predicate{
+		Equal("x", 1),
+		Equal("a", "1"),
+		Equal("b", 1),
+		Equal("c", 1),
+		Equal("d", 1),
+		Equal("e", 1),
+		Equal("f", 1),
+		Equal("f.h.i", 1),
+		Equal("a", struct{}{}),
+		In("a"),
+		And(),
+		Or(Equal("a", 1), Equal("x", 1)),
+	}
+	for _, p := range invalid {
+		require.Error(t, p.check(sd), "%v", p)
+	}
+}
+
+func TestStatisticsRange(t *testing.T) {
+	int32Value := func(v int32) []byte {
+		b := make([]byte, 4)
+		binary.LittleEndian.PutUint32(b, uint32(v))
+		return b
+	}
+	nullCount := func(n int64) *int64 {
+		return &n
+	}
+
+	tests := []struct {
+		typ      parquet.Type
+		unsigned bool
+		stats    *parquet.Statistics
+		expected valueRange
+		ok       bool
+	}{
+		{parquet.Type_INT32, false, nil, valueRange{}, false},
+		{parquet.Type_INT32, false, &parquet.Statistics{MinValue: int32Value(-5), MaxValue: int32Value(7)}, valueRange{int64(-5), int64(7)}, true},
+		{parquet.Type_INT32, false, &parquet.Statistics{Min: int32Value(-5), Max: int32Value(7)}, valueRange{int64(-5), int64(7)}, true},
+		{parquet.Type_INT32, true, &parquet.Statistics{MinValue: int32Value(-5), MaxValue: int32Value(7)}, valueRange{}, false},
+		{parquet.Type_INT32, false, &parquet.Statistics{MinValue: []byte{1}, MaxValue: int32Value(7)}, valueRange{}, false},
+		{parquet.Type_INT32, false, &parquet.Statistics{NullCount: nullCount(10)}, valueRange{}, true},
+		{parquet.Type_INT32, false, &parquet.Statistics{NullCount: nullCount(3)}, valueRange{}, false},
+		{parquet.Type_BYTE_ARRAY, false, &parquet.Statistics{MinValue: []byte("a"), MaxValue: []byte("z")}, valueRange{"a", "z"}, true},
+		{parquet.Type_BYTE_ARRAY, false, &parquet.Statistics{Min: []byte("a"), Max: []byte("z")}, valueRange{}, false},
+		{parquet.Type_BOOLEAN, false, &parquet.Statistics{MinValue: []byte{0}, MaxValue: []byte{1}}, valueRange{false, true}, true},
+		{parquet.Type_INT96, false, &parquet.Statistics{MinValue: make([]byte, 12), MaxValue: make([]byte, 12)}, valueRange{}, false},
+	}
+
+	for i, tt := range tests {
+		chunk := &parquet.ColumnChunk{MetaData: &parquet.ColumnMetaData{Type: tt.typ, NumValues: 10, Statistics: tt.stats}}
+		r, ok := statisticsRange(chunk, tt.unsigned)
+		require.Equal(t, tt.ok, ok, "%d", i)
+		require.Equal(t, tt.expected, r, "%d", i)
+	}
+}
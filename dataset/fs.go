@@ -0,0 +1,60 @@
+package dataset
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// File is a file of a dataset.
+type File interface {
+	io.ReadSeeker
+	io.Closer
+}
+
+// FileSystem is the file system a dataset is stored in. Names are slash-separated paths
+// relative to the root of the file system, like in the io/fs package of newer Go versions,
+// with "." being the root itself.
+type FileSystem interface {
+	// Open opens the file with the provided name for reading.
+	Open(name string) (File, error)
+	// ReadDir returns the entries of the directory with the provided name.
+	ReadDir(name string) ([]os.FileInfo, error)
+}
+
+// WritableFileSystem is a FileSystem that datasets can be written to.
+type WritableFileSystem interface {
+	FileSystem
+	// Create creates or truncates the file with the provided name for writing. Missing parent
+	// directories are created.
+	Create(name string) (io.WriteCloser, error)
+}
+
+// DirFS returns a FileSystem for the directory tree rooted at dir of the local file system.
+func DirFS(dir string) WritableFileSystem {
+	return dirFS(dir)
+}
+
+type dirFS string
+
+func (d dirFS) Open(name string) (File, error) {
+	return os.Open(d.join(name))
+}
+
+func (d dirFS) ReadDir(name string) ([]os.FileInfo, error) {
+	return ioutil.ReadDir(d.join(name))
+}
+
+func (d dirFS) Create(name string) (io.WriteCloser, error) {
+	p := d.join(name)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return nil, err
+	}
+	return os.Create(p)
+}
+
+func (d dirFS) join(name string) string {
+	return filepath.Join(string(d), filepath.FromSlash(path.Clean(name)))
+}
@@ -0,0 +1,177 @@
+package dataset
+
+import (
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sagia-inneractive/parquet-go/parquet"
+	"github.com/sagia-inneractive/parquet-go/parquetschema"
+)
+
+// nullPartition is the directory name Hive uses for null partition values.
+const nullPartition = "__HIVE_DEFAULT_PARTITION__"
+
+// dataFile is a file of a dataset together with the raw values of its partition columns.
+type dataFile struct {
+	name string
+	// values contains the unescaped partition values in the order of the partition columns, nil
+	// for null values.
+	values []*string
+	// partition contains the typed partition values by column, null values are missing.
+	partition map[string]interface{}
+}
+
+// partitionColumn is a column that is derived from the key=value directory names.
+type partitionColumn struct {
+	name string
+	// numeric is true if all values of the column are integers.
+	numeric bool
+}
+
+// discoverFiles returns all data files in dir and its subdirectories. Files and directories
+// whose names start with "_" or "." are ignored, like _SUCCESS, _metadata or checksum files.
+func discoverFiles(fsys FileSystem, dir string) ([]*dataFile, []string, error) {
+	var (
+		files []*dataFile
+		keys  []string
+		found bool
+	)
+
+	var walk func(dir string, keysSoFar []string, values []*string) error
+	walk = func(dir string, keysSoFar []string, values []*string) error {
+		entries, err := fsys.ReadDir(dir)
+		if err != nil {
+			return errors.Wrapf(err, "reading directory %q failed", dir)
+		}
+
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].Name() < entries[j].Name()
+		})
+
+		for _, e := range entries {
+			name := e.Name()
+			if strings.HasPrefix(name, "_") || strings.HasPrefix(name, ".") {
+				continue
+			}
+
+			if e.IsDir() {
+				idx := strings.Index(name, "=")
+				if idx <= 0 {
+					return errors.Errorf("directory %q is not a partition directory of the form key=value", path.Join(dir, name))
+				}
+				value, err := unescapePartitionValue(name[idx+1:])
+				if err != nil {
+					return errors.Wrapf(err, "invalid partition directory %q", path.Join(dir, name))
+				}
+
+				k := append(append([]string(nil), keysSoFar...), name[:idx])
+				v := append(append([]*string(nil), values...), value)
+				if err := walk(path.Join(dir, name), k, v); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if !found {
+				keys, found = keysSoFar, true
+			} else if strings.Join(keys, "/") != strings.Join(keysSoFar, "/") {
+				return errors.Errorf("file %q is partitioned by %v, but other files are partitioned by %v", path.Join(dir, name), keysSoFar, keys)
+			}
+
+			files = append(files, &dataFile{name: path.Join(dir, name), values: values})
+		}
+
+		return nil
+	}
+
+	if err := walk(dir, nil, nil); err != nil {
+		return nil, nil, err
+	}
+
+	seen := make(map[string]bool)
+	for _, k := range keys {
+		if seen[k] {
+			return nil, nil, errors.Errorf("partition column %q is used more than once", k)
+		}
+		seen[k] = true
+	}
+
+	return files, keys, nil
+}
+
+func unescapePartitionValue(s string) (*string, error) {
+	if s == nullPartition {
+		return nil, nil
+	}
+
+	v, err := url.PathUnescape(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v, nil
+}
+
+// partitionColumns returns the partition columns with their types, and sets the typed partition
+// values of all files. A partition column is an INT64 column if all its values are integers, and
+// a STRING column otherwise.
+func partitionColumns(files []*dataFile, keys []string) []*partitionColumn {
+	cols := make([]*partitionColumn, len(keys))
+	for i, k := range keys {
+		numeric := true
+		for _, f := range files {
+			if v := f.values[i]; v != nil {
+				if _, err := strconv.ParseInt(*v, 10, 64); err != nil {
+					numeric = false
+					break
+				}
+			}
+		}
+		cols[i] = &partitionColumn{name: k, numeric: numeric}
+	}
+
+	for _, f := range files {
+		f.partition = make(map[string]interface{}, len(keys))
+		for i, c := range cols {
+			v := f.values[i]
+			if v == nil {
+				continue
+			}
+			if c.numeric {
+				n, _ := strconv.ParseInt(*v, 10, 64)
+				f.partition[c.name] = n
+			} else {
+				f.partition[c.name] = []byte(*v)
+			}
+		}
+	}
+
+	return cols
+}
+
+// columnDefinition returns the schema definition of the partition column. Partition columns are
+// optional, as their values can be null.
+func (c *partitionColumn) columnDefinition() *parquetschema.ColumnDefinition {
+	rep := parquet.FieldRepetitionType_OPTIONAL
+	elem := &parquet.SchemaElement{
+		Name:           c.name,
+		RepetitionType: &rep,
+	}
+
+	if c.numeric {
+		typ := parquet.Type_INT64
+		elem.Type = &typ
+	} else {
+		typ := parquet.Type_BYTE_ARRAY
+		conv := parquet.ConvertedType_UTF8
+		elem.Type = &typ
+		elem.ConvertedType = &conv
+		elem.LogicalType = &parquet.LogicalType{STRING: &parquet.StringType{}}
+	}
+
+	return &parquetschema.ColumnDefinition{SchemaElement: elem}
+}
@@ -0,0 +1,319 @@
+package dataset
+
+import (
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	goparquet "github.com/sagia-inneractive/parquet-go"
+	"github.com/sagia-inneractive/parquet-go/parquet"
+	"github.com/sagia-inneractive/parquet-go/parquetschema"
+)
+
+// ReaderOption is an option to configure a Reader.
+type ReaderOption func(*Reader)
+
+// WithFilter sets the predicate that the rows returned by the reader must match. Partitions and
+// row groups that can't contain matching rows are skipped without reading their data.
+func WithFilter(pred Predicate) ReaderOption {
+	return func(r *Reader) {
+		r.filter = pred
+	}
+}
+
+// WithSchema sets the schema the files of the dataset are read with, instead of the schema
+// that is unified from the schemas of all files. It must not contain the partition columns,
+// they are added to it. All files must be compatible with the schema, see
+// goparquet.WithRequestedSchema.
+func WithSchema(sd *parquetschema.SchemaDefinition) ReaderOption {
+	return func(r *Reader) {
+		r.schema = sd
+	}
+}
+
+// Reader reads the rows of all parquet files of a dataset, one file after another. The values of
+// the partition columns are added to the rows.
+type Reader struct {
+	fsys     FileSystem
+	files    []*dataFile
+	partCols []*partitionColumn
+	filter   Predicate
+
+	schema   *parquetschema.SchemaDefinition
+	full     *parquetschema.SchemaDefinition
+	unsigned map[string]bool
+
+	next     int
+	file     File
+	cur      *goparquet.FileReader
+	curData  *dataFile
+	rowGroup int
+	rowsLeft int64
+}
+
+// Open opens the dataset in directory dir of the file system. All files in dir and its
+// subdirectories belong to the dataset, except files whose names start with "_" or ".". The
+// subdirectories must be Hive-style partition directories named key=value, and their keys
+// become columns of the dataset. Partition columns are INT64 columns if all their values are
+// integers, and STRING columns otherwise.
+//
+// Unless WithSchema is used, the files are read with a schema that combines the schemas of all
+// files. Columns that don't exist in all files are optional, and INT32 and FLOAT columns become
+// INT64 and DOUBLE columns if other files store them with the wider type. Open fails if the
+// schemas of the files are incompatible.
+func Open(fsys FileSystem, dir string, opts ...ReaderOption) (*Reader, error) {
+	r := &Reader{
+		fsys:     fsys,
+		unsigned: make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	files, keys, err := discoverFiles(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, errors.Errorf("no files found in %q", dir)
+	}
+	r.partCols = partitionColumns(files, keys)
+
+	for _, f := range files {
+		if r.filter == nil || r.filter.mayMatch(r.partitionLookup(f, nil)) {
+			r.files = append(r.files, f)
+		}
+	}
+
+	if r.schema == nil {
+		// the schemas of all files are unified, so that the schema doesn't depend on the filter
+		schemas := make([]*parquetschema.SchemaDefinition, 0, len(files))
+		for _, f := range files {
+			sd, err := r.readSchema(f)
+			if err != nil {
+				return nil, err
+			}
+			schemas = append(schemas, sd)
+		}
+
+		if r.schema, err = unifySchemas(schemas); err != nil {
+			return nil, err
+		}
+	}
+
+	full := copyColumn(r.schema.RootColumn)
+	for _, c := range r.partCols {
+		if findColumn(full.Children, c.name) != nil {
+			return nil, errors.Errorf("partition column %q also exists in the files", c.name)
+		}
+		full.Children = append(full.Children, c.columnDefinition())
+	}
+	r.full = parquetschema.SchemaDefinitionFromColumnDefinition(full)
+
+	if r.filter != nil {
+		if err := r.filter.check(r.full); err != nil {
+			return nil, errors.Wrap(err, "invalid filter")
+		}
+	}
+
+	return r, nil
+}
+
+func (r *Reader) readSchema(f *dataFile) (*parquetschema.SchemaDefinition, error) {
+	file, err := r.fsys.Open(f.name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening file %q failed", f.name)
+	}
+	defer file.Close()
+
+	fr, err := goparquet.NewFileReader(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading file %q failed", f.name)
+	}
+
+	return fr.GetSchemaDefinition(), nil
+}
+
+// GetSchemaDefinition returns the schema of the rows returned by the reader, including the
+// partition columns.
+func (r *Reader) GetSchemaDefinition() *parquetschema.SchemaDefinition {
+	return r.full
+}
+
+// Files returns the names of the files that are read, after pruning the partitions that don't
+// match the filter.
+func (r *Reader) Files() []string {
+	names := make([]string, len(r.files))
+	for i, f := range r.files {
+		names[i] = f.name
+	}
+	return names
+}
+
+// NextRow returns the next row of the dataset. It returns io.EOF after the last row of the last
+// file. To read the rows with the floor package, use floor.NewRowReader.
+func (r *Reader) NextRow() (map[string]interface{}, error) {
+	for {
+		if r.cur == nil {
+			if r.next >= len(r.files) {
+				return nil, io.EOF
+			}
+			if err := r.openFile(r.files[r.next]); err != nil {
+				return nil, err
+			}
+			r.next++
+		}
+
+		if r.rowsLeft == 0 {
+			ok, err := r.nextRowGroup()
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				if err := r.closeFile(); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+
+		row, err := r.cur.NextRow()
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading file %q failed", r.curData.name)
+		}
+		r.rowsLeft--
+
+		for k, v := range r.curData.partition {
+			row[k] = v
+		}
+
+		if r.filter != nil && !r.filter.mayMatch(r.rowLookup(row)) {
+			continue
+		}
+
+		return row, nil
+	}
+}
+
+func (r *Reader) openFile(f *dataFile) error {
+	file, err := r.fsys.Open(f.name)
+	if err != nil {
+		return errors.Wrapf(err, "opening file %q failed", f.name)
+	}
+
+	fr, err := goparquet.NewFileReaderWithOptions(file, goparquet.WithRequestedSchema(r.schema))
+	if err != nil {
+		file.Close()
+		return errors.Wrapf(err, "reading file %q failed", f.name)
+	}
+
+	r.file, r.cur, r.curData = file, fr, f
+	r.rowGroup, r.rowsLeft = 0, 0
+	return nil
+}
+
+// nextRowGroup moves the reader of the current file to the next row group that may contain
+// matching rows. It returns false if there is no such row group.
+func (r *Reader) nextRowGroup() (bool, error) {
+	rowGroups := r.cur.RowGroups()
+	for r.rowGroup < len(rowGroups) {
+		idx := r.rowGroup
+		rg := rowGroups[idx]
+		r.rowGroup++
+
+		if rg.NumRows == 0 || (r.filter != nil && !r.filter.mayMatch(r.partitionLookup(r.curData, rg))) {
+			continue
+		}
+
+		if err := r.cur.SeekToRowGroup(idx); err != nil {
+			return false, errors.Wrapf(err, "reading file %q failed", r.curData.name)
+		}
+		r.rowsLeft = rg.NumRows
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (r *Reader) closeFile() error {
+	if r.file == nil {
+		return nil
+	}
+
+	err := r.cur.Close()
+	if cerr := r.file.Close(); err == nil {
+		err = cerr
+	}
+	r.file, r.cur, r.curData = nil, nil, nil
+	return err
+}
+
+// Close closes the file that is currently read.
+func (r *Reader) Close() error {
+	r.next = len(r.files)
+	return r.closeFile()
+}
+
+// partitionLookup returns the value ranges of the partition columns of a file, and if rg is not
+// nil, the value ranges of the other columns from the statistics of the row group. Columns that
+// don't exist in the row group only contain null values.
+func (r *Reader) partitionLookup(f *dataFile, rg *parquet.RowGroup) rangeLookup {
+	return func(column string) (valueRange, bool) {
+		for _, c := range r.partCols {
+			if c.name != column {
+				continue
+			}
+			v, ok := f.partition[column]
+			if !ok {
+				return valueRange{}, true
+			}
+			v = normalizeValue(v)
+			return valueRange{min: v, max: v}, true
+		}
+
+		if rg == nil {
+			return valueRange{}, false
+		}
+
+		for _, chunk := range rg.Columns {
+			if chunk.MetaData != nil && strings.Join(chunk.MetaData.PathInSchema, ".") == column {
+				return statisticsRange(chunk, r.isUnsigned(column))
+			}
+		}
+
+		return valueRange{}, true
+	}
+}
+
+func (r *Reader) isUnsigned(column string) bool {
+	unsigned, ok := r.unsigned[column]
+	if !ok {
+		if elem, err := filterColumn(r.full, column); err == nil {
+			unsigned = isUnsigned(elem)
+		}
+		r.unsigned[column] = unsigned
+	}
+	return unsigned
+}
+
+// rowLookup returns the values of a row as single value ranges.
+func (r *Reader) rowLookup(row map[string]interface{}) rangeLookup {
+	return func(column string) (valueRange, bool) {
+		var v interface{} = row
+		for _, name := range strings.Split(column, ".") {
+			group, ok := v.(map[string]interface{})
+			if !ok {
+				return valueRange{}, true
+			}
+			if v, ok = group[name]; !ok {
+				return valueRange{}, true
+			}
+		}
+
+		v = normalizeValue(v)
+		if v == nil {
+			return valueRange{}, false
+		}
+		return valueRange{min: v, max: v}, true
+	}
+}
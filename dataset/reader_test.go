@@ -0,0 +1,373 @@
+package dataset
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	goparquet "github.com/sagia-inneractive/parquet-go"
+	"github.com/sagia-inneractive/parquet-go/floor"
+	"github.com/sagia-inneractive/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+// memFS is an in-memory WritableFileSystem. Files are only visible after they have been closed. Directories are implied by the names of the files.
+type memFS map[string][]byte
+
+type memFile struct {
+	*bytes.Reader
+}
+
+func (memFile) Close() error {
+	return nil
+}
+
+type memFileInfo struct {
+	name string
+	dir  bool
+}
+
+func (fi memFileInfo) Name() string       { return fi.name }
+func (fi memFileInfo) Size() int64        { return 0 }
+func (fi memFileInfo) Mode() os.FileMode  { return 0 }
+func (fi memFileInfo) ModTime() time.Time { return time.Time{} }
+func (fi memFileInfo) IsDir() bool        { return fi.dir }
+func (fi memFileInfo) Sys() interface{}   { return nil }
+
+func (m memFS) Open(name string) (File, error) {
+	data, ok := m[name]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return memFile{bytes.NewReader(data)}, nil
+}
+
+type memWriter struct {
+	bytes.Buffer
+	fsys memFS
+	name string
+}
+
+func (w *memWriter) Close() error {
+	w.fsys[w.name] = w.Bytes()
+	return nil
+}
+
+func (m memFS) Create(name string) (io.WriteCloser, error) {
+	return &memWriter{fsys: m, name: name}, nil
+}
+
+func (m memFS) ReadDir(name string) ([]os.FileInfo, error) {
+	prefix := ""
+	if name != "." {
+		prefix = name + "/"
+	}
+
+	seen := make(map[string]bool)
+	var entries []os.FileInfo
+	for file := range m {
+		if !strings.HasPrefix(file, prefix) {
+			continue
+		}
+		rest := file[len(prefix):]
+		idx := strings.Index(rest, "/")
+		entry := memFileInfo{name: rest}
+		if idx >= 0 {
+			entry = memFileInfo{name: rest[:idx], dir: true}
+		}
+		if !seen[entry.name] {
+			seen[entry.name] = true
+			entries = append(entries, entry)
+		}
+	}
+
+	if len(entries) == 0 {
+		return nil, os.ErrNotExist
+	}
+	return entries, nil
+}
+
+func writeFile(t *testing.T, schema string, rowsPerGroup int, rows ...map[string]interface{}) []byte {
+	sd, err := parquetschema.ParseSchemaDefinition(schema)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	w := goparquet.NewFileWriter(buf, goparquet.WithSchemaDefinition(sd))
+	for i, row := range rows {
+		require.NoError(t, w.AddData(row))
+		if (i+1)%rowsPerGroup == 0 {
+			require.NoError(t, w.FlushRowGroup())
+		}
+	}
+	require.NoError(t, w.Close())
+
+	return buf.Bytes()
+}
+
+func readAll(t *testing.T, r *Reader) []map[string]interface{} {
+	var rows []map[string]interface{}
+	for {
+		row, err := r.NextRow()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func ids(rows []map[string]interface{}) []int64 {
+	var ret []int64
+	for _, row := range rows {
+		ret = append(ret, normalizeValue(row["id"]).(int64))
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i] < ret[j] })
+	return ret
+}
+
+const (
+	schemaV1 = `message event {
+  required int32 id;
+  optional binary name (STRING);
+}`
+	schemaV2 = `message event {
+  required int64 id;
+  optional binary name (STRING);
+  optional double score;
+}`
+)
+
+func testDataset(t *testing.T) memFS {
+	return memFS{
+		"events/day=1/country=de/part-0.parquet": writeFile(t, schemaV1, 2,
+			map[string]interface{}{"id": int32(1), "name": []byte("a")},
+			map[string]interface{}{"id": int32(2)},
+			map[string]interface{}{"id": int32(3), "name": []byte("c")},
+		),
+		"events/day=1/country=us/part-0.parquet": writeFile(t, schemaV1, 10,
+			map[string]interface{}{"id": int32(4), "name": []byte("d")},
+		),
+		"events/day=2/country=new%20zealand/part-0.parquet": writeFile(t, schemaV2, 2,
+			map[string]interface{}{"id": int64(5), "score": 1.5},
+			map[string]interface{}{"id": int64(6), "name": []byte("f"), "score": 2.5},
+			map[string]interface{}{"id": int64(7), "score": 3.5},
+			map[string]interface{}{"id": int64(8), "score": 4.5},
+		),
+		"events/day=2/country=__HIVE_DEFAULT_PARTITION__/part-0.parquet": writeFile(t, schemaV2, 10,
+			map[string]interface{}{"id": int64(9)},
+		),
+		"events/_SUCCESS":                         nil,
+		"events/day=1/country=de/.part-0.crc":     []byte("garbage"),
+		"events/day=2/_temporary/part-1.parquet":  []byte("garbage"),
+		"events/day=2/country=us/_part-2.parquet": []byte("garbage"),
+	}
+}
+
+func TestOpen(t *testing.T) {
+	r, err := Open(testDataset(t), "events")
+	require.NoError(t, err)
+	defer r.Close()
+
+	require.Equal(t, `message event {
+  required int64 id;
+  optional binary name (STRING);
+  optional double score;
+  optional int64 day;
+  optional binary country (STRING);
+}
+`, r.GetSchemaDefinition().String())
+
+	require.Equal(t, []string{
+		"events/day=1/country=de/part-0.parquet",
+		"events/day=1/country=us/part-0.parquet",
+		"events/day=2/country=__HIVE_DEFAULT_PARTITION__/part-0.parquet",
+		"events/day=2/country=new%20zealand/part-0.parquet",
+	}, r.Files())
+
+	require.Equal(t, []map[string]interface{}{
+		{"id": int64(1), "name": []byte("a"), "day": int64(1), "country": []byte("de")},
+		{"id": int64(2), "day": int64(1), "country": []byte("de")},
+		{"id": int64(3), "name": []byte("c"), "day": int64(1), "country": []byte("de")},
+		{"id": int64(4), "name": []byte("d"), "day": int64(1), "country": []byte("us")},
+		{"id": int64(9), "day": int64(2)},
+		{"id": int64(5), "score": 1.5, "day": int64(2), "country": []byte("new zealand")},
+		{"id": int64(6), "name": []byte("f"), "score": 2.5, "day": int64(2), "country": []byte("new zealand")},
+		{"id": int64(7), "score": 3.5, "day": int64(2), "country": []byte("new zealand")},
+		{"id": int64(8), "score": 4.5, "day": int64(2), "country": []byte("new zealand")},
+	}, readAll(t, r))
+
+	_, err = r.NextRow()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestOpenWithFilter(t *testing.T) {
+	tests := []struct {
+		name  string
+		pred  Predicate
+		files int
+		ids   []int64
+	}{
+		{"partition", Equal("country", "us"), 1, []int64{4}},
+		{"partition in", In("country", "de", []byte("new zealand")), 2, []int64{1, 2, 3, 5, 6, 7, 8}},
+		{"partition and column", And(Equal("day", 2), GreaterOrEqual("id", 7)), 2, []int64{7, 8, 9}},
+		{"null partition", Less("country", "zz"), 3, []int64{1, 2, 3, 4, 5, 6, 7, 8}},
+		{"or", Or(Equal("day", 1), Less("score", 2)), 4, []int64{1, 2, 3, 4, 5}},
+		{"string column", Equal("name", "f"), 4, []int64{6}},
+		{"column missing in some files", Greater("score", 0.0), 4, []int64{5, 6, 7, 8}},
+		{"nothing", Equal("day", 3), 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Open(testDataset(t), "events", WithFilter(tt.pred))
+			require.NoError(t, err)
+			require.Len(t, r.Files(), tt.files)
+			require.Equal(t, tt.ids, ids(readAll(t, r)))
+			require.NoError(t, r.Close())
+		})
+	}
+}
+
+func TestOpenSkipsRowGroups(t *testing.T) {
+	fsys := testDataset(t)
+	// corrupt the data of the first row group, which can't contain matching rows
+	data := fsys["events/day=1/country=de/part-0.parquet"]
+	data = append([]byte(nil), data...)
+	for i := 4; i < 40; i++ {
+		data[i] = 0xff
+	}
+	fsys["events/day=1/country=de/part-0.parquet"] = data
+
+	r, err := Open(fsys, "events", WithFilter(And(Equal("country", "de"), Equal("id", 3))))
+	require.NoError(t, err)
+	require.Equal(t, []int64{3}, ids(readAll(t, r)))
+
+	r, err = Open(fsys, "events", WithFilter(Equal("country", "de")))
+	require.NoError(t, err)
+	_, err = r.NextRow()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "events/day=1/country=de/part-0.parquet")
+}
+
+func TestOpenWithSchema(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(`message event {
+  required int64 id;
+}`)
+	require.NoError(t, err)
+
+	r, err := Open(testDataset(t), "events", WithSchema(sd), WithFilter(Equal("day", 1)))
+	require.NoError(t, err)
+	require.Equal(t, []map[string]interface{}{
+		{"id": int64(1), "day": int64(1), "country": []byte("de")},
+		{"id": int64(2), "day": int64(1), "country": []byte("de")},
+		{"id": int64(3), "day": int64(1), "country": []byte("de")},
+		{"id": int64(4), "day": int64(1), "country": []byte("us")},
+	}, readAll(t, r))
+}
+
+func TestOpenErrors(t *testing.T) {
+	v1 := writeFile(t, schemaV1, 10, map[string]interface{}{"id": int32(1)})
+	incompatible := writeFile(t, `message event {
+  required binary id;
+  repeated binary name (STRING);
+}`, 10, map[string]interface{}{"id": []byte("1")})
+	conflict := writeFile(t, `message event {
+  required int64 day;
+}`, 10, map[string]interface{}{"day": int64(1)})
+
+	tests := []struct {
+		name string
+		fsys memFS
+		opts []ReaderOption
+		err  string
+	}{
+		{"empty", memFS{"data/_SUCCESS": nil}, nil, "no files found"},
+		{"missing directory", memFS{}, nil, "reading directory"},
+		{"no partition directory", memFS{"data/sub/a.parquet": v1}, nil, "not a partition directory"},
+		{"inconsistent partitions", memFS{"data/a=1/x.parquet": v1, "data/b=1/x.parquet": v1}, nil, "partitioned by"},
+		{"duplicate partition", memFS{"data/a=1/a=2/x.parquet": v1}, nil, "used more than once"},
+		{"incompatible schemas", memFS{"data/a.parquet": v1, "data/b.parquet": incompatible}, nil, "id: column is INT32 in one file, but BYTE_ARRAY in another; name: column is optional in one file, but repeated in another"},
+		{"partition conflict", memFS{"data/day=1/a.parquet": conflict}, nil, "partition column \"day\" also exists"},
+		{"not a parquet file", memFS{"data/a.parquet": []byte("garbage")}, nil, "data/a.parquet"},
+		{"unknown filter column", memFS{"data/a.parquet": v1}, []ReaderOption{WithFilter(Equal("foo", 1))}, "column \"foo\" doesn't exist"},
+		{"invalid filter value", memFS{"data/a.parquet": v1}, []ReaderOption{WithFilter(Equal("id", "1"))}, "can't compare column \"id\""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Open(tt.fsys, "data", tt.opts...)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestDirFS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dataset")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for name, data := range testDataset(t) {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+		require.NoError(t, ioutil.WriteFile(file, data, 0644))
+	}
+
+	r, err := Open(DirFS(dir), path.Join("events", "day=1"))
+	require.NoError(t, err)
+	defer r.Close()
+	require.Equal(t, []int64{1, 2, 3, 4}, ids(readAll(t, r)))
+
+	sd, err := parquetschema.ParseSchemaDefinition(writerSchema)
+	require.NoError(t, err)
+	w, err := NewWriter(DirFS(dir), "written", sd, []string{"country"})
+	require.NoError(t, err)
+	for _, row := range writerRows() {
+		require.NoError(t, w.AddData(row))
+	}
+	require.NoError(t, w.Close())
+	require.FileExists(t, filepath.Join(dir, "written", "_SUCCESS"))
+
+	r, err = Open(DirFS(dir), "written")
+	require.NoError(t, err)
+	defer r.Close()
+	require.Len(t, readAll(t, r), 30)
+}
+
+func TestFloorRowReader(t *testing.T) {
+	r, err := Open(testDataset(t), "events", WithFilter(Equal("day", 2)))
+	require.NoError(t, err)
+	defer r.Close()
+
+	type event struct {
+		ID      int64   `parquet:"id"`
+		Score   float64 `parquet:"score"`
+		Day     int64   `parquet:"day"`
+		Country string  `parquet:"country"`
+	}
+
+	var events []event
+	fr := floor.NewRowReader(r)
+	for fr.Next() {
+		var ev event
+		require.NoError(t, fr.Scan(&ev))
+		events = append(events, ev)
+	}
+	require.NoError(t, fr.Err())
+
+	require.Equal(t, []event{
+		{ID: 9, Day: 2},
+		{ID: 5, Score: 1.5, Day: 2, Country: "new zealand"},
+		{ID: 6, Score: 2.5, Day: 2, Country: "new zealand"},
+		{ID: 7, Score: 3.5, Day: 2, Country: "new zealand"},
+		{ID: 8, Score: 4.5, Day: 2, Country: "new zealand"},
+	}, events)
+}
@@ -0,0 +1,143 @@
+package dataset
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sagia-inneractive/parquet-go/parquet"
+	"github.com/sagia-inneractive/parquet-go/parquetschema"
+)
+
+// unifySchemas returns a schema that all provided schemas can be read with. It contains all
+// columns of all schemas. Columns that are required in some schemas but optional or missing in
+// others become optional, INT32 and FLOAT columns become INT64 and DOUBLE columns if other
+// schemas contain them with the wider type. All conflicts are reported in a single error.
+func unifySchemas(schemas []*parquetschema.SchemaDefinition) (*parquetschema.SchemaDefinition, error) {
+	if len(schemas) == 0 {
+		return nil, errors.New("no schemas to unify")
+	}
+
+	root := copyColumn(schemas[0].RootColumn)
+	var problems []string
+	for _, sd := range schemas[1:] {
+		root.Children = unifyChildren(root.Children, sd.RootColumn.Children, "", &problems)
+	}
+	if len(problems) > 0 {
+		return nil, errors.Errorf("the schemas of the files are incompatible: %s", strings.Join(problems, "; "))
+	}
+
+	return parquetschema.SchemaDefinitionFromColumnDefinition(root), nil
+}
+
+func unifyChildren(dst, src []*parquetschema.ColumnDefinition, prefix string, problems *[]string) []*parquetschema.ColumnDefinition {
+	seen := make(map[string]bool, len(src))
+	for _, s := range src {
+		name := s.SchemaElement.Name
+		seen[name] = true
+
+		d := findColumn(dst, name)
+		if d == nil {
+			c := copyColumn(s)
+			makeOptional(c.SchemaElement)
+			dst = append(dst, c)
+			continue
+		}
+
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+		if problem := unifyColumn(d, s, path, problems); problem != "" {
+			*problems = append(*problems, fmt.Sprintf("%s: %s", path, problem))
+		}
+	}
+
+	for _, d := range dst {
+		if !seen[d.SchemaElement.Name] {
+			makeOptional(d.SchemaElement)
+		}
+	}
+
+	return dst
+}
+
+// unifyColumn makes dst a column that both dst and src can be read as. It returns a description
+// of the problem if that is not possible.
+func unifyColumn(dst, src *parquetschema.ColumnDefinition, path string, problems *[]string) string {
+	d, s := dst.SchemaElement, src.SchemaElement
+
+	dRep, sRep := d.GetRepetitionType(), s.GetRepetitionType()
+	if dRep != sRep {
+		if dRep == parquet.FieldRepetitionType_REPEATED || sRep == parquet.FieldRepetitionType_REPEATED {
+			return fmt.Sprintf("column is %s in one file, but %s in another", strings.ToLower(dRep.String()), strings.ToLower(sRep.String()))
+		}
+		makeOptional(d)
+	}
+
+	dGroup, sGroup := d.Type == nil, s.Type == nil
+	switch {
+	case dGroup && sGroup:
+		if d.GetConvertedType() != s.GetConvertedType() {
+			return "group has different annotations in different files"
+		}
+		dst.Children = unifyChildren(dst.Children, src.Children, path, problems)
+		return ""
+	case dGroup || sGroup:
+		return "column is a group in one file, but not in another"
+	}
+
+	if d.GetType() == s.GetType() {
+		if d.GetTypeLength() != s.GetTypeLength() || d.GetConvertedType() != s.GetConvertedType() || !reflect.DeepEqual(d.LogicalType, s.LogicalType) {
+			return fmt.Sprintf("column of type %s has different annotations in different files", d.GetType())
+		}
+		return ""
+	}
+
+	if d.ConvertedType != nil || s.ConvertedType != nil || d.LogicalType != nil || s.LogicalType != nil {
+		return fmt.Sprintf("column is %s in one file, but %s in another", d.GetType(), s.GetType())
+	}
+
+	switch {
+	case d.GetType() == parquet.Type_INT32 && s.GetType() == parquet.Type_INT64,
+		d.GetType() == parquet.Type_FLOAT && s.GetType() == parquet.Type_DOUBLE:
+		typ := s.GetType()
+		d.Type = &typ
+		return ""
+	case d.GetType() == parquet.Type_INT64 && s.GetType() == parquet.Type_INT32,
+		d.GetType() == parquet.Type_DOUBLE && s.GetType() == parquet.Type_FLOAT:
+		return ""
+	}
+
+	return fmt.Sprintf("column is %s in one file, but %s in another", d.GetType(), s.GetType())
+}
+
+func findColumn(cols []*parquetschema.ColumnDefinition, name string) *parquetschema.ColumnDefinition {
+	for _, c := range cols {
+		if c.SchemaElement.Name == name {
+			return c
+		}
+	}
+
+	return nil
+}
+
+func makeOptional(elem *parquet.SchemaElement) {
+	if elem.GetRepetitionType() == parquet.FieldRepetitionType_REQUIRED {
+		rep := parquet.FieldRepetitionType_OPTIONAL
+		elem.RepetitionType = &rep
+	}
+}
+
+// copyColumn returns a copy of the column definition that can be modified without changing the
+// original one.
+func copyColumn(c *parquetschema.ColumnDefinition) *parquetschema.ColumnDefinition {
+	elem := *c.SchemaElement
+	ret := &parquetschema.ColumnDefinition{SchemaElement: &elem}
+	for _, child := range c.Children {
+		ret.Children = append(ret.Children, copyColumn(child))
+	}
+
+	return ret
+}
@@ -0,0 +1,444 @@
+package dataset
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	goparquet "github.com/sagia-inneractive/parquet-go"
+	"github.com/sagia-inneractive/parquet-go/parquet"
+	"github.com/sagia-inneractive/parquet-go/parquetschema"
+)
+
+// defaultMaxOpenFiles is the default number of files a Writer writes to at the same time.
+const defaultMaxOpenFiles = 64
+
+// WriterOption is an option to configure a Writer.
+type WriterOption func(*Writer)
+
+// WithFileWriterOptions sets the options that are used to create the writers of the files of
+// the dataset, e.g. the compression codec or the maximum row group size. The schema definition
+// is set by the dataset writer.
+func WithFileWriterOptions(opts ...goparquet.FileWriterOption) WriterOption {
+	return func(w *Writer) {
+		w.fileOpts = opts
+	}
+}
+
+// WithMaxFileSize sets the size after which a file is closed, and the following rows of the
+// same partition are written to a new file. Like in parquet-tool split, the size is not exact,
+// as only row groups that have been flushed count towards it. Combine it with
+// goparquet.WithMaxRowGroupSize to get files close to the target size. By default, there is one
+// file per partition.
+func WithMaxFileSize(size int64) WriterOption {
+	return func(w *Writer) {
+		w.maxFileSize = size
+	}
+}
+
+// WithMaxOpenFiles sets the maximum number of files that are written to at the same time. If a
+// row belongs to a partition that has no open file while the maximum is reached, the file that
+// was least recently written to is closed. The default is 64.
+func WithMaxOpenFiles(n int) WriterOption {
+	return func(w *Writer) {
+		w.maxOpenFiles = n
+	}
+}
+
+// WithMetaDataFile enables writing a "_metadata" summary file when the writer is closed, see
+// goparquet.WriteMetaDataFile. The summary file doesn't contain the partition columns.
+func WithMetaDataFile() WriterOption {
+	return func(w *Writer) {
+		w.metaDataFile = true
+	}
+}
+
+// Writer writes rows to a dataset with Hive-style partitions. The files of a partition are
+// stored in a directory named key=value for each partition column, and the values of the
+// partition columns are not stored in the files themselves.
+type Writer struct {
+	fsys     WritableFileSystem
+	dir      string
+	schema   *parquetschema.SchemaDefinition
+	partCols []*parquet.SchemaElement
+
+	fileOpts     []goparquet.FileWriterOption
+	maxFileSize  int64
+	maxOpenFiles int
+	metaDataFile bool
+
+	open   map[string]*partitionWriter
+	files  []string
+	seq    int
+	uses   uint64
+	closed bool
+}
+
+// partitionWriter writes to the current file of a partition.
+type partitionWriter struct {
+	dir      string
+	w        io.WriteCloser
+	fw       *goparquet.FileWriter
+	lastUsed uint64
+}
+
+// NewWriter returns a writer that writes a dataset with schema sd to directory dir of the file
+// system, partitioned by the provided columns. Partition columns must be top-level columns that
+// are not repeated, of type boolean, int32, int64 or binary. Their values are formatted as
+// decimal numbers and URL path escaped strings respectively, null values are stored in
+// __HIVE_DEFAULT_PARTITION__ directories. Without partition columns, all files are written to
+// dir itself.
+func NewWriter(fsys WritableFileSystem, dir string, sd *parquetschema.SchemaDefinition, partitionColumns []string, opts ...WriterOption) (*Writer, error) {
+	if sd == nil || sd.RootColumn == nil {
+		return nil, errors.New("no schema definition provided")
+	}
+
+	w := &Writer{
+		fsys:         fsys,
+		dir:          path.Clean(dir),
+		maxOpenFiles: defaultMaxOpenFiles,
+		open:         make(map[string]*partitionWriter),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	if w.maxOpenFiles < 1 {
+		return nil, errors.Errorf("invalid maximum number of open files %d", w.maxOpenFiles)
+	}
+
+	root := copyColumn(sd.RootColumn)
+	for _, name := range partitionColumns {
+		col := findColumn(root.Children, name)
+		if col == nil {
+			return nil, errors.Errorf("partition column %q doesn't exist", name)
+		}
+
+		elem := col.SchemaElement
+		if elem.GetRepetitionType() == parquet.FieldRepetitionType_REPEATED {
+			return nil, errors.Errorf("partition column %q is repeated", name)
+		}
+		switch {
+		case elem.Type == nil:
+			return nil, errors.Errorf("partition column %q is a group", name)
+		case elem.GetType() == parquet.Type_FLOAT, elem.GetType() == parquet.Type_DOUBLE, elem.GetType() == parquet.Type_INT96:
+			return nil, errors.Errorf("partition column %q of type %s is not supported", name, elem.GetType())
+		}
+
+		for i, c := range root.Children {
+			if c == col {
+				root.Children = append(root.Children[:i], root.Children[i+1:]...)
+				break
+			}
+		}
+		w.partCols = append(w.partCols, elem)
+	}
+
+	if len(root.Children) == 0 {
+		return nil, errors.New("no columns left after removing the partition columns")
+	}
+	w.schema = parquetschema.SchemaDefinitionFromColumnDefinition(root)
+
+	return w, nil
+}
+
+// AddData adds a row to the file of its partition. The partition columns are removed from the
+// row before it is written to the file, the row itself is not modified.
+func (w *Writer) AddData(m map[string]interface{}) error {
+	if w.closed {
+		return errors.New("writer is already closed")
+	}
+
+	dir, err := w.partitionDir(m)
+	if err != nil {
+		return err
+	}
+
+	data := m
+	if len(w.partCols) > 0 {
+		data = make(map[string]interface{}, len(m))
+		for k, v := range m {
+			data[k] = v
+		}
+		for _, elem := range w.partCols {
+			delete(data, elem.Name)
+		}
+	}
+
+	pw, err := w.partitionWriter(dir)
+	if err != nil {
+		return err
+	}
+
+	if err := pw.fw.AddData(data); err != nil {
+		return err
+	}
+
+	if w.maxFileSize > 0 && pw.fw.CurrentFileSize() >= w.maxFileSize {
+		return w.closePartition(pw)
+	}
+
+	return nil
+}
+
+// partitionDir returns the directory of the partition the row belongs to.
+func (w *Writer) partitionDir(m map[string]interface{}) (string, error) {
+	parts := make([]string, 0, len(w.partCols)+1)
+	parts = append(parts, w.dir)
+	for _, elem := range w.partCols {
+		v, ok := m[elem.Name]
+		if !ok || v == nil {
+			if elem.GetRepetitionType() == parquet.FieldRepetitionType_REQUIRED {
+				return "", errors.Errorf("the value of the required partition column %q is missing", elem.Name)
+			}
+			parts = append(parts, elem.Name+"="+nullPartition)
+			continue
+		}
+
+		s, err := formatPartitionValue(elem, v)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, elem.Name+"="+s)
+	}
+
+	return path.Join(parts...), nil
+}
+
+func formatPartitionValue(elem *parquet.SchemaElement, v interface{}) (string, error) {
+	switch elem.GetType() {
+	case parquet.Type_BOOLEAN:
+		if b, ok := v.(bool); ok {
+			return strconv.FormatBool(b), nil
+		}
+	case parquet.Type_INT32:
+		switch x := v.(type) {
+		case int32:
+			return strconv.FormatInt(int64(x), 10), nil
+		case uint32:
+			return strconv.FormatUint(uint64(x), 10), nil
+		}
+	case parquet.Type_INT64:
+		switch x := v.(type) {
+		case int64:
+			return strconv.FormatInt(x, 10), nil
+		case uint64:
+			return strconv.FormatUint(x, 10), nil
+		}
+	case parquet.Type_BYTE_ARRAY, parquet.Type_FIXED_LEN_BYTE_ARRAY:
+		if b, ok := v.([]byte); ok {
+			return url.PathEscape(string(b)), nil
+		}
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Array && rv.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(b), rv)
+			return url.PathEscape(string(b)), nil
+		}
+	}
+
+	return "", errors.Errorf("invalid value of type %T for partition column %q of type %s", v, elem.Name, elem.GetType())
+}
+
+// partitionWriter returns the writer of the partition, and creates a new file if necessary.
+func (w *Writer) partitionWriter(dir string) (*partitionWriter, error) {
+	w.uses++
+
+	if pw, ok := w.open[dir]; ok {
+		pw.lastUsed = w.uses
+		return pw, nil
+	}
+
+	if len(w.open) >= w.maxOpenFiles {
+		var lru *partitionWriter
+		for _, pw := range w.open {
+			if lru == nil || pw.lastUsed < lru.lastUsed {
+				lru = pw
+			}
+		}
+		if err := w.closePartition(lru); err != nil {
+			return nil, err
+		}
+	}
+
+	name := path.Join(dir, fmt.Sprintf("part-%05d.parquet", w.seq))
+	w.seq++
+
+	f, err := w.fsys.Create(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating file %q failed", name)
+	}
+
+	opts := append(append([]goparquet.FileWriterOption(nil), w.fileOpts...), goparquet.WithSchemaDefinition(w.schema))
+	pw := &partitionWriter{
+		dir:      dir,
+		w:        f,
+		fw:       goparquet.NewFileWriter(f, opts...),
+		lastUsed: w.uses,
+	}
+	w.open[dir] = pw
+	w.files = append(w.files, name)
+
+	return pw, nil
+}
+
+func (w *Writer) closePartition(pw *partitionWriter) error {
+	delete(w.open, pw.dir)
+
+	err := pw.fw.Close()
+	if cerr := pw.w.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Files returns the names of all files written so far.
+func (w *Writer) Files() []string {
+	return append([]string(nil), w.files...)
+}
+
+// Close closes all open files, and writes the "_metadata" summary file if enabled. Finally, it
+// writes an empty "_SUCCESS" file to mark the dataset as complete.
+func (w *Writer) Close() error {
+	if w.closed {
+		return errors.New("writer is already closed")
+	}
+	w.closed = true
+
+	dirs := make([]string, 0, len(w.open))
+	for dir := range w.open {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	var err error
+	for _, dir := range dirs {
+		if cerr := w.closePartition(w.open[dir]); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if w.metaDataFile && len(w.files) > 0 {
+		if err := w.writeMetaDataFile(); err != nil {
+			return err
+		}
+	}
+
+	return w.writeFile("_SUCCESS", func(io.Writer) error { return nil })
+}
+
+func (w *Writer) writeMetaDataFile() error {
+	// the files are opened one at a time when their meta data is read, so that not all of
+	// them are open at once
+	files := &metaDataFiles{fsys: w.fsys}
+	paths := make([]string, len(w.files))
+	inputs := make([]io.ReadSeeker, len(w.files))
+	for i, name := range w.files {
+		paths[i] = strings.TrimPrefix(name, w.dir+"/")
+		inputs[i] = &metaDataFile{files: files, name: name}
+	}
+
+	err := w.writeFile("_metadata", func(out io.Writer) error {
+		return goparquet.WriteMetaDataFile(out, paths, inputs)
+	})
+	if cerr := files.close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// metaDataFiles keeps at most one of the files read for the summary file open.
+type metaDataFiles struct {
+	fsys FileSystem
+	name string
+	file File
+}
+
+// open returns the file with the provided name, and closes the file that was opened before.
+func (m *metaDataFiles) open(name string) (File, error) {
+	if m.file != nil && m.name == name {
+		return m.file, nil
+	}
+	if err := m.close(); err != nil {
+		return nil, err
+	}
+
+	f, err := m.fsys.Open(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening file %q failed", name)
+	}
+	m.file, m.name = f, name
+	return f, nil
+}
+
+func (m *metaDataFiles) close() error {
+	if m.file == nil {
+		return nil
+	}
+	err := m.file.Close()
+	m.file = nil
+	return errors.Wrapf(err, "closing file %q failed", m.name)
+}
+
+// metaDataFile is a file read for the summary file, which is only opened when it is read.
+type metaDataFile struct {
+	files *metaDataFiles
+	name  string
+	pos   int64
+}
+
+func (f *metaDataFile) Read(p []byte) (int, error) {
+	file, err := f.files.open(f.name)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := file.Seek(f.pos, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	n, err := file.Read(p)
+	f.pos += int64(n)
+	return n, err
+}
+
+func (f *metaDataFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+		f.pos = offset
+	case io.SeekCurrent:
+		f.pos += offset
+	case io.SeekEnd:
+		file, err := f.files.open(f.name)
+		if err != nil {
+			return 0, err
+		}
+		if f.pos, err = file.Seek(offset, io.SeekEnd); err != nil {
+			return 0, err
+		}
+	default:
+		return 0, errors.Errorf("invalid whence %d", whence)
+	}
+	return f.pos, nil
+}
+
+func (w *Writer) writeFile(name string, write func(io.Writer) error) error {
+	name = path.Join(w.dir, name)
+	f, err := w.fsys.Create(name)
+	if err != nil {
+		return errors.Wrapf(err, "creating file %q failed", name)
+	}
+
+	err = write(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return errors.Wrapf(err, "writing file %q failed", name)
+}
@@ -0,0 +1,246 @@
+package dataset
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"testing"
+
+	goparquet "github.com/sagia-inneractive/parquet-go"
+	"github.com/sagia-inneractive/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+const writerSchema = `message event {
+  required int64 id;
+  optional binary name (STRING);
+  optional int32 day;
+  required binary country (STRING);
+}`
+
+func writerRows() []map[string]interface{} {
+	countries := []string{"de", "us", "new zealand/nz"}
+	var rows []map[string]interface{}
+	for i := 0; i < 30; i++ {
+		row := map[string]interface{}{
+			"id":      int64(i),
+			"country": []byte(countries[i%3]),
+		}
+		if i%5 != 0 {
+			row["day"] = int32(i % 2)
+		}
+		if i%4 != 0 {
+			row["name"] = []byte(fmt.Sprintf("name-%d", i))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func writeDataset(t *testing.T, fsys WritableFileSystem, partitions []string, opts ...WriterOption) *Writer {
+	sd, err := parquetschema.ParseSchemaDefinition(writerSchema)
+	require.NoError(t, err)
+
+	w, err := NewWriter(fsys, "out", sd, partitions, opts...)
+	require.NoError(t, err)
+	for _, row := range writerRows() {
+		require.NoError(t, w.AddData(row))
+	}
+	require.NoError(t, w.Close())
+
+	return w
+}
+
+func sortByID(rows []map[string]interface{}) []map[string]interface{} {
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i]["id"].(int64) < rows[j]["id"].(int64)
+	})
+	return rows
+}
+
+func TestWriter(t *testing.T) {
+	fsys := memFS{}
+	w := writeDataset(t, fsys, []string{"day", "country"})
+
+	require.Equal(t, []string{
+		"out/day=__HIVE_DEFAULT_PARTITION__/country=de/part-00000.parquet",
+		"out/day=1/country=us/part-00001.parquet",
+		"out/day=0/country=new%20zealand%2Fnz/part-00002.parquet",
+		"out/day=1/country=de/part-00003.parquet",
+		"out/day=0/country=us/part-00004.parquet",
+		"out/day=__HIVE_DEFAULT_PARTITION__/country=new%20zealand%2Fnz/part-00005.parquet",
+		"out/day=0/country=de/part-00006.parquet",
+		"out/day=__HIVE_DEFAULT_PARTITION__/country=us/part-00007.parquet",
+		"out/day=1/country=new%20zealand%2Fnz/part-00008.parquet",
+	}, w.Files())
+	require.Contains(t, fsys, "out/_SUCCESS")
+	require.NotContains(t, fsys, "out/_metadata")
+	require.Error(t, w.AddData(writerRows()[0]))
+	require.Error(t, w.Close())
+
+	// the partition columns are not stored in the files
+	fr, err := goparquet.NewFileReader(bytes.NewReader(fsys["out/day=1/country=us/part-00001.parquet"]))
+	require.NoError(t, err)
+	require.Equal(t, "message event {\n  required int64 id;\n  optional binary name (STRING);\n}\n", fr.GetSchemaDefinition().String())
+
+	r, err := Open(fsys, "out")
+	require.NoError(t, err)
+	defer r.Close()
+
+	var expected []map[string]interface{}
+	for _, row := range writerRows() {
+		if day, ok := row["day"]; ok {
+			row["day"] = int64(day.(int32))
+		}
+		expected = append(expected, row)
+	}
+	require.Equal(t, expected, sortByID(readAll(t, r)))
+}
+
+func TestWriterWithoutPartitions(t *testing.T) {
+	fsys := memFS{}
+	w := writeDataset(t, fsys, nil)
+	require.Equal(t, []string{"out/part-00000.parquet"}, w.Files())
+
+	r, err := Open(fsys, "out")
+	require.NoError(t, err)
+	require.Equal(t, writerRows(), readAll(t, r))
+}
+
+func TestWriterMaxFileSize(t *testing.T) {
+	fsys := memFS{}
+	w := writeDataset(t, fsys, []string{"country"}, WithMaxFileSize(1), WithFileWriterOptions(goparquet.WithMaxRowGroupSize(1)))
+
+	// every row is flushed as its own row group, so every row ends up in its own file
+	require.Len(t, w.Files(), 30)
+	require.Equal(t, "out/country=de/part-00000.parquet", w.Files()[0])
+	require.Equal(t, "out/country=de/part-00003.parquet", w.Files()[3])
+
+	r, err := Open(fsys, "out", WithFilter(Equal("country", "us")))
+	require.NoError(t, err)
+	require.Len(t, r.Files(), 10)
+	require.Len(t, readAll(t, r), 10)
+}
+
+func TestWriterMaxOpenFiles(t *testing.T) {
+	fsys := memFS{}
+	w := writeDataset(t, fsys, []string{"country"}, WithMaxOpenFiles(2))
+
+	// the countries alternate, so the least recently used file is closed for every row after the
+	// first two
+	require.Len(t, w.Files(), 30)
+
+	fsys = memFS{}
+	w = writeDataset(t, fsys, []string{"country"}, WithMaxOpenFiles(3))
+	require.Len(t, w.Files(), 3)
+}
+
+func TestWriterMetaDataFile(t *testing.T) {
+	fsys := memFS{}
+	w := writeDataset(t, fsys, []string{"country"}, WithMetaDataFile())
+	require.Len(t, w.Files(), 3)
+
+	r, err := goparquet.NewFileReaderWithOptions(bytes.NewReader(fsys["out/_metadata"]), goparquet.WithExternalFileOpener(func(name string) (io.ReadSeeker, error) {
+		return fsys.Open("out/" + name)
+	}))
+	require.NoError(t, err)
+	require.Equal(t, int64(30), r.NumRows())
+	require.Equal(t, 3, r.RowGroupCount())
+
+	var ids []int64
+	for {
+		row, err := r.NextRow()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		ids = append(ids, row["id"].(int64))
+	}
+	require.Len(t, ids, 30)
+}
+
+// openFilesFS is a memFS that counts the files that are open at the same time.
+type openFilesFS struct {
+	memFS
+	open, maxOpen int
+}
+
+type openFile struct {
+	File
+	fsys *openFilesFS
+}
+
+func (f *openFile) Close() error {
+	f.fsys.open--
+	return f.File.Close()
+}
+
+func (m *openFilesFS) Open(name string) (File, error) {
+	f, err := m.memFS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	m.open++
+	if m.open > m.maxOpen {
+		m.maxOpen = m.open
+	}
+	return &openFile{File: f, fsys: m}, nil
+}
+
+func TestWriterMetaDataFileOpensOneFile(t *testing.T) {
+	fsys := &openFilesFS{memFS: memFS{}}
+	w := writeDataset(t, fsys, []string{"country"}, WithMetaDataFile())
+	require.Len(t, w.Files(), 3)
+	require.Contains(t, fsys.memFS, "out/_metadata")
+	require.Equal(t, 1, fsys.maxOpen)
+	require.Equal(t, 0, fsys.open)
+}
+
+func TestWriterErrors(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(`message msg {
+  required int64 id;
+  optional float f;
+  repeated int64 r;
+  optional group g {
+    optional int64 x;
+  }
+  optional binary s (STRING);
+}`)
+	require.NoError(t, err)
+
+	invalid := []struct {
+		partitions []string
+		opts       []WriterOption
+	}{
+		{[]string{"foo"}, nil},
+		{[]string{"f"}, nil},
+		{[]string{"r"}, nil},
+		{[]string{"g"}, nil},
+		{[]string{"g.x"}, nil},
+		{nil, []WriterOption{WithMaxOpenFiles(0)}},
+	}
+	for _, tt := range invalid {
+		_, err := NewWriter(memFS{}, "out", sd, tt.partitions, tt.opts...)
+		require.Error(t, err, "%v", tt.partitions)
+	}
+
+	_, err = NewWriter(memFS{}, "out", nil, nil)
+	require.Error(t, err)
+
+	onlyID, err := parquetschema.ParseSchemaDefinition(`message msg {
+  required int64 id;
+}`)
+	require.NoError(t, err)
+	_, err = NewWriter(memFS{}, "out", onlyID, []string{"id"})
+	require.Error(t, err)
+
+	w, err := NewWriter(memFS{}, "out", sd, []string{"id", "s"})
+	require.NoError(t, err)
+	require.Error(t, w.AddData(map[string]interface{}{"s": []byte("a")}))
+	require.Error(t, w.AddData(map[string]interface{}{"id": int32(1)}))
+	require.Error(t, w.AddData(map[string]interface{}{"id": int64(1), "s": "a"}))
+	require.NoError(t, w.AddData(map[string]interface{}{"id": int64(1)}))
+	require.Error(t, w.AddData(map[string]interface{}{"id": int64(1), "f": "a"}))
+	require.Equal(t, []string{"out/id=1/s=__HIVE_DEFAULT_PARTITION__/part-00000.parquet"}, w.Files())
+}
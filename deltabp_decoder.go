@@ -42,6 +42,11 @@ func (d *deltaBitPackDecoder32) init(r io.Reader) error {
 		return err
 	}
 
+	// a single value is stored in the header, without any block
+	if d.valuesCount <= 1 {
+		return nil
+	}
+
 	if err := d.readMiniBlockHeader(); err != nil {
 		return err
 	}
@@ -117,8 +122,8 @@ func (d *deltaBitPackDecoder32) next() (int32, error) {
 		return 0, io.EOF
 	}
 
-	// need new byte?
-	if d.position%8 == 0 {
+	// need new byte? there is no delta after the last value
+	if d.position%8 == 0 && d.position+1 < d.valuesCount {
 		// do we need to advance a mini block?
 		if d.position%d.miniBlockValueCount == 0 {
 			// do we need to advance a big block?
@@ -147,7 +152,7 @@ func (d *deltaBitPackDecoder32) next() (int32, error) {
 		// there is padding here, read them all from the reader, first deal with the remaining of the current block,
 		// then the next blocks. if the blocks bit width is zero then simply ignore them, but the docs said reader
 		// should accept any arbitrary bit width here.
-		if d.position+8 >= d.valuesCount {
+		if d.position+8 >= d.valuesCount-1 {
 			//  current block
 			l := (d.miniBlockValueCount/8)*w - d.miniBlockPosition
 			if l < 0 {
@@ -156,7 +161,7 @@ func (d *deltaBitPackDecoder32) next() (int32, error) {
 			remaining := make([]byte, l)
 			_, _ = io.ReadFull(d.r, remaining)
 			for i := d.currentMiniBlock; i < d.miniBlockCount; i++ {
-				w := int32(d.miniBlockBitWidth[d.currentMiniBlock])
+				w := int32(d.miniBlockBitWidth[i])
 				if w != 0 {
 					remaining := make([]byte, (d.miniBlockValueCount/8)*w)
 					_, _ = io.ReadFull(d.r, remaining)
@@ -201,6 +206,11 @@ func (d *deltaBitPackDecoder64) init(r io.Reader) error {
 		return err
 	}
 
+	// a single value is stored in the header, without any block
+	if d.valuesCount <= 1 {
+		return nil
+	}
+
 	if err := d.readMiniBlockHeader(); err != nil {
 		return err
 	}
@@ -276,8 +286,8 @@ func (d *deltaBitPackDecoder64) next() (int64, error) {
 		return 0, io.EOF
 	}
 
-	// need new byte?
-	if d.position%8 == 0 {
+	// need new byte? there is no delta after the last value
+	if d.position%8 == 0 && d.position+1 < d.valuesCount {
 		// do we need to advance a mini block?
 		if d.position%d.miniBlockValueCount == 0 {
 			// do we need to advance a big block?
@@ -306,7 +316,7 @@ func (d *deltaBitPackDecoder64) next() (int64, error) {
 		// there is padding here, read them all from the reader, first deal with the remaining of the current block,
 		// then the next blocks. if the blocks bit width is zero then simply ignore them, but the docs said reader
 		// should accept any arbitrary bit width here.
-		if d.position+8 >= d.valuesCount {
+		if d.position+8 >= d.valuesCount-1 {
 			//  current block
 			sliceLen := (d.miniBlockValueCount/8)*w - d.miniBlockPosition
 			if sliceLen < 0 {
@@ -315,7 +325,7 @@ func (d *deltaBitPackDecoder64) next() (int64, error) {
 			remaining := make([]byte, sliceLen)
 			_, _ = io.ReadFull(d.r, remaining)
 			for i := d.currentMiniBlock; i < d.miniBlockCount; i++ {
-				w := int32(d.miniBlockBitWidth[d.currentMiniBlock])
+				w := int32(d.miniBlockBitWidth[i])
 				if w != 0 {
 					remaining := make([]byte, (d.miniBlockValueCount/8)*w)
 					_, _ = io.ReadFull(d.r, remaining)
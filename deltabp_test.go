@@ -50,3 +50,35 @@ func TestDelta(t *testing.T) {
 		assert.Equal(t, toR, to1)
 	}
 }
+
+func TestDeltaValueCounts(t *testing.T) {
+	for _, count := range []int{0, 1, 2, 8, 9, 10, 33, 128, 129, 130, 257} {
+		data := &bytes.Buffer{}
+		enc := &deltaBitPackEncoder64{
+			blockSize:      128,
+			miniBlockCount: 4,
+		}
+		require.NoError(t, enc.init(data))
+		var values []int64
+		for i := 0; i < count; i++ {
+			v := rand.Int63n(1000)
+			values = append(values, v)
+			require.NoError(t, enc.addInt64(v))
+		}
+		require.NoError(t, enc.Close())
+		// the data following the values must not be consumed by the decoder
+		data.WriteString("next")
+
+		r := bytes.NewReader(data.Bytes())
+		dec := &deltaBitPackDecoder64{}
+		require.NoError(t, dec.init(r), "count %d", count)
+		var read []int64
+		for i := 0; i < count; i++ {
+			v, err := dec.next()
+			require.NoError(t, err, "count %d", count)
+			read = append(read, v)
+		}
+		assert.Equal(t, values, read, "count %d", count)
+		assert.Equal(t, 4, r.Len(), "count %d", count)
+	}
+}
@@ -0,0 +1,310 @@
+package goparquet
+
+import (
+	"math"
+
+	"github.com/sagia-inneractive/parquet-go/parquet"
+)
+
+// mapEntrySize is the approximate number of bytes a map entry takes in addition to its key.
+const mapEntrySize = 16
+
+// dictValues holds the distinct values of a column store in insertion order and maps them to
+// their index. The implementations store the values in a slice of their actual type instead of
+// an []interface{}, which avoids an allocation for every value and keeps the memory usage close
+// to the size of the values. Their buffers are kept on reset, so they are reused for the next
+// row group.
+type dictValues interface {
+	// add returns the index of v. If v is not known yet, it is appended to the values.
+	add(v interface{}) int32
+	value(idx int32) interface{}
+	len() int
+	reset()
+	// memSize returns the approximate number of bytes used by the values and their index.
+	memSize() int64
+}
+
+func newDictValues(typ parquet.Type) dictValues {
+	switch typ {
+	case parquet.Type_INT32:
+		return &int32DictValues{indices: make(map[int32]int32)}
+	case parquet.Type_INT64:
+		return &int64DictValues{indices: make(map[int64]int32)}
+	case parquet.Type_FLOAT:
+		return &floatDictValues{indices: make(map[uint32]int32)}
+	case parquet.Type_DOUBLE:
+		return &doubleDictValues{indices: make(map[uint64]int32)}
+	case parquet.Type_INT96:
+		return &int96DictValues{indices: make(map[[12]byte]int32)}
+	case parquet.Type_BYTE_ARRAY, parquet.Type_FIXED_LEN_BYTE_ARRAY:
+		return &byteArrayDictValues{indices: make(map[string]int32)}
+	default:
+		return &genericDictValues{indices: make(map[interface{}]int32)}
+	}
+}
+
+// genericDictValues is used for all types without a typed implementation, which is only
+// BOOLEAN.
+type genericDictValues struct {
+	values  []interface{}
+	indices map[interface{}]int32
+}
+
+func (d *genericDictValues) add(v interface{}) int32 {
+	key := mapKey(v)
+	if idx, ok := d.indices[key]; ok {
+		return idx
+	}
+	d.values = append(d.values, v)
+	idx := int32(len(d.values) - 1)
+	d.indices[key] = idx
+	return idx
+}
+
+func (d *genericDictValues) value(idx int32) interface{} {
+	return d.values[idx]
+}
+
+func (d *genericDictValues) len() int {
+	return len(d.values)
+}
+
+func (d *genericDictValues) reset() {
+	for i := range d.values {
+		d.values[i] = nil
+	}
+	d.values = d.values[:0]
+	for k := range d.indices {
+		delete(d.indices, k)
+	}
+}
+
+func (d *genericDictValues) memSize() int64 {
+	return int64(len(d.values)) * (16 + 16 + mapEntrySize)
+}
+
+type int32DictValues struct {
+	values  []int32
+	indices map[int32]int32
+}
+
+func (d *int32DictValues) add(v interface{}) int32 {
+	key := v.(int32)
+	if idx, ok := d.indices[key]; ok {
+		return idx
+	}
+	d.values = append(d.values, key)
+	idx := int32(len(d.values) - 1)
+	d.indices[key] = idx
+	return idx
+}
+
+func (d *int32DictValues) value(idx int32) interface{} {
+	return d.values[idx]
+}
+
+func (d *int32DictValues) len() int {
+	return len(d.values)
+}
+
+func (d *int32DictValues) reset() {
+	d.values = d.values[:0]
+	for k := range d.indices {
+		delete(d.indices, k)
+	}
+}
+
+func (d *int32DictValues) memSize() int64 {
+	return int64(len(d.values)) * (4 + 4 + mapEntrySize)
+}
+
+type int64DictValues struct {
+	values  []int64
+	indices map[int64]int32
+}
+
+func (d *int64DictValues) add(v interface{}) int32 {
+	key := v.(int64)
+	if idx, ok := d.indices[key]; ok {
+		return idx
+	}
+	d.values = append(d.values, key)
+	idx := int32(len(d.values) - 1)
+	d.indices[key] = idx
+	return idx
+}
+
+func (d *int64DictValues) value(idx int32) interface{} {
+	return d.values[idx]
+}
+
+func (d *int64DictValues) len() int {
+	return len(d.values)
+}
+
+func (d *int64DictValues) reset() {
+	d.values = d.values[:0]
+	for k := range d.indices {
+		delete(d.indices, k)
+	}
+}
+
+func (d *int64DictValues) memSize() int64 {
+	return int64(len(d.values)) * (8 + 8 + mapEntrySize)
+}
+
+// floatDictValues uses the bit representation of the values as key, so that NaN values are
+// deduplicated and 0 and -0 are kept apart.
+type floatDictValues struct {
+	values  []float32
+	indices map[uint32]int32
+}
+
+func (d *floatDictValues) add(v interface{}) int32 {
+	f := v.(float32)
+	key := math.Float32bits(f)
+	if idx, ok := d.indices[key]; ok {
+		return idx
+	}
+	d.values = append(d.values, f)
+	idx := int32(len(d.values) - 1)
+	d.indices[key] = idx
+	return idx
+}
+
+func (d *floatDictValues) value(idx int32) interface{} {
+	return d.values[idx]
+}
+
+func (d *floatDictValues) len() int {
+	return len(d.values)
+}
+
+func (d *floatDictValues) reset() {
+	d.values = d.values[:0]
+	for k := range d.indices {
+		delete(d.indices, k)
+	}
+}
+
+func (d *floatDictValues) memSize() int64 {
+	return int64(len(d.values)) * (4 + 4 + mapEntrySize)
+}
+
+// doubleDictValues uses the bit representation of the values as key, so that NaN values are
+// deduplicated and 0 and -0 are kept apart.
+type doubleDictValues struct {
+	values  []float64
+	indices map[uint64]int32
+}
+
+func (d *doubleDictValues) add(v interface{}) int32 {
+	f := v.(float64)
+	key := math.Float64bits(f)
+	if idx, ok := d.indices[key]; ok {
+		return idx
+	}
+	d.values = append(d.values, f)
+	idx := int32(len(d.values) - 1)
+	d.indices[key] = idx
+	return idx
+}
+
+func (d *doubleDictValues) value(idx int32) interface{} {
+	return d.values[idx]
+}
+
+func (d *doubleDictValues) len() int {
+	return len(d.values)
+}
+
+func (d *doubleDictValues) reset() {
+	d.values = d.values[:0]
+	for k := range d.indices {
+		delete(d.indices, k)
+	}
+}
+
+func (d *doubleDictValues) memSize() int64 {
+	return int64(len(d.values)) * (8 + 8 + mapEntrySize)
+}
+
+type int96DictValues struct {
+	values  [][12]byte
+	indices map[[12]byte]int32
+}
+
+func (d *int96DictValues) add(v interface{}) int32 {
+	key := v.([12]byte)
+	if idx, ok := d.indices[key]; ok {
+		return idx
+	}
+	d.values = append(d.values, key)
+	idx := int32(len(d.values) - 1)
+	d.indices[key] = idx
+	return idx
+}
+
+func (d *int96DictValues) value(idx int32) interface{} {
+	return d.values[idx]
+}
+
+func (d *int96DictValues) len() int {
+	return len(d.values)
+}
+
+func (d *int96DictValues) reset() {
+	d.values = d.values[:0]
+	for k := range d.indices {
+		delete(d.indices, k)
+	}
+}
+
+func (d *int96DictValues) memSize() int64 {
+	return int64(len(d.values)) * (12 + 12 + mapEntrySize)
+}
+
+// byteArrayDictValues uses a copy of the values as string keys. Looking up a []byte converted to
+// a string doesn't allocate, so only new values allocate their key.
+type byteArrayDictValues struct {
+	values  [][]byte
+	indices map[string]int32
+	size    int64
+}
+
+func (d *byteArrayDictValues) add(v interface{}) int32 {
+	b := v.([]byte)
+	if idx, ok := d.indices[string(b)]; ok {
+		return idx
+	}
+	d.values = append(d.values, b)
+	d.size += int64(len(b))
+	idx := int32(len(d.values) - 1)
+	d.indices[string(b)] = idx
+	return idx
+}
+
+func (d *byteArrayDictValues) value(idx int32) interface{} {
+	return d.values[idx]
+}
+
+func (d *byteArrayDictValues) len() int {
+	return len(d.values)
+}
+
+func (d *byteArrayDictValues) reset() {
+	// the values are references to the caller's data, so they are cleared to not keep it alive
+	for i := range d.values {
+		d.values[i] = nil
+	}
+	d.values = d.values[:0]
+	d.size = 0
+	for k := range d.indices {
+		delete(d.indices, k)
+	}
+}
+
+func (d *byteArrayDictValues) memSize() int64 {
+	// the size of the values is counted twice, for the values and their keys
+	return 2*d.size + int64(len(d.values))*(24+16+mapEntrySize)
+}
@@ -0,0 +1,779 @@
+package goparquet
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sagia-inneractive/parquet-go/parquet"
+)
+
+// EncryptionAlgorithm is the algorithm used to encrypt a parquet file.
+type EncryptionAlgorithm int
+
+const (
+	// AESGCM encrypts all modules of the file with AES-GCM.
+	AESGCM EncryptionAlgorithm = iota
+	// AESGCMCTR encrypts the page data with AES-CTR and all other modules with AES-GCM. It is
+	// faster than AESGCM, but the page data is not authenticated.
+	AESGCMCTR
+)
+
+// FileEncryptionProperties configures the Parquet Modular Encryption of a file written by a
+// FileWriter.
+type FileEncryptionProperties struct {
+	// Algorithm is the encryption algorithm, AESGCM by default.
+	Algorithm EncryptionAlgorithm
+	// FooterKey is the key used to encrypt or sign the footer, and to encrypt the columns
+	// without a key of their own. It must be 16, 24 or 32 bytes long.
+	FooterKey []byte
+	// FooterKeyMetadata is stored in the file to allow readers to retrieve the footer key.
+	FooterKeyMetadata []byte
+	// PlaintextFooter makes the footer readable without the footer key. The footer is signed
+	// with the footer key instead, and readers without any key can still read the unencrypted
+	// columns.
+	PlaintextFooter bool
+	// AADPrefix is an optional prefix of the additional authenticated data, e.g. the file name,
+	// that protects against files being swapped.
+	AADPrefix []byte
+	// DisableAADPrefixStorage prevents storing the AAD prefix in the file. Readers then need to
+	// supply it.
+	DisableAADPrefixStorage bool
+	// Columns contains the columns to encrypt, by their flat name. Columns without a key are
+	// encrypted with the footer key. If no columns are provided, all columns are encrypted with
+	// the footer key. Otherwise, only the provided columns are encrypted.
+	Columns map[string]*ColumnEncryptionProperties
+}
+
+// ColumnEncryptionProperties contains the key of an encrypted column.
+type ColumnEncryptionProperties struct {
+	// Key is the key of the column. If it is empty, the footer key is used.
+	Key []byte
+	// KeyMetadata is stored in the file to allow readers to retrieve the key.
+	KeyMetadata []byte
+}
+
+// KeyRetriever retrieves the key of the footer or a column from the key metadata stored in the
+// file, e.g. by looking it up in a key management service.
+type KeyRetriever interface {
+	RetrieveKey(keyMetadata []byte) ([]byte, error)
+}
+
+// StaticKeyRetriever is a KeyRetriever that looks up the keys by their key metadata.
+type StaticKeyRetriever map[string][]byte
+
+// RetrieveKey returns the key for the key metadata.
+func (s StaticKeyRetriever) RetrieveKey(keyMetadata []byte) ([]byte, error) {
+	key, ok := s[string(keyMetadata)]
+	if !ok {
+		return nil, errors.Errorf("no key for key metadata %q", keyMetadata)
+	}
+
+	return key, nil
+}
+
+// FileDecryptionProperties configures the decryption of a file encrypted with Parquet Modular
+// Encryption.
+type FileDecryptionProperties struct {
+	// FooterKey is the key of the footer. If it is empty, it is retrieved with the KeyRetriever.
+	FooterKey []byte
+	// ColumnKeys contains the keys of columns, by their flat name. The keys of other columns
+	// are retrieved with the KeyRetriever.
+	ColumnKeys map[string][]byte
+	// KeyRetriever retrieves the keys that are not provided explicitly.
+	KeyRetriever KeyRetriever
+	// AADPrefix is the prefix of the additional authenticated data. It is required if the
+	// prefix is not stored in the file.
+	AADPrefix []byte
+}
+
+var (
+	magicEncrypted = []byte{'P', 'A', 'R', 'E'}
+
+	// nonceReader is the source of the nonces, replaced in tests to get deterministic output.
+	nonceReader = rand.Reader
+)
+
+const (
+	nonceLength     = 12
+	gcmTagLength    = 16
+	aadFileUniqueLn = 8
+
+	// the module types used in the additional authenticated data
+	moduleFooter               byte = 0
+	moduleColumnMetaData       byte = 1
+	moduleDataPage             byte = 2
+	moduleDictionaryPage       byte = 3
+	moduleDataPageHeader       byte = 4
+	moduleDictionaryPageHeader byte = 5
+	moduleOffsetIndex          byte = 7
+)
+
+// moduleAAD returns the additional authenticated data of a module. Only the footer is not bound
+// to a row group and column, and only data pages and their headers are bound to a page.
+func moduleAAD(fileAAD []byte, moduleType byte, rowGroup, column, page int16) []byte {
+	aad := make([]byte, 0, len(fileAAD)+7)
+	aad = append(aad, fileAAD...)
+	aad = append(aad, moduleType)
+	if moduleType == moduleFooter {
+		return aad
+	}
+
+	aad = appendInt16(aad, rowGroup)
+	aad = appendInt16(aad, column)
+	if moduleType == moduleDataPage || moduleType == moduleDataPageHeader {
+		aad = appendInt16(aad, page)
+	}
+
+	return aad
+}
+
+func appendInt16(b []byte, v int16) []byte {
+	return append(b, byte(v), byte(uint16(v)>>8))
+}
+
+func toOrdinal(v int, what string) (int16, error) {
+	if v < 0 || v > 0x7fff {
+		return 0, errors.Errorf("%s ordinal %d is too large for encryption", what, v)
+	}
+
+	return int16(v), nil
+}
+
+// aesCipher encrypts and decrypts modules. A module consists of its length as 4 byte little
+// endian integer, the nonce, the ciphertext, and for GCM, the authentication tag.
+type aesCipher struct {
+	block cipher.Block
+	gcm   cipher.AEAD
+}
+
+func newAESCipher(key []byte) (*aesCipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid key")
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &aesCipher{block: block, gcm: gcm}, nil
+}
+
+func newNonce() ([]byte, error) {
+	nonce := make([]byte, nonceLength)
+	if _, err := io.ReadFull(nonceReader, nonce); err != nil {
+		return nil, errors.Wrap(err, "creating nonce failed")
+	}
+
+	return nonce, nil
+}
+
+func (c *aesCipher) encryptGCM(plain, aad []byte) ([]byte, error) {
+	nonce, err := newNonce()
+	if err != nil {
+		return nil, err
+	}
+
+	module := make([]byte, 4, 4+nonceLength+len(plain)+gcmTagLength)
+	module = append(module, nonce...)
+	module = c.gcm.Seal(module, nonce, plain, aad)
+	binary.LittleEndian.PutUint32(module, uint32(len(module)-4))
+	return module, nil
+}
+
+func (c *aesCipher) encryptCTR(plain []byte) ([]byte, error) {
+	nonce, err := newNonce()
+	if err != nil {
+		return nil, err
+	}
+
+	module := make([]byte, 4+nonceLength+len(plain))
+	binary.LittleEndian.PutUint32(module, uint32(len(module)-4))
+	copy(module[4:], nonce)
+	cipher.NewCTR(c.block, ctrIV(nonce)).XORKeyStream(module[4+nonceLength:], plain)
+	return module, nil
+}
+
+// decryptGCM decrypts a module without its length.
+func (c *aesCipher) decryptGCM(module, aad []byte) ([]byte, error) {
+	if len(module) < nonceLength+gcmTagLength {
+		return nil, errors.New("encrypted module is too short")
+	}
+
+	plain, err := c.gcm.Open(nil, module[:nonceLength], module[nonceLength:], aad)
+	if err != nil {
+		return nil, errors.Wrap(err, "decrypting module failed")
+	}
+
+	return plain, nil
+}
+
+// decryptCTR decrypts a module without its length.
+func (c *aesCipher) decryptCTR(module []byte) ([]byte, error) {
+	if len(module) < nonceLength {
+		return nil, errors.New("encrypted module is too short")
+	}
+
+	plain := make([]byte, len(module)-nonceLength)
+	cipher.NewCTR(c.block, ctrIV(module[:nonceLength])).XORKeyStream(plain, module[nonceLength:])
+	return plain, nil
+}
+
+// ctrIV returns the initial counter block, which is the nonce followed by a 4 byte counter
+// starting at 1.
+func ctrIV(nonce []byte) []byte {
+	iv := make([]byte, aes.BlockSize)
+	copy(iv, nonce)
+	iv[aes.BlockSize-1] = 1
+	return iv
+}
+
+// readModule reads a module including its length, and returns it without its length.
+func readModule(r io.Reader, maxLen int64) ([]byte, error) {
+	var ln uint32
+	if err := binary.Read(r, binary.LittleEndian, &ln); err != nil {
+		return nil, errors.Wrap(err, "reading module length failed")
+	}
+	if int64(ln) > maxLen-4 {
+		return nil, errors.Errorf("invalid module length %d", ln)
+	}
+
+	module := make([]byte, ln)
+	if _, err := io.ReadFull(r, module); err != nil {
+		return nil, errors.Wrap(err, "reading module failed")
+	}
+
+	return module, nil
+}
+
+// fileEncryptor encrypts the modules of a file that is written.
+type fileEncryptor struct {
+	props     *FileEncryptionProperties
+	fileAAD   []byte
+	algorithm *parquet.EncryptionAlgorithm
+	footer    *aesCipher
+	// columns contains the encryptors of the columns by column index, nil for columns that are
+	// not encrypted.
+	columns []*columnEncryptor
+}
+
+func newFileEncryptor(props *FileEncryptionProperties, schema SchemaCommon) (*fileEncryptor, error) {
+	footer, err := newAESCipher(props.FooterKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid footer key")
+	}
+
+	unique := make([]byte, aadFileUniqueLn)
+	if _, err := io.ReadFull(rand.Reader, unique); err != nil {
+		return nil, err
+	}
+
+	e := &fileEncryptor{
+		props:   props,
+		fileAAD: append(append([]byte(nil), props.AADPrefix...), unique...),
+		footer:  footer,
+		columns: make([]*columnEncryptor, len(schema.Columns())),
+	}
+
+	var (
+		prefix []byte
+		supply *bool
+	)
+	if len(props.AADPrefix) > 0 {
+		if props.DisableAADPrefixStorage {
+			t := true
+			supply = &t
+		} else {
+			prefix = props.AADPrefix
+		}
+	}
+	e.algorithm = &parquet.EncryptionAlgorithm{}
+	switch props.Algorithm {
+	case AESGCM:
+		e.algorithm.AES_GCM_V1 = &parquet.AesGcmV1{AadPrefix: prefix, AadFileUnique: unique, SupplyAadPrefix: supply}
+	case AESGCMCTR:
+		e.algorithm.AES_GCM_CTR_V1 = &parquet.AesGcmCtrV1{AadPrefix: prefix, AadFileUnique: unique, SupplyAadPrefix: supply}
+	default:
+		return nil, errors.Errorf("unknown encryption algorithm %d", props.Algorithm)
+	}
+
+	for _, col := range schema.Columns() {
+		var colProps *ColumnEncryptionProperties
+		if len(props.Columns) > 0 {
+			var ok bool
+			if colProps, ok = props.Columns[col.FlatName()]; !ok {
+				continue
+			}
+		}
+
+		ce := &columnEncryptor{
+			file:      e,
+			cipher:    footer,
+			footerKey: true,
+			ctr:       props.Algorithm == AESGCMCTR,
+		}
+		if colProps != nil && len(colProps.Key) > 0 {
+			if ce.cipher, err = newAESCipher(colProps.Key); err != nil {
+				return nil, errors.Wrapf(err, "invalid key for column %q", col.FlatName())
+			}
+			ce.footerKey = false
+			ce.keyMetadata = colProps.KeyMetadata
+		}
+		e.columns[col.Index()] = ce
+	}
+
+	for name := range props.Columns {
+		if col := schema.GetColumnByName(name); col == nil || col.Element().Type == nil {
+			return nil, errors.Errorf("encrypted column %q doesn't exist", name)
+		}
+	}
+
+	return e, nil
+}
+
+// column returns the encryptor of the column with the provided index, or nil if the column is not
+// encrypted.
+func (e *fileEncryptor) column(index int) *columnEncryptor {
+	if e == nil || index >= len(e.columns) {
+		return nil
+	}
+
+	return e.columns[index]
+}
+
+// writeFooter writes the file meta data, its length and the magic footer. In encrypted footer
+// mode, the file crypto meta data is followed by the encrypted file meta data. Otherwise, the
+// plaintext file meta data is signed by appending the nonce and tag of its encryption.
+func (e *fileEncryptor) writeFooter(w writePos, meta *parquet.FileMetaData) error {
+	pos := w.Pos()
+	aad := moduleAAD(e.fileAAD, moduleFooter, 0, 0, 0)
+
+	if e.props.PlaintextFooter {
+		meta.EncryptionAlgorithm = e.algorithm
+		meta.FooterSigningKeyMetadata = e.props.FooterKeyMetadata
+
+		buf := &bytes.Buffer{}
+		if err := writeThrift(meta, buf); err != nil {
+			return err
+		}
+		module, err := e.footer.encryptGCM(buf.Bytes(), aad)
+		if err != nil {
+			return err
+		}
+		if err := writeFull(w, buf.Bytes()); err != nil {
+			return err
+		}
+		if err := writeFull(w, module[4:4+nonceLength]); err != nil {
+			return err
+		}
+		if err := writeFull(w, module[len(module)-gcmTagLength:]); err != nil {
+			return err
+		}
+	} else {
+		cryptoMeta := &parquet.FileCryptoMetaData{
+			EncryptionAlgorithm: e.algorithm,
+			KeyMetadata:         e.props.FooterKeyMetadata,
+		}
+		if err := writeThrift(cryptoMeta, w); err != nil {
+			return err
+		}
+
+		buf := &bytes.Buffer{}
+		if err := writeThrift(meta, buf); err != nil {
+			return err
+		}
+		module, err := e.footer.encryptGCM(buf.Bytes(), aad)
+		if err != nil {
+			return err
+		}
+		if err := writeFull(w, module); err != nil {
+			return err
+		}
+	}
+
+	ln := int32(w.Pos() - pos)
+	if err := binary.Write(w, binary.LittleEndian, &ln); err != nil {
+		return err
+	}
+
+	return writeFull(w, e.magic())
+}
+
+func (e *fileEncryptor) magic() []byte {
+	if e.props.PlaintextFooter {
+		return magic
+	}
+
+	return magicEncrypted
+}
+
+// columnEncryptor encrypts the modules of a column.
+type columnEncryptor struct {
+	file        *fileEncryptor
+	cipher      *aesCipher
+	footerKey   bool
+	keyMetadata []byte
+	ctr         bool
+}
+
+// writePage encrypts a page that was written to buf, consisting of the page header followed by
+// the page data, and writes it to w. It returns the size of the encrypted page data.
+func (c *columnEncryptor) writePage(w io.Writer, buf []byte, rowGroup, column int16, page int) (int, error) {
+	br := bytes.NewReader(buf)
+	ph := &parquet.PageHeader{}
+	if err := readThrift(ph, br); err != nil {
+		return 0, err
+	}
+	data := buf[len(buf)-br.Len():]
+
+	headerType, dataType := moduleDataPageHeader, moduleDataPage
+	if ph.Type == parquet.PageType_DICTIONARY_PAGE {
+		headerType, dataType = moduleDictionaryPageHeader, moduleDictionaryPage
+	}
+	ordinal, err := toOrdinal(page, "page")
+	if err != nil {
+		return 0, err
+	}
+
+	var module []byte
+	if c.ctr {
+		module, err = c.cipher.encryptCTR(data)
+	} else {
+		module, err = c.cipher.encryptGCM(data, moduleAAD(c.file.fileAAD, dataType, rowGroup, column, ordinal))
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	ph.CompressedPageSize = int32(len(module))
+	header := &bytes.Buffer{}
+	if err := writeThrift(ph, header); err != nil {
+		return 0, err
+	}
+	headerModule, err := c.cipher.encryptGCM(header.Bytes(), moduleAAD(c.file.fileAAD, headerType, rowGroup, column, ordinal))
+	if err != nil {
+		return 0, err
+	}
+
+	if err := writeFull(w, headerModule); err != nil {
+		return 0, err
+	}
+
+	return len(module), writeFull(w, module)
+}
+
+// encryptColumnChunk sets the crypto meta data of the column chunk and encrypts its meta data.
+// The meta data of columns encrypted with the footer key is only encrypted separately if the
+// footer is not encrypted. With a plaintext footer, a copy of the meta data without statistics
+// is kept in plaintext for readers without the key.
+func (c *columnEncryptor) encryptColumnChunk(ch *parquet.ColumnChunk, rowGroup, column int16) error {
+	if c.footerKey {
+		ch.CryptoMetadata = &parquet.ColumnCryptoMetaData{ENCRYPTION_WITH_FOOTER_KEY: &parquet.EncryptionWithFooterKey{}}
+	} else {
+		ch.CryptoMetadata = &parquet.ColumnCryptoMetaData{ENCRYPTION_WITH_COLUMN_KEY: &parquet.EncryptionWithColumnKey{
+			PathInSchema: ch.MetaData.PathInSchema,
+			KeyMetadata:  c.keyMetadata,
+		}}
+	}
+
+	if c.footerKey && !c.file.props.PlaintextFooter {
+		return nil
+	}
+
+	buf := &bytes.Buffer{}
+	if err := writeThrift(ch.MetaData, buf); err != nil {
+		return err
+	}
+	module, err := c.cipher.encryptGCM(buf.Bytes(), moduleAAD(c.file.fileAAD, moduleColumnMetaData, rowGroup, column, 0))
+	if err != nil {
+		return err
+	}
+	ch.EncryptedColumnMetadata = module[4:]
+
+	if c.file.props.PlaintextFooter {
+		md := *ch.MetaData
+		md.Statistics = nil
+		md.EncodingStats = nil
+		ch.MetaData = &md
+	} else {
+		ch.MetaData = nil
+	}
+
+	return nil
+}
+
+func (c *columnEncryptor) encryptOffsetIndex(oi *parquet.OffsetIndex, rowGroup, column int16) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := writeThrift(oi, buf); err != nil {
+		return nil, err
+	}
+
+	return c.cipher.encryptGCM(buf.Bytes(), moduleAAD(c.file.fileAAD, moduleOffsetIndex, rowGroup, column, 0))
+}
+
+// fileDecryptor decrypts the modules of a file that is read.
+type fileDecryptor struct {
+	props   *FileDecryptionProperties
+	fileAAD []byte
+	ctr     bool
+	footer  *aesCipher
+	// decrypted contains the column chunks whose meta data is decrypted already.
+	decrypted map[*parquet.ColumnChunk]bool
+	columns   map[string]*aesCipher
+}
+
+func newFileDecryptor(props *FileDecryptionProperties, alg *parquet.EncryptionAlgorithm, footerKeyMetadata []byte) (*fileDecryptor, error) {
+	var (
+		prefix, unique []byte
+		supply         bool
+		d              = &fileDecryptor{
+			props:     props,
+			decrypted: make(map[*parquet.ColumnChunk]bool),
+			columns:   make(map[string]*aesCipher),
+		}
+	)
+	switch {
+	case alg.AES_GCM_V1 != nil:
+		prefix, unique, supply = alg.AES_GCM_V1.AadPrefix, alg.AES_GCM_V1.AadFileUnique, alg.AES_GCM_V1.GetSupplyAadPrefix()
+	case alg.AES_GCM_CTR_V1 != nil:
+		prefix, unique, supply = alg.AES_GCM_CTR_V1.AadPrefix, alg.AES_GCM_CTR_V1.AadFileUnique, alg.AES_GCM_CTR_V1.GetSupplyAadPrefix()
+		d.ctr = true
+	default:
+		return nil, errors.New("unsupported encryption algorithm")
+	}
+
+	if len(props.AADPrefix) > 0 {
+		if len(prefix) > 0 && !bytes.Equal(prefix, props.AADPrefix) {
+			return nil, errors.New("the AAD prefix doesn't match the AAD prefix stored in the file")
+		}
+		prefix = props.AADPrefix
+	} else if supply {
+		return nil, errors.New("the file requires an AAD prefix")
+	}
+	d.fileAAD = append(append([]byte(nil), prefix...), unique...)
+
+	key := props.FooterKey
+	if len(key) == 0 && props.KeyRetriever != nil {
+		var err error
+		if key, err = props.KeyRetriever.RetrieveKey(footerKeyMetadata); err != nil {
+			return nil, errors.Wrap(err, "retrieving footer key failed")
+		}
+	}
+	if len(key) > 0 {
+		var err error
+		if d.footer, err = newAESCipher(key); err != nil {
+			return nil, errors.Wrap(err, "invalid footer key")
+		}
+	}
+
+	return d, nil
+}
+
+func (d *fileDecryptor) footerCipher() (*aesCipher, error) {
+	if d.footer == nil {
+		return nil, errors.New("the footer key is not available")
+	}
+
+	return d.footer, nil
+}
+
+// decryptFooter decrypts the encrypted file meta data.
+func (d *fileDecryptor) decryptFooter(module []byte) (*parquet.FileMetaData, error) {
+	c, err := d.footerCipher()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(module) < 4 {
+		return nil, errors.New("encrypted footer is too short")
+	}
+	plain, err := c.decryptGCM(module[4:], moduleAAD(d.fileAAD, moduleFooter, 0, 0, 0))
+	if err != nil {
+		return nil, errors.Wrap(err, "decrypting footer failed")
+	}
+
+	meta := &parquet.FileMetaData{}
+	if err := readThrift(meta, bytes.NewReader(plain)); err != nil {
+		return nil, errors.Wrap(err, "read file meta failed")
+	}
+
+	return meta, nil
+}
+
+// verifyFooter verifies the signature of a plaintext footer, which consists of the nonce and tag
+// of the encrypted plaintext.
+func (d *fileDecryptor) verifyFooter(plain, signature []byte) error {
+	c, err := d.footerCipher()
+	if err != nil {
+		return err
+	}
+
+	if len(signature) != nonceLength+gcmTagLength {
+		return errors.New("invalid footer signature")
+	}
+	sealed := c.gcm.Seal(nil, signature[:nonceLength], plain, moduleAAD(d.fileAAD, moduleFooter, 0, 0, 0))
+	if subtle.ConstantTimeCompare(sealed[len(sealed)-gcmTagLength:], signature[nonceLength:]) != 1 {
+		return errors.New("footer signature verification failed")
+	}
+
+	return nil
+}
+
+// column returns the decryptor of a column chunk, or nil if the column chunk is not encrypted.
+// If the meta data of the column chunk is encrypted, it is decrypted and stored in the column
+// chunk.
+func (d *fileDecryptor) column(col *Column, chunk *parquet.ColumnChunk, rowGroup int) (*columnDecryptor, error) {
+	if chunk.CryptoMetadata == nil {
+		return nil, nil
+	}
+
+	var c *aesCipher
+	switch {
+	case chunk.CryptoMetadata.ENCRYPTION_WITH_FOOTER_KEY != nil:
+		var err error
+		if c, err = d.footerCipher(); err != nil {
+			return nil, errors.Wrapf(err, "column %q is encrypted with the footer key", col.FlatName())
+		}
+	case chunk.CryptoMetadata.ENCRYPTION_WITH_COLUMN_KEY != nil:
+		var err error
+		if c, err = d.columnCipher(col.FlatName(), chunk.CryptoMetadata.ENCRYPTION_WITH_COLUMN_KEY); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.Errorf("unsupported crypto meta data for column %q", col.FlatName())
+	}
+
+	rg, err := toOrdinal(rowGroup, "row group")
+	if err != nil {
+		return nil, err
+	}
+	column, err := toOrdinal(col.Index(), "column")
+	if err != nil {
+		return nil, err
+	}
+
+	if chunk.EncryptedColumnMetadata != nil && !d.decrypted[chunk] {
+		plain, err := c.decryptGCM(chunk.EncryptedColumnMetadata, moduleAAD(d.fileAAD, moduleColumnMetaData, rg, column, 0))
+		if err != nil {
+			return nil, errors.Wrapf(err, "decrypting meta data of column %q failed", col.FlatName())
+		}
+		md := &parquet.ColumnMetaData{}
+		if err := readThrift(md, bytes.NewReader(plain)); err != nil {
+			return nil, errors.Wrapf(err, "reading meta data of column %q failed", col.FlatName())
+		}
+		chunk.MetaData = md
+		d.decrypted[chunk] = true
+	}
+
+	return &columnDecryptor{cipher: c, ctr: d.ctr, fileAAD: d.fileAAD, rowGroup: rg, column: column}, nil
+}
+
+func (d *fileDecryptor) columnCipher(name string, meta *parquet.EncryptionWithColumnKey) (*aesCipher, error) {
+	if c, ok := d.columns[name]; ok {
+		return c, nil
+	}
+
+	key := d.props.ColumnKeys[name]
+	if len(key) == 0 && d.props.KeyRetriever != nil {
+		var err error
+		if key, err = d.props.KeyRetriever.RetrieveKey(meta.KeyMetadata); err != nil {
+			return nil, errors.Wrapf(err, "retrieving key of column %q failed", name)
+		}
+	}
+	if len(key) == 0 {
+		return nil, errors.Errorf("no key for column %q (%s)", name, strings.Join(meta.PathInSchema, "."))
+	}
+
+	c, err := newAESCipher(key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid key for column %q", name)
+	}
+	d.columns[name] = c
+	return c, nil
+}
+
+// columnDecryptor decrypts the modules of a column chunk.
+type columnDecryptor struct {
+	cipher   *aesCipher
+	ctr      bool
+	fileAAD  []byte
+	rowGroup int16
+	column   int16
+}
+
+// readPageHeader reads and decrypts the header of a page.
+func (c *columnDecryptor) readPageHeader(r io.Reader, maxLen int64, dictionary bool, page int) (*parquet.PageHeader, error) {
+	module, err := readModule(r, maxLen)
+	if err != nil {
+		return nil, err
+	}
+
+	moduleType := moduleDataPageHeader
+	if dictionary {
+		moduleType = moduleDictionaryPageHeader
+	}
+	ordinal, err := toOrdinal(page, "page")
+	if err != nil {
+		return nil, err
+	}
+	plain, err := c.cipher.decryptGCM(module, moduleAAD(c.fileAAD, moduleType, c.rowGroup, c.column, ordinal))
+	if err != nil {
+		return nil, errors.Wrap(err, "decrypting page header failed")
+	}
+
+	ph := &parquet.PageHeader{}
+	if err := readThrift(ph, bytes.NewReader(plain)); err != nil {
+		return nil, err
+	}
+
+	return ph, nil
+}
+
+// readPage reads and decrypts the data of a page, and sets the compressed page size of the
+// header to the size of the decrypted data.
+func (c *columnDecryptor) readPage(r io.Reader, ph *parquet.PageHeader, page int) (io.Reader, error) {
+	if ph.CompressedPageSize < 4 {
+		return nil, errors.New("invalid page data size")
+	}
+	module, err := readModule(r, int64(ph.CompressedPageSize))
+	if err != nil {
+		return nil, err
+	}
+
+	var plain []byte
+	if c.ctr {
+		plain, err = c.cipher.decryptCTR(module)
+	} else {
+		moduleType := moduleDataPage
+		if ph.Type == parquet.PageType_DICTIONARY_PAGE {
+			moduleType = moduleDictionaryPage
+		}
+		ordinal, oerr := toOrdinal(page, "page")
+		if oerr != nil {
+			return nil, oerr
+		}
+		plain, err = c.cipher.decryptGCM(module, moduleAAD(c.fileAAD, moduleType, c.rowGroup, c.column, ordinal))
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "decrypting page failed")
+	}
+
+	ph.CompressedPageSize = int32(len(plain))
+	return bytes.NewReader(plain), nil
+}
+
+func (c *columnDecryptor) decryptOffsetIndex(module []byte) ([]byte, error) {
+	if len(module) < 4 {
+		return nil, errors.New("encrypted offset index is too short")
+	}
+
+	return c.cipher.decryptGCM(module[4:], moduleAAD(c.fileAAD, moduleOffsetIndex, c.rowGroup, c.column, 0))
+}
@@ -0,0 +1,458 @@
+package goparquet
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sagia-inneractive/parquet-go/parquet"
+	"github.com/sagia-inneractive/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+// withNonces makes the encryption use the provided nonces, and returns a function to restore the
+// random nonces.
+func withNonces(nonces []byte) func() {
+	old := nonceReader
+	nonceReader = bytes.NewReader(nonces)
+	return func() { nonceReader = old }
+}
+
+func TestAESGCMModule(t *testing.T) {
+	// test case 2 of the GCM specification
+	defer withNonces(make([]byte, nonceLength))()
+
+	c, err := newAESCipher(make([]byte, 16))
+	require.NoError(t, err)
+
+	module, err := c.encryptGCM(make([]byte, 16), nil)
+	require.NoError(t, err)
+
+	expected := mustDecodeHex(t, "2c000000"+
+		"000000000000000000000000"+
+		"0388dace60b6a392f328c2b971b2fe78"+
+		"ab6e47d42cec13bdf53a67b21257bddf")
+	require.Equal(t, expected, module)
+
+	plain, err := c.decryptGCM(module[4:], nil)
+	require.NoError(t, err)
+	require.Equal(t, make([]byte, 16), plain)
+
+	_, err = c.decryptGCM(module[4:], []byte{1})
+	require.Error(t, err)
+}
+
+func TestAESCTRModule(t *testing.T) {
+	nonce := mustDecodeHex(t, "101112131415161718191a1b")
+	defer withNonces(nonce)()
+
+	c, err := newAESCipher(mustDecodeHex(t, "000102030405060708090a0b0c0d0e0f"))
+	require.NoError(t, err)
+
+	plain := []byte("hello parquet modular encryption")
+	module, err := c.encryptCTR(plain)
+	require.NoError(t, err)
+
+	// the ciphertext was computed with openssl enc -aes-128-ctr, using the nonce followed by the
+	// initial counter 1 as IV
+	expected := append(mustDecodeHex(t, "2c000000"), nonce...)
+	expected = append(expected, mustDecodeHex(t, "66b249f6b23061802ba17b04c5b2397fa05b6fce7d6fd38174af2485b34e8450")...)
+	require.Equal(t, expected, module)
+
+	decrypted, err := c.decryptCTR(module[4:])
+	require.NoError(t, err)
+	require.Equal(t, plain, decrypted)
+}
+
+func TestModuleAAD(t *testing.T) {
+	fileAAD := []byte("prefix")
+
+	require.Equal(t, []byte("prefix\x00"), moduleAAD(fileAAD, moduleFooter, 1, 2, 3))
+	require.Equal(t, []byte("prefix\x01\x01\x00\x02\x01"), moduleAAD(fileAAD, moduleColumnMetaData, 1, 258, 3))
+	require.Equal(t, []byte("prefix\x02\x01\x00\x02\x00\x03\x00"), moduleAAD(fileAAD, moduleDataPage, 1, 2, 3))
+	require.Equal(t, []byte("prefix\x04\x01\x00\x02\x00\x03\x00"), moduleAAD(fileAAD, moduleDataPageHeader, 1, 2, 3))
+	require.Equal(t, []byte("prefix\x05\x01\x00\x02\x00"), moduleAAD(fileAAD, moduleDictionaryPageHeader, 1, 2, 3))
+	require.Equal(t, []byte("prefix\x07\x01\x00\x02\x00"), moduleAAD(fileAAD, moduleOffsetIndex, 1, 2, 3))
+}
+
+var (
+	// the keys of the encrypted files of the parquet-testing repository, which are also used for
+	// the files written by the tests
+	testFooterKey  = []byte("0123456789012345")
+	testColumnKey1 = []byte("1234567890123450")
+	testColumnKey2 = []byte("1234567890123451")
+
+	testKeyRetriever = StaticKeyRetriever{
+		"kf":  testFooterKey,
+		"kc1": testColumnKey1,
+		"kc2": testColumnKey2,
+	}
+)
+
+func writeEncryptedFile(t *testing.T, props *FileEncryptionProperties) ([]byte, []map[string]interface{}) {
+	sd, err := parquetschema.ParseSchemaDefinition(`message msg {
+  required int64 id;
+  optional binary name (STRING);
+  required double value;
+  optional group tags (LIST) {
+    repeated group list {
+      required binary element (STRING);
+    }
+  }
+}`)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	w := NewFileWriter(buf, WithSchemaDefinition(sd), WithEncryption(props), WithOffsetIndex(), WithMaxPageSize(256))
+
+	var rows []map[string]interface{}
+	for rg := 0; rg < 3; rg++ {
+		for i := 0; i < 100; i++ {
+			id := int64(rg*100 + i)
+			row := map[string]interface{}{
+				"id":    id,
+				"value": float64(id) / 2,
+			}
+			if id%4 != 0 {
+				row["name"] = []byte(fmt.Sprintf("name-%d", id%5))
+			}
+			if id%3 == 0 {
+				row["tags"] = map[string]interface{}{
+					"list": []map[string]interface{}{
+						{"element": []byte("a")},
+						{"element": []byte(fmt.Sprintf("tag-%d", id))},
+					},
+				}
+			}
+			require.NoError(t, w.AddData(row))
+			rows = append(rows, row)
+		}
+		require.NoError(t, w.FlushRowGroup())
+	}
+	require.NoError(t, w.Close())
+
+	return buf.Bytes(), rows
+}
+
+func TestEncryptionRoundtrip(t *testing.T) {
+	for _, alg := range []EncryptionAlgorithm{AESGCM, AESGCMCTR} {
+		for _, plaintextFooter := range []bool{false, true} {
+			t.Run(fmt.Sprintf("algorithm=%d,plaintext_footer=%t", alg, plaintextFooter), func(t *testing.T) {
+				data, rows := writeEncryptedFile(t, &FileEncryptionProperties{
+					Algorithm:         alg,
+					FooterKey:         testFooterKey,
+					FooterKeyMetadata: []byte("kf"),
+					PlaintextFooter:   plaintextFooter,
+					Columns: map[string]*ColumnEncryptionProperties{
+						"name":              {Key: testColumnKey1, KeyMetadata: []byte("kc1")},
+						"value":             {},
+						"tags.list.element": {Key: testColumnKey2, KeyMetadata: []byte("kc2")},
+					},
+				})
+				require.False(t, bytes.Contains(data, []byte("name-1")), "plaintext value found in encrypted file")
+				require.False(t, bytes.Contains(data, []byte("tag-3")), "plaintext value found in encrypted file")
+
+				decryption := WithDecryption(&FileDecryptionProperties{KeyRetriever: testKeyRetriever})
+
+				r, err := NewFileReaderWithOptions(bytes.NewReader(data), decryption)
+				require.NoError(t, err)
+				require.Equal(t, rows, readAllRows(t, r))
+
+				r, err = NewFileReaderAt(bytes.NewReader(data), int64(len(data)), decryption, WithFooterReadSize(100))
+				require.NoError(t, err)
+				require.Equal(t, rows, readAllRows(t, r))
+
+				// the offset indexes are encrypted as well
+				require.NoError(t, r.SeekToRow(157))
+				row, err := r.NextRow()
+				require.NoError(t, err)
+				require.Equal(t, rows[157], row)
+
+				dict, err := r.ReadColumnDictionary(1, "name")
+				require.NoError(t, err)
+				require.NotNil(t, dict)
+				require.Len(t, dict.Values, 5)
+
+				// explicitly provided keys take precedence over the key retriever
+				r, err = NewFileReaderWithOptions(bytes.NewReader(data), WithDecryption(&FileDecryptionProperties{
+					FooterKey:  testFooterKey,
+					ColumnKeys: map[string][]byte{"name": testColumnKey1, "tags.list.element": testColumnKey2},
+				}))
+				require.NoError(t, err)
+				require.Equal(t, rows, readAllRows(t, r))
+
+				_, err = NewFileReaderWithOptions(bytes.NewReader(data), WithDecryption(&FileDecryptionProperties{
+					FooterKey:  testFooterKey,
+					ColumnKeys: map[string][]byte{"name": testColumnKey2, "tags.list.element": testColumnKey2},
+				}))
+				require.Error(t, err)
+
+				if !plaintextFooter {
+					_, err = NewFileReader(bytes.NewReader(data))
+					require.Error(t, err)
+					return
+				}
+
+				// without keys, only the columns that are not encrypted can be read
+				_, err = NewFileReader(bytes.NewReader(data))
+				require.Error(t, err)
+
+				r, err = NewFileReader(bytes.NewReader(data), "id")
+				require.NoError(t, err)
+				for _, expected := range rows {
+					row, err := r.NextRow()
+					require.NoError(t, err)
+					require.Equal(t, map[string]interface{}{"id": expected["id"]}, row)
+				}
+
+				// the signature of the plaintext footer is verified
+				_, err = NewFileReaderWithOptions(bytes.NewReader(data), WithDecryption(&FileDecryptionProperties{FooterKey: testColumnKey1}))
+				require.Error(t, err)
+			})
+		}
+	}
+}
+
+func TestEncryptionAllColumnsWithFooterKey(t *testing.T) {
+	data, rows := writeEncryptedFile(t, &FileEncryptionProperties{FooterKey: testFooterKey, PlaintextFooter: true})
+
+	r, err := NewFileReaderWithOptions(bytes.NewReader(data), WithDecryption(&FileDecryptionProperties{FooterKey: testFooterKey}))
+	require.NoError(t, err)
+	require.Equal(t, rows, readAllRows(t, r))
+
+	_, err = NewFileReader(bytes.NewReader(data), "id")
+	require.Error(t, err)
+}
+
+func TestEncryptionAADPrefix(t *testing.T) {
+	data, rows := writeEncryptedFile(t, &FileEncryptionProperties{
+		FooterKey:               testFooterKey,
+		AADPrefix:               []byte("file-1.parquet"),
+		DisableAADPrefixStorage: true,
+	})
+
+	_, err := NewFileReaderWithOptions(bytes.NewReader(data), WithDecryption(&FileDecryptionProperties{FooterKey: testFooterKey}))
+	require.Error(t, err)
+
+	_, err = NewFileReaderWithOptions(bytes.NewReader(data), WithDecryption(&FileDecryptionProperties{
+		FooterKey: testFooterKey,
+		AADPrefix: []byte("file-2.parquet"),
+	}))
+	require.Error(t, err)
+
+	r, err := NewFileReaderWithOptions(bytes.NewReader(data), WithDecryption(&FileDecryptionProperties{
+		FooterKey: testFooterKey,
+		AADPrefix: []byte("file-1.parquet"),
+	}))
+	require.NoError(t, err)
+	require.Equal(t, rows, readAllRows(t, r))
+
+	// stored AAD prefixes don't need to be provided
+	data, rows = writeEncryptedFile(t, &FileEncryptionProperties{
+		FooterKey: testFooterKey,
+		AADPrefix: []byte("file-1.parquet"),
+	})
+
+	r, err = NewFileReaderWithOptions(bytes.NewReader(data), WithDecryption(&FileDecryptionProperties{FooterKey: testFooterKey}))
+	require.NoError(t, err)
+	require.Equal(t, rows, readAllRows(t, r))
+}
+
+func TestEncryptionInvalidProperties(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(`message msg {
+  required int64 id;
+}`)
+	require.NoError(t, err)
+
+	for _, props := range []*FileEncryptionProperties{
+		{FooterKey: []byte("short")},
+		{FooterKey: testFooterKey, Columns: map[string]*ColumnEncryptionProperties{"missing": {}}},
+		{FooterKey: testFooterKey, Columns: map[string]*ColumnEncryptionProperties{"id": {Key: []byte("short")}}},
+	} {
+		w := NewFileWriter(&bytes.Buffer{}, WithSchemaDefinition(sd), WithEncryption(props))
+		require.NoError(t, w.AddData(map[string]interface{}{"id": int64(1)}))
+		require.Error(t, w.Close())
+	}
+}
+
+func TestEncryptedFilesCantBeMerged(t *testing.T) {
+	data, _ := writeEncryptedFile(t, &FileEncryptionProperties{FooterKey: testFooterKey, PlaintextFooter: true})
+
+	err := MergeFiles(&bytes.Buffer{}, bytes.NewReader(data))
+	require.Error(t, err)
+}
+
+// parquetTestingDir returns the data directory of the parquet-testing repository, which
+// contains files written by other implementations. It can be set with PARQUET_TESTING_DATA_DIR.
+func parquetTestingDir() string {
+	if dir := os.Getenv("PARQUET_TESTING_DATA_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join("testdata", "parquet-testing")
+}
+
+func TestEncryptionInterop(t *testing.T) {
+	// the files are encrypted with the published keys and contain the rows of the encryption
+	// tests of parquet-cpp
+	for _, tt := range []struct {
+		file            string
+		plaintextFooter bool
+	}{
+		{"encrypt_columns_and_footer.parquet.encrypted", false},
+		{"encrypt_columns_and_footer_ctr.parquet.encrypted", false},
+		{"encrypt_columns_plaintext_footer.parquet.encrypted", true},
+	} {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := ioutil.ReadFile(filepath.Join(parquetTestingDir(), tt.file))
+			if os.IsNotExist(err) {
+				t.Skipf("%s is missing, copy the data directory of the parquet-testing repository to %s", tt.file, parquetTestingDir())
+			}
+			require.NoError(t, err)
+
+			columns := []string{"boolean_field", "int32_field", "float_field", "double_field"}
+			r, err := NewFileReaderWithOptions(bytes.NewReader(data),
+				WithColumns(columns...),
+				WithDecryption(&FileDecryptionProperties{KeyRetriever: testKeyRetriever}))
+			require.NoError(t, err)
+
+			rows := readAllRows(t, r)
+			require.NotEmpty(t, rows)
+			for i, row := range rows {
+				require.Equal(t, map[string]interface{}{
+					"boolean_field": i%2 == 0,
+					"int32_field":   int32(i),
+					"float_field":   float32(i) * 1.1,
+					"double_field":  float64(i) * 1.1111111,
+				}, row, "row %d", i)
+			}
+
+			r, err = NewFileReaderWithOptions(bytes.NewReader(data), WithColumns(columns...),
+				WithDecryption(&FileDecryptionProperties{FooterKey: testFooterKey, ColumnKeys: map[string][]byte{"double_field": testColumnKey2}}))
+			if err == nil {
+				_, err = r.NextRow()
+			}
+			require.Error(t, err)
+
+			if !tt.plaintextFooter {
+				return
+			}
+
+			// the columns encrypted with the footer key are stored in plaintext
+			r, err = NewFileReader(bytes.NewReader(data), "int32_field")
+			require.NoError(t, err)
+			for i, row := range readAllRows(t, r) {
+				require.Equal(t, map[string]interface{}{"int32_field": int32(i)}, row)
+			}
+		})
+	}
+}
+
+func TestEncryptionSpecLayout(t *testing.T) {
+	// the file is decrypted using only crypto/aes and the layout of the specification, to find
+	// mistakes that the encryption and decryption of this package have in common
+	sd, err := parquetschema.ParseSchemaDefinition(`message msg {
+  required int64 id;
+}`)
+	require.NoError(t, err)
+
+	for _, alg := range []EncryptionAlgorithm{AESGCM, AESGCMCTR} {
+		t.Run(fmt.Sprintf("algorithm=%d", alg), func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w := NewFileWriter(buf,
+				WithSchemaDefinition(sd),
+				WithCompressionCodec(parquet.CompressionCodec_UNCOMPRESSED),
+				WithColumnEncoding(parquet.Encoding_PLAIN, false, "id"),
+				WithEncryption(&FileEncryptionProperties{Algorithm: alg, FooterKey: testFooterKey, AADPrefix: []byte("tester")}))
+			for i := 0; i < 10; i++ {
+				require.NoError(t, w.AddData(map[string]interface{}{"id": int64(i)}))
+			}
+			require.NoError(t, w.Close())
+			data := buf.Bytes()
+
+			// an encrypted footer is framed by PARE instead of PAR1
+			require.Equal(t, "PARE", string(data[:4]))
+			require.Equal(t, "PARE", string(data[len(data)-4:]))
+			footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+			footer := bytes.NewReader(data[len(data)-8-footerLen : len(data)-8])
+
+			cryptoMeta := &parquet.FileCryptoMetaData{}
+			require.NoError(t, readThrift(cryptoMeta, footer))
+			var aadPrefix, aadFileUnique []byte
+			if alg == AESGCM {
+				require.NotNil(t, cryptoMeta.EncryptionAlgorithm.AES_GCM_V1)
+				aadPrefix, aadFileUnique = cryptoMeta.EncryptionAlgorithm.AES_GCM_V1.AadPrefix, cryptoMeta.EncryptionAlgorithm.AES_GCM_V1.AadFileUnique
+			} else {
+				require.NotNil(t, cryptoMeta.EncryptionAlgorithm.AES_GCM_CTR_V1)
+				aadPrefix, aadFileUnique = cryptoMeta.EncryptionAlgorithm.AES_GCM_CTR_V1.AadPrefix, cryptoMeta.EncryptionAlgorithm.AES_GCM_CTR_V1.AadFileUnique
+			}
+			require.Equal(t, []byte("tester"), aadPrefix)
+			require.Len(t, aadFileUnique, 8)
+			fileAAD := append(append([]byte{}, aadPrefix...), aadFileUnique...)
+
+			block, err := aes.NewCipher(testFooterKey)
+			require.NoError(t, err)
+			gcm, err := cipher.NewGCM(block)
+			require.NoError(t, err)
+
+			// a module is its length as 4 byte little endian integer, followed by the 12 byte nonce
+			// and the ciphertext, which ends with the 16 byte tag for AES-GCM
+			module := func(b []byte) []byte {
+				return b[4 : 4+binary.LittleEndian.Uint32(b)]
+			}
+			openGCM := func(m, aad []byte) []byte {
+				plain, err := gcm.Open(nil, m[:12], m[12:], aad)
+				require.NoError(t, err)
+				return plain
+			}
+			// the AAD of a module is the file AAD, the module type, and the row group, column and
+			// page ordinals as 2 byte little endian integers
+			footerModule := module(data[len(data)-8-footer.Len() : len(data)-8])
+			meta := &parquet.FileMetaData{}
+			require.NoError(t, readThrift(meta, bytes.NewReader(openGCM(footerModule, append(fileAAD, 0)))))
+			require.Equal(t, int64(10), meta.NumRows)
+			require.Len(t, meta.RowGroups, 1)
+			require.NotNil(t, meta.RowGroups[0].Columns[0].CryptoMetadata.ENCRYPTION_WITH_FOOTER_KEY)
+
+			pos := meta.RowGroups[0].Columns[0].MetaData.DataPageOffset
+			headerModule := module(data[pos:])
+			ph := &parquet.PageHeader{}
+			require.NoError(t, readThrift(ph, bytes.NewReader(openGCM(headerModule, append(fileAAD, 4, 0, 0, 0, 0, 0, 0)))))
+			require.Equal(t, parquet.PageType_DATA_PAGE, ph.Type)
+			require.Equal(t, int32(10), ph.DataPageHeader.NumValues)
+
+			// the compressed page size includes the length, nonce and tag of the page module
+			pos += int64(4 + len(headerModule))
+			pageModule := module(data[pos:])
+			require.Equal(t, int(ph.CompressedPageSize), 4+len(pageModule))
+
+			var page []byte
+			if alg == AESGCM {
+				page = openGCM(pageModule, append(fileAAD, 2, 0, 0, 0, 0, 0, 0))
+			} else {
+				// AES-CTR uses the nonce followed by the 4 byte big endian counter 1 as IV
+				iv := append(append([]byte{}, pageModule[:12]...), 0, 0, 0, 1)
+				page = make([]byte, len(pageModule)-12)
+				cipher.NewCTR(block, iv).XORKeyStream(page, pageModule[12:])
+			}
+
+			// the required column has no levels, so the page only contains the plain values
+			require.Len(t, page, 80)
+			for i := 0; i < 10; i++ {
+				require.Equal(t, uint64(i), binary.LittleEndian.Uint64(page[8*i:]))
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package goparquet
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/sagia-inneractive/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+type closeTracker struct {
+	*bytes.Reader
+	closed bool
+}
+
+func (c *closeTracker) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestExternalColumns(t *testing.T) {
+	schema := `message msg {
+  required int64 id;
+  optional binary name (STRING);
+}`
+
+	sidecar := &bytes.Buffer{}
+	data := buildMergeInput(t, schema, 0, 3, WithExternalColumns("data/names.bin", sidecar, "name"), WithOffsetIndex(), WithMaxPageSize(128))
+	require.False(t, bytes.Contains(data, []byte("name-1")))
+	require.True(t, bytes.Contains(sidecar.Bytes(), []byte("name-1")))
+	require.Equal(t, magic, sidecar.Bytes()[:4])
+
+	meta, _, err := readFileMetaData(bytes.NewReader(data), nil)
+	require.NoError(t, err)
+	for _, rg := range meta.RowGroups {
+		require.Nil(t, rg.Columns[0].FilePath)
+		require.NotNil(t, rg.Columns[1].FilePath)
+		require.Equal(t, "data/names.bin", *rg.Columns[1].FilePath)
+	}
+
+	var opened []string
+	ext := &closeTracker{Reader: bytes.NewReader(sidecar.Bytes())}
+	opener := WithExternalFileOpener(func(path string) (io.ReadSeeker, error) {
+		opened = append(opened, path)
+		return ext, nil
+	})
+
+	var rows []map[string]interface{}
+	for i := 0; i < 150; i++ {
+		row := map[string]interface{}{"id": int64(i)}
+		if i%3 != 0 {
+			row["name"] = []byte(fmt.Sprintf("name-%d", i%7))
+		}
+		rows = append(rows, row)
+	}
+
+	r, err := NewFileReaderWithOptions(bytes.NewReader(data), opener)
+	require.NoError(t, err)
+	require.Equal(t, rows, readAllRows(t, r))
+
+	require.NoError(t, r.SeekToRow(77))
+	row, err := r.NextRow()
+	require.NoError(t, err)
+	require.Equal(t, rows[77], row)
+
+	dict, err := r.ReadColumnDictionary(2, "name")
+	require.NoError(t, err)
+	require.NotNil(t, dict)
+	require.Len(t, dict.Values, 7)
+
+	require.Equal(t, []string{"data/names.bin"}, opened)
+	require.NoError(t, r.Close())
+	require.True(t, ext.closed)
+
+	r, err = NewFileReaderAt(bytes.NewReader(data), int64(len(data)), WithExternalFileOpener(func(string) (io.ReadSeeker, error) {
+		return bytes.NewReader(sidecar.Bytes()), nil
+	}))
+	require.NoError(t, err)
+	require.Equal(t, rows, readAllRows(t, r))
+
+	// the columns stored in the file itself can be read without an opener
+	r, err = NewFileReader(bytes.NewReader(data), "id")
+	require.NoError(t, err)
+	require.Len(t, readAllRows(t, r), len(rows))
+
+	r, err = NewFileReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	_, err = r.NextRow()
+	require.Error(t, err)
+}
+
+func TestExternalColumnsUnknownColumn(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(`message msg {
+  required int64 id;
+}`)
+	require.NoError(t, err)
+
+	w := NewFileWriter(&bytes.Buffer{}, WithSchemaDefinition(sd), WithExternalColumns("ids.bin", &bytes.Buffer{}, "missing"))
+	require.NoError(t, w.AddData(map[string]interface{}{"id": int64(1)}))
+	require.Error(t, w.Close())
+}
+
+func TestWriteMetaDataFile(t *testing.T) {
+	schema := `message msg {
+  required int64 id;
+  optional binary name (STRING);
+}`
+
+	files := map[string][]byte{
+		"part-0.parquet": buildMergeInput(t, schema, 0, 2, WithMetaData(map[string]string{"a": "1"})),
+		"part-1.parquet": buildMergeInput(t, schema, 100, 1, WithOffsetIndex()),
+	}
+	paths := []string{"part-0.parquet", "part-1.parquet"}
+
+	var (
+		inputs   []io.ReadSeeker
+		expected []map[string]interface{}
+	)
+	for _, p := range paths {
+		inputs = append(inputs, bytes.NewReader(files[p]))
+
+		r, err := NewFileReader(bytes.NewReader(files[p]))
+		require.NoError(t, err)
+		expected = append(expected, readAllRows(t, r)...)
+	}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteMetaDataFile(buf, paths, inputs))
+
+	r, err := NewFileReaderWithOptions(bytes.NewReader(buf.Bytes()), WithExternalFileOpener(func(path string) (io.ReadSeeker, error) {
+		data, ok := files[path]
+		if !ok {
+			return nil, fmt.Errorf("unknown file %q", path)
+		}
+		return bytes.NewReader(data), nil
+	}))
+	require.NoError(t, err)
+	require.Equal(t, 3, r.RowGroupCount())
+	require.Equal(t, int64(len(expected)), r.NumRows())
+	require.Equal(t, map[string]string{"a": "1"}, r.MetaData())
+	require.Equal(t, expected, readAllRows(t, r))
+
+	require.Error(t, WriteMetaDataFile(&bytes.Buffer{}, paths[:1], inputs))
+	require.Error(t, MergeFiles(&bytes.Buffer{}, bytes.NewReader(buf.Bytes())))
+}
@@ -11,52 +11,203 @@ import (
 
 var magic = []byte{'P', 'A', 'R', '1'}
 
-func readFileMetaData(r io.ReadSeeker) (*parquet.FileMetaData, error) {
+// readFileMetaData reads the file meta data. If the file is encrypted, the returned
+// fileDecryptor is used to decrypt the column chunks. It is nil if the file is not encrypted or
+// no decryption properties were provided for a file with a plaintext footer.
+func readFileMetaData(r io.ReadSeeker, props *FileDecryptionProperties) (*parquet.FileMetaData, *fileDecryptor, error) {
 	if _, err := r.Seek(0, io.SeekStart); err != nil {
-		return nil, errors.Wrap(err, "seek for the file magic header failed")
+		return nil, nil, errors.Wrap(err, "seek for the file magic header failed")
 	}
 
-	buf := make([]byte, 4)
+	header := make([]byte, 4)
 	// read and validate header
-	if _, err := io.ReadFull(r, buf); err != nil {
-		return nil, errors.Wrap(err, "read the file magic header failed")
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, nil, errors.Wrap(err, "read the file magic header failed")
 	}
-	if !bytes.Equal(buf, magic) {
-		return nil, errors.Errorf("invalid parquet file header")
+	if !bytes.Equal(header, magic) && !bytes.Equal(header, magicEncrypted) {
+		return nil, nil, errors.Errorf("invalid parquet file header")
 	}
 
 	// read and validate footer
 	if _, err := r.Seek(-4, io.SeekEnd); err != nil {
-		return nil, errors.Wrap(err, "seek for the file magic footer failed")
+		return nil, nil, errors.Wrap(err, "seek for the file magic footer failed")
 	}
 
+	buf := make([]byte, 4)
 	if _, err := io.ReadFull(r, buf); err != nil {
-		return nil, errors.Wrap(err, "read the file magic header failed")
+		return nil, nil, errors.Wrap(err, "read the file magic header failed")
 	}
-	if !bytes.Equal(buf, magic) {
-		return nil, errors.Errorf("invalid parquet file footer")
+	if !bytes.Equal(buf, header) {
+		return nil, nil, errors.Errorf("invalid parquet file footer")
 	}
 
 	// read footer length
 	if _, err := r.Seek(-8, io.SeekEnd); err != nil {
-		return nil, errors.Wrap(err, "seek for the footer len failed")
+		return nil, nil, errors.Wrap(err, "seek for the footer len failed")
 	}
 	var fl int32
 	if err := binary.Read(r, binary.LittleEndian, &fl); err != nil {
-		return nil, errors.Wrap(err, "read the footer len failed")
+		return nil, nil, errors.Wrap(err, "read the footer len failed")
 	}
 	if fl <= 0 {
-		return nil, errors.Errorf("invalid footer len %d", fl)
+		return nil, nil, errors.Errorf("invalid footer len %d", fl)
 	}
 
 	// read file metadata
 	if _, err := r.Seek(-8-int64(fl), io.SeekEnd); err != nil {
-		return nil, errors.Wrap(err, "seek file meta data failed")
+		return nil, nil, errors.Wrap(err, "seek file meta data failed")
+	}
+	footer := make([]byte, fl)
+	if _, err := io.ReadFull(r, footer); err != nil {
+		return nil, nil, errors.Wrap(err, "read file meta failed")
+	}
+
+	return decodeFileMetaData(footer, bytes.Equal(header, magicEncrypted), props)
+}
+
+// readFileMetaDataAt reads the file meta data using a single read of the last tailSize bytes of
+// the file. Only if the meta data is larger than that, a second read is required for the rest of
+// it. The magic header is only validated if the file is not larger than tailSize.
+func readFileMetaDataAt(r io.ReaderAt, size int64, tailSize int64, props *FileDecryptionProperties) (*parquet.FileMetaData, *fileDecryptor, error) {
+	if size < 12 {
+		return nil, nil, errors.Errorf("file size %d is too small for a parquet file", size)
+	}
+
+	if tailSize < 8 {
+		tailSize = 8
+	}
+	if tailSize > size {
+		tailSize = size
+	}
+
+	tail := make([]byte, tailSize)
+	if err := readAtFull(r, tail, size-tailSize); err != nil {
+		return nil, nil, errors.Wrap(err, "read the file footer failed")
+	}
+
+	footerMagic := tail[tailSize-4:]
+	if !bytes.Equal(footerMagic, magic) && !bytes.Equal(footerMagic, magicEncrypted) {
+		return nil, nil, errors.Errorf("invalid parquet file footer")
+	}
+
+	if tailSize == size && !bytes.Equal(tail[:4], footerMagic) {
+		return nil, nil, errors.Errorf("invalid parquet file header")
+	}
+
+	fl := int64(int32(binary.LittleEndian.Uint32(tail[tailSize-8:])))
+	if fl <= 0 || fl > size-12 {
+		return nil, nil, errors.Errorf("invalid footer len %d", fl)
+	}
+
+	data := tail[:tailSize-8]
+	if fl <= int64(len(data)) {
+		data = data[int64(len(data))-fl:]
+	} else {
+		missing := fl - int64(len(data))
+		buf := make([]byte, fl)
+		if err := readAtFull(r, buf[:missing], size-8-fl); err != nil {
+			return nil, nil, errors.Wrap(err, "read file meta data failed")
+		}
+		copy(buf[missing:], data)
+		data = buf
+	}
+
+	return decodeFileMetaData(data, bytes.Equal(footerMagic, magicEncrypted), props)
+}
+
+// decodeFileMetaData decodes the footer of a file, without its length and magic. An encrypted
+// footer consists of the file crypto meta data followed by the encrypted file meta data. A
+// plaintext footer of an encrypted file is followed by its signature, which is verified if the
+// footer key is available.
+func decodeFileMetaData(footer []byte, encrypted bool, props *FileDecryptionProperties) (*parquet.FileMetaData, *fileDecryptor, error) {
+	br := bytes.NewReader(footer)
+	if encrypted {
+		if props == nil {
+			return nil, nil, errors.New("the file has an encrypted footer, but no decryption properties were provided")
+		}
+
+		cryptoMeta := &parquet.FileCryptoMetaData{}
+		if err := readThrift(cryptoMeta, br); err != nil {
+			return nil, nil, errors.Wrap(err, "read file crypto meta data failed")
+		}
+
+		d, err := newFileDecryptor(props, cryptoMeta.EncryptionAlgorithm, cryptoMeta.KeyMetadata)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		meta, err := d.decryptFooter(footer[len(footer)-br.Len():])
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return meta, d, nil
 	}
+
 	meta := &parquet.FileMetaData{}
-	if err := readThrift(meta, io.LimitReader(r, int64(fl))); err != nil {
-		return nil, errors.Wrap(err, "read file meta failed")
+	if err := readThrift(meta, br); err != nil {
+		return nil, nil, errors.Wrap(err, "read file meta failed")
+	}
+
+	if meta.EncryptionAlgorithm == nil || props == nil {
+		return meta, nil, nil
+	}
+
+	d, err := newFileDecryptor(props, meta.EncryptionAlgorithm, meta.FooterSigningKeyMetadata)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if d.footer != nil {
+		n := len(footer) - br.Len()
+		if err := d.verifyFooter(footer[:n], footer[n:]); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return meta, d, nil
+}
+
+// readAtFull reads exactly len(buf) bytes from r at offset off.
+func readAtFull(r io.ReaderAt, buf []byte, off int64) error {
+	n, err := r.ReadAt(buf, off)
+	if n == len(buf) {
+		return nil
+	}
+	if err == nil || err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// writeFileMetaData writes the file meta data, its length and the magic footer.
+func writeFileMetaData(w writePos, meta *parquet.FileMetaData) error {
+	pos := w.Pos()
+	if err := writeThrift(meta, w); err != nil {
+		return err
+	}
+
+	ln := int32(w.Pos() - pos)
+	if err := binary.Write(w, binary.LittleEndian, &ln); err != nil {
+		return err
+	}
+
+	return writeFull(w, magic)
+}
+
+func mapToKeyValueMetaData(kvStore map[string]string) []*parquet.KeyValue {
+	kv := make([]*parquet.KeyValue, 0, len(kvStore))
+	for i := range kvStore {
+		v := kvStore[i]
+		addr := &v
+		if v == "" {
+			addr = nil
+		}
+		kv = append(kv, &parquet.KeyValue{
+			Key:   i,
+			Value: addr,
+		})
 	}
 
-	return meta, nil
+	return kv
 }
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/sagia-inneractive/parquet-go/parquet"
+	"github.com/sagia-inneractive/parquet-go/parquetschema"
 	"github.com/pkg/errors"
 )
 
@@ -19,32 +20,272 @@ type FileReader struct {
 	rowGroupPosition int
 	currentRecord    int64
 	skipRowGroup     bool
+
+	columns    []string
+	exprs      []string
+	zeroCopy   bool
+	requested  *parquetschema.SchemaDefinition
+	projection *projection
+	decryption *FileDecryptionProperties
+	decryptor  *fileDecryptor
+
+	openExternal  func(path string) (io.ReadSeeker, error)
+	externalFiles map[string]io.ReadSeeker
+
+	// only used by readers created with NewFileReaderAt
+	ranges     *rangeReader
+	footerSize int64
+	maxGap     int64
+	prefetcher Prefetcher
+}
+
+const (
+	defaultFooterReadSize = 64 * 1024
+	defaultMaxRangeGap    = 64 * 1024
+)
+
+// FileReaderOption is an option that can be passed on to NewFileReaderWithOptions when
+// creating a new parquet file reader.
+type FileReaderOption func(*FileReader)
+
+// WithColumns limits the columns that are read to the provided columns. The column names
+// need to be provided using dotted notation. If no columns are provided, all columns are read.
+func WithColumns(columns ...string) FileReaderOption {
+	return func(fr *FileReader) {
+		fr.columns = columns
+	}
+}
+
+// WithProjection limits the columns that are read to the columns selected by the provided
+// projection expressions, e.g. "address.*", "attrs.key_value.key" or "events[].type". See
+// ResolveColumnPaths for the syntax of the expressions. Creating the reader fails if an
+// expression doesn't match any column of the file. The selected columns are added to the
+// columns provided with WithColumns.
+func WithProjection(exprs ...string) FileReaderOption {
+	return func(fr *FileReader) {
+		fr.exprs = exprs
+	}
+}
+
+// WithRequestedSchema sets the schema of the rows returned by the reader. The file can be read
+// with the requested schema if it is compatible with the file's schema: optional and repeated
+// columns that don't exist in the file are returned as null, columns of the file that are not
+// in the requested schema are not read, and INT32 and FLOAT columns can be read as INT64 and
+// DOUBLE respectively. If the schemas are incompatible, creating the reader fails with an error
+// listing all offending columns. The requested schema takes precedence over WithColumns.
+func WithRequestedSchema(sd *parquetschema.SchemaDefinition) FileReaderOption {
+	return func(fr *FileReader) {
+		fr.requested = sd
+	}
+}
+
+// WithDecryption sets the properties to read a file encrypted with Parquet Modular Encryption.
+// Files with a plaintext footer can be read without decryption properties, as long as none of
+// the encrypted columns are selected.
+func WithDecryption(props *FileDecryptionProperties) FileReaderOption {
+	return func(fr *FileReader) {
+		fr.decryption = props
+	}
+}
+
+// WithExternalFileOpener sets the function that opens the files that column chunks stored outside
+// of the parquet file refer to, e.g. the data files of a "_metadata" summary file. The function is
+// called with the path from the meta data of the column chunk, which is relative to the parquet
+// file, and is called only once per path. The opened files that implement io.Closer are closed by
+// FileReader.Close.
+func WithExternalFileOpener(open func(path string) (io.ReadSeeker, error)) FileReaderOption {
+	return func(fr *FileReader) {
+		fr.openExternal = open
+	}
+}
+
+// WithZeroCopyByteArrays enables the zero-copy mode for BYTE_ARRAY and FIXED_LEN_BYTE_ARRAY
+// columns. In this mode, the []byte values returned by the reader are slices of the buffers
+// of the pages they were read from, instead of copies. The page buffers are reused for the
+// next row group, so the values are only valid until the next row group is loaded. Callers
+// that need to keep a value longer than that have to copy it.
+func WithZeroCopyByteArrays() FileReaderOption {
+	return func(fr *FileReader) {
+		fr.zeroCopy = true
+	}
+}
+
+// WithFooterReadSize sets the number of bytes that NewFileReaderAt reads from the end of the
+// file to get the file meta data. If the file meta data is larger, a second read is required.
+// The default is 64 KiB.
+func WithFooterReadSize(size int64) FileReaderOption {
+	return func(fr *FileReader) {
+		fr.footerSize = size
+	}
+}
+
+// WithMaxRangeGap sets the maximum number of unused bytes between two selected column chunks
+// of a row group for which a FileReader created with NewFileReaderAt still fetches both
+// column chunks with a single read. The default is 64 KiB.
+func WithMaxRangeGap(gap int64) FileReaderOption {
+	return func(fr *FileReader) {
+		fr.maxGap = gap
+	}
+}
+
+// WithPrefetcher sets the Prefetcher that a FileReader created with NewFileReaderAt uses to
+// fetch the data of row groups. By default, no data is prefetched.
+func WithPrefetcher(p Prefetcher) FileReaderOption {
+	return func(fr *FileReader) {
+		fr.prefetcher = p
+	}
 }
 
 // NewFileReader creates a new FileReader. You can limit the columns that are read by providing
 // the names of the specific columns to read using dotted notation. If no columns are provided,
 // then all columns are read.
 func NewFileReader(r io.ReadSeeker, columns ...string) (*FileReader, error) {
-	meta, err := readFileMetaData(r)
+	return NewFileReaderWithOptions(r, WithColumns(columns...))
+}
+
+// NewFileReaderWithOptions creates a new FileReader. Options can be provided to configure
+// the reader, e.g. to limit the columns that are read.
+func NewFileReaderWithOptions(r io.ReadSeeker, opts ...FileReaderOption) (*FileReader, error) {
+	fr := newFileReader(r, opts)
+
+	meta, decryptor, err := readFileMetaData(r, fr.decryption)
 	if err != nil {
 		return nil, errors.Wrap(err, "reading file meta data failed")
 	}
 
-	schema, err := makeSchema(meta)
-	if err != nil {
-		return nil, errors.Wrap(err, "creating schema failed")
+	if err := fr.init(meta, decryptor); err != nil {
+		return nil, err
 	}
 
-	schema.setSelectedColumns(columns...)
 	// Reset the reader to the beginning of the file
 	if _, err := r.Seek(4, io.SeekStart); err != nil {
 		return nil, err
 	}
-	return &FileReader{
-		meta:         meta,
-		SchemaReader: schema,
-		reader:       r,
-	}, nil
+
+	return fr, nil
+}
+
+// NewFileReaderAt creates a new FileReader on a file of the provided size, which is suited for
+// storage with a high latency per request, like object stores accessed via HTTP range requests.
+// The file meta data is fetched with a single read from the end of the file. The selected column
+// chunks of a row group are fetched at once before the row group is read, where column chunks
+// that are next to each other are combined into a single read. A Prefetcher can be provided to
+// fetch the next row group in the background.
+func NewFileReaderAt(r io.ReaderAt, size int64, opts ...FileReaderOption) (*FileReader, error) {
+	rr := &rangeReader{r: r, size: size}
+	fr := newFileReader(rr, opts)
+	fr.ranges = rr
+
+	meta, decryptor, err := readFileMetaDataAt(r, size, fr.footerSize, fr.decryption)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading file meta data failed")
+	}
+
+	if err := fr.init(meta, decryptor); err != nil {
+		return nil, err
+	}
+
+	return fr, nil
+}
+
+func newFileReader(r io.ReadSeeker, opts []FileReaderOption) *FileReader {
+	fr := &FileReader{
+		reader:     r,
+		footerSize: defaultFooterReadSize,
+		maxGap:     defaultMaxRangeGap,
+		prefetcher: syncPrefetcher{},
+	}
+
+	for _, opt := range opts {
+		opt(fr)
+	}
+
+	return fr
+}
+
+func (f *FileReader) init(meta *parquet.FileMetaData, decryptor *fileDecryptor) error {
+	schema, err := makeSchema(meta)
+	if err != nil {
+		return errors.Wrap(err, "creating schema failed")
+	}
+
+	columns := f.columns
+	if len(f.exprs) > 0 {
+		paths, err := ResolveColumnPaths(schema.GetSchemaDefinition(), f.exprs...)
+		if err != nil {
+			return err
+		}
+		columns = append(append([]string(nil), columns...), paths...)
+	}
+
+	if f.requested != nil {
+		f.projection, columns, err = newProjection(f.requested, schema.GetSchemaDefinition())
+		if err != nil {
+			return err
+		}
+	}
+
+	schema.setSelectedColumns(columns...)
+
+	f.meta = meta
+	f.SchemaReader = schema
+	f.decryptor = decryptor
+
+	// the meta data of encrypted column chunks is needed before their row group is read
+	for i := range meta.RowGroups {
+		if _, err := f.rowGroupDecryptors(i); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rowGroupDecryptors returns the decryptors of the selected column chunks of a row group, by
+// column index. Column chunks that are not encrypted have a nil decryptor.
+func (f *FileReader) rowGroupDecryptors(rowGroup int) ([]*columnDecryptor, error) {
+	rg := f.meta.RowGroups[rowGroup]
+
+	var decryptors []*columnDecryptor
+	for _, c := range f.Columns() {
+		if !f.isSelected(c.flatName) || c.Index() >= len(rg.Columns) {
+			continue
+		}
+
+		d, err := f.columnDecryptor(rowGroup, c, rg.Columns[c.Index()])
+		if err != nil {
+			return nil, err
+		}
+		if d == nil {
+			continue
+		}
+
+		if decryptors == nil {
+			decryptors = make([]*columnDecryptor, len(rg.Columns))
+		}
+		decryptors[c.Index()] = d
+	}
+
+	return decryptors, nil
+}
+
+// columnDecryptor returns the decryptor of a column chunk, or nil if the column chunk is not
+// encrypted.
+func (f *FileReader) columnDecryptor(rowGroup int, col *Column, chunk *parquet.ColumnChunk) (*columnDecryptor, error) {
+	if chunk.CryptoMetadata == nil {
+		return nil, nil
+	}
+
+	if f.decryptor == nil {
+		return nil, errors.Errorf("column %q is encrypted, but no decryption properties were provided", col.FlatName())
+	}
+
+	ordinal := rowGroup
+	if o := f.meta.RowGroups[rowGroup].Ordinal; o != nil {
+		ordinal = int(*o)
+	}
+
+	return f.decryptor.column(col, chunk, ordinal)
 }
 
 // readRowGroup read the next row group into memory
@@ -52,8 +293,119 @@ func (f *FileReader) readRowGroup() error {
 	if len(f.meta.RowGroups) <= f.rowGroupPosition {
 		return io.EOF
 	}
-	f.rowGroupPosition++
-	return readRowGroup(f.reader, f.SchemaReader, f.meta.RowGroups[f.rowGroupPosition-1])
+	return f.loadRowGroup(f.rowGroupPosition, 0)
+}
+
+// loadRowGroup reads the row group with the provided index into memory, positioned at the row
+// with index firstRow within the row group.
+func (f *FileReader) loadRowGroup(rowGroup int, firstRow int64) error {
+	f.rowGroupPosition = rowGroup + 1
+
+	if f.ranges != nil {
+		if err := f.fetch(f.rowGroupRanges(rowGroup)); err != nil {
+			return err
+		}
+		if f.rowGroupPosition < len(f.meta.RowGroups) {
+			f.prefetcher.Prefetch(f.ranges.r, f.rowGroupRanges(f.rowGroupPosition))
+		}
+	}
+
+	decryptors, err := f.rowGroupDecryptors(rowGroup)
+	if err != nil {
+		return err
+	}
+
+	return readRowGroup(f.reader, f.chunkReader, f.SchemaReader, f.meta.RowGroups[rowGroup], f.zeroCopy, firstRow, decryptors)
+}
+
+// chunkReader returns the reader that contains the data of a column chunk. This is the reader of
+// the parquet file itself, unless the column chunk is stored in an external file.
+func (f *FileReader) chunkReader(chunk *parquet.ColumnChunk) (io.ReadSeeker, error) {
+	if chunk.FilePath == nil {
+		return f.reader, nil
+	}
+
+	path := *chunk.FilePath
+	if r, ok := f.externalFiles[path]; ok {
+		return r, nil
+	}
+
+	if f.openExternal == nil {
+		return nil, errors.Errorf("column chunk is stored in external file %q, but no opener was provided", path)
+	}
+
+	r, err := f.openExternal(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening external file %q failed", path)
+	}
+
+	if f.externalFiles == nil {
+		f.externalFiles = make(map[string]io.ReadSeeker)
+	}
+	f.externalFiles[path] = r
+	return r, nil
+}
+
+// Close closes the external files opened with the function provided with WithExternalFileOpener.
+// The reader of the parquet file itself is not closed.
+func (f *FileReader) Close() error {
+	var firstErr error
+	for path, r := range f.externalFiles {
+		if c, ok := r.(io.Closer); ok {
+			if err := c.Close(); err != nil && firstErr == nil {
+				firstErr = errors.Wrapf(err, "closing external file %q failed", path)
+			}
+		}
+		delete(f.externalFiles, path)
+	}
+
+	return firstErr
+}
+
+// rowGroupRanges returns the coalesced byte ranges of the selected column chunks of a row group.
+// Column chunks with invalid offsets are left out, reading them fails later on.
+func (f *FileReader) rowGroupRanges(rowGroup int) []ByteRange {
+	rg := f.meta.RowGroups[rowGroup]
+
+	var ranges []ByteRange
+	for _, c := range f.Columns() {
+		if !f.isSelected(c.flatName) || c.Index() >= len(rg.Columns) {
+			continue
+		}
+		if br, ok := f.chunkRange(rg.Columns[c.Index()]); ok {
+			ranges = append(ranges, br)
+		}
+	}
+
+	return coalesceRanges(ranges, f.maxGap)
+}
+
+func (f *FileReader) chunkRange(chunk *parquet.ColumnChunk) (ByteRange, bool) {
+	if chunk.FilePath != nil || chunk.MetaData == nil {
+		return ByteRange{}, false
+	}
+
+	br := ByteRange{Offset: chunkOffset(chunk.MetaData), Length: chunk.MetaData.TotalCompressedSize}
+	if br.Offset < 0 || br.Length <= 0 || br.end() > f.ranges.size {
+		return ByteRange{}, false
+	}
+
+	return br, true
+}
+
+// fetch loads the byte ranges into memory, if the FileReader was created with NewFileReaderAt.
+func (f *FileReader) fetch(ranges []ByteRange) error {
+	if f.ranges == nil {
+		return nil
+	}
+
+	data, err := f.prefetcher.Fetch(f.ranges.r, ranges)
+	if err != nil {
+		return errors.Wrap(err, "fetching column chunks failed")
+	}
+
+	f.ranges.setRanges(ranges, data)
+	return nil
 }
 
 // CurrentRowGroup returns information about the current row group.
@@ -69,6 +421,12 @@ func (f *FileReader) RowGroupCount() int {
 	return len(f.meta.RowGroups)
 }
 
+// RowGroups returns the meta data of all row groups of the parquet file, e.g. to decide which
+// row groups to read based on the statistics of their column chunks.
+func (f *FileReader) RowGroups() []*parquet.RowGroup {
+	return f.meta.RowGroups
+}
+
 // NumRows returns the number of rows in the parquet file. This information is directly taken from
 // the file's meta data.
 func (f *FileReader) NumRows() int64 {
@@ -104,7 +462,12 @@ func (f *FileReader) NextRow() (map[string]interface{}, error) {
 	}
 
 	f.currentRecord++
-	return f.SchemaReader.getData()
+	row, err := f.SchemaReader.getData()
+	if err != nil || f.projection == nil {
+		return row, err
+	}
+
+	return f.projection.apply(row), nil
 }
 
 // SkipRowGroup skips the currently loaded row group and advances to the next row group.
@@ -112,6 +475,292 @@ func (f *FileReader) SkipRowGroup() {
 	f.skipRowGroup = true
 }
 
+// SeekToRowGroup moves the reader to the beginning of the row group with the provided index,
+// so that the next call to NextRow returns the first row of that row group. Row groups that are
+// skipped this way are not read at all.
+func (f *FileReader) SeekToRowGroup(rowGroup int) error {
+	if rowGroup < 0 || rowGroup >= len(f.meta.RowGroups) {
+		return errors.Errorf("row group index %d is out of range", rowGroup)
+	}
+
+	f.rowGroupPosition = rowGroup
+	f.skipRowGroup = true
+	return nil
+}
+
+// SeekToRow moves the reader to the row with the provided index, counting from the beginning of
+// the file, so that the next call to NextRow returns this row. Row groups before the row are not
+// read at all. If the column chunks of the row group have an offset index, only the data pages
+// starting with the one that contains the row are read. All values before the row are skipped
+// using their repetition and definition levels, without assembling them into rows.
+func (f *FileReader) SeekToRow(row int64) error {
+	if row < 0 {
+		return errors.Errorf("row index %d is out of range", row)
+	}
+
+	var first int64
+	for i, rg := range f.meta.RowGroups {
+		if row >= first+rg.NumRows {
+			first += rg.NumRows
+			continue
+		}
+
+		rowInGroup := row - first
+		if f.rowGroupPosition == i+1 && !f.skipRowGroup && rowInGroup >= f.currentRecord {
+			// the row group is already loaded, so just skip forward
+			if err := f.skipRecords(rowInGroup - f.currentRecord); err != nil {
+				f.skipRowGroup = true
+				return err
+			}
+		} else if err := f.loadRowGroup(i, rowInGroup); err != nil {
+			f.skipRowGroup = true
+			return err
+		}
+
+		f.currentRecord = rowInGroup
+		f.skipRowGroup = false
+		return nil
+	}
+
+	return errors.Errorf("row index %d is out of range", row)
+}
+
+func (f *FileReader) skipRecords(n int64) error {
+	for _, c := range f.Columns() {
+		if err := c.data.skipRecords(n, int32(c.MaxDefinitionLevel())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadColumnDictionary reads the dictionary page of the column chunk of column colName,
+// provided in dotted notation, in the row group with index rowGroup. No data pages are
+// read. It returns nil if the column chunk has no dictionary page. Combined with
+// SeekToRowGroup, this can be used to skip row groups that can't contain the values a
+// caller is looking for.
+func (f *FileReader) ReadColumnDictionary(rowGroup int, colName string) (*ColumnDictionary, error) {
+	col, chunk, err := f.columnChunk(rowGroup, colName)
+	if err != nil {
+		return nil, err
+	}
+
+	decryptor, err := f.columnDecryptor(rowGroup, col, chunk)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.ranges != nil && chunk.FilePath == nil && chunk.MetaData != nil && chunk.MetaData.DictionaryPageOffset != nil {
+		// the dictionary page ends where the first data page starts
+		offset := *chunk.MetaData.DictionaryPageOffset
+		br := ByteRange{Offset: offset, Length: chunk.MetaData.DataPageOffset - offset}
+		if br.Offset >= 0 && br.Length > 0 && br.end() <= f.ranges.size {
+			if err := f.fetch([]ByteRange{br}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	r, err := f.chunkReader(chunk)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := readDictionaryPage(r, col, chunk, decryptor)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading dictionary page of column %q failed", colName)
+	}
+	if p == nil {
+		return nil, nil
+	}
+
+	return &ColumnDictionary{
+		Values:   p.values,
+		Sorted:   p.ph.DictionaryPageHeader.GetIsSorted(),
+		Complete: onlyDictionaryDataPages(chunk.MetaData),
+	}, nil
+}
+
+// ReadDictionaryIndices reads the column chunk of column colName, provided in dotted notation,
+// in the row group with index rowGroup, and returns its dictionary together with the dictionary
+// indices of all data pages, without resolving the indices to their values. This allows callers
+// to work on the indices, e.g. to aggregate low-cardinality columns, and to only look up the
+// values of the final results. It fails if the column chunk is not entirely dictionary encoded.
+func (f *FileReader) ReadDictionaryIndices(rowGroup int, colName string) (*DictionaryIndices, error) {
+	col, chunk, err := f.columnChunk(rowGroup, colName)
+	if err != nil {
+		return nil, err
+	}
+
+	decryptor, err := f.columnDecryptor(rowGroup, col, chunk)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.ranges != nil {
+		if br, ok := f.chunkRange(chunk); ok {
+			if err := f.fetch([]ByteRange{br}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	r, err := f.chunkReader(chunk)
+	if err != nil {
+		return nil, err
+	}
+
+	pages, dict, err := readChunk(r, col, chunk, false, decryptor)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading column %q failed", colName)
+	}
+	defer func() {
+		for _, p := range pages {
+			p.release()
+		}
+	}()
+	if dict == nil {
+		return nil, errors.Errorf("column %q has no dictionary in row group %d", colName, rowGroup)
+	}
+
+	ret := &DictionaryIndices{
+		Dictionary: &ColumnDictionary{
+			Values:   dict.values,
+			Sorted:   dict.ph.DictionaryPageHeader.GetIsSorted(),
+			Complete: true,
+		},
+		Pages: make([]*DictionaryIndexPage, 0, len(pages)),
+	}
+
+	for _, p := range pages {
+		indices := make([]int32, p.numValues())
+		n, dl, rl, err := p.readIndices(indices)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading column %q failed", colName)
+		}
+
+		if int32(n) != p.numValues() {
+			return nil, errors.Errorf("expect %d value but read %d", p.numValues(), n)
+		}
+
+		page := &DictionaryIndexPage{
+			DefinitionLevels: make([]int32, 0, n),
+			RepetitionLevels: make([]int32, 0, n),
+		}
+		if n > 0 {
+			page.DefinitionLevels = dl.toArray()
+			page.RepetitionLevels = rl.toArray()
+		}
+
+		notNull := 0
+		for _, d := range page.DefinitionLevels {
+			if d == int32(col.MaxDefinitionLevel()) {
+				notNull++
+			}
+		}
+		page.Indices = indices[:notNull]
+
+		ret.Pages = append(ret.Pages, page)
+	}
+
+	return ret, nil
+}
+
+// ColumnValues contains the decoded values and levels of a column chunk.
+type ColumnValues struct {
+	// Values contains all non-null values of the column chunk.
+	Values []interface{}
+	// DefinitionLevels and RepetitionLevels contain the levels of all values of the column chunk,
+	// including nulls. A value is null if its definition level is lower than the maximum
+	// definition level of the column.
+	DefinitionLevels []int32
+	RepetitionLevels []int32
+}
+
+// ReadColumnValues reads and decodes the column chunk of column colName, provided in dotted
+// notation, in the row group with index rowGroup, independently of the rows it belongs to. This
+// allows callers to check a column chunk against its meta data, e.g. its statistics.
+func (f *FileReader) ReadColumnValues(rowGroup int, colName string) (*ColumnValues, error) {
+	col, chunk, err := f.columnChunk(rowGroup, colName)
+	if err != nil {
+		return nil, err
+	}
+
+	decryptor, err := f.columnDecryptor(rowGroup, col, chunk)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.ranges != nil {
+		if br, ok := f.chunkRange(chunk); ok {
+			if err := f.fetch([]ByteRange{br}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	r, err := f.chunkReader(chunk)
+	if err != nil {
+		return nil, err
+	}
+
+	pages, _, err := readChunk(r, col, chunk, false, decryptor)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading column %q failed", colName)
+	}
+	defer func() {
+		for _, p := range pages {
+			p.release()
+		}
+	}()
+
+	ret := &ColumnValues{}
+	for _, p := range pages {
+		values := make([]interface{}, p.numValues())
+		n, dl, rl, err := p.readValues(values)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading column %q failed", colName)
+		}
+
+		if int32(n) != p.numValues() {
+			return nil, errors.Errorf("expect %d value but read %d", p.numValues(), n)
+		}
+		if n == 0 {
+			continue
+		}
+
+		notNull := 0
+		for _, d := range dl.toArray() {
+			ret.DefinitionLevels = append(ret.DefinitionLevels, d)
+			if d == int32(col.MaxDefinitionLevel()) {
+				notNull++
+			}
+		}
+		ret.RepetitionLevels = append(ret.RepetitionLevels, rl.toArray()...)
+		ret.Values = append(ret.Values, values[:notNull]...)
+	}
+
+	return ret, nil
+}
+
+func (f *FileReader) columnChunk(rowGroup int, colName string) (*Column, *parquet.ColumnChunk, error) {
+	if rowGroup < 0 || rowGroup >= len(f.meta.RowGroups) {
+		return nil, nil, errors.Errorf("row group index %d is out of range", rowGroup)
+	}
+
+	col := f.GetColumnByName(colName)
+	if col == nil {
+		return nil, nil, errors.Errorf("column %q not found", colName)
+	}
+
+	rg := f.meta.RowGroups[rowGroup]
+	if col.Index() >= len(rg.Columns) {
+		return nil, nil, errors.Errorf("column index %d is out of bounds", col.Index())
+	}
+
+	return col, rg.Columns[col.Index()], nil
+}
+
 // PreLoad is used to load the row group if required. It does nothing if the row group is already loaded.
 func (f *FileReader) PreLoad() error {
 	return f.advanceIfNeeded()
@@ -126,6 +775,10 @@ func (f *FileReader) MetaData() map[string]string {
 // row group. The column name has to be provided in its dotted notation.
 func (f *FileReader) ColumnMetaData(colName string) (map[string]string, error) {
 	for _, col := range f.CurrentRowGroup().Columns {
+		if col.MetaData == nil {
+			// the meta data of encrypted columns that are not selected is not decrypted
+			continue
+		}
 		if colName == strings.Join(col.MetaData.PathInSchema, ".") {
 			return keyValueMetaDataToMap(col.MetaData.KeyValueMetadata), nil
 		}
@@ -1,8 +1,10 @@
 package goparquet
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
 
 	"github.com/sagia-inneractive/parquet-go/parquet"
@@ -22,14 +24,40 @@ type FileWriter struct {
 	createdBy       string
 
 	rowGroupFlushSize int64
+	maxBufferedBytes  int64
+	maxPageSize       int64
+	maxDictSize       int64
+	sortDictionaries  bool
+	writeOffsetIndex  bool
 
-	rowGroups []*parquet.RowGroup
+	rowGroups     []*parquet.RowGroup
+	offsetIndexes [][]*parquet.OffsetIndex
+
+	// appendSize is the size of the file opened with OpenForAppend, if it can't be truncated. The
+	// file is padded on Close to be at least that large.
+	appendSize int64
+
+	encryption *FileEncryptionProperties
+	encryptor  *fileEncryptor
+
+	// external contains the files that the column chunks of columns are written to instead of w,
+	// by column.
+	external map[string]*externalFile
+
+	// encodings contains the encodings of columns that override the encodings of their column
+	// stores, in the order of the options. They are applied when the first record is added.
+	encodings []columnEncoding
 
 	codec parquet.CompressionCodec
 
 	newPage newDataPageFunc
 }
 
+const (
+	defaultMaxPageSize       = 1024 * 1024
+	defaultMaxDictionarySize = 1024 * 1024
+)
+
 // FileWriterOption describes an option function that is applied to a FileWriter when it is created.
 type FileWriterOption func(fw *FileWriter)
 
@@ -47,6 +75,8 @@ func NewFileWriter(w io.Writer, options ...FileWriterOption) *FileWriter {
 		rowGroups:    []*parquet.RowGroup{},
 		createdBy:    "parquet-go",
 		newPage:      newDataPageV1Writer,
+		maxPageSize:  defaultMaxPageSize,
+		maxDictSize:  defaultMaxDictionarySize,
 	}
 
 	for _, opt := range options {
@@ -56,6 +86,74 @@ func NewFileWriter(w io.Writer, options ...FileWriterOption) *FileWriter {
 	return fw
 }
 
+// OpenForAppend opens an existing parquet file to append row groups to it. The existing meta
+// data footer is removed, and a footer containing both the existing and the new row groups is
+// written on Close. The schema, version and key-value meta data of the existing file are kept,
+// but can be overridden using FileWriterOptions, except for the schema, which must not change.
+// If rw has a Truncate method like *os.File, the file is truncated to remove the footer right
+// away. Otherwise, Close pads the file before the new footer if the file would be smaller than
+// the existing file, which can only happen if the key-value meta data was reduced, so that no
+// bytes of the existing footer remain at the end of the file.
+func OpenForAppend(rw io.ReadWriteSeeker, options ...FileWriterOption) (*FileWriter, error) {
+	meta, _, err := readFileMetaData(rw, nil)
+	if err != nil {
+		return nil, err
+	}
+	if meta.EncryptionAlgorithm != nil {
+		return nil, errors.New("appending to encrypted files is not supported")
+	}
+
+	size, err := rw.Seek(-8, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	var footerLen int32
+	if err := binary.Read(rw, binary.LittleEndian, &footerLen); err != nil {
+		return nil, err
+	}
+	footerPos := size - int64(footerLen)
+	size += 8
+
+	s, err := makeSchema(meta)
+	if err != nil {
+		return nil, err
+	}
+	sd := s.GetSchemaDefinition()
+
+	opts := []FileWriterOption{
+		WithSchemaDefinition(sd),
+		WithMetaData(keyValueMetaDataToMap(meta.KeyValueMetadata)),
+		FileVersion(meta.Version),
+	}
+	fw := NewFileWriter(rw, append(opts, options...)...)
+	if fw.encryption != nil {
+		return nil, errors.New("appending encrypted row groups is not supported")
+	}
+	if fw.GetSchemaDefinition().String() != sd.String() {
+		return nil, errors.New("the schema of the appended data differs from the schema of the file")
+	}
+
+	if t, ok := rw.(interface{ Truncate(int64) error }); ok {
+		if err := t.Truncate(footerPos); err != nil {
+			return nil, err
+		}
+	} else {
+		fw.appendSize = size
+	}
+
+	if _, err := rw.Seek(footerPos, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	fw.w = &writePosStruct{w: rw, pos: footerPos}
+	fw.rowGroups = meta.RowGroups
+	fw.totalNumRecords = meta.NumRows
+	// the offset indexes of the existing row groups are already in the file
+	fw.offsetIndexes = make([][]*parquet.OffsetIndex, len(meta.RowGroups))
+
+	return fw, nil
+}
+
 // FileVersion sets the version of the file itself.
 func FileVersion(version int32) FileWriterOption {
 	return func(fw *FileWriter) {
@@ -98,6 +196,53 @@ func WithMaxRowGroupSize(size int64) FileWriterOption {
 	}
 }
 
+// WithMaxBufferedBytes sets the maximum number of bytes the data of the current row group may
+// use in memory before the row group is flushed automatically. In contrast to
+// WithMaxRowGroupSize, which is based on the size of the values, this takes the actual memory
+// used by the column stores into account, like the repetition and definition levels and the
+// index of the distinct values. The same restrictions as for WithMaxRowGroupSize apply.
+func WithMaxBufferedBytes(size int64) FileWriterOption {
+	return func(fw *FileWriter) {
+		fw.maxBufferedBytes = size
+	}
+}
+
+// WithMaxPageSize sets the rough maximum size of a data page. Column chunks that are
+// larger are split into multiple data pages. A record is never split across pages.
+// The default is 1 MiB.
+func WithMaxPageSize(size int64) FileWriterOption {
+	return func(fw *FileWriter) {
+		fw.maxPageSize = size
+	}
+}
+
+// WithMaxDictionarySize sets the maximum size of the dictionary of a column chunk. The
+// data pages of a column chunk are dictionary encoded until the dictionary would grow
+// beyond this size, all following data pages fall back to the encoding configured for
+// the column. The default is 1 MiB.
+func WithMaxDictionarySize(size int64) FileWriterOption {
+	return func(fw *FileWriter) {
+		fw.maxDictSize = size
+	}
+}
+
+// WithSortedDictionaries enables sorting the values of dictionary pages. Sorted dictionaries
+// are marked as such in the page header, which allows readers to search them more efficiently.
+func WithSortedDictionaries() FileWriterOption {
+	return func(fw *FileWriter) {
+		fw.sortDictionaries = true
+	}
+}
+
+// WithOffsetIndex enables writing an offset index for every column chunk. The offset index
+// contains the location and the first row of every data page, which allows readers to jump
+// directly to the page that contains a specific row.
+func WithOffsetIndex() FileWriterOption {
+	return func(fw *FileWriter) {
+		fw.writeOffsetIndex = true
+	}
+}
+
 // WithSchemaDefinition sets the schema definition to use for this parquet file.
 func WithSchemaDefinition(sd *parquetschema.SchemaDefinition) FileWriterOption {
 	return func(fw *FileWriter) {
@@ -107,6 +252,75 @@ func WithSchemaDefinition(sd *parquetschema.SchemaDefinition) FileWriterOption {
 	}
 }
 
+// WithEncryption enables Parquet Modular Encryption of the file using the provided properties.
+// It can't be used with OpenForAppend, which neither appends to encrypted files nor appends
+// encrypted row groups.
+func WithEncryption(props *FileEncryptionProperties) FileWriterOption {
+	return func(fw *FileWriter) {
+		fw.encryption = props
+	}
+}
+
+// WithExternalColumns writes the column chunks of the provided columns to w instead of the
+// parquet file itself, and references them by path in the meta data of the file. The path should
+// be relative to the parquet file. Like a parquet file, w starts with the magic bytes, so the
+// offsets of the column chunks are never 0. Readers need to be created with
+// WithExternalFileOpener to read these columns. The option can be provided multiple times to
+// distribute columns over multiple files.
+func WithExternalColumns(path string, w io.Writer, columns ...string) FileWriterOption {
+	return func(fw *FileWriter) {
+		if fw.external == nil {
+			fw.external = make(map[string]*externalFile)
+		}
+		ext := &externalFile{path: path, w: &writePosStruct{w: w}}
+		for _, col := range columns {
+			fw.external[col] = ext
+		}
+	}
+}
+
+type externalFile struct {
+	path string
+	w    writePos
+}
+
+// WithColumnEncoding sets the encoding of the provided columns, in dotted notation. This
+// overrides the encoding of their column stores, e.g. the PLAIN encoding of the columns created
+// from a schema definition. If allowDict is false, the columns are never dictionary encoded.
+// AddData fails before the first record is added if a column doesn't exist or its type doesn't
+// support the encoding. If a column is provided more than once, the last encoding is used.
+func WithColumnEncoding(enc parquet.Encoding, allowDict bool, columns ...string) FileWriterOption {
+	return func(fw *FileWriter) {
+		for _, col := range columns {
+			fw.encodings = append(fw.encodings, columnEncoding{column: col, enc: enc, allowDict: allowDict})
+		}
+	}
+}
+
+type columnEncoding struct {
+	column    string
+	enc       parquet.Encoding
+	allowDict bool
+}
+
+// applyColumnEncodings sets the encodings provided with WithColumnEncoding on the column stores,
+// in the order of the options, so the error of the first invalid encoding is returned.
+func (fw *FileWriter) applyColumnEncodings() error {
+	for _, ce := range fw.encodings {
+		col := fw.GetColumnByName(ce.column)
+		if col == nil {
+			return fmt.Errorf("column %q not found", ce.column)
+		}
+		if err := checkEncoding(col.data.parquetType(), ce.enc); err != nil {
+			return fmt.Errorf("column %q: %v", ce.column, err)
+		}
+		col.data.enc = ce.enc
+		col.data.allowDict = ce.allowDict
+	}
+	fw.encodings = nil
+	return nil
+}
+
 // WithDataPageV2 enables the writer to write pages in the new V2 format. By default,
 // the library is using the V1 format. Please be aware that this may cause compatibility
 // issues with older implementations of parquet.
@@ -181,8 +395,29 @@ func (fw *FileWriter) FlushRowGroup(opts ...FlushRowGroupOption) error {
 		return errors.New("nothing to write")
 	}
 
+	if err := fw.applyColumnEncodings(); err != nil {
+		return err
+	}
+
+	if fw.encryption != nil && fw.encryptor == nil {
+		e, err := newFileEncryptor(fw.encryption, fw.SchemaWriter)
+		if err != nil {
+			return err
+		}
+		fw.encryptor = e
+	}
+	if fw.encryptor != nil {
+		if _, err := toOrdinal(len(fw.rowGroups), "row group"); err != nil {
+			return err
+		}
+	}
+
 	if fw.w.Pos() == 0 {
-		if err := writeFull(fw.w, magic); err != nil {
+		header := magic
+		if fw.encryptor != nil {
+			header = fw.encryptor.magic()
+		}
+		if err := writeFull(fw.w, header); err != nil {
 			return err
 		}
 	}
@@ -193,17 +428,26 @@ func (fw *FileWriter) FlushRowGroup(opts ...FlushRowGroupOption) error {
 		o(h)
 	}
 
-	cc, err := writeRowGroup(fw.w, fw.SchemaWriter, fw.codec, fw.newPage, h)
+	cc, oi, err := writeRowGroup(fw.w, fw, h)
 	if err != nil {
 		return err
 	}
+	if !fw.writeOffsetIndex {
+		oi = nil
+	}
+	fw.offsetIndexes = append(fw.offsetIndexes, oi)
 
-	fw.rowGroups = append(fw.rowGroups, &parquet.RowGroup{
+	rg := &parquet.RowGroup{
 		Columns:        cc,
 		TotalByteSize:  0,
 		NumRows:        fw.rowGroupNumRecords(),
 		SortingColumns: nil,
-	})
+	}
+	if fw.encryptor != nil {
+		ordinal := int16(len(fw.rowGroups))
+		rg.Ordinal = &ordinal
+	}
+	fw.rowGroups = append(fw.rowGroups, rg)
 	fw.totalNumRecords += fw.rowGroupNumRecords()
 	// flush the schema
 	fw.SchemaWriter.resetData()
@@ -214,6 +458,10 @@ func (fw *FileWriter) FlushRowGroup(opts ...FlushRowGroupOption) error {
 // AddData adds a new record to the current row group and flushes it if auto-flush is enabled and the size
 // is equal to or greater than the configured maximum row group size.
 func (fw *FileWriter) AddData(m map[string]interface{}) error {
+	if err := fw.applyColumnEncodings(); err != nil {
+		return err
+	}
+
 	if err := fw.SchemaWriter.AddData(m); err != nil {
 		return err
 	}
@@ -222,6 +470,10 @@ func (fw *FileWriter) AddData(m map[string]interface{}) error {
 		return fw.FlushRowGroup()
 	}
 
+	if fw.maxBufferedBytes > 0 && fw.SchemaWriter.bufferedSize() >= fw.maxBufferedBytes {
+		return fw.FlushRowGroup()
+	}
+
 	return nil
 }
 
@@ -237,39 +489,38 @@ func (fw *FileWriter) Close(opts ...FlushRowGroupOption) error {
 		}
 	}
 
-	kv := make([]*parquet.KeyValue, 0, len(fw.kvStore))
-	for i := range fw.kvStore {
-		v := fw.kvStore[i]
-		addr := &v
-		if v == "" {
-			addr = nil
-		}
-		kv = append(kv, &parquet.KeyValue{
-			Key:   i,
-			Value: addr,
-		})
+	if err := writeOffsetIndexes(fw.w, fw.rowGroups, fw.offsetIndexes, fw.encryptor); err != nil {
+		return err
 	}
+
 	meta := &parquet.FileMetaData{
 		Version:          fw.version,
 		Schema:           fw.getSchemaArray(),
 		NumRows:          fw.totalNumRecords,
 		RowGroups:        fw.rowGroups,
-		KeyValueMetadata: kv,
+		KeyValueMetadata: mapToKeyValueMetaData(fw.kvStore),
 		CreatedBy:        &fw.createdBy,
 		ColumnOrders:     nil,
 	}
 
-	pos := fw.w.Pos()
-	if err := writeThrift(meta, fw.w); err != nil {
+	// the footer is written to a buffer first, so that the file can be padded if it would
+	// otherwise end before the end of the footer of the file opened with OpenForAppend
+	footer := &writePosStruct{w: &bytes.Buffer{}}
+	if fw.encryptor != nil {
+		if err := fw.encryptor.writeFooter(footer, meta); err != nil {
+			return err
+		}
+	} else if err := writeFileMetaData(footer, meta); err != nil {
 		return err
 	}
 
-	ln := int32(fw.w.Pos() - pos)
-	if err := binary.Write(fw.w, binary.LittleEndian, &ln); err != nil {
-		return err
+	if padding := fw.appendSize - fw.w.Pos() - footer.Pos(); padding > 0 {
+		if err := writeFull(fw.w, make([]byte, padding)); err != nil {
+			return err
+		}
 	}
 
-	return writeFull(fw.w, magic)
+	return writeFull(fw.w, footer.w.(*bytes.Buffer).Bytes())
 }
 
 // CurrentRowGroupSize returns a rough estimation of the uncompressed size of the current row group data. If you selected
@@ -279,6 +530,12 @@ func (fw *FileWriter) CurrentRowGroupSize() int64 {
 	return fw.SchemaWriter.DataSize()
 }
 
+// CurrentBufferedSize returns the approximate number of bytes the data of the current row group
+// uses in memory.
+func (fw *FileWriter) CurrentBufferedSize() int64 {
+	return fw.SchemaWriter.bufferedSize()
+}
+
 // CurrentFileSize returns the amount of data written to the file so far. This does not include data that is in the
 // current row group and has not been flushed yet. After closing the file, the size will be even larger since the
 // footer is appended to the file upon closing.
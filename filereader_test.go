@@ -2,10 +2,18 @@ package goparquet
 
 import (
 	"bytes"
+	"fmt"
+	"hash/crc32"
 	"io"
+	"math"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/sagia-inneractive/parquet-go/parquet"
 	"github.com/sagia-inneractive/parquet-go/parquetschema"
 	"github.com/stretchr/testify/require"
 )
@@ -108,3 +116,717 @@ func TestByteReaderSelectedInnerFull(t *testing.T) {
 		require.Empty(t, y)
 	}
 }
+
+func TestReadColumnDictionary(t *testing.T) {
+	schema, err := parquetschema.ParseSchemaDefinition(`message msg {
+  required int64 id;
+  required binary country (STRING);
+}
+`)
+	require.NoError(t, err)
+
+	countries := [][]string{
+		{"US", "CA"},
+		{"DE", "AT", "CH"},
+		{"US", "FR"},
+	}
+
+	buf := &bytes.Buffer{}
+	pw := NewFileWriter(buf, WithSchemaDefinition(schema), WithSortedDictionaries(), WithMaxPageSize(64))
+	var id int64
+	for _, values := range countries {
+		for i := 0; i < 100; i++ {
+			require.NoError(t, pw.AddData(map[string]interface{}{
+				"id":      id,
+				"country": []byte(values[i%len(values)]),
+			}))
+			id++
+		}
+		require.NoError(t, pw.FlushRowGroup())
+	}
+	require.NoError(t, pw.Close())
+
+	r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, len(countries), r.RowGroupCount())
+
+	var matching []int
+	for rg := 0; rg < r.RowGroupCount(); rg++ {
+		dict, err := r.ReadColumnDictionary(rg, "country")
+		require.NoError(t, err)
+		require.NotNil(t, dict)
+		require.True(t, dict.Sorted)
+		require.True(t, dict.Complete)
+		require.Len(t, dict.Values, len(countries[rg]))
+		for i := 1; i < len(dict.Values); i++ {
+			require.True(t, bytes.Compare(dict.Values[i-1].([]byte), dict.Values[i].([]byte)) < 0)
+		}
+
+		if dict.ContainsAny("DE", "FR") {
+			matching = append(matching, rg)
+		}
+	}
+	require.Equal(t, []int{1, 2}, matching)
+
+	_, err = r.ReadColumnDictionary(0, "id")
+	require.NoError(t, err)
+	_, err = r.ReadColumnDictionary(0, "does.not.exist")
+	require.Error(t, err)
+	_, err = r.ReadColumnDictionary(3, "country")
+	require.Error(t, err)
+
+	require.NoError(t, r.SeekToRowGroup(2))
+	row, err := r.NextRow()
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"id": int64(200), "country": []byte("US")}, row)
+
+	require.NoError(t, r.SeekToRowGroup(0))
+	for i := int64(0); i < id; i++ {
+		row, err := r.NextRow()
+		require.NoError(t, err)
+		require.Equal(t, i, row["id"])
+		rg := i / 100
+		require.Equal(t, []byte(countries[rg][int(i%100)%len(countries[rg])]), row["country"])
+	}
+	_, err = r.NextRow()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestReadColumnDictionaryNaN(t *testing.T) {
+	schema, err := parquetschema.ParseSchemaDefinition(`message msg {
+  required double value;
+}
+`)
+	require.NoError(t, err)
+
+	values := []float64{3, math.NaN(), 1, 7, math.NaN(), -2, 5, 0.5, math.Inf(1), math.NaN(), math.Inf(-1), 4}
+
+	buf := &bytes.Buffer{}
+	pw := NewFileWriter(buf, WithSchemaDefinition(schema), WithSortedDictionaries())
+	for _, v := range values {
+		require.NoError(t, pw.AddData(map[string]interface{}{"value": v}))
+	}
+	require.NoError(t, pw.Close())
+
+	r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+
+	dict, err := r.ReadColumnDictionary(0, "value")
+	require.NoError(t, err)
+	require.True(t, dict.Sorted)
+	require.True(t, dict.Complete)
+	for i := 1; i < len(dict.Values); i++ {
+		prev, cur := dict.Values[i-1].(float64), dict.Values[i].(float64)
+		require.True(t, prev < cur || math.IsNaN(cur), "%v is sorted before %v", prev, cur)
+	}
+
+	for _, v := range values {
+		require.True(t, dict.ContainsAny(v), "%v is in the dictionary", v)
+	}
+	require.False(t, dict.ContainsAny(2.0, 6.0))
+}
+
+func TestReadDictionaryIndices(t *testing.T) {
+	schema, err := parquetschema.ParseSchemaDefinition(`message msg {
+  required int64 id;
+  optional binary country (STRING);
+}
+`)
+	require.NoError(t, err)
+
+	countries := []string{"DE", "FR", "US", "AT"}
+
+	buf := &bytes.Buffer{}
+	pw := NewFileWriter(buf, WithSchemaDefinition(schema), WithMaxPageSize(128))
+	expectedCounts := map[string]int{}
+	var expectedNulls int
+	for i := 0; i < 1000; i++ {
+		data := map[string]interface{}{"id": int64(i)}
+		if i%5 == 0 {
+			expectedNulls++
+		} else {
+			c := countries[(i/3)%len(countries)]
+			expectedCounts[c]++
+			data["country"] = []byte(c)
+		}
+		require.NoError(t, pw.AddData(data))
+	}
+	require.NoError(t, pw.Close())
+
+	r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+
+	indices, err := r.ReadDictionaryIndices(0, "country")
+	require.NoError(t, err)
+	require.True(t, len(indices.Pages) > 1, "expected multiple pages")
+	require.Len(t, indices.Dictionary.Values, len(countries))
+
+	counts := make([]int, len(indices.Dictionary.Values))
+	var nulls int
+	for _, p := range indices.Pages {
+		require.Len(t, p.RepetitionLevels, len(p.DefinitionLevels))
+		notNull := 0
+		for _, dl := range p.DefinitionLevels {
+			if dl == 0 {
+				nulls++
+			} else {
+				notNull++
+			}
+		}
+		require.Len(t, p.Indices, notNull)
+		for _, idx := range p.Indices {
+			counts[idx]++
+		}
+	}
+
+	actualCounts := map[string]int{}
+	for idx, c := range counts {
+		actualCounts[string(indices.Dictionary.Values[idx].([]byte))] = c
+	}
+	require.Equal(t, expectedCounts, actualCounts)
+	require.Equal(t, expectedNulls, nulls)
+
+	_, err = r.ReadDictionaryIndices(0, "id")
+	require.Error(t, err)
+}
+
+func TestReadColumnValues(t *testing.T) {
+	schema, err := parquetschema.ParseSchemaDefinition(`message msg {
+  required int64 id;
+  optional binary name (STRING);
+  repeated int32 numbers;
+}
+`)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	pw := NewFileWriter(buf, WithSchemaDefinition(schema), WithMaxPageSize(64))
+	for i := 0; i < 100; i++ {
+		data := map[string]interface{}{"id": int64(i)}
+		if i%2 == 0 {
+			data["name"] = []byte(fmt.Sprint(i))
+		}
+		if i%3 != 0 {
+			data["numbers"] = []int32{int32(i), int32(i + 1)}
+		}
+		require.NoError(t, pw.AddData(data))
+	}
+	require.NoError(t, pw.Close())
+
+	r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+
+	id, err := r.ReadColumnValues(0, "id")
+	require.NoError(t, err)
+	require.Len(t, id.Values, 100)
+	require.Equal(t, int64(99), id.Values[99])
+	require.Len(t, id.DefinitionLevels, 100)
+	require.Len(t, id.RepetitionLevels, 100)
+
+	name, err := r.ReadColumnValues(0, "name")
+	require.NoError(t, err)
+	require.Len(t, name.Values, 50)
+	require.Equal(t, []byte("2"), name.Values[1])
+	require.Equal(t, []int32{1, 0, 1}, name.DefinitionLevels[:3])
+
+	numbers, err := r.ReadColumnValues(0, "numbers")
+	require.NoError(t, err)
+	require.Len(t, numbers.Values, 2*66)
+	require.Equal(t, []interface{}{int32(1), int32(2), int32(2), int32(3)}, numbers.Values[:4])
+	require.Equal(t, []int32{0, 1, 1, 1, 1}, numbers.DefinitionLevels[:5])
+	require.Equal(t, []int32{0, 0, 1, 0, 1}, numbers.RepetitionLevels[:5])
+
+	_, err = r.ReadColumnValues(0, "does.not.exist")
+	require.Error(t, err)
+}
+
+func TestReadPageInfo(t *testing.T) {
+	schema, err := parquetschema.ParseSchemaDefinition(`message msg {
+  required int64 id;
+  optional binary country (STRING);
+}
+`)
+	require.NoError(t, err)
+
+	countries := []string{"DE", "FR", "US", "AT"}
+
+	for _, v2 := range []bool{false, true} {
+		opts := []FileWriterOption{WithSchemaDefinition(schema), WithMaxPageSize(128), WithCompressionCodec(parquet.CompressionCodec_SNAPPY)}
+		if v2 {
+			opts = append(opts, WithDataPageV2())
+		}
+
+		buf := &bytes.Buffer{}
+		pw := NewFileWriter(buf, opts...)
+		for i := 0; i < 1000; i++ {
+			data := map[string]interface{}{"id": int64(i)}
+			if i%5 != 0 {
+				data["country"] = []byte(countries[(i/3)%len(countries)])
+			}
+			require.NoError(t, pw.AddData(data))
+		}
+		require.NoError(t, pw.Close())
+
+		r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+		require.NoError(t, err)
+
+		dataPageType := parquet.PageType_DATA_PAGE
+		if v2 {
+			dataPageType = parquet.PageType_DATA_PAGE_V2
+		}
+
+		chunk := r.meta.RowGroups[0].Columns[1].MetaData
+		pages, err := r.ReadPageInfo(0, "country")
+		require.NoError(t, err)
+		require.True(t, len(pages) > 2, "expected a dictionary page and multiple data pages")
+
+		require.Equal(t, parquet.PageType_DICTIONARY_PAGE, pages[0].Header.Type)
+		require.Equal(t, *chunk.DictionaryPageOffset, pages[0].Offset)
+		require.Equal(t, chunk.DataPageOffset, pages[1].Offset)
+
+		var (
+			size   int64
+			values int64
+		)
+		for i, p := range pages {
+			require.Equal(t, CRCMissing, p.CRC)
+			if i > 0 {
+				require.Equal(t, pages[i-1].Offset+pages[i-1].HeaderSize+int64(pages[i-1].Header.CompressedPageSize), p.Offset)
+				require.Equal(t, dataPageType, p.Header.Type)
+				if v2 {
+					values += int64(p.Header.DataPageHeaderV2.NumValues)
+				} else {
+					values += int64(p.Header.DataPageHeader.NumValues)
+				}
+			}
+			size += p.HeaderSize + int64(p.Header.CompressedPageSize)
+		}
+		require.Equal(t, chunk.TotalCompressedSize, size)
+		require.Equal(t, chunk.NumValues, values)
+
+		_, err = r.ReadPageInfo(0, "does.not.exist")
+		require.Error(t, err)
+		_, err = r.ReadPageInfo(1, "id")
+		require.Error(t, err)
+	}
+}
+
+func TestCheckPageCRC(t *testing.T) {
+	data := []byte("page data")
+	crc := int32(crc32.ChecksumIEEE(data))
+	invalid := crc + 1
+
+	require.Equal(t, CRCMissing, checkPageCRC(&parquet.PageHeader{}, data))
+	require.Equal(t, CRCValid, checkPageCRC(&parquet.PageHeader{Crc: &crc}, data))
+	require.Equal(t, CRCInvalid, checkPageCRC(&parquet.PageHeader{Crc: &invalid}, data))
+}
+
+// httpReaderAt is an io.ReaderAt that reads using HTTP range requests and counts them.
+type httpReaderAt struct {
+	url      string
+	requests int32
+}
+
+func (h *httpReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	atomic.AddInt32(&h.requests, 1)
+
+	req, err := http.NewRequest(http.MethodGet, h.url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", off, off+int64(len(p))-1))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent {
+		return 0, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	n, err := io.ReadFull(resp.Body, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func newTestRangeServer(data []byte) (*httptest.Server, func() *httpReaderAt) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "test.parquet", time.Time{}, bytes.NewReader(data))
+	}))
+
+	return srv, func() *httpReaderAt {
+		return &httpReaderAt{url: srv.URL}
+	}
+}
+
+func readAllRows(t *testing.T, r *FileReader) []map[string]interface{} {
+	var rows []map[string]interface{}
+	for {
+		row, err := r.NextRow()
+		if err == io.EOF {
+			return rows
+		}
+		require.NoError(t, err)
+		rows = append(rows, row)
+	}
+}
+
+func TestFileReaderAt(t *testing.T) {
+	data := buildTestStream(t)
+	srv, newReaderAt := newTestRangeServer(data)
+	defer srv.Close()
+
+	expected, err := NewFileReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	expectedRows := readAllRows(t, expected)
+	numRowGroups := int32(expected.RowGroupCount())
+
+	t.Run("all columns", func(t *testing.T) {
+		ra := newReaderAt()
+		r, err := NewFileReaderAt(ra, int64(len(data)))
+		require.NoError(t, err)
+		require.Equal(t, int32(1), ra.requests, "footer should be read with a single request")
+
+		require.Equal(t, expectedRows, readAllRows(t, r))
+		require.Equal(t, 1+numRowGroups, ra.requests, "every row group should be read with a single request")
+	})
+
+	t.Run("small footer read", func(t *testing.T) {
+		ra := newReaderAt()
+		_, err := NewFileReaderAt(ra, int64(len(data)), WithFooterReadSize(16))
+		require.NoError(t, err)
+		require.Equal(t, int32(2), ra.requests)
+	})
+
+	t.Run("selected columns", func(t *testing.T) {
+		for _, tt := range []struct {
+			gap      int64
+			requests int32
+		}{
+			{gap: 0, requests: 1 + 2*numRowGroups},
+			{gap: defaultMaxRangeGap, requests: 1 + numRowGroups},
+		} {
+			ra := newReaderAt()
+			r, err := NewFileReaderAt(ra, int64(len(data)), WithColumns("a", "x.d"), WithMaxRangeGap(tt.gap))
+			require.NoError(t, err)
+
+			rows := readAllRows(t, r)
+			require.Len(t, rows, len(expectedRows))
+			for i := range rows {
+				require.Equal(t, expectedRows[i]["a"], rows[i]["a"])
+				require.Equal(t, expectedRows[i]["x"].(map[string]interface{})["d"], rows[i]["x"].(map[string]interface{})["d"])
+			}
+			require.Equal(t, tt.requests, ra.requests, "gap %d", tt.gap)
+		}
+	})
+
+	t.Run("background prefetcher", func(t *testing.T) {
+		ra := newReaderAt()
+		r, err := NewFileReaderAt(ra, int64(len(data)), WithPrefetcher(NewBackgroundPrefetcher()))
+		require.NoError(t, err)
+
+		require.Equal(t, expectedRows, readAllRows(t, r))
+		require.Equal(t, 1+numRowGroups, atomic.LoadInt32(&ra.requests))
+	})
+
+	t.Run("seek to row group", func(t *testing.T) {
+		ra := newReaderAt()
+		r, err := NewFileReaderAt(ra, int64(len(data)), WithPrefetcher(NewBackgroundPrefetcher()))
+		require.NoError(t, err)
+
+		require.NoError(t, r.SeekToRowGroup(int(numRowGroups)-1))
+		rows := readAllRows(t, r)
+		require.Equal(t, expectedRows[len(expectedRows)-len(rows):], rows)
+	})
+}
+
+// countingReadSeeker counts the number of bytes read.
+type countingReadSeeker struct {
+	io.ReadSeeker
+	n int64
+}
+
+func (c *countingReadSeeker) Read(p []byte) (int, error) {
+	n, err := c.ReadSeeker.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func TestSeekToRow(t *testing.T) {
+	schema, err := parquetschema.ParseSchemaDefinition(`message msg {
+  required int64 id;
+  optional binary name (STRING);
+  repeated int32 tags;
+}`)
+	require.NoError(t, err)
+
+	const numRows = 5000
+
+	build := func(opts ...FileWriterOption) []byte {
+		buf := &bytes.Buffer{}
+		w := NewFileWriter(buf, append(opts, WithSchemaDefinition(schema), WithMaxPageSize(512))...)
+		for i := 0; i < numRows; i++ {
+			data := map[string]interface{}{"id": int64(i)}
+			if i%3 != 0 {
+				data["name"] = []byte(fmt.Sprintf("name-%d", i))
+			}
+			tags := make([]int32, i%4)
+			for j := range tags {
+				tags[j] = int32(i + j)
+			}
+			if len(tags) > 0 {
+				data["tags"] = tags
+			}
+			require.NoError(t, w.AddData(data))
+			if i%2000 == 1999 {
+				require.NoError(t, w.FlushRowGroup())
+			}
+		}
+		require.NoError(t, w.Close())
+		return buf.Bytes()
+	}
+
+	for _, opts := range [][]FileWriterOption{
+		{},
+		{WithOffsetIndex()},
+		{WithOffsetIndex(), WithDataPageV2()},
+		{WithOffsetIndex(), WithCompressionCodec(parquet.CompressionCodec_SNAPPY)},
+	} {
+		data := build(opts...)
+
+		r, err := NewFileReader(bytes.NewReader(data))
+		require.NoError(t, err)
+		expected := readAllRows(t, r)
+		require.Len(t, expected, numRows)
+
+		r, err = NewFileReader(bytes.NewReader(data))
+		require.NoError(t, err)
+
+		for _, row := range []int64{0, 4999, 2000, 2001, 1999, 3500, 3600, 3601, 17, 4000} {
+			require.NoError(t, r.SeekToRow(row), "row %d", row)
+			for i := row; i < row+3 && i < numRows; i++ {
+				got, err := r.NextRow()
+				require.NoError(t, err, "row %d", i)
+				require.Equal(t, expected[i], got, "row %d", i)
+			}
+		}
+
+		require.Error(t, r.SeekToRow(numRows))
+		require.Error(t, r.SeekToRow(-1))
+	}
+
+	t.Run("offset index", func(t *testing.T) {
+		seekAndCount := func(data []byte) int64 {
+			rs := &countingReadSeeker{ReadSeeker: bytes.NewReader(data)}
+			r, err := NewFileReader(rs)
+			require.NoError(t, err)
+			rs.n = 0
+
+			require.NoError(t, r.SeekToRow(1990))
+			row, err := r.NextRow()
+			require.NoError(t, err)
+			require.Equal(t, int64(1990), row["id"])
+			return rs.n
+		}
+
+		withIndex := build(WithOffsetIndex())
+		r, err := NewFileReader(bytes.NewReader(withIndex))
+		require.NoError(t, err)
+		for _, col := range r.meta.RowGroups[0].Columns {
+			require.NotNil(t, col.OffsetIndexOffset)
+			require.NotNil(t, col.OffsetIndexLength)
+		}
+
+		require.True(t, seekAndCount(withIndex) < seekAndCount(build())/2, "seeking with offset index should read less data")
+	})
+}
+
+func TestRequestedSchema(t *testing.T) {
+	schema, err := parquetschema.ParseSchemaDefinition(`message msg {
+  required int32 id;
+  optional float score;
+  required binary dropped (STRING);
+  repeated int32 tags;
+  optional group address {
+    required binary city (STRING);
+    optional binary zip (STRING);
+  }
+}`)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	w := NewFileWriter(buf, WithSchemaDefinition(schema))
+	require.NoError(t, w.AddData(map[string]interface{}{
+		"id":      int32(1),
+		"score":   float32(1.5),
+		"dropped": []byte("x"),
+		"tags":    []int32{1, 2},
+		"address": map[string]interface{}{
+			"city": []byte("Berlin"),
+			"zip":  []byte("10115"),
+		},
+	}))
+	require.NoError(t, w.AddData(map[string]interface{}{
+		"id":      int32(2),
+		"dropped": []byte("y"),
+	}))
+	require.NoError(t, w.Close())
+
+	requested, err := parquetschema.ParseSchemaDefinition(`message msg {
+  required int64 id;
+  optional double score;
+  repeated int64 tags;
+  optional binary comment (STRING);
+  optional group address {
+    optional binary city (STRING);
+  }
+  optional group extra {
+    required int32 value;
+  }
+}`)
+	require.NoError(t, err)
+
+	r, err := NewFileReaderWithOptions(bytes.NewReader(buf.Bytes()), WithRequestedSchema(requested))
+	require.NoError(t, err)
+	require.Equal(t, []map[string]interface{}{
+		{
+			"id":      int64(1),
+			"score":   float64(1.5),
+			"tags":    []int64{1, 2},
+			"address": map[string]interface{}{"city": []byte("Berlin")},
+		},
+		{
+			"id": int64(2),
+		},
+	}, readAllRows(t, r))
+
+	incompatible, err := parquetschema.ParseSchemaDefinition(`message msg {
+  required binary id;
+  required int64 missing;
+  optional float tags;
+  optional int32 address;
+}`)
+	require.NoError(t, err)
+
+	_, err = NewFileReaderWithOptions(bytes.NewReader(buf.Bytes()), WithRequestedSchema(incompatible))
+	require.Error(t, err)
+	for _, path := range []string{"id:", "missing:", "tags:", "address:"} {
+		require.Contains(t, err.Error(), path)
+	}
+}
+
+func TestResolveColumnPaths(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(`message msg {
+  required int64 id;
+  optional group address {
+    optional binary street (STRING);
+    optional group geo {
+      required double lat;
+      required double lon;
+    }
+  }
+  optional group attrs (MAP) {
+    repeated group key_value {
+      required binary key (STRING);
+      optional binary value (STRING);
+    }
+  }
+  optional group events (LIST) {
+    repeated group list {
+      required group element {
+        required binary type (STRING);
+        required int64 ts;
+      }
+    }
+  }
+  repeated group items {
+    required binary sku (STRING);
+  }
+}`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		exprs    []string
+		expected []string
+	}{
+		{[]string{"id"}, []string{"id"}},
+		{[]string{"address.*"}, []string{"address.street", "address.geo.lat", "address.geo.lon"}},
+		{[]string{"address.geo"}, []string{"address.geo.lat", "address.geo.lon"}},
+		{[]string{"*.geo.lat"}, []string{"address.geo.lat"}},
+		{[]string{"attrs.key_value.key"}, []string{"attrs.key_value.key"}},
+		{[]string{"attrs[].value"}, []string{"attrs.key_value.value"}},
+		{[]string{"attrs"}, []string{"attrs.key_value.key", "attrs.key_value.value"}},
+		{[]string{"events[].type"}, []string{"events.list.element.type"}},
+		{[]string{"events[]"}, []string{"events.list.element.type", "events.list.element.ts"}},
+		{[]string{"items[].sku"}, []string{"items.sku"}},
+		{[]string{"id", "address.geo.*", "id"}, []string{"id", "address.geo.lat", "address.geo.lon"}},
+	}
+
+	for idx, tt := range tests {
+		paths, err := ResolveColumnPaths(sd, tt.exprs...)
+		require.NoError(t, err, "%d. %v", idx, tt.exprs)
+		require.Equal(t, tt.expected, paths, "%d. %v", idx, tt.exprs)
+	}
+
+	for _, expr := range []string{"missing", "id.x", "id[]", "address..street", "events[x]"} {
+		_, err := ResolveColumnPaths(sd, expr)
+		require.Error(t, err, expr)
+	}
+}
+
+func TestReadWithProjection(t *testing.T) {
+	schema, err := parquetschema.ParseSchemaDefinition(`message msg {
+  required int64 id;
+  optional group address {
+    optional binary city (STRING);
+    optional binary zip (STRING);
+  }
+  optional group attrs (MAP) {
+    repeated group key_value {
+      required binary key (STRING);
+      optional binary value (STRING);
+    }
+  }
+}`)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	w := NewFileWriter(buf, WithSchemaDefinition(schema))
+	require.NoError(t, w.AddData(map[string]interface{}{
+		"id": int64(1),
+		"address": map[string]interface{}{
+			"city": []byte("Berlin"),
+			"zip":  []byte("10115"),
+		},
+		"attrs": map[string]interface{}{
+			"key_value": []map[string]interface{}{
+				{"key": []byte("a"), "value": []byte("1")},
+				{"key": []byte("b"), "value": []byte("2")},
+			},
+		},
+	}))
+	require.NoError(t, w.Close())
+
+	r, err := NewFileReaderWithOptions(bytes.NewReader(buf.Bytes()), WithProjection("address.*", "attrs[].key"))
+	require.NoError(t, err)
+	rows := readAllRows(t, r)
+	require.Len(t, rows, 1)
+	require.NotContains(t, rows[0], "id")
+	require.Equal(t, map[string]interface{}{
+		"city": []byte("Berlin"),
+		"zip":  []byte("10115"),
+	}, rows[0]["address"])
+	require.Equal(t, map[string]interface{}{
+		"key_value": []map[string]interface{}{
+			{"key": []byte("a")},
+			{"key": []byte("b")},
+		},
+	}, rows[0]["attrs"])
+
+	_, err = NewFileReaderWithOptions(bytes.NewReader(buf.Bytes()), WithProjection("address.country"))
+	require.Error(t, err)
+}
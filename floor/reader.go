@@ -21,15 +21,31 @@ func NewReader(r *goparquet.FileReader) *Reader {
 	}
 }
 
+// RowReader is a source of rows, like goparquet.FileReader, or readers that combine
+// the rows of multiple files.
+type RowReader interface {
+	NextRow() (map[string]interface{}, error)
+	GetSchemaDefinition() *parquetschema.SchemaDefinition
+}
+
+// NewRowReader returns a new high-level reader that reads the rows from r.
+func NewRowReader(r RowReader) *Reader {
+	return &Reader{
+		r: r,
+	}
+}
+
 // NewFileReader returns a new high-level parquet file reader
-// that directly reads from the provided file.
-func NewFileReader(file string) (*Reader, error) {
+// that directly reads from the provided file. The options are passed
+// on to the underlying goparquet.FileReader, e.g. to only read the
+// columns selected with goparquet.WithProjection.
+func NewFileReader(file string, opts ...goparquet.FileReaderOption) (*Reader, error) {
 	f, err := os.Open(file)
 	if err != nil {
 		return nil, err
 	}
 
-	r, err := goparquet.NewFileReader(f)
+	r, err := goparquet.NewFileReaderWithOptions(f, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -42,7 +58,7 @@ func NewFileReader(file string) (*Reader, error) {
 
 // Reader represents a high-level reader for parquet files.
 type Reader struct {
-	r *goparquet.FileReader
+	r RowReader
 	f io.Closer
 
 	data map[string]interface{}
@@ -1,6 +1,7 @@
 package goparquet
 
 import (
+	"bytes"
 	"encoding/binary"
 	"hash/fnv"
 	"io"
@@ -14,7 +15,8 @@ import (
 // DefaultHashFunc is used to generate a hash value to detect and handle duplicate values.
 // The function has to return any type that can be used as a map key. In particular, the
 // result can not be a slice. The default implementation used the fnv hash function as
-// implemented in Go's standard library.
+// implemented in Go's standard library. The dictionaries of the column stores no longer use
+// it, they compare byte array and INT96 values by their content.
 var DefaultHashFunc func([]byte) interface{}
 
 func init() {
@@ -294,6 +296,108 @@ func mapKey(a interface{}) interface{} {
 	}
 }
 
+// compareValues compares two values of the same type and returns -1, 0 or +1. Byte arrays are compared
+// lexicographically as unsigned bytes, a string is accepted wherever a byte array is expected.
+// Floating point NaN values are equal to each other and ordered after all other values, so
+// that the order is total and can be used to sort and search dictionaries.
+func compareValues(a, b interface{}) (int, error) {
+	if s, ok := a.(string); ok {
+		a = []byte(s)
+	}
+	if s, ok := b.(string); ok {
+		b = []byte(s)
+	}
+
+	switch av := a.(type) {
+	case bool:
+		if bv, ok := b.(bool); ok {
+			switch {
+			case av == bv:
+				return 0, nil
+			case bv:
+				return -1, nil
+			default:
+				return 1, nil
+			}
+		}
+	case int32:
+		if bv, ok := b.(int32); ok {
+			return compareInt64(int64(av), int64(bv)), nil
+		}
+	case int64:
+		if bv, ok := b.(int64); ok {
+			return compareInt64(av, bv), nil
+		}
+	case uint32:
+		if bv, ok := b.(uint32); ok {
+			return compareUint64(uint64(av), uint64(bv)), nil
+		}
+	case uint64:
+		if bv, ok := b.(uint64); ok {
+			return compareUint64(av, bv), nil
+		}
+	case float32:
+		if bv, ok := b.(float32); ok {
+			return compareFloat64(float64(av), float64(bv)), nil
+		}
+	case float64:
+		if bv, ok := b.(float64); ok {
+			return compareFloat64(av, bv), nil
+		}
+	case []byte:
+		if bv, ok := b.([]byte); ok {
+			return bytes.Compare(av, bv), nil
+		}
+	case [12]byte:
+		if bv, ok := b.([12]byte); ok {
+			return bytes.Compare(av[:], bv[:]), nil
+		}
+	default:
+		return 0, errors.Errorf("unsupported type %T for comparison", a)
+	}
+
+	return 0, errors.Errorf("can not compare %T with %T", a, b)
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareUint64(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareFloat64(a, b float64) int {
+	switch aNaN, bNaN := math.IsNaN(a), math.IsNaN(b); {
+	case aNaN && bNaN:
+		return 0
+	case aNaN:
+		return 1
+	case bNaN:
+		return -1
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
 func fnvHashFunc(in []byte) interface{} {
 	hash := fnv.New64()
 	if err := writeFull(hash, in); err != nil {
@@ -12,15 +12,20 @@ type pageReader interface {
 	read(r io.Reader, ph *parquet.PageHeader, codec parquet.CompressionCodec) error
 
 	readValues([]interface{}) (n int, dLevel *packedArray, rLevel *packedArray, err error)
+	readIndices([]int32) (n int, dLevel *packedArray, rLevel *packedArray, err error)
 
 	numValues() int32
+
+	// release returns the buffers of the page to the pool. Values read from the page in zero-copy
+	// mode must not be used afterwards.
+	release()
 }
 
-// pageReader is an internal interface used only internally to read the pages
+// pageWriter is an internal interface used only internally to write the pages
 type pageWriter interface {
-	init(schema SchemaWriter, col *Column, codec parquet.CompressionCodec) error
+	init(col *Column, codec parquet.CompressionCodec) error
 
-	write(w io.Writer) (int, int, error)
+	write(w io.Writer, page *dataPage) (int, int, error)
 }
 
 type newDataPageFunc func(useDict bool) pageWriter
@@ -0,0 +1,314 @@
+package goparquet
+
+import (
+	"io"
+	"math"
+
+	"github.com/pkg/errors"
+	"github.com/sagia-inneractive/parquet-go/parquet"
+	"github.com/sagia-inneractive/parquet-go/parquetschema"
+)
+
+// MergeOption is an option that can be passed on to MergeFilesWithOptions.
+type MergeOption func(*mergeOptions)
+
+type mergeOptions struct {
+	reencode      bool
+	writerOptions []FileWriterOption
+}
+
+// WithReencoding makes MergeFilesWithOptions read all rows of the input files and write them
+// to the output using a FileWriter with the provided options, instead of copying the row groups
+// of the input files. This allows coalescing many small row groups into larger ones, e.g. by
+// providing WithMaxRowGroupSize, or changing the compression codec.
+func WithReencoding(opts ...FileWriterOption) MergeOption {
+	return func(o *mergeOptions) {
+		o.reencode = true
+		o.writerOptions = opts
+	}
+}
+
+// MergeFiles writes the content of all input files as a single parquet file to w. The schemas of
+// all input files must be identical. The column chunks of the input files are copied byte for
+// byte, so every row group of the input files becomes a row group of the output file. Offset
+// indexes are copied as well if all column chunks have one, column indexes are dropped. The
+// key-value meta data of the input files is merged, the values of later input files take
+// precedence over the values of earlier input files.
+func MergeFiles(w io.Writer, inputs ...io.ReadSeeker) error {
+	return MergeFilesWithOptions(w, inputs)
+}
+
+// MergeFilesWithOptions works like MergeFiles, but allows to provide options that influence
+// how the files are merged.
+func MergeFilesWithOptions(w io.Writer, inputs []io.ReadSeeker, opts ...MergeOption) error {
+	if len(inputs) == 0 {
+		return errors.New("no input files")
+	}
+
+	o := &mergeOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	metas, schemaDef, kvStore, err := readMergeInputs(inputs)
+	if err != nil {
+		return err
+	}
+
+	if o.reencode {
+		return mergeReencoded(w, inputs, schemaDef, kvStore, o.writerOptions)
+	}
+
+	return mergeRowGroups(w, inputs, metas, kvStore)
+}
+
+// WriteMetaDataFile writes a "_metadata" summary file to w, which contains the meta data of all
+// input files, but no data. The column chunks of the summary file refer to the input files using
+// the provided paths, which should be relative to the summary file. Reading the summary file
+// with WithExternalFileOpener reads the data of all input files. The schemas of all input files
+// must be identical, and their key-value meta data is merged like in MergeFiles. Offset indexes
+// and column indexes are not referenced by the summary file.
+func WriteMetaDataFile(w io.Writer, paths []string, inputs []io.ReadSeeker) error {
+	if len(paths) != len(inputs) {
+		return errors.Errorf("got %d paths for %d input files", len(paths), len(inputs))
+	}
+	if len(inputs) == 0 {
+		return errors.New("no input files")
+	}
+
+	metas, _, kvStore, err := readMergeInputs(inputs)
+	if err != nil {
+		return err
+	}
+
+	var (
+		rowGroups []*parquet.RowGroup
+		numRows   int64
+	)
+	for i, meta := range metas {
+		for j, rg := range meta.RowGroups {
+			newRG := *rg
+			newRG.Columns = make([]*parquet.ColumnChunk, len(rg.Columns))
+			for k, chunk := range rg.Columns {
+				if chunk.FilePath != nil {
+					return errors.Errorf("column chunk %d of row group %d of input %d is stored in external file %q", k, j, i, *chunk.FilePath)
+				}
+
+				path := paths[i]
+				newRG.Columns[k] = &parquet.ColumnChunk{
+					FilePath:   &path,
+					FileOffset: chunk.FileOffset,
+					MetaData:   chunk.MetaData,
+				}
+			}
+			rowGroups = append(rowGroups, &newRG)
+			numRows += rg.NumRows
+		}
+	}
+
+	wp := &writePosStruct{w: w}
+	if err := writeFull(wp, magic); err != nil {
+		return err
+	}
+
+	createdBy := "parquet-go"
+	return writeFileMetaData(wp, &parquet.FileMetaData{
+		Version:          metas[0].Version,
+		Schema:           metas[0].Schema,
+		NumRows:          numRows,
+		RowGroups:        rowGroups,
+		KeyValueMetadata: mapToKeyValueMetaData(kvStore),
+		CreatedBy:        &createdBy,
+		ColumnOrders:     metas[0].ColumnOrders,
+	})
+}
+
+// readMergeInputs reads the meta data of all input files, checks that their schemas are
+// identical, and merges their key-value meta data.
+func readMergeInputs(inputs []io.ReadSeeker) ([]*parquet.FileMetaData, *parquetschema.SchemaDefinition, map[string]string, error) {
+	metas := make([]*parquet.FileMetaData, len(inputs))
+	var schemaDef *parquetschema.SchemaDefinition
+	for i, in := range inputs {
+		meta, _, err := readFileMetaData(in, nil)
+		if err != nil {
+			return nil, nil, nil, errors.Wrapf(err, "reading meta data of input %d failed", i)
+		}
+		if meta.EncryptionAlgorithm != nil {
+			return nil, nil, nil, errors.Errorf("input %d is encrypted", i)
+		}
+
+		s, err := makeSchema(meta)
+		if err != nil {
+			return nil, nil, nil, errors.Wrapf(err, "creating schema of input %d failed", i)
+		}
+
+		sd := s.GetSchemaDefinition()
+		if schemaDef == nil {
+			schemaDef = sd
+		} else if sd.String() != schemaDef.String() {
+			return nil, nil, nil, errors.Errorf("schema of input %d differs from the schema of input 0", i)
+		}
+
+		metas[i] = meta
+	}
+
+	kvStore := make(map[string]string)
+	for _, meta := range metas {
+		for k, v := range keyValueMetaDataToMap(meta.KeyValueMetadata) {
+			kvStore[k] = v
+		}
+	}
+
+	return metas, schemaDef, kvStore, nil
+}
+
+func mergeReencoded(w io.Writer, inputs []io.ReadSeeker, sd *parquetschema.SchemaDefinition, kvStore map[string]string, opts []FileWriterOption) error {
+	fw := NewFileWriter(w, append([]FileWriterOption{WithSchemaDefinition(sd), WithMetaData(kvStore)}, opts...)...)
+	for i, in := range inputs {
+		r, err := NewFileReader(in)
+		if err != nil {
+			return errors.Wrapf(err, "opening input %d failed", i)
+		}
+
+		for {
+			row, err := r.NextRow()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return errors.Wrapf(err, "reading row of input %d failed", i)
+			}
+
+			if err := fw.AddData(row); err != nil {
+				return errors.Wrapf(err, "writing row of input %d failed", i)
+			}
+		}
+	}
+
+	return fw.Close()
+}
+
+func mergeRowGroups(w io.Writer, inputs []io.ReadSeeker, metas []*parquet.FileMetaData, kvStore map[string]string) error {
+	wp := &writePosStruct{w: w}
+	if err := writeFull(wp, magic); err != nil {
+		return err
+	}
+
+	var (
+		rowGroups     []*parquet.RowGroup
+		offsetIndexes [][]*parquet.OffsetIndex
+		withIndexes   = true
+		numRows       int64
+	)
+	for i, in := range inputs {
+		for j, rg := range metas[i].RowGroups {
+			newRG, indexes, err := copyRowGroup(wp, in, rg)
+			if err != nil {
+				return errors.Wrapf(err, "copying row group %d of input %d failed", j, i)
+			}
+			// the ordinal is the position of the row group in the file, so it has to be updated
+			if rg.Ordinal != nil && len(rowGroups) <= math.MaxInt16 {
+				ordinal := int16(len(rowGroups))
+				newRG.Ordinal = &ordinal
+			}
+
+			rowGroups = append(rowGroups, newRG)
+			offsetIndexes = append(offsetIndexes, indexes)
+			withIndexes = withIndexes && indexes != nil
+			numRows += rg.NumRows
+		}
+	}
+
+	// the offset indexes are only written if all column chunks have one
+	if withIndexes {
+		if err := writeOffsetIndexes(wp, rowGroups, offsetIndexes, nil); err != nil {
+			return err
+		}
+	}
+
+	createdBy := "parquet-go"
+	meta := &parquet.FileMetaData{
+		Version:          metas[0].Version,
+		Schema:           metas[0].Schema,
+		NumRows:          numRows,
+		RowGroups:        rowGroups,
+		KeyValueMetadata: mapToKeyValueMetaData(kvStore),
+		CreatedBy:        &createdBy,
+		ColumnOrders:     metas[0].ColumnOrders,
+	}
+
+	return writeFileMetaData(wp, meta)
+}
+
+// copyRowGroup copies the column chunks of a row group to w and returns the row group with the
+// offsets adjusted to the new positions. The ordinal of the row group is not set. The offset indexes of the column chunks are returned
+// with adjusted offsets as well, or nil if not all column chunks have an offset index.
+func copyRowGroup(w writePos, r io.ReadSeeker, rg *parquet.RowGroup) (*parquet.RowGroup, []*parquet.OffsetIndex, error) {
+	newRG := &parquet.RowGroup{
+		Columns:             make([]*parquet.ColumnChunk, len(rg.Columns)),
+		TotalByteSize:       rg.TotalByteSize,
+		NumRows:             rg.NumRows,
+		SortingColumns:      rg.SortingColumns,
+		TotalCompressedSize: rg.TotalCompressedSize,
+	}
+
+	indexes := make([]*parquet.OffsetIndex, len(rg.Columns))
+	for i, chunk := range rg.Columns {
+		if chunk.FilePath != nil {
+			return nil, nil, errors.Errorf("column chunk %d is stored in external file %q", i, *chunk.FilePath)
+		}
+		if chunk.MetaData == nil {
+			return nil, nil, errors.Errorf("column chunk %d has no meta data", i)
+		}
+
+		oi, err := readOffsetIndex(r, chunk, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		start := chunkOffset(chunk.MetaData)
+		if _, err := r.Seek(start, io.SeekStart); err != nil {
+			return nil, nil, err
+		}
+
+		delta := w.Pos() - start
+		if i == 0 && rg.FileOffset != nil {
+			offset := *rg.FileOffset + delta
+			newRG.FileOffset = &offset
+		}
+		if _, err := io.CopyN(w, r, chunk.MetaData.TotalCompressedSize); err != nil {
+			return nil, nil, errors.Wrapf(err, "copying column chunk %d failed", i)
+		}
+
+		md := *chunk.MetaData
+		md.DataPageOffset += delta
+		if md.DictionaryPageOffset != nil {
+			offset := *md.DictionaryPageOffset + delta
+			md.DictionaryPageOffset = &offset
+		}
+		if md.IndexPageOffset != nil {
+			offset := *md.IndexPageOffset + delta
+			md.IndexPageOffset = &offset
+		}
+
+		newRG.Columns[i] = &parquet.ColumnChunk{
+			FileOffset: chunk.FileOffset + delta,
+			MetaData:   &md,
+		}
+
+		if oi != nil {
+			for _, loc := range oi.PageLocations {
+				loc.Offset += delta
+			}
+		}
+		indexes[i] = oi
+	}
+
+	for _, oi := range indexes {
+		if oi == nil {
+			return newRG, nil, nil
+		}
+	}
+
+	return newRG, indexes, nil
+}
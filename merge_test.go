@@ -0,0 +1,184 @@
+package goparquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/sagia-inneractive/parquet-go/parquet"
+	"github.com/sagia-inneractive/parquet-go/parquetschema"
+	"github.com/stretchr/testify/require"
+)
+
+func buildMergeInput(t *testing.T, schema string, first, rowGroups int, opts ...FileWriterOption) []byte {
+	sd, err := parquetschema.ParseSchemaDefinition(schema)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	w := NewFileWriter(buf, append([]FileWriterOption{WithSchemaDefinition(sd)}, opts...)...)
+	id := int64(first)
+	for rg := 0; rg < rowGroups; rg++ {
+		for i := 0; i < 50; i++ {
+			row := map[string]interface{}{"id": id}
+			if id%3 != 0 {
+				row["name"] = []byte(fmt.Sprintf("name-%d", id%7))
+			}
+			require.NoError(t, w.AddData(row))
+			id++
+		}
+		require.NoError(t, w.FlushRowGroup())
+	}
+	require.NoError(t, w.Close())
+
+	return buf.Bytes()
+}
+
+func TestMergeFiles(t *testing.T) {
+	schema := `message msg {
+  required int64 id;
+  optional binary name (STRING);
+}`
+
+	inputs := [][]byte{
+		buildMergeInput(t, schema, 0, 2, WithMetaData(map[string]string{"a": "1", "b": "1"})),
+		buildMergeInput(t, schema, 100, 1, WithCompressionCodec(parquet.CompressionCodec_SNAPPY)),
+		buildMergeInput(t, schema, 150, 3, WithMetaData(map[string]string{"b": "2"})),
+	}
+
+	var expected []map[string]interface{}
+	for _, in := range inputs {
+		r, err := NewFileReader(bytes.NewReader(in))
+		require.NoError(t, err)
+		expected = append(expected, readAllRows(t, r)...)
+	}
+
+	readers := func() []io.ReadSeeker {
+		var ret []io.ReadSeeker
+		for _, in := range inputs {
+			ret = append(ret, bytes.NewReader(in))
+		}
+		return ret
+	}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, MergeFiles(buf, readers()...))
+
+	r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, 6, r.RowGroupCount())
+	require.Equal(t, int64(len(expected)), r.NumRows())
+	require.Equal(t, map[string]string{"a": "1", "b": "2"}, r.MetaData())
+	require.Equal(t, expected, readAllRows(t, r))
+
+	buf.Reset()
+	require.NoError(t, MergeFilesWithOptions(buf, readers(), WithReencoding()))
+
+	r, err = NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, 1, r.RowGroupCount())
+	require.Equal(t, map[string]string{"a": "1", "b": "2"}, r.MetaData())
+	require.Equal(t, expected, readAllRows(t, r))
+
+	other := buildMergeInput(t, `message msg {
+  required int64 id;
+  optional binary name (STRING);
+  optional int32 extra;
+}`, 0, 1)
+	err = MergeFiles(&bytes.Buffer{}, bytes.NewReader(inputs[0]), bytes.NewReader(other))
+	require.Error(t, err)
+}
+
+func TestMergeFilesWithOffsetIndex(t *testing.T) {
+	schema := `message msg {
+  required int64 id;
+  optional binary name (STRING);
+}`
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, MergeFiles(buf,
+		bytes.NewReader(buildMergeInput(t, schema, 0, 2, WithOffsetIndex(), WithMaxPageSize(128))),
+		bytes.NewReader(buildMergeInput(t, schema, 100, 2, WithOffsetIndex(), WithMaxPageSize(128))),
+	))
+
+	r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	for rg := 0; rg < r.RowGroupCount(); rg++ {
+		for _, chunk := range r.meta.RowGroups[rg].Columns {
+			require.NotNil(t, chunk.OffsetIndexOffset)
+		}
+	}
+
+	for _, row := range []int64{170, 3, 199, 120, 50} {
+		require.NoError(t, r.SeekToRow(row))
+		data, err := r.NextRow()
+		require.NoError(t, err)
+		require.Equal(t, row, data["id"])
+	}
+}
+
+// setRowGroupMetaData rewrites the footer of a file with the column orders and the row group
+// offsets, sizes and ordinals set, which the FileWriter doesn't write.
+func setRowGroupMetaData(t *testing.T, data []byte) []byte {
+	meta, _, err := readFileMetaData(bytes.NewReader(data), nil)
+	require.NoError(t, err)
+
+	for i, rg := range meta.RowGroups {
+		offset := chunkOffset(rg.Columns[0].MetaData)
+		rg.FileOffset = &offset
+		var size int64
+		for _, chunk := range rg.Columns {
+			size += chunk.MetaData.TotalCompressedSize
+		}
+		rg.TotalCompressedSize = &size
+		ordinal := int16(i)
+		rg.Ordinal = &ordinal
+	}
+	for range meta.RowGroups[0].Columns {
+		meta.ColumnOrders = append(meta.ColumnOrders, &parquet.ColumnOrder{TYPE_ORDER: parquet.NewTypeDefinedOrder()})
+	}
+
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	wp := &writePosStruct{w: &bytes.Buffer{}}
+	require.NoError(t, writeFull(wp, data[:len(data)-8-footerLen]))
+	require.NoError(t, writeFileMetaData(wp, meta))
+	return wp.w.(*bytes.Buffer).Bytes()
+}
+
+func TestMergeFilesRowGroupMetaData(t *testing.T) {
+	schema := `message msg {
+  required int64 id;
+  optional binary name (STRING);
+}`
+
+	inputs := [][]byte{
+		setRowGroupMetaData(t, buildMergeInput(t, schema, 0, 2)),
+		setRowGroupMetaData(t, buildMergeInput(t, schema, 100, 1)),
+	}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, MergeFiles(buf, bytes.NewReader(inputs[0]), bytes.NewReader(inputs[1])))
+
+	meta, _, err := readFileMetaData(bytes.NewReader(buf.Bytes()), nil)
+	require.NoError(t, err)
+	require.Len(t, meta.ColumnOrders, 2)
+	require.NotNil(t, meta.ColumnOrders[0].TYPE_ORDER)
+
+	var inputRowGroups []*parquet.RowGroup
+	for _, in := range inputs {
+		m, _, err := readFileMetaData(bytes.NewReader(in), nil)
+		require.NoError(t, err)
+		inputRowGroups = append(inputRowGroups, m.RowGroups...)
+	}
+
+	require.Len(t, meta.RowGroups, 3)
+	for i, rg := range meta.RowGroups {
+		require.NotNil(t, rg.FileOffset)
+		require.Equal(t, chunkOffset(rg.Columns[0].MetaData), *rg.FileOffset)
+		require.Equal(t, inputRowGroups[i].TotalCompressedSize, rg.TotalCompressedSize)
+		require.NotNil(t, rg.Ordinal)
+		require.Equal(t, int16(i), *rg.Ordinal)
+	}
+	require.NotEqual(t, *inputRowGroups[2].FileOffset, *meta.RowGroups[2].FileOffset)
+}
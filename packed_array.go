@@ -22,7 +22,7 @@ type packedArray struct {
 	reader unpack8int32Func
 }
 
-// This function is only for testing, as it flushes first, so be careful!
+// toArray returns all values of the packed array as a slice.
 func (pa *packedArray) toArray() []int32 {
 	ret := make([]int32, pa.count)
 	for i := range ret {
@@ -99,3 +99,20 @@ func (pa *packedArray) appendArray(other *packedArray) {
 		pa.appendSingle(v)
 	}
 }
+
+// subArray returns a new packed array that contains the values from start
+// (inclusive) to end (exclusive).
+func (pa *packedArray) subArray(start, end int) *packedArray {
+	if start == 0 && end == pa.count {
+		return pa
+	}
+
+	ret := &packedArray{}
+	ret.reset(pa.bw)
+	for i := start; i < end; i++ {
+		v, _ := pa.at(i)
+		ret.appendSingle(v)
+	}
+
+	return ret
+}
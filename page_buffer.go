@@ -0,0 +1,92 @@
+package goparquet
+
+import (
+	"io"
+	"sync"
+)
+
+// bufferPool holds byte slices that are used for the compressed and decompressed content of
+// pages, so that reading a file doesn't allocate new buffers for every single page.
+var bufferPool sync.Pool
+
+// getBuffer returns a buffer of the provided size from the pool, or nil if the pool has no buffer
+// that is large enough. New buffers are never allocated up front, as the sizes come from the file
+// and a corrupt file must not lead to huge allocations.
+func getBuffer(size int) []byte {
+	if p, ok := bufferPool.Get().(*[]byte); ok && cap(*p) >= size {
+		return (*p)[:size]
+	}
+
+	return nil
+}
+
+func putBuffer(buf []byte) {
+	if cap(buf) == 0 {
+		return
+	}
+	buf = buf[:0]
+	bufferPool.Put(&buf)
+}
+
+// pageBuffer is a reader on the decompressed content of a single page. Besides reading, it allows
+// decoders to take slices of the content instead of copying it. The buffers owned by the
+// pageBuffer are returned to the pool on release, so any slice taken from it must not be used
+// afterwards.
+type pageBuffer struct {
+	data []byte
+	pos  int
+
+	// pooled contains the buffers that are returned to the pool once the page is released.
+	pooled [][]byte
+}
+
+func (p *pageBuffer) Read(b []byte) (int, error) {
+	if p.pos >= len(p.data) {
+		return 0, io.EOF
+	}
+
+	n := copy(b, p.data[p.pos:])
+	p.pos += n
+	return n, nil
+}
+
+func (p *pageBuffer) ReadByte() (byte, error) {
+	if p.pos >= len(p.data) {
+		return 0, io.EOF
+	}
+
+	b := p.data[p.pos]
+	p.pos++
+	return b, nil
+}
+
+// next returns the next n bytes of the page without copying them. The capacity of the returned
+// slice is limited to its length, so appending to it never overwrites the rest of the page.
+func (p *pageBuffer) next(n int) ([]byte, error) {
+	if n < 0 || n > len(p.data)-p.pos {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	ret := p.data[p.pos : p.pos+n : p.pos+n]
+	p.pos += n
+	return ret, nil
+}
+
+// alloc returns a buffer of size n whose lifetime is bound to the page.
+func (p *pageBuffer) alloc(n int) []byte {
+	buf := getBuffer(n)
+	if buf == nil {
+		buf = make([]byte, n)
+	}
+	p.pooled = append(p.pooled, buf)
+	return buf
+}
+
+func (p *pageBuffer) release() {
+	for _, buf := range p.pooled {
+		putBuffer(buf)
+	}
+	p.pooled = nil
+	p.data = nil
+	p.pos = 0
+}
@@ -3,6 +3,7 @@ package goparquet
 import (
 	"bytes"
 	"io"
+	"sort"
 
 	"github.com/sagia-inneractive/parquet-go/parquet"
 	"github.com/pkg/errors"
@@ -46,6 +47,8 @@ func (dp *dictPageReader) read(r io.Reader, ph *parquet.PageHeader, codec parque
 	if err != nil {
 		return err
 	}
+	// the dictionary values are always copied, so the page isn't needed after decoding them
+	defer reader.release()
 
 	if cap(dp.values) < int(dp.numValues) {
 		dp.values = make([]interface{}, 0, dp.numValues)
@@ -64,12 +67,14 @@ func (dp *dictPageReader) read(r io.Reader, ph *parquet.PageHeader, codec parque
 }
 
 type dictPageWriter struct {
-	col *Column
+	col    *Column
+	values []interface{}
+	sorted bool
 
 	codec parquet.CompressionCodec
 }
 
-func (dp *dictPageWriter) init(schema SchemaWriter, col *Column, codec parquet.CompressionCodec) error {
+func (dp *dictPageWriter) init(col *Column, codec parquet.CompressionCodec) error {
 	dp.col = col
 	dp.codec = codec
 	return nil
@@ -82,9 +87,9 @@ func (dp *dictPageWriter) getHeader(comp, unComp int) *parquet.PageHeader {
 		CompressedPageSize:   int32(comp),
 		Crc:                  nil,
 		DictionaryPageHeader: &parquet.DictionaryPageHeader{
-			NumValues: dp.col.data.values.numDistinctValues(),
+			NumValues: int32(len(dp.values)),
 			Encoding:  parquet.Encoding_PLAIN, // PLAIN_DICTIONARY is deprecated in the Parquet 2.0 specification
-			IsSorted:  nil,
+			IsSorted:  &dp.sorted,
 		},
 	}
 	return ph
@@ -99,7 +104,7 @@ func (dp *dictPageWriter) write(w io.Writer) (int, int, error) {
 		return 0, 0, err
 	}
 
-	err = encodeValue(dataBuf, encoder, dp.col.data.values.values)
+	err = encodeValue(dataBuf, encoder, dp.values)
 	if err != nil {
 		return 0, 0, err
 	}
@@ -117,3 +122,73 @@ func (dp *dictPageWriter) write(w io.Writer) (int, int, error) {
 
 	return compSize, unCompSize, writeFull(w, comp)
 }
+
+// ColumnDictionary contains the dictionary of a column chunk.
+type ColumnDictionary struct {
+	// Values contains the dictionary values in the order they are stored in the dictionary page.
+	Values []interface{}
+	// Sorted is true if the writer marked the dictionary values as sorted.
+	Sorted bool
+	// Complete is true if all data pages of the column chunk are dictionary encoded, i.e. every
+	// value of the column chunk is contained in the dictionary.
+	Complete bool
+}
+
+// ContainsAny reports whether the column chunk may contain any of the provided values. It only
+// returns false if the dictionary is complete and contains none of the values, in which case the
+// row group of the column chunk can be skipped when looking for these values. For byte array
+// columns, the values can be provided as either []byte or string.
+func (d *ColumnDictionary) ContainsAny(values ...interface{}) bool {
+	if !d.Complete {
+		return true
+	}
+
+	for _, v := range values {
+		if d.contains(v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (d *ColumnDictionary) contains(v interface{}) bool {
+	if d.Sorted {
+		idx := sort.Search(len(d.Values), func(i int) bool {
+			c, err := compareValues(d.Values[i], v)
+			return err != nil || c >= 0
+		})
+		if idx == len(d.Values) {
+			return false
+		}
+		c, err := compareValues(d.Values[idx], v)
+		return err != nil || c == 0
+	}
+
+	for _, dv := range d.Values {
+		// values that can't be compared are assumed to be contained to err on the safe side
+		if c, err := compareValues(dv, v); err != nil || c == 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DictionaryIndexPage contains the dictionary indices of a single data page.
+type DictionaryIndexPage struct {
+	// Indices contains the dictionary index of every non-null value of the page.
+	Indices []int32
+	// DefinitionLevels and RepetitionLevels contain the levels of all values of the page,
+	// including nulls. A value is null if its definition level is lower than the maximum
+	// definition level of the column.
+	DefinitionLevels []int32
+	RepetitionLevels []int32
+}
+
+// DictionaryIndices contains the dictionary of a column chunk together with the dictionary
+// indices of all its data pages.
+type DictionaryIndices struct {
+	Dictionary *ColumnDictionary
+	Pages      []*DictionaryIndexPage
+}
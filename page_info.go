@@ -0,0 +1,159 @@
+package goparquet
+
+import (
+	"hash/crc32"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/sagia-inneractive/parquet-go/parquet"
+)
+
+// CRCStatus is the result of the verification of the checksum of a page.
+type CRCStatus int
+
+const (
+	// CRCMissing means that the page header doesn't contain a checksum.
+	CRCMissing CRCStatus = iota
+	// CRCValid means that the checksum matches the page data.
+	CRCValid
+	// CRCInvalid means that the checksum doesn't match the page data.
+	CRCInvalid
+)
+
+func (s CRCStatus) String() string {
+	switch s {
+	case CRCMissing:
+		return "none"
+	case CRCValid:
+		return "valid"
+	case CRCInvalid:
+		return "invalid"
+	}
+	return "unknown"
+}
+
+// PageInfo describes a page of a column chunk as it is stored in the file.
+type PageInfo struct {
+	// Offset is the position of the page header in the file.
+	Offset int64
+	// HeaderSize is the size of the page header in bytes. For encrypted column chunks, this is
+	// the size of the encrypted header.
+	HeaderSize int64
+	// Header is the page header. For encrypted column chunks, its compressed page size is the
+	// size of the encrypted page data.
+	Header *parquet.PageHeader
+	// CRC is the result of the verification of the checksum of the page data as it is stored in
+	// the file.
+	CRC CRCStatus
+}
+
+// ReadPageInfo reads the page headers of the column chunk of column colName, provided in dotted
+// notation, in the row group with index rowGroup, and verifies the checksums of the pages that
+// have one. The pages are walked the same way they are when the data is read, but none of them is
+// decompressed or decoded. This is meant for tools that need to look into the layout of a file.
+func (f *FileReader) ReadPageInfo(rowGroup int, colName string) ([]*PageInfo, error) {
+	col, chunk, err := f.columnChunk(rowGroup, colName)
+	if err != nil {
+		return nil, err
+	}
+
+	if chunk.MetaData == nil {
+		return nil, errors.Errorf("missing meta data for column %q", colName)
+	}
+
+	decryptor, err := f.columnDecryptor(rowGroup, col, chunk)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.ranges != nil {
+		if br, ok := f.chunkRange(chunk); ok {
+			if err := f.fetch([]ByteRange{br}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	r, err := f.chunkReader(chunk)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := chunkOffset(chunk.MetaData)
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	pages, err := readPageInfo(&offsetReader{inner: r, offset: offset}, chunk.MetaData, decryptor)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading pages of column %q failed", colName)
+	}
+
+	return pages, nil
+}
+
+// readPageInfo walks the pages of a column chunk like readPages.
+func readPageInfo(r *offsetReader, chunkMeta *parquet.ColumnMetaData, dec *columnDecryptor) ([]*PageInfo, error) {
+	var (
+		pages []*PageInfo
+		// the ordinal of the next data page, which is part of the AAD of encrypted page headers
+		ordinal int
+		first   = true
+	)
+
+	for chunkMeta.TotalCompressedSize-r.Count() > 0 {
+		pageOffset := r.offset
+		ph, err := readPageHeader(r, dec, first && chunkMeta.DictionaryPageOffset != nil, ordinal, chunkMeta.TotalCompressedSize-r.Count())
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading page header at offset %d failed", pageOffset)
+		}
+		first = false
+
+		if ph.CompressedPageSize < 0 {
+			return nil, errors.Errorf("invalid compressed page size %d at offset %d", ph.CompressedPageSize, pageOffset)
+		}
+
+		info := &PageInfo{
+			Offset:     pageOffset,
+			HeaderSize: r.offset - pageOffset,
+			Header:     ph,
+		}
+
+		if ph.Crc != nil {
+			data := make([]byte, ph.CompressedPageSize)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return nil, errors.Wrapf(err, "reading page data at offset %d failed", pageOffset)
+			}
+			info.CRC = checkPageCRC(ph, data)
+		} else if _, err := r.Seek(int64(ph.CompressedPageSize), io.SeekCurrent); err != nil {
+			return nil, err
+		}
+		pages = append(pages, info)
+
+		if ph.Type == parquet.PageType_DICTIONARY_PAGE {
+			// the data pages start at DataPageOffset, like in readPages
+			if chunkMeta.DictionaryPageOffset != nil && chunkMeta.DataPageOffset > r.offset {
+				if _, err := r.Seek(chunkMeta.DataPageOffset, io.SeekStart); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		ordinal++
+	}
+
+	return pages, nil
+}
+
+// checkPageCRC verifies the CRC-32 checksum of the page header against the page data as it is
+// stored in the file, i.e. compressed and, if the column chunk is encrypted, encrypted.
+func checkPageCRC(ph *parquet.PageHeader, data []byte) CRCStatus {
+	if ph.Crc == nil {
+		return CRCMissing
+	}
+	if crc32.ChecksumIEEE(data) != uint32(*ph.Crc) {
+		return CRCInvalid
+	}
+	return CRCValid
+}
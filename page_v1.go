@@ -16,6 +16,7 @@ type dataPageReaderV1 struct {
 	dDecoder, rDecoder levelDecoder
 	valuesDecoder      valuesDecoder
 	fn                 getValueDecoderFn
+	buf                *pageBuffer
 
 	position int
 }
@@ -54,6 +55,24 @@ func (dp *dataPageReaderV1) readValues(val []interface{}) (n int, dLevel *packed
 	return size, dLevel, rLevel, nil
 }
 
+// readIndices works like readValues, but returns the dictionary indices of the values
+// instead of the values themselves. It fails if the page isn't dictionary encoded.
+func (dp *dataPageReaderV1) readIndices(val []int32) (n int, dLevel *packedArray, rLevel *packedArray, err error) {
+	n, dLevel, rLevel, err = readDictIndices(val, int(dp.valuesCount)-dp.position, dp.encoding, dp.valuesDecoder, dp.dDecoder, dp.rDecoder)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	dp.position += n
+	return n, dLevel, rLevel, nil
+}
+
+func (dp *dataPageReaderV1) release() {
+	if dp.buf != nil {
+		dp.buf.release()
+		dp.buf = nil
+	}
+}
+
 func (dp *dataPageReaderV1) init(dDecoder, rDecoder getLevelDecoder, values getValueDecoderFn) error {
 	if dp.ph.DataPageHeader == nil {
 		return errors.New("page header is missing data page header")
@@ -89,6 +108,7 @@ func (dp *dataPageReaderV1) read(r io.Reader, ph *parquet.PageHeader, codec parq
 		return err
 	}
 
+	dp.buf = reader
 	dp.encoding = ph.DataPageHeader.Encoding
 	dp.ph = ph
 
@@ -114,13 +134,13 @@ type dataPageWriterV1 struct {
 	dictionary bool
 }
 
-func (dp *dataPageWriterV1) init(schema SchemaWriter, col *Column, codec parquet.CompressionCodec) error {
+func (dp *dataPageWriterV1) init(col *Column, codec parquet.CompressionCodec) error {
 	dp.col = col
 	dp.codec = codec
 	return nil
 }
 
-func (dp *dataPageWriterV1) getHeader(comp, unComp int) *parquet.PageHeader {
+func (dp *dataPageWriterV1) getHeader(page *dataPage, comp, unComp int) *parquet.PageHeader {
 	enc := dp.col.data.encoding()
 	if dp.dictionary {
 		enc = parquet.Encoding_RLE_DICTIONARY
@@ -131,7 +151,7 @@ func (dp *dataPageWriterV1) getHeader(comp, unComp int) *parquet.PageHeader {
 		CompressedPageSize:   int32(comp),
 		Crc:                  nil,
 		DataPageHeader: &parquet.DataPageHeader{
-			NumValues: dp.col.data.values.numValues() + dp.col.data.values.nullValueCount(),
+			NumValues: page.numValues,
 			Encoding:  enc,
 			// Only RLE supported for now, not sure if we need support for more encoding
 			DefinitionLevelEncoding: parquet.Encoding_RLE,
@@ -142,34 +162,23 @@ func (dp *dataPageWriterV1) getHeader(comp, unComp int) *parquet.PageHeader {
 	return ph
 }
 
-func (dp *dataPageWriterV1) write(w io.Writer) (int, int, error) {
+func (dp *dataPageWriterV1) write(w io.Writer, page *dataPage) (int, int, error) {
 	dataBuf := &bytes.Buffer{}
 	// Only write repetition value higher than zero
 	if dp.col.MaxRepetitionLevel() > 0 {
-		if err := encodeLevelsV1(dataBuf, dp.col.MaxRepetitionLevel(), dp.col.data.rLevels); err != nil {
+		if err := encodeLevelsV1(dataBuf, dp.col.MaxRepetitionLevel(), page.rLevels); err != nil {
 			return 0, 0, err
 		}
 	}
 
 	// Only write definition value higher than zero
 	if dp.col.MaxDefinitionLevel() > 0 {
-		if err := encodeLevelsV1(dataBuf, dp.col.MaxDefinitionLevel(), dp.col.data.dLevels); err != nil {
+		if err := encodeLevelsV1(dataBuf, dp.col.MaxDefinitionLevel(), page.dLevels); err != nil {
 			return 0, 0, err
 		}
 	}
 
-	enc := dp.col.data.encoding()
-	if dp.dictionary {
-		enc = parquet.Encoding_RLE_DICTIONARY
-	}
-
-	encoder, err := getValuesEncoder(enc, dp.col.Element(), dp.col.data.values)
-	if err != nil {
-		return 0, 0, err
-	}
-
-	err = encodeValue(dataBuf, encoder, dp.col.data.values.assemble())
-	if err != nil {
+	if err := encodePageValues(dataBuf, dp.col, page, dp.dictionary); err != nil {
 		return 0, 0, err
 	}
 
@@ -179,7 +188,7 @@ func (dp *dataPageWriterV1) write(w io.Writer) (int, int, error) {
 	}
 	compSize, unCompSize := len(comp), len(dataBuf.Bytes())
 
-	header := dp.getHeader(compSize, unCompSize)
+	header := dp.getHeader(page, compSize, unCompSize)
 	if err := writeThrift(header, w); err != nil {
 		return 0, 0, err
 	}
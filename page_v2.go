@@ -16,6 +16,7 @@ type dataPageReaderV2 struct {
 	valuesDecoder      valuesDecoder
 	dDecoder, rDecoder levelDecoder
 	fn                 getValueDecoderFn
+	buf                *pageBuffer
 	position           int
 }
 
@@ -53,6 +54,24 @@ func (dp *dataPageReaderV2) readValues(val []interface{}) (n int, dLevel *packed
 	return size, dLevel, rLevel, nil
 }
 
+// readIndices works like readValues, but returns the dictionary indices of the values
+// instead of the values themselves. It fails if the page isn't dictionary encoded.
+func (dp *dataPageReaderV2) readIndices(val []int32) (n int, dLevel *packedArray, rLevel *packedArray, err error) {
+	n, dLevel, rLevel, err = readDictIndices(val, int(dp.valuesCount)-dp.position, dp.encoding, dp.valuesDecoder, dp.dDecoder, dp.rDecoder)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	dp.position += n
+	return n, dLevel, rLevel, nil
+}
+
+func (dp *dataPageReaderV2) release() {
+	if dp.buf != nil {
+		dp.buf.release()
+		dp.buf = nil
+	}
+}
+
 func (dp *dataPageReaderV2) init(dDecoder, rDecoder getLevelDecoder, values getValueDecoderFn) error {
 	var err error
 	// Page v2 dose not have any encoding for the levels
@@ -124,26 +143,25 @@ func (dp *dataPageReaderV2) read(r io.Reader, ph *parquet.PageHeader, codec parq
 	if err != nil {
 		return err
 	}
+	dp.buf = reader
 
 	return dp.valuesDecoder.init(reader)
 }
 
 type dataPageWriterV2 struct {
-	col    *Column
-	schema SchemaWriter
+	col *Column
 
 	codec      parquet.CompressionCodec
 	dictionary bool
 }
 
-func (dp *dataPageWriterV2) init(schema SchemaWriter, col *Column, codec parquet.CompressionCodec) error {
+func (dp *dataPageWriterV2) init(col *Column, codec parquet.CompressionCodec) error {
 	dp.col = col
 	dp.codec = codec
-	dp.schema = schema
 	return nil
 }
 
-func (dp *dataPageWriterV2) getHeader(comp, unComp, defSize, repSize int, isCompressed bool) *parquet.PageHeader {
+func (dp *dataPageWriterV2) getHeader(page *dataPage, comp, unComp, defSize, repSize int, isCompressed bool) *parquet.PageHeader {
 	enc := dp.col.data.encoding()
 	if dp.dictionary {
 		enc = parquet.Encoding_RLE_DICTIONARY
@@ -154,9 +172,9 @@ func (dp *dataPageWriterV2) getHeader(comp, unComp, defSize, repSize int, isComp
 		CompressedPageSize:   int32(comp + defSize + repSize),
 		Crc:                  nil,
 		DataPageHeaderV2: &parquet.DataPageHeaderV2{
-			NumValues:                  dp.col.data.values.numValues() + dp.col.data.values.nullValueCount(),
-			NumNulls:                   dp.col.data.values.nullValueCount(),
-			NumRows:                    int32(dp.schema.rowGroupNumRecords()),
+			NumValues:                  page.numValues,
+			NumNulls:                   page.numNulls,
+			NumRows:                    page.numRows,
 			Encoding:                   enc,
 			DefinitionLevelsByteLength: int32(defSize),
 			RepetitionLevelsByteLength: int32(repSize),
@@ -167,12 +185,12 @@ func (dp *dataPageWriterV2) getHeader(comp, unComp, defSize, repSize int, isComp
 	return ph
 }
 
-func (dp *dataPageWriterV2) write(w io.Writer) (int, int, error) {
+func (dp *dataPageWriterV2) write(w io.Writer, page *dataPage) (int, int, error) {
 	rep := &bytes.Buffer{}
 
 	// Only write repetition value higher than zero
 	if dp.col.MaxRepetitionLevel() > 0 {
-		if err := encodeLevelsV2(rep, dp.col.MaxRepetitionLevel(), dp.col.data.rLevels); err != nil {
+		if err := encodeLevelsV2(rep, dp.col.MaxRepetitionLevel(), page.rLevels); err != nil {
 			return 0, 0, err
 		}
 	}
@@ -181,23 +199,13 @@ func (dp *dataPageWriterV2) write(w io.Writer) (int, int, error) {
 
 	// Only write definition level higher than zero
 	if dp.col.MaxDefinitionLevel() > 0 {
-		if err := encodeLevelsV2(def, dp.col.MaxDefinitionLevel(), dp.col.data.dLevels); err != nil {
+		if err := encodeLevelsV2(def, dp.col.MaxDefinitionLevel(), page.dLevels); err != nil {
 			return 0, 0, err
 		}
 	}
 
 	dataBuf := &bytes.Buffer{}
-	enc := dp.col.data.encoding()
-	if dp.dictionary {
-		enc = parquet.Encoding_RLE_DICTIONARY
-	}
-
-	encoder, err := getValuesEncoder(enc, dp.col.Element(), dp.col.data.values)
-	if err != nil {
-		return 0, 0, err
-	}
-
-	if err = encodeValue(dataBuf, encoder, dp.col.data.values.assemble()); err != nil {
+	if err := encodePageValues(dataBuf, dp.col, page, dp.dictionary); err != nil {
 		return 0, 0, err
 	}
 
@@ -207,7 +215,7 @@ func (dp *dataPageWriterV2) write(w io.Writer) (int, int, error) {
 	}
 	compSize, unCompSize := len(comp), len(dataBuf.Bytes())
 	defLen, repLen := def.Len(), rep.Len()
-	header := dp.getHeader(compSize, unCompSize, defLen, repLen, dp.codec != parquet.CompressionCodec_UNCOMPRESSED)
+	header := dp.getHeader(page, compSize, unCompSize, defLen, repLen, dp.codec != parquet.CompressionCodec_UNCOMPRESSED)
 	if err := writeThrift(header, w); err != nil {
 		return 0, 0, err
 	}
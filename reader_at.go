@@ -0,0 +1,211 @@
+package goparquet
+
+import (
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ByteRange describes a range of bytes in a file.
+type ByteRange struct {
+	Offset int64
+	Length int64
+}
+
+func (br ByteRange) end() int64 {
+	return br.Offset + br.Length
+}
+
+// Prefetcher is used by a FileReader created with NewFileReaderAt to fetch the byte ranges of the
+// column chunks of a row group. Before a row group is read, the FileReader fetches all of its
+// byte ranges at once using Fetch. Right after that, it announces the byte ranges of the next row
+// group using Prefetch, so that implementations can start fetching them while the current row
+// group is processed.
+type Prefetcher interface {
+	// Prefetch announces byte ranges that will probably be fetched next.
+	Prefetch(r io.ReaderAt, ranges []ByteRange)
+	// Fetch returns the data of the byte ranges, in the same order as the ranges.
+	Fetch(r io.ReaderAt, ranges []ByteRange) ([][]byte, error)
+}
+
+// syncPrefetcher is the default Prefetcher. It doesn't prefetch anything and fetches the byte
+// ranges one after the other.
+type syncPrefetcher struct{}
+
+func (syncPrefetcher) Prefetch(io.ReaderAt, []ByteRange) {}
+
+func (syncPrefetcher) Fetch(r io.ReaderAt, ranges []ByteRange) ([][]byte, error) {
+	ret := make([][]byte, len(ranges))
+	for i, br := range ranges {
+		data, err := fetchRange(r, br)
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = data
+	}
+
+	return ret, nil
+}
+
+func fetchRange(r io.ReaderAt, br ByteRange) ([]byte, error) {
+	buf := make([]byte, br.Length)
+	if err := readAtFull(r, buf, br.Offset); err != nil {
+		return nil, errors.Wrapf(err, "reading %d bytes at offset %d failed", br.Length, br.Offset)
+	}
+	return buf, nil
+}
+
+// BackgroundPrefetcher is a Prefetcher that fetches announced byte ranges in the background, so
+// that the next row group is already in memory when it is needed. All byte ranges are fetched
+// concurrently. Byte ranges that are announced but not fetched by the next call to Fetch are
+// discarded.
+type BackgroundPrefetcher struct {
+	mu      sync.Mutex
+	pending map[ByteRange]*prefetch
+}
+
+type prefetch struct {
+	done chan struct{}
+	data []byte
+	err  error
+}
+
+// NewBackgroundPrefetcher creates a new BackgroundPrefetcher.
+func NewBackgroundPrefetcher() *BackgroundPrefetcher {
+	return &BackgroundPrefetcher{
+		pending: make(map[ByteRange]*prefetch),
+	}
+}
+
+// Prefetch starts fetching the byte ranges in the background.
+func (p *BackgroundPrefetcher) Prefetch(r io.ReaderAt, ranges []ByteRange) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, br := range ranges {
+		if _, ok := p.pending[br]; ok {
+			continue
+		}
+
+		pf := &prefetch{done: make(chan struct{})}
+		p.pending[br] = pf
+		go func(br ByteRange) {
+			pf.data, pf.err = fetchRange(r, br)
+			close(pf.done)
+		}(br)
+	}
+}
+
+// Fetch returns the data of the byte ranges. Byte ranges that haven't been prefetched are
+// fetched concurrently.
+func (p *BackgroundPrefetcher) Fetch(r io.ReaderAt, ranges []ByteRange) ([][]byte, error) {
+	p.Prefetch(r, ranges)
+
+	p.mu.Lock()
+	pfs := make([]*prefetch, len(ranges))
+	for i, br := range ranges {
+		pfs[i] = p.pending[br]
+	}
+	p.pending = make(map[ByteRange]*prefetch)
+	p.mu.Unlock()
+
+	ret := make([][]byte, len(ranges))
+	for i, pf := range pfs {
+		<-pf.done
+		if pf.err != nil {
+			return nil, pf.err
+		}
+		ret[i] = pf.data
+	}
+
+	return ret, nil
+}
+
+// coalesceRanges sorts the byte ranges and merges ranges that overlap or that are separated by
+// no more than maxGap bytes.
+func coalesceRanges(ranges []ByteRange, maxGap int64) []ByteRange {
+	if len(ranges) == 0 {
+		return nil
+	}
+
+	sorted := make([]ByteRange, len(ranges))
+	copy(sorted, ranges)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Offset < sorted[j].Offset
+	})
+
+	ret := []ByteRange{sorted[0]}
+	for _, br := range sorted[1:] {
+		last := &ret[len(ret)-1]
+		if br.Offset > last.end()+maxGap {
+			ret = append(ret, br)
+			continue
+		}
+		if br.end() > last.end() {
+			last.Length = br.end() - last.Offset
+		}
+	}
+
+	return ret
+}
+
+// rangeReader is an io.ReadSeeker on an io.ReaderAt, of which some byte ranges have been fetched
+// into memory. Reads outside of these byte ranges are passed on to the io.ReaderAt.
+type rangeReader struct {
+	r    io.ReaderAt
+	size int64
+	pos  int64
+
+	ranges []ByteRange
+	data   [][]byte
+}
+
+func (rr *rangeReader) setRanges(ranges []ByteRange, data [][]byte) {
+	rr.ranges = ranges
+	rr.data = data
+}
+
+func (rr *rangeReader) Read(p []byte) (int, error) {
+	if rr.pos >= rr.size {
+		return 0, io.EOF
+	}
+
+	for i, br := range rr.ranges {
+		if rr.pos >= br.Offset && rr.pos < br.Offset+int64(len(rr.data[i])) {
+			n := copy(p, rr.data[i][rr.pos-br.Offset:])
+			rr.pos += int64(n)
+			return n, nil
+		}
+	}
+
+	if rem := rr.size - rr.pos; int64(len(p)) > rem {
+		p = p[:rem]
+	}
+	n, err := rr.r.ReadAt(p, rr.pos)
+	rr.pos += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+func (rr *rangeReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += rr.pos
+	case io.SeekEnd:
+		offset += rr.size
+	default:
+		return 0, errors.Errorf("invalid whence %d", whence)
+	}
+
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+
+	rr.pos = offset
+	return offset, nil
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"testing"
 	"time"
@@ -460,6 +461,53 @@ func TestWriteThenReadFileNested3(t *testing.T) {
 	}
 }
 
+func TestWriteThenReadListWithNullElements(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int64 id;
+		optional group tags (LIST) {
+			repeated group list {
+				optional binary element (STRING);
+			}
+		}
+	}`)
+	require.NoError(t, err)
+
+	data := []map[string]interface{}{
+		{"id": int64(1), "tags": map[string]interface{}{"list": []map[string]interface{}{{"element": []byte("a")}, {}}}},
+		{"id": int64(2)},
+		{"id": int64(3), "tags": map[string]interface{}{"list": []map[string]interface{}{{}, {}, {"element": []byte("b")}}}},
+		{"id": int64(4), "tags": map[string]interface{}{"list": []map[string]interface{}{{}}}},
+	}
+
+	var buf bytes.Buffer
+	w := NewFileWriter(&buf, WithSchemaDefinition(sd))
+	for i := range data {
+		require.NoError(t, w.AddData(data[i]))
+	}
+	require.NoError(t, w.Close())
+
+	for _, columns := range [][]string{nil, {"tags.list.element"}} {
+		r, err := NewFileReader(bytes.NewReader(buf.Bytes()), columns...)
+		require.NoError(t, err)
+
+		for i := range data {
+			row, err := r.NextRow()
+			require.NoError(t, err)
+
+			expected := data[i]
+			if columns != nil {
+				expected = map[string]interface{}{}
+				if tags, ok := data[i]["tags"]; ok {
+					expected["tags"] = tags
+				}
+			}
+			require.Equal(t, expected, row)
+		}
+		_, err = r.NextRow()
+		require.Equal(t, io.EOF, err)
+	}
+}
+
 func TestWriteEmptyDict(t *testing.T) {
 	_ = os.Mkdir("files", 0755)
 
@@ -863,6 +911,416 @@ func TestReadWriteColumeEncodings(t *testing.T) {
 	require.Equal(t, io.EOF, err)
 }
 
+func TestWriteThenReadUnsigned(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int32 a (INT(32, false));
+		optional int64 b (UINT_64);
+		required int32 c (UINT_8);
+	}`)
+	require.NoError(t, err)
+
+	values := []uint32{0, 1, math.MaxInt32, math.MaxInt32 + 1, math.MaxUint32}
+	for name, opts := range map[string][]FileWriterOption{
+		"plain": {WithColumnEncoding(parquet.Encoding_PLAIN, false, "a", "b", "c")},
+		"delta": {WithColumnEncoding(parquet.Encoding_DELTA_BINARY_PACKED, false, "a", "b", "c")},
+		"dict":  nil,
+	} {
+		t.Run(name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w := NewFileWriter(buf, append(opts, WithSchemaDefinition(sd))...)
+			for i, v := range values {
+				// the column stores keep unsigned values as signed integers with the same bits
+				require.NoError(t, w.AddData(map[string]interface{}{
+					"a": int32(v),
+					"b": int64(uint64(v) << 32),
+					"c": int32(i * 60),
+				}))
+			}
+			require.NoError(t, w.Close())
+
+			r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+			require.NoError(t, err)
+			for i, v := range values {
+				row, err := r.NextRow()
+				require.NoError(t, err)
+				require.Equal(t, map[string]interface{}{
+					"a": v,
+					"b": uint64(v) << 32,
+					"c": uint32(i * 60),
+				}, row)
+			}
+		})
+	}
+}
+
+func TestWriteWithColumnEncoding(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(`message test {
+		required int64 id;
+		optional binary name (STRING);
+		required boolean flag;
+	}`)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	w := NewFileWriter(buf,
+		WithSchemaDefinition(sd),
+		WithColumnEncoding(parquet.Encoding_DELTA_BINARY_PACKED, false, "id"),
+		WithColumnEncoding(parquet.Encoding_DELTA_BYTE_ARRAY, false, "name"),
+		WithColumnEncoding(parquet.Encoding_RLE, false, "flag"),
+	)
+
+	var expected []map[string]interface{}
+	for i := 0; i < 100; i++ {
+		data := map[string]interface{}{
+			"id":   int64(i * 3),
+			"flag": i%3 == 0,
+		}
+		if i%5 != 0 {
+			data["name"] = []byte(fmt.Sprintf("name-%d", i%10))
+		}
+		expected = append(expected, data)
+		require.NoError(t, w.AddData(data))
+	}
+	require.NoError(t, w.Close())
+
+	r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+
+	for i, enc := range []parquet.Encoding{parquet.Encoding_DELTA_BINARY_PACKED, parquet.Encoding_DELTA_BYTE_ARRAY, parquet.Encoding_RLE} {
+		meta := r.meta.RowGroups[0].Columns[i].MetaData
+		assert.Nil(t, meta.DictionaryPageOffset)
+		assert.Contains(t, meta.Encodings, enc)
+	}
+
+	for _, data := range expected {
+		row, err := r.NextRow()
+		require.NoError(t, err)
+		require.Equal(t, data, row)
+	}
+	_, err = r.NextRow()
+	require.Equal(t, io.EOF, err)
+
+	// invalid encodings are reported before the first record is buffered
+	w = NewFileWriter(&bytes.Buffer{}, WithSchemaDefinition(sd), WithColumnEncoding(parquet.Encoding_DELTA_BINARY_PACKED, true, "name"))
+	require.EqualError(t, w.AddData(expected[1]), `column "name": encoding "DELTA_BINARY_PACKED" is not supported on this type`)
+	require.EqualError(t, w.AddData(expected[1]), `column "name": encoding "DELTA_BINARY_PACKED" is not supported on this type`)
+	require.Equal(t, int64(0), w.rowGroupNumRecords())
+
+	w = NewFileWriter(&bytes.Buffer{}, WithSchemaDefinition(sd), WithColumnEncoding(parquet.Encoding_PLAIN, false, "missing"))
+	require.EqualError(t, w.AddData(expected[1]), `column "missing" not found`)
+	require.EqualError(t, w.Close(), `nothing to write`)
+
+	for i := 0; i < 10; i++ {
+		w = NewFileWriter(&bytes.Buffer{}, WithSchemaDefinition(sd),
+			WithColumnEncoding(parquet.Encoding_PLAIN, false, "missing"),
+			WithColumnEncoding(parquet.Encoding_DELTA_BINARY_PACKED, true, "name"),
+			WithColumnEncoding(parquet.Encoding_PLAIN, false, "other"))
+		require.EqualError(t, w.AddData(expected[1]), `column "missing" not found`)
+	}
+
+	w = NewFileWriter(&bytes.Buffer{}, WithSchemaDefinition(sd),
+		WithColumnEncoding(parquet.Encoding_PLAIN, true, "name"),
+		WithColumnEncoding(parquet.Encoding_DELTA_BYTE_ARRAY, false, "name"))
+	require.NoError(t, w.AddData(expected[1]))
+	require.NoError(t, w.FlushRowGroup())
+	require.Equal(t, parquet.Encoding_DELTA_BYTE_ARRAY, w.GetColumnByName("name").data.enc)
+	require.False(t, w.GetColumnByName("name").data.allowDict)
+}
+
 func strPtr(s string) *string {
 	return &s
 }
+
+func TestWriteThenReadDictionaryFallback(t *testing.T) {
+	testFunc := func(opts ...FileWriterOption) {
+		buf := &bytes.Buffer{}
+		w := NewFileWriter(buf, append(opts, WithMaxPageSize(1024), WithMaxDictionarySize(2048))...)
+
+		valueStore, err := NewByteArrayStore(parquet.Encoding_PLAIN, true, &ColumnParameters{})
+		require.NoError(t, err)
+		require.NoError(t, w.AddColumn("value", NewDataColumn(valueStore, parquet.FieldRepetitionType_OPTIONAL)))
+
+		var expected []map[string]interface{}
+		for i := 0; i < 5000; i++ {
+			data := map[string]interface{}{}
+			switch {
+			case i%7 == 0:
+			case i < 2000:
+				data["value"] = []byte(fmt.Sprintf("value-%d", i%10))
+			default:
+				data["value"] = []byte(fmt.Sprintf("unique-value-%d", i))
+			}
+			expected = append(expected, data)
+			require.NoError(t, w.AddData(data))
+		}
+		require.NoError(t, w.Close())
+
+		r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+		require.NoError(t, err)
+		require.Equal(t, 1, r.RowGroupCount())
+
+		meta := r.meta.RowGroups[0].Columns[0].MetaData
+		require.NotNil(t, meta.DictionaryPageOffset)
+		require.Contains(t, meta.Encodings, parquet.Encoding_RLE_DICTIONARY)
+		require.Contains(t, meta.Encodings, parquet.Encoding_PLAIN)
+
+		pageCount := map[parquet.Encoding]int32{}
+		for _, s := range meta.EncodingStats {
+			if s.PageType == parquet.PageType_DICTIONARY_PAGE {
+				require.Equal(t, int32(1), s.Count)
+				continue
+			}
+			pageCount[s.Encoding] += s.Count
+		}
+		require.True(t, pageCount[parquet.Encoding_RLE_DICTIONARY] > 0, "expected dictionary encoded pages")
+		require.True(t, pageCount[parquet.Encoding_PLAIN] > 0, "expected plain encoded pages after the fallback")
+
+		for i := range expected {
+			row, err := r.NextRow()
+			require.NoError(t, err)
+			require.Equal(t, expected[i], row, "row %d", i)
+		}
+		_, err = r.NextRow()
+		require.Equal(t, io.EOF, err)
+	}
+
+	testFunc()
+	testFunc(WithDataPageV2(), WithCompressionCodec(parquet.CompressionCodec_SNAPPY))
+}
+
+func TestWriteThenReadZeroCopy(t *testing.T) {
+	encodings := []parquet.Encoding{
+		parquet.Encoding_PLAIN,
+		parquet.Encoding_DELTA_LENGTH_BYTE_ARRAY,
+		parquet.Encoding_DELTA_BYTE_ARRAY,
+	}
+
+	codecs := []parquet.CompressionCodec{
+		parquet.CompressionCodec_UNCOMPRESSED,
+		parquet.CompressionCodec_SNAPPY,
+		parquet.CompressionCodec_GZIP,
+	}
+
+	const (
+		numRecords = 3000
+		flushLimit = 1000
+	)
+
+	value := func(idx int) []byte {
+		if idx%7 == 0 {
+			return nil
+		}
+		return []byte(fmt.Sprintf("prefix-%d-value-%d", idx/10, idx))
+	}
+
+	for _, enc := range encodings {
+		for _, codec := range codecs {
+			for _, opts := range [][]FileWriterOption{{}, {WithDataPageV2()}} {
+				var buf bytes.Buffer
+				w := NewFileWriter(&buf, append(opts, WithCompressionCodec(codec), WithMaxPageSize(4096))...)
+
+				store, err := NewByteArrayStore(enc, false, &ColumnParameters{})
+				require.NoError(t, err)
+				require.NoError(t, w.AddColumn("value", NewDataColumn(store, parquet.FieldRepetitionType_OPTIONAL)))
+
+				for idx := 0; idx < numRecords; idx++ {
+					if idx > 0 && idx%flushLimit == 0 {
+						require.NoError(t, w.FlushRowGroup())
+					}
+					data := map[string]interface{}{}
+					if v := value(idx); v != nil {
+						data["value"] = v
+					}
+					require.NoError(t, w.AddData(data))
+				}
+				require.NoError(t, w.Close())
+
+				r, err := NewFileReaderWithOptions(bytes.NewReader(buf.Bytes()), WithZeroCopyByteArrays())
+				require.NoError(t, err)
+
+				for idx := 0; idx < numRecords; idx++ {
+					row, err := r.NextRow()
+					require.NoError(t, err, "%s/%s: reading row %d failed", enc, codec, idx)
+
+					if v := value(idx); v != nil {
+						require.Equal(t, v, row["value"], "%s/%s: row %d", enc, codec, idx)
+					} else {
+						require.NotContains(t, row, "value", "%s/%s: row %d", enc, codec, idx)
+					}
+				}
+
+				_, err = r.NextRow()
+				require.Equal(t, io.EOF, err)
+			}
+		}
+	}
+}
+
+func TestWriteWithMaxBufferedBytes(t *testing.T) {
+	const (
+		numRecords  = 10000
+		maxBuffered = 32 * 1024
+	)
+
+	var buf bytes.Buffer
+	w := NewFileWriter(&buf, WithMaxBufferedBytes(maxBuffered))
+
+	idStore, err := NewInt64Store(parquet.Encoding_PLAIN, true, &ColumnParameters{})
+	require.NoError(t, err)
+	nameStore, err := NewByteArrayStore(parquet.Encoding_PLAIN, true, &ColumnParameters{})
+	require.NoError(t, err)
+
+	require.NoError(t, w.AddColumn("id", NewDataColumn(idStore, parquet.FieldRepetitionType_REQUIRED)))
+	require.NoError(t, w.AddColumn("name", NewDataColumn(nameStore, parquet.FieldRepetitionType_OPTIONAL)))
+
+	for idx := 0; idx < numRecords; idx++ {
+		require.NoError(t, w.AddData(map[string]interface{}{"id": int64(idx), "name": []byte(fmt.Sprintf("name-%d", idx%100))}))
+		require.True(t, w.CurrentBufferedSize() < maxBuffered, "%d. buffered size %d exceeds maximum", idx, w.CurrentBufferedSize())
+	}
+	require.NoError(t, w.Close())
+
+	r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.True(t, r.RowGroupCount() > 1, "expected multiple row groups, got %d", r.RowGroupCount())
+
+	for idx := 0; idx < numRecords; idx++ {
+		row, err := r.NextRow()
+		require.NoError(t, err)
+		require.Equal(t, int64(idx), row["id"])
+		require.Equal(t, []byte(fmt.Sprintf("name-%d", idx%100)), row["name"])
+	}
+}
+
+// memFile is an in-memory io.ReadWriteSeeker without a Truncate method.
+type memFile struct {
+	data []byte
+	pos  int64
+}
+
+func (m *memFile) Read(p []byte) (int, error) {
+	if m.pos >= int64(len(m.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, m.data[m.pos:])
+	m.pos += int64(n)
+	return n, nil
+}
+
+func (m *memFile) Write(p []byte) (int, error) {
+	if end := m.pos + int64(len(p)); end > int64(len(m.data)) {
+		m.data = append(m.data, make([]byte, end-int64(len(m.data)))...)
+	}
+	n := copy(m.data[m.pos:], p)
+	m.pos += int64(n)
+	return n, nil
+}
+
+func (m *memFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += m.pos
+	case io.SeekEnd:
+		offset += int64(len(m.data))
+	}
+	m.pos = offset
+	return offset, nil
+}
+
+func TestOpenForAppend(t *testing.T) {
+	sd, err := parquetschema.ParseSchemaDefinition(`message msg {
+  required int64 id;
+  optional binary name (STRING);
+}`)
+	require.NoError(t, err)
+
+	writeRows := func(w *FileWriter, first, count int64) {
+		for id := first; id < first+count; id++ {
+			require.NoError(t, w.AddData(map[string]interface{}{
+				"id":   id,
+				"name": []byte(fmt.Sprintf("name-%d", id)),
+			}))
+		}
+	}
+
+	checkRows := func(r io.ReadSeeker, rowGroups int, count int64) *FileReader {
+		fr, err := NewFileReader(r)
+		require.NoError(t, err)
+		require.Equal(t, rowGroups, fr.RowGroupCount())
+		require.Equal(t, count, fr.NumRows())
+		rows := readAllRows(t, fr)
+		require.Len(t, rows, int(count))
+		for i, row := range rows {
+			require.Equal(t, int64(i), row["id"])
+			require.Equal(t, []byte(fmt.Sprintf("name-%d", i)), row["name"])
+		}
+		return fr
+	}
+
+	_ = os.Mkdir("files", 0755)
+	f, err := os.OpenFile("files/append.parquet", os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0644)
+	require.NoError(t, err)
+	defer f.Close()
+
+	w := NewFileWriter(f, WithSchemaDefinition(sd), WithMetaData(map[string]string{"a": "b"}), WithOffsetIndex())
+	writeRows(w, 0, 100)
+	require.NoError(t, w.Close())
+
+	for i := int64(1); i < 3; i++ {
+		w, err := OpenForAppend(f, WithOffsetIndex())
+		require.NoError(t, err)
+		writeRows(w, i*100, 100)
+		require.NoError(t, w.Close())
+	}
+
+	fr := checkRows(f, 3, 300)
+	require.Equal(t, map[string]string{"a": "b"}, fr.MetaData())
+	for _, row := range []int64{250, 10, 199} {
+		require.NoError(t, fr.SeekToRow(row))
+		data, err := fr.NextRow()
+		require.NoError(t, err)
+		require.Equal(t, row, data["id"])
+	}
+
+	// closing without adding data keeps the file as it is
+	w, err = OpenForAppend(f)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	checkRows(f, 3, 300)
+
+	// encrypted row groups can't be appended to a plaintext file
+	stat, err := f.Stat()
+	require.NoError(t, err)
+	_, err = OpenForAppend(f, WithEncryption(&FileEncryptionProperties{FooterKey: []byte("0123456789012345")}))
+	require.EqualError(t, err, "appending encrypted row groups is not supported")
+	stat2, err := f.Stat()
+	require.NoError(t, err)
+	require.Equal(t, stat.Size(), stat2.Size())
+	checkRows(f, 3, 300)
+
+	other, err := parquetschema.ParseSchemaDefinition(`message msg {
+  required int64 id;
+}`)
+	require.NoError(t, err)
+	_, err = OpenForAppend(f, WithSchemaDefinition(other))
+	require.Error(t, err)
+
+	mf := &memFile{}
+	w = NewFileWriter(mf, WithSchemaDefinition(sd), WithMetaData(map[string]string{"long": "some long value"}))
+	writeRows(w, 0, 10)
+	require.NoError(t, w.Close())
+
+	w, err = OpenForAppend(mf)
+	require.NoError(t, err)
+	writeRows(w, 10, 10)
+	require.NoError(t, w.Close())
+	checkRows(bytes.NewReader(mf.data), 2, 20)
+
+	// without truncation, the file is padded to not get smaller
+	size := len(mf.data)
+	w, err = OpenForAppend(mf, WithMetaData(nil))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.Equal(t, size, len(mf.data))
+	fr = checkRows(bytes.NewReader(mf.data), 2, 20)
+	require.Empty(t, fr.MetaData())
+}
@@ -232,6 +232,38 @@ func (c *Column) getFirstRDLevel() (int32, int32, bool) {
 	return -1, -1, false
 }
 
+// nextRLevel returns the repetition level of the next value of the group. Unlike
+// getFirstRDLevel, it also considers nil values, since every data column within the group has
+// levels for every value of the group, so the first column that was read determines the level.
+func (c *Column) nextRLevel() (int32, bool) {
+	if c.data != nil {
+		rl, _, last := c.data.getRDLevelAt(-1)
+		return rl, last
+	}
+
+	for i := range c.children {
+		if !c.children[i].isSkipped() {
+			return c.children[i].nextRLevel()
+		}
+	}
+	return -1, false
+}
+
+// isSkipped returns true if the data of the column, or of all data columns within the group, was
+// not read.
+func (c *Column) isSkipped() bool {
+	if c.data != nil {
+		return c.data.skipped
+	}
+
+	for i := range c.children {
+		if !c.children[i].isSkipped() {
+			return false
+		}
+	}
+	return true
+}
+
 func (c *Column) getData() (interface{}, int32, error) {
 	if c.children != nil {
 		data, maxD, err := c.getNextData()
@@ -245,7 +277,7 @@ func (c *Column) getData() (interface{}, int32, error) {
 
 		ret := []map[string]interface{}{data}
 		for {
-			rl, _, last := c.getFirstRDLevel()
+			rl, last := c.nextRLevel()
 			if last || rl < int32(c.maxR) || rl == 0 {
 				// end of this object
 				return ret, maxD, nil
@@ -398,6 +430,7 @@ func (r *schema) SetSchemaDefinition(sd *parquetschema.SchemaDefinition) error {
 	for _, c := range r.root.children {
 		recursiveFix(c, "", 0, 0)
 	}
+	r.sortIndex()
 
 	return nil
 }
@@ -692,6 +725,11 @@ func (r *schema) findDataColumn(path string) (*Column, error) {
 func (r *schema) AddData(m map[string]interface{}) error {
 	r.readOnly = 1
 	r.ensureRoot()
+	// the row is checked before any column receives data, so that a missing value doesn't leave
+	// the columns with different numbers of values
+	if err := checkRequiredValues(r.root.children, m, 0); err != nil {
+		return err
+	}
 	err := recursiveAddColumnData(r.root.children, m, 0, 0, 0)
 	if err == nil {
 		r.numRecords++
@@ -786,6 +824,48 @@ func recursiveAddColumnData(c []*Column, m interface{}, defLvl uint16, maxRepLvl
 	return nil
 }
 
+// checkRequiredValues returns an error if a required value of the data is missing. Like
+// recursiveAddColumnData, it only requires values whose parents are all present.
+func checkRequiredValues(c []*Column, data map[string]interface{}, defLvl uint16) error {
+	for i := range c {
+		d := data[c[i].name]
+		if c[i].data != nil && d == nil && c[i].rep == parquet.FieldRepetitionType_REQUIRED && defLvl == c[i].maxD {
+			return errors.Errorf("the value %q is required", c[i].flatName)
+		}
+		if c[i].children == nil {
+			continue
+		}
+
+		l := defLvl
+		if c[i].rep != parquet.FieldRepetitionType_REQUIRED && d != nil {
+			l++
+		}
+		switch v := d.(type) {
+		case nil:
+			if err := checkRequiredValues(c[i].children, nil, l); err != nil {
+				return err
+			}
+		case map[string]interface{}:
+			if err := checkRequiredValues(c[i].children, v, l); err != nil {
+				return err
+			}
+		case []map[string]interface{}:
+			if len(v) == 0 {
+				if err := checkRequiredValues(c[i].children, nil, l); err != nil {
+					return err
+				}
+			}
+			for vi := range v {
+				if err := checkRequiredValues(c[i].children, v[vi], l); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
 func (c *Column) readColumnSchema(schema []*parquet.SchemaElement, name string, idx int, dLevel, rLevel uint16) (int, error) {
 	s := schema[idx]
 
@@ -950,6 +1030,18 @@ func (r *schema) DataSize() int64 {
 	return size
 }
 
+// bufferedSize returns the approximate number of bytes used in memory by the data stored in the
+// schema right now.
+func (r *schema) bufferedSize() int64 {
+	cols := r.Columns()
+	var size int64
+	for i := range cols {
+		size += cols[i].data.bufferedSize()
+	}
+
+	return size
+}
+
 func (r *schema) rowGroupNumRecords() int64 {
 	return r.numRecords
 }
@@ -991,6 +1083,9 @@ type SchemaWriter interface {
 	AddGroup(path string, rep parquet.FieldRepetitionType) error
 	AddColumn(path string, col *Column) error
 	DataSize() int64
+
+	// Internal functions
+	bufferedSize() int64
 }
 
 func makeSchema(meta *parquet.FileMetaData) (SchemaReader, error) {
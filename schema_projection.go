@@ -0,0 +1,233 @@
+package goparquet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sagia-inneractive/parquet-go/parquet"
+	"github.com/sagia-inneractive/parquet-go/parquetschema"
+)
+
+// projection turns the rows read using the schema of a file into rows of a requested schema.
+type projection struct {
+	fields []*projectedField
+}
+
+type projectedField struct {
+	name string
+	// convert promotes the values of a column to the requested type. It is nil if no promotion
+	// is required.
+	convert func(interface{}) interface{}
+	// children is nil for data columns.
+	children []*projectedField
+}
+
+// newProjection checks that the requested schema can be read from a file with the file schema,
+// and returns the projection as well as the flat names of the file columns that need to be read.
+// Optional and repeated columns of the requested schema that don't exist in the file are
+// returned as null, columns of the file that are not in the requested schema are dropped. All
+// incompatibilities are reported in a single error.
+func newProjection(requested, file *parquetschema.SchemaDefinition) (*projection, []string, error) {
+	if requested == nil || requested.RootColumn == nil {
+		return nil, nil, errors.New("requested schema is empty")
+	}
+
+	var (
+		columns  []string
+		problems []string
+	)
+	fields := projectChildren(requested.RootColumn.Children, file.RootColumn.Children, "", &columns, &problems)
+	if len(problems) > 0 {
+		return nil, nil, errors.Errorf("requested schema is incompatible with the file schema: %s", strings.Join(problems, "; "))
+	}
+
+	return &projection{fields: fields}, columns, nil
+}
+
+func projectChildren(requested, file []*parquetschema.ColumnDefinition, prefix string, columns, problems *[]string) []*projectedField {
+	fields := make([]*projectedField, 0, len(requested))
+	for _, rc := range requested {
+		path := rc.SchemaElement.Name
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+
+		fc := findColumnDefinition(file, rc.SchemaElement.Name)
+		if fc == nil {
+			if rc.SchemaElement.GetRepetitionType() == parquet.FieldRepetitionType_REQUIRED {
+				*problems = append(*problems, fmt.Sprintf("%s: required column is missing in file", path))
+			}
+			continue
+		}
+
+		if problem := checkRepetition(rc.SchemaElement, fc.SchemaElement); problem != "" {
+			*problems = append(*problems, fmt.Sprintf("%s: %s", path, problem))
+			continue
+		}
+
+		requestedGroup, fileGroup := rc.SchemaElement.Type == nil, fc.SchemaElement.Type == nil
+		switch {
+		case requestedGroup && fileGroup:
+			children := projectChildren(rc.Children, fc.Children, path, columns, problems)
+			fields = append(fields, &projectedField{name: rc.SchemaElement.Name, children: children})
+		case requestedGroup:
+			*problems = append(*problems, fmt.Sprintf("%s: requested group, but file contains a column of type %s", path, fc.SchemaElement.GetType()))
+		case fileGroup:
+			*problems = append(*problems, fmt.Sprintf("%s: requested column of type %s, but file contains a group", path, rc.SchemaElement.GetType()))
+		default:
+			convert, err := promotion(rc.SchemaElement, fc.SchemaElement)
+			if err != nil {
+				*problems = append(*problems, fmt.Sprintf("%s: %v", path, err))
+				continue
+			}
+			*columns = append(*columns, path)
+			fields = append(fields, &projectedField{name: rc.SchemaElement.Name, convert: convert})
+		}
+	}
+
+	return fields
+}
+
+func findColumnDefinition(cols []*parquetschema.ColumnDefinition, name string) *parquetschema.ColumnDefinition {
+	for _, c := range cols {
+		if c.SchemaElement.Name == name {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// checkRepetition returns a description of the problem if a column with the requested repetition
+// type can't be read from a column with the file's repetition type.
+func checkRepetition(requested, file *parquet.SchemaElement) string {
+	reqRep, fileRep := requested.GetRepetitionType(), file.GetRepetitionType()
+	switch {
+	case reqRep == fileRep:
+		return ""
+	case reqRep == parquet.FieldRepetitionType_OPTIONAL && fileRep == parquet.FieldRepetitionType_REQUIRED:
+		return ""
+	default:
+		return fmt.Sprintf("requested %s column, but file column is %s", strings.ToLower(reqRep.String()), strings.ToLower(fileRep.String()))
+	}
+}
+
+// promotion returns the function to convert the values of the file column to the values of
+// the requested column. Only the promotions from INT32 to INT64 and from FLOAT to DOUBLE are
+// supported.
+func promotion(requested, file *parquet.SchemaElement) (func(interface{}) interface{}, error) {
+	reqType, fileType := requested.GetType(), file.GetType()
+	switch {
+	case reqType == fileType:
+		if reqType == parquet.Type_FIXED_LEN_BYTE_ARRAY && requested.GetTypeLength() != file.GetTypeLength() {
+			return nil, errors.Errorf("requested %s(%d), but file column is %s(%d)", reqType, requested.GetTypeLength(), fileType, file.GetTypeLength())
+		}
+		return nil, nil
+	case reqType == parquet.Type_INT64 && fileType == parquet.Type_INT32:
+		return promoteInt32(isUnsigned(requested)), nil
+	case reqType == parquet.Type_DOUBLE && fileType == parquet.Type_FLOAT:
+		return promoteFloat, nil
+	default:
+		return nil, errors.Errorf("can't read column of type %s as %s", fileType, reqType)
+	}
+}
+
+func isUnsigned(elem *parquet.SchemaElement) bool {
+	if elem.LogicalType != nil && elem.LogicalType.INTEGER != nil {
+		return !elem.LogicalType.INTEGER.IsSigned
+	}
+
+	return elem.GetConvertedType() == parquet.ConvertedType_UINT_64
+}
+
+func promoteInt32(unsigned bool) func(interface{}) interface{} {
+	return func(v interface{}) interface{} {
+		var values []int64
+		switch x := v.(type) {
+		case int32:
+			values = []int64{int64(x)}
+		case uint32:
+			values = []int64{int64(x)}
+		case []int32:
+			values = make([]int64, len(x))
+			for i := range x {
+				values[i] = int64(x[i])
+			}
+		case []uint32:
+			values = make([]int64, len(x))
+			for i := range x {
+				values[i] = int64(x[i])
+			}
+		default:
+			return v
+		}
+
+		switch v.(type) {
+		case int32, uint32:
+			if unsigned {
+				return uint64(values[0])
+			}
+			return values[0]
+		}
+
+		if !unsigned {
+			return values
+		}
+		ret := make([]uint64, len(values))
+		for i := range values {
+			ret[i] = uint64(values[i])
+		}
+		return ret
+	}
+}
+
+func promoteFloat(v interface{}) interface{} {
+	switch x := v.(type) {
+	case float32:
+		return float64(x)
+	case []float32:
+		ret := make([]float64, len(x))
+		for i := range x {
+			ret[i] = float64(x[i])
+		}
+		return ret
+	}
+
+	return v
+}
+
+func (p *projection) apply(row map[string]interface{}) map[string]interface{} {
+	return projectGroup(p.fields, row)
+}
+
+func projectGroup(fields []*projectedField, in map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(fields))
+	for _, f := range fields {
+		v, ok := in[f.name]
+		if !ok || v == nil {
+			continue
+		}
+
+		if f.children == nil {
+			if f.convert != nil {
+				v = f.convert(v)
+			}
+			out[f.name] = v
+			continue
+		}
+
+		switch g := v.(type) {
+		case map[string]interface{}:
+			out[f.name] = projectGroup(f.children, g)
+		case []map[string]interface{}:
+			list := make([]map[string]interface{}, len(g))
+			for i := range g {
+				list[i] = projectGroup(f.children, g[i])
+			}
+			out[f.name] = list
+		}
+	}
+
+	return out
+}
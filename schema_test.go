@@ -137,6 +137,44 @@ func TestSchemaCopy(t *testing.T) {
 	require.NoError(t, writer2.Close())
 }
 
+func TestAddDataMissingRequired(t *testing.T) {
+	def, err := parquetschema.ParseSchemaDefinition(`message msg {
+  required int64 id;
+  required binary name (STRING);
+  optional group address {
+    required binary city (STRING);
+  }
+}`)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	writer := NewFileWriter(buf, WithSchemaDefinition(def))
+	require.NoError(t, writer.AddData(map[string]interface{}{"id": int64(1), "name": []byte("a")}))
+	require.EqualError(t, writer.AddData(map[string]interface{}{"id": int64(2)}), `the value "name" is required`)
+	require.EqualError(t, writer.AddData(map[string]interface{}{
+		"id":      int64(3),
+		"name":    []byte("c"),
+		"address": map[string]interface{}{},
+	}), `the value "address.city" is required`)
+
+	// the rows that failed didn't add values to any column
+	rows := []map[string]interface{}{
+		{"id": int64(1), "name": []byte("a")},
+		{"id": int64(4), "name": []byte("d"), "address": map[string]interface{}{"city": []byte("D")}},
+	}
+	require.NoError(t, writer.AddData(rows[1]))
+	require.NoError(t, writer.FlushRowGroup())
+	require.Error(t, writer.AddData(map[string]interface{}{"id": int64(5)}))
+	rows = append(rows, map[string]interface{}{"id": int64(5), "name": []byte("e")})
+	require.NoError(t, writer.AddData(rows[2]))
+	require.NoError(t, writer.Close())
+
+	r, err := NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, int64(3), r.NumRows())
+	require.Equal(t, rows, readAllRows(t, r))
+}
+
 func TestFuzzCrashReadGroupSchema2(t *testing.T) {
 	data := []byte("PAR1\x15\x02\x19\x9c5\x00\x18\x06schema\x15\f" +
 		"\x00\x15\x02%\x02\x18\x01a% L\xac\x13\x10\x11\x00\x00\x00\x15\n" +
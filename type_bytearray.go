@@ -14,10 +14,18 @@ type byteArrayPlainDecoder struct {
 	r io.Reader
 	// if the length is set, then this is a fix size array decoder, unless it reads the len first
 	length int
+
+	// in zero-copy mode, the values are slices of the page buffer
+	zeroCopy bool
+	buf      *pageBuffer
 }
 
 func (b *byteArrayPlainDecoder) init(r io.Reader) error {
 	b.r = r
+	b.buf = nil
+	if b.zeroCopy {
+		b.buf, _ = r.(*pageBuffer)
+	}
 	return nil
 }
 
@@ -35,6 +43,10 @@ func (b *byteArrayPlainDecoder) next() ([]byte, error) {
 		return nil, errors.New("bytearray/plain: len is negative")
 	}
 
+	if b.buf != nil {
+		return b.buf.next(int(l))
+	}
+
 	buf := make([]byte, l)
 	_, err := io.ReadFull(b.r, buf)
 	if err != nil {
@@ -99,11 +111,19 @@ type byteArrayDeltaLengthDecoder struct {
 	r        io.Reader
 	position int
 	lens     []int32
+
+	// in zero-copy mode, the values are slices of the page buffer
+	zeroCopy bool
+	buf      *pageBuffer
 }
 
 func (b *byteArrayDeltaLengthDecoder) init(r io.Reader) error {
 	b.r = r
 	b.position = 0
+	b.buf = nil
+	if b.zeroCopy {
+		b.buf, _ = r.(*pageBuffer)
+	}
 	lensDecoder := int32DeltaBPDecoder{}
 	if err := lensDecoder.init(r); err != nil {
 		return err
@@ -118,6 +138,15 @@ func (b *byteArrayDeltaLengthDecoder) next() ([]byte, error) {
 		return nil, io.EOF
 	}
 	size := int(b.lens[b.position])
+	if b.buf != nil {
+		value, err := b.buf.next(size)
+		if err != nil {
+			return nil, errors.Wrap(err, "there is no byte left")
+		}
+		b.position++
+		return value, nil
+	}
+
 	value := make([]byte, size)
 	if _, err := io.ReadFull(b.r, value); err != nil {
 		return nil, errors.Wrap(err, "there is no byte left")
@@ -190,6 +219,10 @@ type byteArrayDeltaDecoder struct {
 	suffixDecoder byteArrayDeltaLengthDecoder
 	prefixLens    []int32
 	previousValue []byte
+
+	// in zero-copy mode, the values are allocated from a single buffer owned by the page
+	zeroCopy bool
+	arena    []byte
 }
 
 func (d *byteArrayDeltaDecoder) init(r io.Reader) error {
@@ -202,6 +235,8 @@ func (d *byteArrayDeltaDecoder) init(r io.Reader) error {
 	if err := decodeInt32(&lensDecoder, d.prefixLens); err != nil {
 		return err
 	}
+	// the suffixes are always copied into the values, so they never need their own allocation
+	d.suffixDecoder.zeroCopy = true
 	if err := d.suffixDecoder.init(r); err != nil {
 		return err
 	}
@@ -211,9 +246,42 @@ func (d *byteArrayDeltaDecoder) init(r io.Reader) error {
 	}
 	d.previousValue = make([]byte, 0)
 
+	d.arena = nil
+	if buf, ok := r.(*pageBuffer); ok && d.zeroCopy {
+		if size, ok := d.valuesSize(); ok {
+			d.arena = buf.alloc(size)[:0]
+		}
+	}
+
 	return nil
 }
 
+// valuesSize returns the total size of all values. It returns false if the prefix lengths are
+// invalid, in which case decodeValues fails eventually.
+func (d *byteArrayDeltaDecoder) valuesSize() (int, bool) {
+	var total, previous int
+	for i := range d.prefixLens {
+		prefix, suffix := int(d.prefixLens[i]), int(d.suffixDecoder.lens[i])
+		if prefix < 0 || prefix > previous || suffix < 0 {
+			return 0, false
+		}
+		previous = prefix + suffix
+		total += previous
+	}
+
+	return total, true
+}
+
+func (d *byteArrayDeltaDecoder) newValue(size int) []byte {
+	start := len(d.arena)
+	if cap(d.arena)-start < size {
+		return make([]byte, 0, size)
+	}
+
+	d.arena = d.arena[:start+size]
+	return d.arena[start : start : start+size]
+}
+
 func (d *byteArrayDeltaDecoder) decodeValues(dst []interface{}) (int, error) {
 	total := len(dst)
 	for i := 0; i < total; i++ {
@@ -223,11 +291,14 @@ func (d *byteArrayDeltaDecoder) decodeValues(dst []interface{}) (int, error) {
 		}
 		// after this line no error is acceptable
 		prefixLen := int(d.prefixLens[d.suffixDecoder.position-1])
-		value := make([]byte, 0, prefixLen+len(suffix))
+		if prefixLen < 0 {
+			return 0, errors.Errorf("invalid prefix len %d in the stream", prefixLen)
+		}
 		if len(d.previousValue) < prefixLen {
 			// prevent panic from invalid input
 			return 0, errors.Errorf("invalid prefix len in the stream, the value is %d byte but the it needs %d byte", len(d.previousValue), prefixLen)
 		}
+		value := d.newValue(prefixLen + len(suffix))
 		if prefixLen > 0 {
 			value = append(value, d.previousValue[:prefixLen]...)
 		}
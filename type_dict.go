@@ -5,6 +5,7 @@ import (
 	"math/bits"
 
 	"github.com/pkg/errors"
+	"github.com/sagia-inneractive/parquet-go/parquet"
 )
 
 type dictDecoder struct {
@@ -58,10 +59,72 @@ func (d *dictDecoder) decodeValues(dst []interface{}) (int, error) {
 	return len(dst), nil
 }
 
+// decodeIndices decodes the dictionary indices without resolving them to their values.
+func (d *dictDecoder) decodeIndices(dst []int32) (int, error) {
+	if d.keys == nil {
+		return 0, errors.New("no value is inside dictionary")
+	}
+	size := int32(len(d.values))
+
+	for i := range dst {
+		key, err := d.keys.next()
+		if err != nil {
+			return i, err
+		}
+
+		if key < 0 || key >= size {
+			return 0, errors.Errorf("dict: invalid index %d, values count are %d", key, size)
+		}
+
+		dst[i] = key
+	}
+
+	return len(dst), nil
+}
+
+// readDictIndices reads the levels and dictionary indices of up to len(val) of the remaining
+// values of a data page. It fails if the values of the page aren't dictionary encoded.
+func readDictIndices(val []int32, remaining int, enc parquet.Encoding, values valuesDecoder, dDecoder, rDecoder levelDecoder) (n int, dLevel *packedArray, rLevel *packedArray, err error) {
+	dict, ok := values.(*dictDecoder)
+	if !ok {
+		return 0, nil, nil, errors.Errorf("page with encoding %s is not dictionary encoded", enc)
+	}
+
+	size := len(val)
+	if remaining < size {
+		size = remaining
+	}
+
+	if size == 0 {
+		return 0, nil, nil, nil
+	}
+
+	rLevel, _, err = decodePackedArray(rDecoder, size)
+	if err != nil {
+		return 0, nil, nil, errors.Wrap(err, "read repetition levels failed")
+	}
+
+	var notNull int
+	dLevel, notNull, err = decodePackedArray(dDecoder, size)
+	if err != nil {
+		return 0, nil, nil, errors.Wrap(err, "read definition levels failed")
+	}
+
+	if notNull != 0 {
+		if n, err := dict.decodeIndices(val[:notNull]); err != nil {
+			return 0, nil, nil, errors.Wrapf(err, "read indices from page failed, need %d values but read %d", notNull, n)
+		}
+	}
+	return size, dLevel, rLevel, nil
+}
+
+// dictStore holds the values of a column store. When writing, the distinct values are kept in
+// dict and data holds the index of every value in dict. When reading, the values are appended
+// to values in the order they are read (noDictMode).
 type dictStore struct {
 	values     []interface{}
+	dict       dictValues
 	data       []int32
-	indices    map[interface{}]int32
 	size       int64
 	valueSize  int64
 	readPos    int
@@ -69,8 +132,19 @@ type dictStore struct {
 	noDictMode bool
 }
 
+// newDictStore creates the value store of a column store of type typ.
+func newDictStore(typ parquet.Type) *dictStore {
+	return &dictStore{dict: newDictValues(typ)}
+}
+
 func (d *dictStore) init() {
-	d.indices = make(map[interface{}]int32)
+	if d.dict == nil {
+		panic("dictStore should be created with newDictStore")
+	}
+	d.dict.reset()
+	for i := range d.values {
+		d.values[i] = nil
+	}
 	d.values = d.values[:0]
 	d.data = d.data[:0]
 	d.nullCount = 0
@@ -84,21 +158,18 @@ func (d *dictStore) assemble() []interface{} {
 	}
 	ret := make([]interface{}, 0, len(d.data))
 	for i := range d.data {
-		ret = append(ret, d.values[d.data[i]])
+		ret = append(ret, d.dict.value(d.data[i]))
 	}
 
 	return ret
 }
 
 func (d *dictStore) getIndex(in interface{}, size int) int32 {
-	key := mapKey(in)
-	if idx, ok := d.indices[key]; ok {
-		return idx
-	}
-	d.valueSize += int64(size)
-	d.values = append(d.values, in)
-	idx := int32(len(d.values) - 1)
-	d.indices[key] = idx
+	n := d.dict.len()
+	idx := d.dict.add(in)
+	if d.dict.len() > n {
+		d.valueSize += int64(size)
+	}
 	return idx
 }
 
@@ -125,7 +196,7 @@ func (d *dictStore) getNextValue() (interface{}, error) {
 	}
 	d.readPos++
 	pos := d.data[d.readPos-1]
-	return d.values[pos], nil
+	return d.dict.value(pos), nil
 }
 
 func (d *dictStore) numValues() int32 {
@@ -137,20 +208,41 @@ func (d *dictStore) nullValueCount() int32 {
 }
 
 func (d *dictStore) numDistinctValues() int32 {
-	return int32(len(d.values))
+	return int32(d.dict.len())
 }
 
-// sizes is an experimental guess for the dictionary size and real value size (when there is no dictionary)
-func (d *dictStore) sizes() (dictLen int64, noDictLen int64) {
-	count := len(d.data)
-	max := bits.Len(uint(count)) // bits required for any value in data
-	if max > 0 {
-		dictLen = int64(count/max) + 1
+// distinctValue returns the distinct value with index idx.
+func (d *dictStore) distinctValue(idx int32) interface{} {
+	return d.dict.value(idx)
+}
+
+// distinctValues returns the first n distinct values.
+func (d *dictStore) distinctValues(n int) []interface{} {
+	ret := make([]interface{}, n)
+	for i := range ret {
+		ret[i] = d.dict.value(int32(i))
 	}
+	return ret
+}
 
-	dictLen += d.valueSize
-	noDictLen = d.size
-	return
+// valueAt returns the value at position i in insertion order.
+func (d *dictStore) valueAt(i int) interface{} {
+	return d.dict.value(d.data[i])
+}
+
+// valueRange returns the values from start (inclusive) to end (exclusive) in insertion order.
+func (d *dictStore) valueRange(start, end int) []interface{} {
+	ret := make([]interface{}, 0, end-start)
+	for i := start; i < end; i++ {
+		ret = append(ret, d.dict.value(d.data[i]))
+	}
+
+	return ret
+}
+
+// memSize returns the approximate number of bytes used by the buffered values.
+func (d *dictStore) memSize() int64 {
+	return d.dict.memSize() + int64(len(d.data))*4 + int64(len(d.values))*16
 }
 
 type dictEncoder struct {
@@ -159,21 +251,27 @@ type dictEncoder struct {
 }
 
 func (d *dictEncoder) Close() error {
-	v := len(d.values)
+	v := d.dict.len()
 	if v == 0 { // empty dictionary?
 		return errors.New("empty dictionary nothing to write")
 	}
 
-	w := bits.Len(uint(v))
+	return writeDictIndices(d.w, v, d.data)
+}
+
+// writeDictIndices writes the indices of a dictionary-encoded data page, which is the bit width as a single
+// byte followed by the RLE/bit-packing hybrid encoded indices.
+func writeDictIndices(w io.Writer, dictLen int, indices []int32) error {
+	bw := bits.Len(uint(dictLen))
 	// first write the bitLength in a byte
-	if err := writeFull(d.w, []byte{byte(w)}); err != nil {
+	if err := writeFull(w, []byte{byte(bw)}); err != nil {
 		return err
 	}
-	enc := newHybridEncoder(w)
-	if err := enc.init(d.w); err != nil {
+	enc := newHybridEncoder(bw)
+	if err := enc.init(w); err != nil {
 		return err
 	}
-	if err := enc.encode(d.data); err != nil {
+	if err := enc.encode(indices); err != nil {
 		return err
 	}
 
@@ -197,5 +295,5 @@ func (d *dictEncoder) encodeValues(values []interface{}) error {
 
 // just for tests
 func (d *dictEncoder) getValues() []interface{} {
-	return d.values
+	return d.distinctValues(d.dict.len())
 }
@@ -1,13 +1,16 @@
 package goparquet
 
 import (
+	"fmt"
+	"math"
 	"testing"
 
+	"github.com/sagia-inneractive/parquet-go/parquet"
 	"github.com/stretchr/testify/require"
 )
 
 func TestDictStore(t *testing.T) {
-	d := dictStore{}
+	d := newDictStore(parquet.Type_INT32)
 	d.init()
 	require.Equal(t, d.size, int64(0))
 
@@ -27,6 +30,82 @@ func TestDictStore(t *testing.T) {
 	require.Equal(t, d.size, int64(0))
 }
 
+func TestDictValues(t *testing.T) {
+	tests := []struct {
+		typ    parquet.Type
+		values []interface{}
+		want   []interface{}
+	}{
+		{parquet.Type_INT32, []interface{}{int32(1), int32(2), int32(1), int32(3)}, []interface{}{int32(1), int32(2), int32(3)}},
+		{parquet.Type_INT64, []interface{}{int64(7), int64(7), int64(-1)}, []interface{}{int64(7), int64(-1)}},
+		{parquet.Type_FLOAT, []interface{}{float32(0), float32(math.Copysign(0, -1)), float32(0)}, []interface{}{float32(0), float32(math.Copysign(0, -1))}},
+		{parquet.Type_DOUBLE, []interface{}{math.NaN(), 1.5, math.NaN()}, []interface{}{math.NaN(), 1.5}},
+		{parquet.Type_INT96, []interface{}{[12]byte{1}, [12]byte{2}, [12]byte{1}}, []interface{}{[12]byte{1}, [12]byte{2}}},
+		{parquet.Type_BYTE_ARRAY, []interface{}{[]byte("a"), []byte("b"), []byte("a")}, []interface{}{[]byte("a"), []byte("b")}},
+		{parquet.Type_FIXED_LEN_BYTE_ARRAY, []interface{}{[]byte("ab"), []byte("ab"), []byte("ba")}, []interface{}{[]byte("ab"), []byte("ba")}},
+		{parquet.Type_BOOLEAN, []interface{}{true, false, true}, []interface{}{true, false}},
+	}
+
+	for _, tt := range tests {
+		d := newDictValues(tt.typ)
+		for round := 0; round < 2; round++ {
+			for _, v := range tt.values {
+				d.add(v)
+			}
+			require.Equal(t, len(tt.want), d.len(), "%s", tt.typ)
+			require.True(t, d.memSize() > 0, "%s", tt.typ)
+			for i := range tt.want {
+				if f, ok := tt.want[i].(float64); ok && math.IsNaN(f) {
+					require.True(t, math.IsNaN(d.value(int32(i)).(float64)), "%s", tt.typ)
+					continue
+				}
+				require.Equal(t, tt.want[i], d.value(int32(i)), "%s", tt.typ)
+			}
+
+			d.reset()
+			require.Equal(t, 0, d.len(), "%s", tt.typ)
+			require.Equal(t, int64(0), d.memSize(), "%s", tt.typ)
+		}
+	}
+}
+
+func TestDictStoreAllocations(t *testing.T) {
+	// adding a value that is already in the dictionary doesn't allocate, and the buffers of the
+	// previous row group are reused
+	distinct := 10
+	tests := []struct {
+		typ   parquet.Type
+		value func(i int) interface{}
+		// the number of allocations for every distinct value of a row group
+		allocs int
+	}{
+		{parquet.Type_INT32, func(i int) interface{} { return int32(i) }, 0},
+		{parquet.Type_INT64, func(i int) interface{} { return int64(i) }, 0},
+		{parquet.Type_INT96, func(i int) interface{} { return [12]byte{byte(i)} }, 0},
+		{parquet.Type_FLOAT, func(i int) interface{} { return float32(i) }, 0},
+		{parquet.Type_DOUBLE, func(i int) interface{} { return float64(i) }, 0},
+		// the key of a new byte array is a copy of it
+		{parquet.Type_BYTE_ARRAY, func(i int) interface{} { return []byte(fmt.Sprintf("value-%d", i)) }, 1},
+	}
+
+	for _, tt := range tests {
+		values := make([]interface{}, 1000)
+		for i := range values {
+			values[i] = tt.value(i % distinct)
+		}
+
+		d := newDictStore(tt.typ)
+		allocs := testing.AllocsPerRun(10, func() {
+			d.init()
+			for _, v := range values {
+				d.addValue(v, 4)
+			}
+		})
+		require.Equal(t, distinct, d.dict.len(), "%s", tt.typ)
+		require.LessOrEqual(t, allocs, float64(tt.allocs*distinct), "%s", tt.typ)
+	}
+}
+
 func TestFuzzCrashDictDecoderDecodeValues(t *testing.T) {
 	data := []byte("PAR1\x15\x04\x15\x80\x13\x15\x80\x13L\x15\xe0\x04\x15\x04\x12\x00" +
 		"\x009\xf9\xff\xff \xeb\xff\xff\xd3\x0e\x00\x00\xf8\x1c\x00\x00\xe1\x02\x00" +
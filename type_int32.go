@@ -53,14 +53,8 @@ func (i *int32PlainEncoder) init(w io.Writer) error {
 
 func (i *int32PlainEncoder) encodeValues(values []interface{}) error {
 	d := make([]int32, len(values))
-	if i.unSigned {
-		for i := range values {
-			d[i] = int32(values[i].(uint32))
-		}
-	} else {
-		for j := range values {
-			d[j] = values[j].(int32)
-		}
+	for j := range values {
+		d[j] = int32Value(values[j])
 	}
 	return binary.Write(i.w, binary.LittleEndian, d)
 }
@@ -92,23 +86,24 @@ type int32DeltaBPEncoder struct {
 }
 
 func (d *int32DeltaBPEncoder) encodeValues(values []interface{}) error {
-	if d.unSigned {
-		for i := range values {
-			if err := d.addInt32(int32(values[i].(uint32))); err != nil {
-				return err
-			}
-		}
-	} else {
-		for i := range values {
-			if err := d.addInt32(values[i].(int32)); err != nil {
-				return err
-			}
+	for i := range values {
+		if err := d.addInt32(int32Value(values[i])); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
+// int32Value returns the value to encode. The values of unsigned columns are either uint32, or
+// int32 with the same bits, which is how the column store keeps them.
+func int32Value(v interface{}) int32 {
+	if u, ok := v.(uint32); ok {
+		return int32(u)
+	}
+	return v.(int32)
+}
+
 type int32Store struct {
 	repTyp   parquet.FieldRepetitionType
 	min, max int32
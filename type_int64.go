@@ -53,14 +53,8 @@ func (i *int64PlainEncoder) init(w io.Writer) error {
 
 func (i *int64PlainEncoder) encodeValues(values []interface{}) error {
 	d := make([]int64, len(values))
-	if i.unSigned {
-		for i := range values {
-			d[i] = int64(values[i].(uint64))
-		}
-	} else {
-		for i := range values {
-			d[i] = values[i].(int64)
-		}
+	for j := range values {
+		d[j] = int64Value(values[j])
 	}
 	return binary.Write(i.w, binary.LittleEndian, d)
 }
@@ -93,23 +87,24 @@ type int64DeltaBPEncoder struct {
 }
 
 func (d *int64DeltaBPEncoder) encodeValues(values []interface{}) error {
-	if d.unSigned {
-		for i := range values {
-			if err := d.addInt64(int64(values[i].(uint64))); err != nil {
-				return err
-			}
-		}
-	} else {
-		for i := range values {
-			if err := d.addInt64(values[i].(int64)); err != nil {
-				return err
-			}
+	for i := range values {
+		if err := d.addInt64(int64Value(values[i])); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
+// int64Value returns the value to encode. The values of unsigned columns are either uint64, or
+// int64 with the same bits, which is how the column store keeps them.
+func int64Value(v interface{}) int64 {
+	if u, ok := v.(uint64); ok {
+		return int64(u)
+	}
+	return v.(int64)
+}
+
 type int64Store struct {
 	repTyp   parquet.FieldRepetitionType
 	min, max int64
@@ -142,7 +142,7 @@ var (
 		},
 		{
 			name: "DictionaryInt32",
-			enc:  &dictEncoder{},
+			enc:  &dictEncoder{dictStore: *newDictStore(parquet.Type_INT32)},
 			dec:  &dictDecoder{},
 			rand: func() interface{} {
 				return rand.Int31n(100)
@@ -150,7 +150,7 @@ var (
 		},
 		{
 			name: "DictionaryInt96",
-			enc:  &dictEncoder{},
+			enc:  &dictEncoder{dictStore: *newDictStore(parquet.Type_INT96)},
 			dec:  &dictDecoder{},
 			rand: func() interface{} {
 				var data [12]byte